@@ -2,9 +2,13 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"github.com/KinitaL/testovoye/config"
 	"github.com/KinitaL/testovoye/internal/app/api"
+	"github.com/KinitaL/testovoye/internal/app/importer"
+	"github.com/KinitaL/testovoye/internal/app/migrate"
 	"go.uber.org/zap"
+	"os"
 )
 
 func main() {
@@ -15,6 +19,22 @@ func main() {
 		panic(err)
 	}
 
+	// "api migrate ..." manages the database schema and "api import ..." loads books from files
+	// instead of serving requests
+	if len(os.Args) > 1 {
+		commands := map[string]func(args []string) error{
+			"migrate": migrate.NewApp(c, os.Stdout).Run,
+			"import":  importer.NewApp(c, os.Stdout).Run,
+		}
+		if run, ok := commands[os.Args[1]]; ok {
+			if err := run(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	log, err := newLogger(c.Service.Development)
 	if err != nil {
 		return
@@ -5,9 +5,13 @@ import (
 )
 
 type Config struct {
-	Service Service `yaml:"service"`
-	Logs    Logs    `yaml:"logs"`
-	DB      DB      `yaml:"db"`
+	Service     Service     `yaml:"service"`
+	Logs        Logs        `yaml:"logs"`
+	DB          DB          `yaml:"db"`
+	Trash       Trash       `yaml:"trash"`
+	Idempotency Idempotency `yaml:"idempotency"`
+	OAI         OAI         `yaml:"oai"`
+	SRU         SRU         `yaml:"sru"`
 }
 
 func NewConfig() (*Config, error) {
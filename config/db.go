@@ -7,4 +7,6 @@ type DB struct {
 	DBName   string `yaml:"dbName" env:"POSTGRES_DB"`
 	SSLMode  string `yaml:"sslMode"`
 	Password string `yaml:"password" env:"POSTGRES_PASSWORD"`
+	// MigrateOnStart applies pending migrations when the API starts.
+	MigrateOnStart bool `yaml:"migrateOnStart" env:"DB_MIGRATE_ON_START"`
 }
@@ -0,0 +1,10 @@
+package config
+
+import "time"
+
+type Idempotency struct {
+	// TTL is how long an Idempotency-Key is remembered along with the response to its request.
+	TTL time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL" env-default:"24h"`
+	// PurgeInterval is how often expired keys are deleted.
+	PurgeInterval time.Duration `yaml:"purgeInterval" env:"IDEMPOTENCY_PURGE_INTERVAL" env-default:"1h"`
+}
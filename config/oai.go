@@ -0,0 +1,10 @@
+package config
+
+type OAI struct {
+	// RepositoryName is the name of the catalog reported to harvesters.
+	RepositoryName string `yaml:"repositoryName" env:"OAI_REPOSITORY_NAME" env-default:"Books"`
+	// AdminEmail is the address of the administrator of the catalog, which OAI-PMH requires.
+	AdminEmail string `yaml:"adminEmail" env:"OAI_ADMIN_EMAIL"`
+	// RepositoryIdentifier is a domain name of the catalog that prefixes identifiers of books, as in "oai:<domain>:<id>".
+	RepositoryIdentifier string `yaml:"repositoryIdentifier" env:"OAI_REPOSITORY_IDENTIFIER"`
+}
@@ -3,4 +3,6 @@ package config
 type Service struct {
 	Address     string `yaml:"address" env:"ADDRESS"`
 	Development bool   `yaml:"development" env:"DEVELOPMENT"`
+	// RequireIfMatch makes clients send If-Match with the ETag of a book to modify or delete it.
+	RequireIfMatch bool `yaml:"requireIfMatch" env:"REQUIRE_IF_MATCH"`
 }
@@ -0,0 +1,6 @@
+package config
+
+type SRU struct {
+	// Title is the name of the catalog reported to library clients in explain responses.
+	Title string `yaml:"title" env:"SRU_TITLE" env-default:"Books"`
+}
@@ -0,0 +1,10 @@
+package config
+
+import "time"
+
+type Trash struct {
+	// RetentionDays is how long deleted books are kept before they are purged, 0 keeps them forever.
+	RetentionDays int `yaml:"retentionDays" env:"TRASH_RETENTION_DAYS"`
+	// PurgeInterval is how often expired books are purged.
+	PurgeInterval time.Duration `yaml:"purgeInterval" env:"TRASH_PURGE_INTERVAL" env-default:"1h"`
+}
@@ -5,13 +5,18 @@ import (
 	"github.com/KinitaL/testovoye/config"
 	"github.com/KinitaL/testovoye/internal/infrastructure/controllers"
 	booksPostgres "github.com/KinitaL/testovoye/internal/infrastructure/repositories/books/postgres"
+	idempotencyPostgres "github.com/KinitaL/testovoye/internal/infrastructure/repositories/idempotency/postgres"
 	"github.com/KinitaL/testovoye/internal/server"
 	"github.com/KinitaL/testovoye/internal/usecases"
+	"github.com/KinitaL/testovoye/internal/usecases/books"
+	"github.com/KinitaL/testovoye/internal/usecases/idempotency"
+	"github.com/KinitaL/testovoye/pkg/migrate"
 	"github.com/KinitaL/testovoye/pkg/postgres"
 	"github.com/labstack/echo/v4/middleware"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 	"os/signal"
+	"slices"
 	"syscall"
 	"time"
 )
@@ -61,15 +66,45 @@ func (app *App) Run() error {
 	}
 	app.DB = db
 
+	if app.config.DB.MigrateOnStart {
+		if err := app.migrate(ctx); err != nil {
+			app.logger.Error("cannot migrate db", zap.Error(err))
+			return err
+		}
+	}
+
 	s := server.BuildServer(app.config.Service,
 		middleware.RequestID(),
 		server.ZapLogger(app.logger),
 	)
 
-	repsRegistry := usecases.NewRepositoriesRegistry(booksPostgres.NewPostgresRepo(app.DB))
-	ucRegistry := usecases.NewRegistry(repsRegistry)
+	repsRegistry := usecases.NewRepositoriesRegistry(
+		booksPostgres.NewPostgresRepo(app.DB),
+		booksPostgres.NewAuthorsRepo(app.DB),
+		booksPostgres.NewWorksRepo(app.DB),
+		booksPostgres.NewGenresRepo(app.DB),
+		booksPostgres.NewTagsRepo(app.DB),
+		idempotencyPostgres.NewPostgresRepo(app.DB),
+	)
+	ucRegistry := usecases.NewRegistry(repsRegistry, app.config.Idempotency.TTL)
+
+	controllers.Register(s, ucRegistry,
+		controllers.OAIRepository{
+			Name:                app.config.OAI.RepositoryName,
+			AdminEmail:          app.config.OAI.AdminEmail,
+			Identifier:          app.config.OAI.RepositoryIdentifier,
+			PersistentDeletions: app.config.Trash.RetentionDays == 0,
+		},
+		controllers.SRUDatabase{
+			Title: app.config.SRU.Title,
+		},
+		controllers.RequireIfMatch(app.config.Service.RequireIfMatch),
+	)
 
-	controllers.Register(s, ucRegistry)
+	if app.config.Trash.RetentionDays > 0 {
+		go app.purgeTrash(ctx, ucRegistry.Books)
+	}
+	go app.purgeIdempotencyKeys(ctx, ucRegistry.Idempotency)
 
 	appErrors := make(chan error, 1)
 	go func() {
@@ -101,3 +136,76 @@ func (app *App) Run() error {
 
 	return nil
 }
+
+// migrate applies pending migrations; replicas starting at once wait for each other on the migration lock.
+func (app *App) migrate(ctx context.Context) error {
+	migrator, err := postgres.NewMigrator(app.DB)
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(ctx)
+	for _, m := range applied {
+		app.logger.Info("migration applied", zap.Uint64("version", m.Version), zap.String("name", m.Name))
+	}
+	if err != nil || !slices.ContainsFunc(applied, func(m migrate.Migration) bool {
+		return m.Version == booksPostgres.SearchKeysMigration
+	}) {
+		return err
+	}
+	rekeyed, err := booksPostgres.RekeyBooks(ctx, app.DB)
+	app.logger.Info("search keys recomputed", zap.Int64("books", rekeyed))
+	return err
+}
+
+// purgeTrash periodically purges books that have been in the trash longer than the retention period.
+func (app *App) purgeTrash(ctx context.Context, uc books.Books) {
+	retention := time.Duration(app.config.Trash.RetentionDays) * 24 * time.Hour
+	interval := app.config.Trash.PurgeInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purged, err := uc.PurgeExpired(ctx, retention)
+		switch {
+		case err != nil:
+			app.logger.Error("cannot purge trash", zap.Error(err))
+		case purged > 0:
+			app.logger.Info("trash purged", zap.Int64("books", purged))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeIdempotencyKeys periodically deletes idempotency keys whose TTL has passed.
+func (app *App) purgeIdempotencyKeys(ctx context.Context, uc idempotency.Keys) {
+	interval := app.config.Idempotency.PurgeInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purged, err := uc.PurgeExpired(ctx)
+		switch {
+		case err != nil:
+			app.logger.Error("cannot purge idempotency keys", zap.Error(err))
+		case purged > 0:
+			app.logger.Info("idempotency keys purged", zap.Int64("keys", purged))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
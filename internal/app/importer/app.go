@@ -0,0 +1,315 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/KinitaL/testovoye/config"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/infrastructure/controllers/dto"
+	booksPostgres "github.com/KinitaL/testovoye/internal/infrastructure/repositories/books/postgres"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/internal/usecases/books"
+	"github.com/KinitaL/testovoye/pkg/isbn"
+	"github.com/KinitaL/testovoye/pkg/postgres"
+	"github.com/KinitaL/testovoye/pkg/validator"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+)
+
+// Usage describes arguments of the import command.
+const Usage = `usage: api import [flags] <file>
+
+Imports books from a CSV file with title, author and year columns and optional
+isbn, publisher, language, format, genres and tags ones, where genre IDs and tags
+are separated by ";", a JSON array or NDJSON objects with the fields of a created
+book, or references of a BibTeX or RIS file.
+The file "-" is the standard input.
+
+flags:
+  -format csv|json|ndjson|bib|ris
+                            format of the file, detected by the extension by default
+  -dry-run                  validate and look for duplicates without writing anything
+  -report <file>            write rejected rows to the file as NDJSON
+  -batch-size <N>           number of books inserted at once (100 by default)`
+
+// ErrUsage is returned when the command is called with invalid arguments.
+var ErrUsage = errors.New(Usage)
+
+// Reasons why a row is rejected.
+const (
+	ReasonInvalid   = "invalid"
+	ReasonDuplicate = "duplicate"
+)
+
+const defaultBatchSize = 100
+
+type (
+	App struct {
+		config *config.Config
+		out    io.Writer
+	}
+
+	// options are parsed arguments of the command.
+	options struct {
+		path      string
+		format    string
+		dryRun    bool
+		report    string
+		batchSize int
+	}
+
+	// Rejection is a line of the report about a row that hasn't been imported.
+	Rejection struct {
+		Line    int                `json:"line"`
+		Reason  string             `json:"reason"`
+		Message string             `json:"message"`
+		Errors  []errs.FieldError  `json:"errors,omitempty"`
+		Book    *dto.CreateBookDto `json:"book,omitempty"`
+	}
+
+	// Summary counts rows by their outcome.
+	Summary struct {
+		Rows       int
+		Imported   int
+		Duplicates int
+		Invalid    int
+	}
+)
+
+func NewApp(
+	config *config.Config,
+	out io.Writer,
+) *App {
+	return &App{
+		config: config,
+		out:    out,
+	}
+}
+
+// Run executes the import command with the given arguments.
+func (app *App) Run(args []string) error {
+	opts, err := parseOptions(args)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var in io.Reader = os.Stdin
+	if opts.path != "-" {
+		file, err := os.Open(opts.path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+	records, err := newReader(opts.format, in)
+	if err != nil {
+		return err
+	}
+
+	var report io.Writer = io.Discard
+	if opts.report != "" {
+		file, err := os.Create(opts.report)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		report = file
+	}
+
+	db, err := postgres.NewPostgresDB(app.config.DB)
+	if err != nil {
+		return err
+	}
+	uc := books.NewBooksUsecase(booksPostgres.NewPostgresRepo(db))
+
+	summary, err := importRecords(ctx, uc, records, report, opts.dryRun, opts.batchSize)
+	fmt.Fprintf(app.out, "%d rows: %d imported, %d duplicates, %d invalid\n",
+		summary.Rows, summary.Imported, summary.Duplicates, summary.Invalid)
+	if opts.dryRun {
+		fmt.Fprintln(app.out, "dry run: nothing has been written")
+	}
+	return err
+}
+
+// parseOptions parses the flags and the file of the command.
+func parseOptions(args []string) (options, error) {
+	opts := options{batchSize: defaultBatchSize}
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&opts.format, "format", "", "")
+	flags.BoolVar(&opts.dryRun, "dry-run", false, "")
+	flags.StringVar(&opts.report, "report", "", "")
+	flags.IntVar(&opts.batchSize, "batch-size", defaultBatchSize, "")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return opts, ErrUsage
+	}
+	if opts.batchSize < 1 || opts.batchSize > books.MaxBatchSize {
+		return opts, fmt.Errorf("batch size must be from 1 to %d", books.MaxBatchSize)
+	}
+
+	opts.path = flags.Arg(0)
+	if opts.format == "" {
+		opts.format = strings.TrimPrefix(strings.ToLower(filepath.Ext(opts.path)), ".")
+		switch opts.format {
+		case "jsonl":
+			opts.format = FormatNDJSON
+		case "bibtex":
+			opts.format = FormatBibTeX
+		}
+	}
+	switch opts.format {
+	case FormatCSV, FormatJSON, FormatNDJSON, FormatBibTeX, FormatRIS:
+		return opts, nil
+	default:
+		return opts, ErrUsage
+	}
+}
+
+// importer sends valid rows to the usecase in batches and reports rejected ones.
+type importer struct {
+	uc       books.Books
+	report   *json.Encoder
+	dryRun   bool
+	seen     map[models.BookIdentity]struct{} // Books met earlier in the file
+	isbns    map[string]struct{}              // ISBN-13 met earlier in the file
+	pending  []record
+	validate *validator.CustomValidator
+	summary  Summary
+}
+
+// importRecords streams records into the catalog, writing rejected rows to the report, and returns the counts of rows.
+// Rows are validated with the rules of the create endpoint; rows that repeat earlier rows or books
+// of the catalog are skipped.
+func importRecords(ctx context.Context, uc books.Books, records reader, report io.Writer, dryRun bool, batchSize int) (Summary, error) {
+	imp := &importer{
+		uc:       uc,
+		report:   json.NewEncoder(report),
+		dryRun:   dryRun,
+		seen:     make(map[models.BookIdentity]struct{}),
+		isbns:    make(map[string]struct{}),
+		validate: validator.New(),
+	}
+	for {
+		rec, err := records.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return imp.summary, err
+		}
+		imp.summary.Rows++
+		if err := imp.add(rec); err != nil {
+			return imp.summary, err
+		}
+		if len(imp.pending) >= batchSize {
+			if err := imp.flush(ctx); err != nil {
+				return imp.summary, err
+			}
+		}
+	}
+	return imp.summary, imp.flush(ctx)
+}
+
+// add validates a record and queues it for import.
+func (imp *importer) add(rec record) error {
+	if rec.err == nil {
+		rec.err = imp.validate.Validate(rec.book)
+	}
+	if rec.err != nil {
+		imp.summary.Invalid++
+		return imp.reject(rec, ReasonInvalid, rec.err)
+	}
+
+	book := imp.book(rec)
+	identity := books.Identity(book)
+	number, _ := isbn.Normalize(book.ISBN)
+	_, repeated := imp.seen[identity]
+	if _, ok := imp.isbns[number]; repeated || ok {
+		imp.summary.Duplicates++
+		return imp.reject(rec, ReasonDuplicate, errs.Conflict("book is repeated in the file"))
+	}
+	imp.seen[identity] = struct{}{}
+	if number != "" {
+		imp.isbns[number] = struct{}{}
+	}
+	imp.pending = append(imp.pending, rec)
+	return nil
+}
+
+// flush imports queued records.
+func (imp *importer) flush(ctx context.Context) error {
+	if len(imp.pending) == 0 {
+		return nil
+	}
+	batch := make([]models.Book, len(imp.pending))
+	for i, rec := range imp.pending {
+		batch[i] = imp.book(rec)
+	}
+	results, err := imp.uc.Import(ctx, batch, imp.dryRun)
+	if err != nil {
+		return err
+	}
+
+	for i, result := range results {
+		if result.Err != nil {
+			// the row passed the rules of the create endpoint, but its book can't be normalized or stored
+			imp.summary.Invalid++
+			if err := imp.reject(imp.pending[i], ReasonInvalid, result.Err); err != nil {
+				return err
+			}
+			continue
+		}
+		if result.Duplicate {
+			imp.summary.Duplicates++
+			if err := imp.reject(imp.pending[i], ReasonDuplicate, errs.Conflict("book is already in the catalog")); err != nil {
+				return err
+			}
+			continue
+		}
+		imp.summary.Imported++
+	}
+	imp.pending = imp.pending[:0]
+	return nil
+}
+
+// book converts a valid record to the model.
+func (imp *importer) book(rec record) models.Book {
+	return models.Book{
+		Title:     rec.book.Title,
+		Author:    rec.book.Author,
+		Authors:   dto.Credits(rec.book.Authors),
+		Year:      rec.book.Year,
+		ISBN:      rec.book.ISBN,
+		Publisher: rec.book.Publisher,
+		Language:  rec.book.Language,
+		Format:    models.BookFormat(rec.book.Format),
+		Genres:    dto.Genres(rec.book.Genres),
+		Tags:      rec.book.Tags,
+	}
+}
+
+// reject writes a rejected row to the report.
+func (imp *importer) reject(rec record, reason string, err error) error {
+	rejection := Rejection{
+		Line:    rec.line,
+		Reason:  reason,
+		Message: errs.MessageOf(err),
+		Errors:  errs.FieldsOf(err),
+	}
+	if !reflect.DeepEqual(rec.book, dto.CreateBookDto{}) {
+		rejection.Book = &rec.book
+	}
+	return imp.report.Encode(rejection)
+}
@@ -0,0 +1,186 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	booksRepo "github.com/KinitaL/testovoye/internal/infrastructure/repositories/books"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/internal/usecases/books"
+	"github.com/KinitaL/testovoye/internal/usecases/genres"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestImportRecords(t *testing.T) {
+	cases := []struct {
+		name string
+
+		catalog    []models.Book // Books created before the import
+		data       string        // NDJSON file
+		dryRun     bool
+		batchSize  int
+		summary    Summary
+		rejections []Rejection // Without books and field errors
+		titles     []string    // Titles of the books of the catalog after the import
+	}{
+		{
+			name: "New books in several batches",
+
+			data: `{"title": "Nos", "author": "Gogol", "year": 1836}
+{"title": "Shinel", "author": "Gogol", "year": 1842}
+{"title": "Revizor", "author": "Gogol", "year": 1836}`,
+			batchSize: 2,
+			summary:   Summary{Rows: 3, Imported: 3},
+			titles:    []string{"Nos", "Revizor", "Shinel"},
+		},
+		{
+			name: "Duplicates in the file and in the catalog",
+
+			catalog: []models.Book{{Title: "Война и мир", Author: "Толстой", Year: 1869}},
+			data: `{"title": "Voyna i mir", "author": "Tolstoy", "year": 1869}
+{"title": "Nos", "author": "Gogol", "year": 1836, "isbn": "0-14-044913-2"}
+{"title": " NOS ", "author": "gogol", "year": 1836}
+{"title": "The Nose", "author": "Gogol", "year": 1836, "isbn": "9780140449136"}`,
+			batchSize: defaultBatchSize,
+			summary:   Summary{Rows: 4, Imported: 1, Duplicates: 3},
+			rejections: []Rejection{
+				{Line: 1, Reason: ReasonDuplicate, Message: "book is already in the catalog"},
+				{Line: 3, Reason: ReasonDuplicate, Message: "book is repeated in the file"},
+				{Line: 4, Reason: ReasonDuplicate, Message: "book is repeated in the file"},
+			},
+			titles: []string{"Nos", "Война и мир"},
+		},
+		{
+			name: "Invalid rows",
+
+			data: `{"title": "Nos", "author": "Gogol", "year": 1836}
+{"author": "Gogol", "year": 1842}
+[1]
+{"title": "Dvenadtsat stulyev", "authors": [{"name": "Ilf and Petrov"}], "year": 1928}
+{"title": "Zolotoy telenok", "authors": [{"name": "Ilf"}, {"name": "Petrov"}], "year": 1931}`,
+			batchSize: defaultBatchSize,
+			summary:   Summary{Rows: 5, Imported: 2, Invalid: 3},
+			rejections: []Rejection{
+				{Line: 2, Reason: ReasonInvalid, Message: "request has invalid fields"},
+				{Line: 3, Reason: ReasonInvalid, Message: "book must be a JSON object"},
+				{Line: 4, Reason: ReasonInvalid, Message: "request has invalid fields"},
+			},
+			titles: []string{"Nos", "Zolotoy telenok"},
+		},
+		{
+			name: "Dry run",
+
+			catalog: []models.Book{{Title: "Nos", Author: "Gogol", Year: 1836}},
+			data: `{"title": "Nos", "author": "Gogol", "year": 1836}
+{"title": "Shinel", "author": "Gogol", "year": 1842}`,
+			dryRun:    true,
+			batchSize: defaultBatchSize,
+			summary:   Summary{Rows: 2, Imported: 1, Duplicates: 1},
+			rejections: []Rejection{
+				{Line: 1, Reason: ReasonDuplicate, Message: "book is already in the catalog"},
+			},
+			titles: []string{"Nos"},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := booksRepo.NewInMemoryRepo()
+			uc := books.NewBooksUsecase(repo)
+			for _, book := range testCase.catalog {
+				_, err := uc.Create(ctx, book)
+				assert.NoError(t, err)
+			}
+
+			var report bytes.Buffer
+			summary, err := importRecords(ctx, uc, newNDJSONReader(strings.NewReader(testCase.data)), &report, testCase.dryRun, testCase.batchSize)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.summary, summary)
+
+			var rejections []Rejection
+			decoder := json.NewDecoder(&report)
+			for {
+				var rejection Rejection
+				if err := decoder.Decode(&rejection); errors.Is(err, io.EOF) {
+					break
+				} else if !assert.NoError(t, err) {
+					break
+				}
+				rejection.Book, rejection.Errors = nil, nil
+				rejections = append(rejections, rejection)
+			}
+			slices.SortFunc(rejections, func(a, b Rejection) int { return a.Line - b.Line })
+			assert.Equal(t, testCase.rejections, rejections)
+
+			stored, err := repo.GetAll(ctx, models.BookQuery{Limit: 100})
+			assert.NoError(t, err)
+			titles := make([]string, len(stored))
+			for i, book := range stored {
+				titles[i] = book.Title
+			}
+			slices.Sort(titles)
+			assert.Equal(t, testCase.titles, titles)
+		})
+	}
+}
+
+func TestImportFields(t *testing.T) {
+	ctx := context.Background()
+	repo := booksRepo.NewInMemoryRepo()
+	uc := books.NewBooksUsecase(repo)
+	novels, err := genres.NewGenresUsecase(booksRepo.NewInMemoryGenresRepo(repo)).Create(ctx, models.Genre{Name: "Novels"})
+	assert.NoError(t, err)
+
+	data := `[{
+  "title": "War and Peace",
+  "authors": [{"name": "Leo Tolstoy"}, {"name": "Aylmer Maude", "role": "translator"}],
+  "year": 1869,
+  "isbn": "0-14-044913-2",
+  "publisher": "Penguin",
+  "language": "EN",
+  "format": "paperback",
+  "genres": ["` + novels.ID.String() + `"],
+  "tags": ["War", " classic "]
+},
+{"title": "Anna Karenina", "author": "Leo Tolstoy", "year": 1878, "genres": ["` + uuid.NewString() + `"]},
+{"title": "Resurrection", "author": "Leo Tolstoy", "year": 1899, "tags": [" "]}]`
+	records, err := newJSONReader(strings.NewReader(data))
+	assert.NoError(t, err)
+	var report bytes.Buffer
+	summary, err := importRecords(ctx, uc, records, &report, false, defaultBatchSize)
+	assert.NoError(t, err)
+	assert.Equal(t, Summary{Rows: 3, Imported: 1, Invalid: 2}, summary)
+
+	// the rows that fail in the usecase and in the repository are rejected with their lines
+	var rejections []Rejection
+	for line := range strings.Lines(report.String()) {
+		var rejection Rejection
+		assert.NoError(t, json.Unmarshal([]byte(line), &rejection))
+		rejections = append(rejections, Rejection{Line: rejection.Line, Reason: rejection.Reason})
+	}
+	assert.ElementsMatch(t, []Rejection{{Line: 12, Reason: ReasonInvalid}, {Line: 13, Reason: ReasonInvalid}}, rejections)
+
+	stored, err := repo.GetAll(ctx, models.BookQuery{Limit: 100})
+	assert.NoError(t, err)
+	if assert.Len(t, stored, 1) {
+		book := stored[0]
+		assert.Equal(t, "War and Peace", book.Title)
+		assert.Equal(t, "Leo Tolstoy", book.Author)
+		assert.Len(t, book.Authors, 2)
+		assert.Equal(t, models.AuthorRoleTranslator, book.Authors[1].Role)
+		assert.Equal(t, uint16(1869), book.Year)
+		assert.Equal(t, "9780140449136", book.ISBN)
+		assert.Equal(t, "Penguin", book.Publisher)
+		assert.Equal(t, "en", book.Language)
+		assert.Equal(t, models.BookFormatPaperback, book.Format)
+		assert.Equal(t, []uuid.UUID{novels.ID}, book.Genres)
+		assert.Equal(t, []string{"classic", "war"}, book.Tags)
+	}
+}
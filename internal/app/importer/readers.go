@@ -0,0 +1,306 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/infrastructure/controllers/dto"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/pkg/citation"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Formats of import files.
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"   // A single array of objects
+	FormatNDJSON = "ndjson" // One object per line
+	FormatBibTeX = "bib"
+	FormatRIS    = "ris"
+)
+
+// maxLineSize limits the length of an NDJSON line.
+const maxLineSize = 1 << 20
+
+// listSeparator separates items of the genres and tags columns of a CSV file.
+const listSeparator = ";"
+
+type (
+	// record is a row of an import file. A row that can't be parsed carries the error instead of the book.
+	record struct {
+		line int
+		book dto.CreateBookDto
+		err  error
+	}
+
+	// reader streams records of an import file and returns io.EOF after the last one.
+	reader interface {
+		next() (record, error)
+	}
+)
+
+// newReader returns a reader of the format.
+func newReader(format string, r io.Reader) (reader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r)
+	case FormatJSON:
+		return newJSONReader(r)
+	case FormatNDJSON:
+		return newNDJSONReader(r), nil
+	case FormatBibTeX:
+		return &bibTeXReader{bibtex: citation.NewBibTeXReader(r)}, nil
+	case FormatRIS:
+		return &risReader{ris: citation.NewRISReader(r)}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// csvReader reads a CSV file whose header names the title, author and year columns in any order.
+// The isbn, publisher, language, format, genres and tags columns are optional, and other columns are ignored.
+// Genres and tags are lists separated by listSeparator.
+type csvReader struct {
+	csv     *csv.Reader
+	columns map[string]int // Column name -> index
+	width   int            // Number of columns in the header
+}
+
+// newCSVReader reads the header of a CSV file.
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // rows of a wrong width are rejected one by one
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// spreadsheet applications may start the file with a byte order mark
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range []string{"title", "author", "year"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("CSV header has no %q column", name)
+		}
+	}
+	return &csvReader{csv: reader, columns: columns, width: len(header)}, nil
+}
+
+func (r *csvReader) next() (record, error) {
+	row, err := r.csv.Read()
+	var parseErr *csv.ParseError
+	switch {
+	case errors.As(err, &parseErr):
+		return record{line: parseErr.StartLine, err: errs.Validation("malformed CSV: %v", parseErr.Err)}, nil
+	case err != nil:
+		return record{}, err
+	}
+
+	line, _ := r.csv.FieldPos(0)
+	if len(row) != r.width {
+		return record{line: line, err: errs.Validation("row has %d fields instead of %d", len(row), r.width)}, nil
+	}
+	rec := record{line: line, book: dto.CreateBookDto{
+		Title:  row[r.columns["title"]],
+		Author: row[r.columns["author"]],
+	}}
+	for name, field := range map[string]*string{
+		"isbn":      &rec.book.ISBN,
+		"publisher": &rec.book.Publisher,
+		"language":  &rec.book.Language,
+		"format":    &rec.book.Format,
+	} {
+		if i, ok := r.columns[name]; ok {
+			*field = strings.TrimSpace(row[i])
+		}
+	}
+	for name, field := range map[string]*[]string{
+		"genres": &rec.book.Genres,
+		"tags":   &rec.book.Tags,
+	} {
+		if i, ok := r.columns[name]; ok {
+			*field = splitList(row[i])
+		}
+	}
+	if year := strings.TrimSpace(row[r.columns["year"]]); year != "" {
+		value, err := strconv.ParseUint(year, 10, 16)
+		if err != nil {
+			rec.err = errs.InvalidFields(errs.FieldError{Field: "year", Message: "has a wrong type"})
+		}
+		rec.book.Year = uint16(value)
+	}
+	return rec, nil
+}
+
+// splitList splits a list of a CSV field into trimmed items, skipping empty ones.
+func splitList(field string) []string {
+	var items []string
+	for item := range strings.SplitSeq(field, listSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// jsonReader streams objects of a JSON array.
+type jsonReader struct {
+	decoder *json.Decoder
+	lines   *lineCounter
+}
+
+// newJSONReader reads the opening bracket of a JSON array.
+func newJSONReader(r io.Reader) (*jsonReader, error) {
+	lines := &lineCounter{r: r, line: 1}
+	decoder := json.NewDecoder(lines)
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the JSON array: %w", err)
+	}
+	if token != json.Delim('[') {
+		return nil, errors.New("JSON file must contain an array of books")
+	}
+	return &jsonReader{decoder: decoder, lines: lines}, nil
+}
+
+func (r *jsonReader) next() (record, error) {
+	if !r.decoder.More() {
+		return record{}, io.EOF
+	}
+	var raw json.RawMessage
+	if err := r.decoder.Decode(&raw); err != nil {
+		// the rest of a malformed array can't be told apart, so the whole import stops
+		return record{}, fmt.Errorf("malformed JSON after line %d: %w", r.lines.lineAt(r.decoder.InputOffset()), err)
+	}
+	line := r.lines.lineAt(r.decoder.InputOffset() - int64(len(raw)))
+	book, err := decodeBook(raw)
+	return record{line: line, book: book, err: err}, nil
+}
+
+// ndjsonReader reads a JSON object from each non-empty line.
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newNDJSONReader(r io.Reader) *ndjsonReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	return &ndjsonReader{scanner: scanner}
+}
+
+func (r *ndjsonReader) next() (record, error) {
+	for r.scanner.Scan() {
+		r.line++
+		data := bytes.TrimSpace(r.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		book, err := decodeBook(data)
+		return record{line: r.line, book: book, err: err}, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return record{}, fmt.Errorf("cannot read line %d: %w", r.line+1, err)
+	}
+	return record{}, io.EOF
+}
+
+// bibTeXReader reads a book from each entry of a BibTeX file.
+type bibTeXReader struct {
+	bibtex *citation.BibTeXReader
+}
+
+func (r *bibTeXReader) next() (record, error) {
+	entry, err := r.bibtex.Read()
+	var syntaxErr *citation.SyntaxError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return record{line: syntaxErr.Line, err: errs.Validation("malformed BibTeX: %s", syntaxErr.Msg)}, nil
+	case err != nil:
+		return record{}, err
+	}
+	return record{line: entry.Line, book: referenceBook(citation.FromBibTeX(entry))}, nil
+}
+
+// risReader reads a book from each reference of a RIS file.
+type risReader struct {
+	ris *citation.RISReader
+}
+
+func (r *risReader) next() (record, error) {
+	reference, err := r.ris.Read()
+	var syntaxErr *citation.SyntaxError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return record{line: syntaxErr.Line, err: errs.Validation("malformed RIS: %s", syntaxErr.Msg)}, nil
+	case err != nil:
+		return record{}, err
+	}
+	return record{line: reference.Line, book: referenceBook(citation.FromRIS(reference))}, nil
+}
+
+// referenceBook converts a book of a reference to the row of an import file.
+func referenceBook(book models.Book) dto.CreateBookDto {
+	return dto.CreateBookDto{Title: book.Title, Author: book.Author, Year: book.Year, ISBN: book.ISBN}
+}
+
+// decodeBook decodes a JSON object of a book, reporting type mismatches and unknown fields the way the API does.
+func decodeBook(data []byte) (dto.CreateBookDto, error) {
+	var book dto.CreateBookDto
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&book)
+
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+		return book, nil
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return book, errs.InvalidFields(errs.FieldError{Field: typeErr.Field, Message: "has a wrong type"})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return book, errs.InvalidFields(errs.FieldError{Field: field, Message: "is not a field of a book"})
+	default:
+		return book, errs.Validation("book must be a JSON object")
+	}
+}
+
+// lineCounter remembers where lines start in the data read through it, so that
+// offsets reported by a JSON decoder can be turned into line numbers.
+type lineCounter struct {
+	r        io.Reader
+	read     int64   // Number of bytes read so far
+	newlines []int64 // Offsets of newlines that lie after the last offset asked for
+	line     int     // Line of the last offset asked for
+}
+
+func (c *lineCounter) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	for i, b := range p[:n] {
+		if b == '\n' {
+			c.newlines = append(c.newlines, c.read+int64(i))
+		}
+	}
+	c.read += int64(n)
+	return n, err
+}
+
+// lineAt returns the line of the offset; offsets must not decrease between calls.
+func (c *lineCounter) lineAt(offset int64) int {
+	passed := 0
+	for passed < len(c.newlines) && c.newlines[passed] < offset {
+		passed++
+	}
+	c.line += passed
+	c.newlines = c.newlines[passed:]
+	return c.line
+}
@@ -0,0 +1,183 @@
+package importer
+
+import (
+	"errors"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/infrastructure/controllers/dto"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReaders(t *testing.T) {
+	cases := []struct {
+		name string
+
+		format  string
+		data    string
+		records []record
+		err     string // Error of the reader after the records
+	}{
+		{
+			name: "CSV with columns in any order",
+
+			format: FormatCSV,
+			data:   "\ufeffyear,Title,author,isbn,publisher,language,format,version\n1836,Nos,Gogol,0-14-044913-2, Penguin ,en,paperback,3\n1842,Mertvye dushi,Gogol,,,,,\n",
+			records: []record{
+				{line: 2, book: dto.CreateBookDto{
+					Title: "Nos", Author: "Gogol", Year: 1836, ISBN: "0-14-044913-2",
+					Publisher: "Penguin", Language: "en", Format: "paperback",
+				}},
+				{line: 3, book: dto.CreateBookDto{Title: "Mertvye dushi", Author: "Gogol", Year: 1842}},
+			},
+		},
+		{
+			name: "CSV with genres and tags",
+
+			format: FormatCSV,
+			data:   "title,author,year,genres,tags\nNos,Gogol,1836,\"0b4a3c4e-0f6b-4d7e-9a43-9c1f0e6f3b11; 5f0e4c1a-2b3d-4e5f-8a9b-0c1d2e3f4a5b\",\"Satire;; classic \"\nShinel,Gogol,1842,,\n",
+			records: []record{
+				{line: 2, book: dto.CreateBookDto{
+					Title: "Nos", Author: "Gogol", Year: 1836,
+					Genres: []string{"0b4a3c4e-0f6b-4d7e-9a43-9c1f0e6f3b11", "5f0e4c1a-2b3d-4e5f-8a9b-0c1d2e3f4a5b"},
+					Tags:   []string{"Satire", "classic"},
+				}},
+				{line: 3, book: dto.CreateBookDto{Title: "Shinel", Author: "Gogol", Year: 1842}},
+			},
+		},
+		{
+			name: "CSV with invalid rows",
+
+			format: FormatCSV,
+			data:   "title,author,year\n\"Multi\nline\",Tester,later\nshort,row\nNo\"s,Gogol,1836\nNos,Gogol,1836\n",
+			records: []record{
+				{
+					line: 2,
+					book: dto.CreateBookDto{Title: "Multi\nline", Author: "Tester"},
+					err:  errs.InvalidFields(errs.FieldError{Field: "year", Message: "has a wrong type"}),
+				},
+				{line: 4, err: errs.Validation("row has 2 fields instead of 3")},
+				{line: 5, err: errs.Validation("malformed CSV: bare \" in non-quoted-field")},
+				{line: 6, book: dto.CreateBookDto{Title: "Nos", Author: "Gogol", Year: 1836}},
+			},
+		},
+		{
+			name: "CSV without a required column",
+
+			format: FormatCSV,
+			data:   "title,author\nNos,Gogol\n",
+			err:    `CSV header has no "year" column`,
+		},
+		{
+			name: "JSON array",
+
+			format: FormatJSON,
+			data: `[
+  {"title": "Nos", "author": "Gogol", "year": 1836},
+
+  {
+    "title": "Mertvye dushi",
+    "author": "Gogol",
+    "year": "1842"
+  },
+  {"title": "Shinel", "pages": 36}
+]`,
+			records: []record{
+				{line: 2, book: dto.CreateBookDto{Title: "Nos", Author: "Gogol", Year: 1836}},
+				{
+					line: 4,
+					book: dto.CreateBookDto{Title: "Mertvye dushi", Author: "Gogol"},
+					err:  errs.InvalidFields(errs.FieldError{Field: "year", Message: "has a wrong type"}),
+				},
+				{
+					line: 9,
+					book: dto.CreateBookDto{Title: "Shinel"},
+					err:  errs.InvalidFields(errs.FieldError{Field: "pages", Message: "is not a field of a book"}),
+				},
+			},
+		},
+		{
+			name: "JSON that isn't an array",
+
+			format: FormatJSON,
+			data:   `{"title": "Nos", "author": "Gogol", "year": 1836}`,
+			err:    "JSON file must contain an array of books",
+		},
+		{
+			name: "Malformed JSON array",
+
+			format: FormatJSON,
+			data:   "[\n  {\"title\": \"Nos\", \"author\": \"Gogol\", \"year\": 1836},\n  {\"title\": ",
+			records: []record{
+				{line: 2, book: dto.CreateBookDto{Title: "Nos", Author: "Gogol", Year: 1836}},
+			},
+			err: "malformed JSON after line 2: unexpected EOF",
+		},
+		{
+			name: "NDJSON with blank lines",
+
+			format: FormatNDJSON,
+			data:   "{\"title\": \"Nos\", \"author\": \"Gogol\", \"year\": 1836}\n\n   \n[1]\r\n{\"title\": \"Shinel\", \"author\": \"Gogol\", \"year\": 1842}",
+			records: []record{
+				{line: 1, book: dto.CreateBookDto{Title: "Nos", Author: "Gogol", Year: 1836}},
+				{line: 4, err: errs.Validation("book must be a JSON object")},
+				{line: 5, book: dto.CreateBookDto{Title: "Shinel", Author: "Gogol", Year: 1842}},
+			},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			// a reader must not depend on how the data is split into reads
+			records, err := newReader(testCase.format, iotest.HalfReader(strings.NewReader(testCase.data)))
+			var got []record
+			for err == nil {
+				var rec record
+				if rec, err = records.next(); err == nil {
+					got = append(got, rec)
+				}
+			}
+
+			assert.Equal(t, testCase.records, got)
+			if testCase.err == "" {
+				assert.ErrorIs(t, err, io.EOF)
+			} else {
+				assert.EqualError(t, err, testCase.err)
+			}
+		})
+	}
+}
+
+func TestLineCounter(t *testing.T) {
+	data := "a\nbb\n\nccc\n"
+	counter := &lineCounter{r: iotest.OneByteReader(strings.NewReader(data)), line: 1}
+
+	// offsets are asked for while the data is still being read, the way a JSON decoder does
+	read := make([]byte, 0, len(data))
+	buf := make([]byte, 1)
+	for _, step := range []struct {
+		offset int64
+		line   int
+	}{
+		{offset: 0, line: 1},
+		{offset: 1, line: 1}, // the newline belongs to the line it ends
+		{offset: 2, line: 2},
+		{offset: 5, line: 3},
+		{offset: 6, line: 4},
+		{offset: 9, line: 4},
+		{offset: 10, line: 5},
+	} {
+		for int64(len(read)) < step.offset {
+			n, err := counter.Read(buf)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			assert.NoError(t, err)
+			read = append(read, buf[:n]...)
+		}
+		assert.Equal(t, step.line, counter.lineAt(step.offset), "offset %d", step.offset)
+	}
+	assert.Equal(t, data, string(read))
+}
@@ -0,0 +1,141 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/KinitaL/testovoye/config"
+	booksPostgres "github.com/KinitaL/testovoye/internal/infrastructure/repositories/books/postgres"
+	"github.com/KinitaL/testovoye/pkg/migrate"
+	"github.com/KinitaL/testovoye/pkg/postgres"
+	"gorm.io/gorm"
+	"io"
+	"os/signal"
+	"slices"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+// Usage describes arguments of the migrate command.
+const Usage = `usage: api migrate <command>
+
+commands:
+  up          apply all pending migrations
+  down [N]    roll back the last N applied migrations (1 by default)
+  status      show applied and pending migrations
+  rekey       recompute search keys of all books`
+
+// ErrUsage is returned when the command is called with invalid arguments.
+var ErrUsage = errors.New(Usage)
+
+type App struct {
+	config *config.Config
+	out    io.Writer
+}
+
+func NewApp(
+	config *config.Config,
+	out io.Writer,
+) *App {
+	return &App{
+		config: config,
+		out:    out,
+	}
+}
+
+// Run executes the migrate command with the given arguments.
+func (app *App) Run(args []string) error {
+	if len(args) == 0 {
+		return ErrUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	db, err := postgres.NewPostgresDB(app.config.DB)
+	if err != nil {
+		return err
+	}
+	migrator, err := postgres.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		if len(args) != 1 {
+			return ErrUsage
+		}
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			fmt.Fprintf(app.out, "applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(app.out, "no pending migrations")
+		}
+		// the migration adding search keys only approximates them for existing books
+		if err != nil || !slices.ContainsFunc(applied, func(m migrate.Migration) bool {
+			return m.Version == booksPostgres.SearchKeysMigration
+		}) {
+			return err
+		}
+		return app.rekey(ctx, db)
+	case "down":
+		steps := 1
+		if len(args) > 2 {
+			return ErrUsage
+		}
+		if len(args) == 2 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return ErrUsage
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Fprintf(app.out, "rolled back %04d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(reverted) == 0 {
+			fmt.Fprintln(app.out, "no applied migrations")
+		}
+		return err
+	case "status":
+		if len(args) != 1 {
+			return ErrUsage
+		}
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(app.out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return w.Flush()
+	case "rekey":
+		if len(args) != 1 {
+			return ErrUsage
+		}
+		return app.rekey(ctx, db)
+	default:
+		return ErrUsage
+	}
+}
+
+// rekey recomputes search keys of books, which is needed once for databases where the migration adding them
+// has been applied before it was followed by this step.
+func (app *App) rekey(ctx context.Context, db *gorm.DB) error {
+	rekeyed, err := booksPostgres.RekeyBooks(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to recompute search keys: %w", err)
+	}
+	fmt.Fprintf(app.out, "recomputed search keys of %d books\n", rekeyed)
+	return nil
+}
@@ -0,0 +1,164 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kind classifies a domain error so that transport layers can map it to a status code.
+type Kind uint8
+
+const (
+	KindInternal             Kind = iota // Unexpected failure (database down, bug, etc.)
+	KindNotFound                         // Requested entity doesn't exist
+	KindConflict                         // Entity clashes with an existing one
+	KindValidation                       // Input doesn't satisfy business rules
+	KindPreconditionFailed               // Request precondition doesn't hold
+	KindPreconditionRequired             // Request must be conditional but isn't
+	KindUnprocessable                    // Request is well-formed, but can't be processed in the current state
+)
+
+// String returns a human-readable name of the kind.
+func (k Kind) String() string {
+	switch k {
+	case KindNotFound:
+		return "not found"
+	case KindConflict:
+		return "conflict"
+	case KindValidation:
+		return "validation failed"
+	case KindPreconditionFailed:
+		return "precondition failed"
+	case KindPreconditionRequired:
+		return "precondition required"
+	case KindUnprocessable:
+		return "unprocessable"
+	default:
+		return "internal error"
+	}
+}
+
+// Sentinel errors that can be matched with errors.Is regardless of the message.
+var (
+	ErrNotFound             = &Error{Kind: KindNotFound}
+	ErrConflict             = &Error{Kind: KindConflict}
+	ErrValidation           = &Error{Kind: KindValidation}
+	ErrPreconditionFailed   = &Error{Kind: KindPreconditionFailed}
+	ErrPreconditionRequired = &Error{Kind: KindPreconditionRequired}
+	ErrUnprocessable        = &Error{Kind: KindUnprocessable}
+)
+
+type (
+	// Error is a domain error returned by repositories and usecases.
+	Error struct {
+		Kind    Kind         // Category of the error
+		Message string       // Safe to show to API clients
+		Fields  []FieldError // Per-field details of a validation error
+		Err     error        // Underlying cause, never shown to API clients
+	}
+
+	// FieldError describes why a single input field is invalid.
+	FieldError struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	}
+)
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = e.Kind.String()
+	}
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+// Unwrap returns the underlying cause.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is a domain error of the same kind.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return t.Kind == e.Kind
+}
+
+// NotFound returns an error of KindNotFound with a formatted message.
+func NotFound(format string, args ...any) error {
+	return &Error{Kind: KindNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+// Conflict returns an error of KindConflict with a formatted message.
+func Conflict(format string, args ...any) error {
+	return &Error{Kind: KindConflict, Message: fmt.Sprintf(format, args...)}
+}
+
+// Validation returns an error of KindValidation with a formatted message.
+func Validation(format string, args ...any) error {
+	return &Error{Kind: KindValidation, Message: fmt.Sprintf(format, args...)}
+}
+
+// InvalidFields returns an error of KindValidation that carries per-field details.
+func InvalidFields(fields ...FieldError) error {
+	return &Error{Kind: KindValidation, Message: "request has invalid fields", Fields: fields}
+}
+
+// PreconditionFailed returns an error of KindPreconditionFailed with a formatted message.
+func PreconditionFailed(format string, args ...any) error {
+	return &Error{Kind: KindPreconditionFailed, Message: fmt.Sprintf(format, args...)}
+}
+
+// PreconditionRequired returns an error of KindPreconditionRequired with a formatted message.
+func PreconditionRequired(format string, args ...any) error {
+	return &Error{Kind: KindPreconditionRequired, Message: fmt.Sprintf(format, args...)}
+}
+
+// Unprocessable returns an error of KindUnprocessable with a formatted message.
+func Unprocessable(format string, args ...any) error {
+	return &Error{Kind: KindUnprocessable, Message: fmt.Sprintf(format, args...)}
+}
+
+// Internal wraps an unexpected error so that its details are hidden from API clients.
+func Internal(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: KindInternal, Message: "internal error", Err: err}
+}
+
+// KindOf returns the kind of the first domain error in the chain, KindInternal otherwise.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return KindInternal
+}
+
+// MessageOf returns the client-safe message of the first domain error in the chain.
+func MessageOf(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		if e.Message != "" {
+			return e.Message
+		}
+		return e.Kind.String()
+	}
+	return KindInternal.String()
+}
+
+// FieldsOf returns the per-field details of the first domain error in the chain.
+func FieldsOf(err error) []FieldError {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Fields
+	}
+	return nil
+}
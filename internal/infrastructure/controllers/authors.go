@@ -0,0 +1,323 @@
+package controllers
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/infrastructure/controllers/dto"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"path"
+	"strings"
+)
+
+type (
+	// AuthorsController handles HTTP requests on authors and the books crediting them.
+	AuthorsController struct {
+		u     authorsUsecase
+		books authorBooksUsecase
+	}
+
+	// authorsUsecase defines the business logic layer interface for author operations.
+	authorsUsecase interface {
+		GetAll(ctx context.Context, params models.AuthorListParams) (*models.AuthorPage, error)  // Retrieves a page of authors
+		GetOne(ctx context.Context, ID uuid.UUID) (*models.Author, error)                        // Retrieves an author by ID, following redirects
+		Lookup(ctx context.Context, name string) (*models.Author, error)                         // Finds an author by the preferred name or a variant
+		Create(ctx context.Context, author models.Author) (*models.Author, error)                // Creates a new author
+		Update(ctx context.Context, ID uuid.UUID, author models.Author) (*models.Author, error)  // Renames an author along with the credits
+		Delete(ctx context.Context, ID uuid.UUID) error                                          // Deletes an author who isn't credited
+		Merge(ctx context.Context, ID uuid.UUID, duplicates []uuid.UUID) (*models.Author, error) // Merges duplicates into an author
+	}
+
+	// authorBooksUsecase defines the methods of the book usecase that the books of an author are listed with.
+	authorBooksUsecase interface {
+		GetAll(ctx context.Context, params models.BookListParams) (*models.BookPage, error)
+	}
+)
+
+// NewAuthorsController initializes a new AuthorsController instance.
+func NewAuthorsController(usecase authorsUsecase, books authorBooksUsecase) *AuthorsController {
+	return &AuthorsController{u: usecase, books: books}
+}
+
+// GetAll handles HTTP GET requests to retrieve a page of authors.
+// @Summary Get a page of authors
+// @Description Retrieves authors ordered by name using keyset pagination.
+// @Tags authors
+// @Produce json,application/problem+json
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param cursor query string false "Cursor returned as next_cursor with the previous page"
+// @Param name query string false "Case-insensitive name prefix"
+// @Success 200 {object} dto.AuthorListDto
+// @Failure 400 {object} dto.Problem "Invalid query parameters"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/authors [get]
+func (c *AuthorsController) GetAll(ctx echo.Context) error {
+	var query dto.ListAuthorsQuery
+	if err := ctx.Bind(&query); err != nil {
+		return errs.Validation("invalid query parameters")
+	}
+	if err := ctx.Validate(query); err != nil {
+		return err
+	}
+	page, err := c.u.GetAll(ctx.Request().Context(), models.AuthorListParams{
+		NamePrefix: query.Name,
+		Limit:      query.Limit,
+		Cursor:     query.Cursor,
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, dto.AuthorListDto{
+		Items:      page.Items,
+		NextCursor: page.NextCursor,
+	})
+}
+
+// GetOne handles HTTP GET requests to retrieve an author by ID.
+// @Summary Get an author by ID
+// @Description Retrieves a single author by their ID. The ID of an author merged into another one
+// @Description permanently redirects to the author they have been merged into.
+// @Tags authors
+// @Produce json,application/problem+json
+// @Param id path string true "Author ID"
+// @Success 200 {object} models.Author
+// @Success 308 "The author has been merged into the one at Location"
+// @Header 308 {string} Location "URL of the author the requested one has been merged into"
+// @Failure 400 {object} dto.Problem "Invalid author ID"
+// @Failure 404 {object} dto.Problem "Author not found"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/authors/{id} [get]
+func (c *AuthorsController) GetOne(ctx echo.Context) error {
+	id, err := parseAuthorID(ctx)
+	if err != nil {
+		return err
+	}
+	author, err := c.u.GetOne(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	if author.ID != id {
+		return redirectAuthor(ctx, id, author.ID)
+	}
+	return ctx.JSON(http.StatusOK, author)
+}
+
+// Lookup handles HTTP GET requests to find an author by name.
+// @Summary Look up an author by name
+// @Description Finds the author whose preferred name or one of the variant names, transliterations included,
+// @Description is the name, ignoring case. Preferred names take precedence over variants.
+// @Tags authors
+// @Produce json,application/problem+json
+// @Param name query string true "Preferred or variant name"
+// @Success 200 {object} models.Author
+// @Failure 400 {object} dto.Problem "Invalid query parameters"
+// @Failure 404 {object} dto.Problem "Author not found"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/authors/lookup [get]
+func (c *AuthorsController) Lookup(ctx echo.Context) error {
+	var query dto.LookupAuthorQuery
+	if err := ctx.Bind(&query); err != nil {
+		return errs.Validation("invalid query parameters")
+	}
+	if err := ctx.Validate(query); err != nil {
+		return err
+	}
+	author, err := c.u.Lookup(ctx.Request().Context(), query.Name)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, author)
+}
+
+// Create handles HTTP POST requests to create a new author.
+// @Summary Create a new author
+// @Description Adds a new author, who can then be credited for books by ID.
+// @Tags authors
+// @Accept json
+// @Produce json,application/problem+json
+// @Param author body dto.AuthorDto true "Author Data"
+// @Success 201 {object} models.Author
+// @Header 201 {string} Location "URL of the created author"
+// @Failure 400 {object} dto.Problem "Invalid request body"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/authors [post]
+func (c *AuthorsController) Create(ctx echo.Context) error {
+	var author dto.AuthorDto
+	if err := ctx.Bind(&author); err != nil {
+		return errs.Validation("invalid request body")
+	}
+	if err := ctx.Validate(author); err != nil {
+		return err
+	}
+	created, err := c.u.Create(ctx.Request().Context(), models.Author{Name: author.Name, Variants: author.Variants})
+	if err != nil {
+		return err
+	}
+	ctx.Response().Header().Set(echo.HeaderLocation, path.Join(ctx.Request().URL.Path, created.ID.String()))
+	return ctx.JSON(http.StatusCreated, created)
+}
+
+// Replace handles HTTP PUT requests to rename an author.
+// @Summary Rename an author
+// @Description Renames an author and replaces the variant names. If the preferred name changes, the Author text
+// @Description of the books crediting the author changes as well, which makes them new versions.
+// @Tags authors
+// @Accept json
+// @Produce json,application/problem+json
+// @Param id path string true "Author ID"
+// @Param author body dto.AuthorDto true "New Author Data"
+// @Success 200 {object} models.Author
+// @Failure 400 {object} dto.Problem "Invalid author ID / Invalid request body"
+// @Failure 404 {object} dto.Problem "Author not found"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/authors/{id} [put]
+func (c *AuthorsController) Replace(ctx echo.Context) error {
+	id, err := parseAuthorID(ctx)
+	if err != nil {
+		return err
+	}
+	var author dto.AuthorDto
+	if err := ctx.Bind(&author); err != nil {
+		return errs.Validation("invalid request body")
+	}
+	if err := ctx.Validate(author); err != nil {
+		return err
+	}
+	updated, err := c.u.Update(ctx.Request().Context(), id, models.Author{Name: author.Name, Variants: author.Variants})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, updated)
+}
+
+// Delete handles HTTP DELETE requests to remove an author by ID.
+// @Summary Delete an author
+// @Description Deletes an author who isn't credited for any book, including the books in the trash.
+// @Tags authors
+// @Produce application/problem+json
+// @Param id path string true "Author ID"
+// @Success 200
+// @Failure 400 {object} dto.Problem "Invalid author ID"
+// @Failure 404 {object} dto.Problem "Author not found"
+// @Failure 409 {object} dto.Problem "Author is credited for books"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/authors/{id} [delete]
+func (c *AuthorsController) Delete(ctx echo.Context) error {
+	id, err := parseAuthorID(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.u.Delete(ctx.Request().Context(), id); err != nil {
+		return err
+	}
+	return ctx.NoContent(http.StatusOK)
+}
+
+// Merge handles HTTP POST requests to merge duplicate authors into an author.
+// @Summary Merge duplicate authors
+// @Description Administrative operation merging duplicates into the author: the books crediting the duplicates
+// @Description credit the author instead and get new versions, the names and variants of the duplicates become
+// @Description variants of the author, and the IDs of the duplicates permanently redirect to the author.
+// @Tags authors
+// @Accept json
+// @Produce json,application/problem+json
+// @Param id path string true "Author ID"
+// @Param duplicates body dto.MergeAuthorsDto true "IDs of the duplicates"
+// @Param Idempotency-Key header string false "Key that makes retries of the request return the first response"
+// @Success 200 {object} models.Author
+// @Failure 400 {object} dto.Problem "Invalid author ID / Invalid request body"
+// @Failure 404 {object} dto.Problem "Author or duplicate not found"
+// @Failure 409 {object} dto.Problem "A request with the same Idempotency-Key is in progress"
+// @Failure 422 {object} dto.Problem "Idempotency-Key has been used for a different request"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/authors/{id}/merge [post]
+func (c *AuthorsController) Merge(ctx echo.Context) error {
+	id, err := parseAuthorID(ctx)
+	if err != nil {
+		return err
+	}
+	var body dto.MergeAuthorsDto
+	if err := ctx.Bind(&body); err != nil {
+		return errs.Validation("invalid request body")
+	}
+	if err := ctx.Validate(body); err != nil {
+		return err
+	}
+	duplicates := make([]uuid.UUID, len(body.Duplicates))
+	for i, duplicate := range body.Duplicates {
+		duplicates[i] = uuid.MustParse(duplicate) // validated above
+	}
+	merged, err := c.u.Merge(ctx.Request().Context(), id, duplicates)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, merged)
+}
+
+// GetBooks handles HTTP GET requests to retrieve a page of books crediting an author.
+// @Summary Get books of an author
+// @Description Retrieves books crediting the author in any role, in the order of creation.
+// @Tags authors
+// @Produce json,application/problem+json
+// @Param id path string true "Author ID"
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param cursor query string false "Cursor returned as next_cursor with the previous page"
+// @Success 200 {object} dto.BookListDto
+// @Success 308 "The author has been merged into the one at Location"
+// @Header 308 {string} Location "URL of the books of the author the requested one has been merged into"
+// @Failure 400 {object} dto.Problem "Invalid author ID / Invalid query parameters"
+// @Failure 404 {object} dto.Problem "Author not found"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/authors/{id}/books [get]
+func (c *AuthorsController) GetBooks(ctx echo.Context) error {
+	id, err := parseAuthorID(ctx)
+	if err != nil {
+		return err
+	}
+	var query dto.AuthorBooksQuery
+	if err := ctx.Bind(&query); err != nil {
+		return errs.Validation("invalid query parameters")
+	}
+	if err := ctx.Validate(query); err != nil {
+		return err
+	}
+	author, err := c.u.GetOne(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	if author.ID != id {
+		return redirectAuthor(ctx, id, author.ID)
+	}
+	page, err := c.books.GetAll(ctx.Request().Context(), models.BookListParams{
+		BookFilter: models.BookFilter{AuthorID: id},
+		Limit:      query.Limit,
+		Cursor:     query.Cursor,
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, dto.BookListDto{
+		Items:      page.Items,
+		NextCursor: page.NextCursor,
+	})
+}
+
+// redirectAuthor permanently redirects a request on a merged author to the same URL of the author
+// they have been merged into.
+func redirectAuthor(ctx echo.Context, from, to uuid.UUID) error {
+	location := *ctx.Request().URL
+	location.Path = strings.Replace(location.Path, from.String(), to.String(), 1)
+	location.RawPath = ""
+	return ctx.Redirect(http.StatusPermanentRedirect, location.RequestURI())
+}
+
+// parseAuthorID extracts the author ID from the path.
+func parseAuthorID(ctx echo.Context) (uuid.UUID, error) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return uuid.Nil, errs.Validation("invalid author ID")
+	}
+	return id, nil
+}
@@ -0,0 +1,250 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/infrastructure/controllers/dto"
+	"github.com/KinitaL/testovoye/internal/models"
+	authors_mock "github.com/KinitaL/testovoye/internal/usecases/authors"
+	usecase_mock "github.com/KinitaL/testovoye/internal/usecases/books"
+	"github.com/KinitaL/testovoye/pkg/validator"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// TestAuthors tests the AuthorsController methods
+func TestAuthors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	e.Validator = validator.New()
+	mockAuthors := authors_mock.NewMockAuthors(ctrl)
+	mockBooks := usecase_mock.NewMockBooks(ctrl)
+	controller := NewAuthorsController(mockAuthors, mockBooks)
+
+	author := models.Author{ID: uuid.New(), Name: "Leo Tolstoy"}
+
+	t.Run("GetAll", func(t *testing.T) {
+		mockAuthors.EXPECT().GetAll(gomock.Any(), models.AuthorListParams{NamePrefix: "leo", Limit: 10}).
+			Return(&models.AuthorPage{Items: []models.Author{author}, NextCursor: "next"}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/authors?name=leo&limit=10", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.GetAll)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response dto.AuthorListDto
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, []models.Author{author}, response.Items)
+		assert.Equal(t, "next", response.NextCursor)
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		mockAuthors.EXPECT().Create(gomock.Any(), models.Author{Name: author.Name}).Return(&author, nil)
+
+		body, _ := json.Marshal(dto.AuthorDto{Name: author.Name})
+		req := httptest.NewRequest(http.MethodPost, "/api/authors", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.Create)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "/api/authors/"+author.ID.String(), rec.Header().Get(echo.HeaderLocation))
+	})
+
+	t.Run("Create without a name", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/authors", bytes.NewBufferString(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.Create)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		var problem dto.Problem
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+		assert.Equal(t, []errs.FieldError{{Field: "name", Message: "is required"}}, problem.Errors)
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		renamed := models.Author{ID: author.ID, Name: "Lev Tolstoy"}
+		mockAuthors.EXPECT().Update(gomock.Any(), author.ID, models.Author{Name: renamed.Name}).Return(&renamed, nil)
+
+		req := httptest.NewRequest(http.MethodPut, "/api/authors/"+author.ID.String(), bytes.NewBufferString(`{"name": "Lev Tolstoy"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(author.ID.String())
+
+		handle(ctx, controller.Replace)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response models.Author
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, renamed, response)
+	})
+
+	t.Run("GetOne of a merged author", func(t *testing.T) {
+		merged := uuid.New()
+		mockAuthors.EXPECT().GetOne(gomock.Any(), merged).Return(&author, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/authors/"+merged.String(), nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(merged.String())
+
+		handle(ctx, controller.GetOne)
+		assert.Equal(t, http.StatusPermanentRedirect, rec.Code)
+		assert.Equal(t, "/api/authors/"+author.ID.String(), rec.Header().Get(echo.HeaderLocation))
+	})
+
+	t.Run("Lookup", func(t *testing.T) {
+		mockAuthors.EXPECT().Lookup(gomock.Any(), "Лев Толстой").Return(&author, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/authors/lookup?name="+url.QueryEscape("Лев Толстой"), nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.Lookup)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response models.Author
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, author, response)
+	})
+
+	t.Run("Lookup without a name", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/authors/lookup", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.Lookup)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Merge", func(t *testing.T) {
+		duplicate := uuid.New()
+		merged := models.Author{ID: author.ID, Name: author.Name, Variants: []string{"Лев Толстой"}}
+		mockAuthors.EXPECT().Merge(gomock.Any(), author.ID, []uuid.UUID{duplicate}).Return(&merged, nil)
+
+		body := `{"duplicates": ["` + duplicate.String() + `"]}`
+		req := httptest.NewRequest(http.MethodPost, "/api/authors/"+author.ID.String()+"/merge", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(author.ID.String())
+
+		handle(ctx, controller.Merge)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response models.Author
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, merged, response)
+	})
+
+	t.Run("Merge with an invalid duplicate", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/authors/"+author.ID.String()+"/merge", bytes.NewBufferString(`{"duplicates": ["42"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(author.ID.String())
+
+		handle(ctx, controller.Merge)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		var problem dto.Problem
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+		assert.Equal(t, []errs.FieldError{{Field: "duplicates[0]", Message: "must be a UUID"}}, problem.Errors)
+	})
+
+	t.Run("Delete credited", func(t *testing.T) {
+		mockAuthors.EXPECT().Delete(gomock.Any(), author.ID).Return(errs.Conflict("author is credited for books"))
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/authors/"+author.ID.String(), nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(author.ID.String())
+
+		handle(ctx, controller.Delete)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("GetBooks", func(t *testing.T) {
+		books := []models.Book{{ID: uuid.New(), Title: "War and Peace", Author: author.Name, Year: 1869,
+			Authors: []models.BookAuthor{{AuthorID: author.ID, Name: author.Name, Role: models.AuthorRoleAuthor}}}}
+		mockAuthors.EXPECT().GetOne(gomock.Any(), author.ID).Return(&author, nil)
+		mockBooks.EXPECT().GetAll(gomock.Any(), models.BookListParams{
+			BookFilter: models.BookFilter{AuthorID: author.ID},
+			Limit:      5,
+		}).Return(&models.BookPage{Items: books}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/authors/"+author.ID.String()+"/books?limit=5", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(author.ID.String())
+
+		handle(ctx, controller.GetBooks)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response dto.BookListDto
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, books[0].Authors, response.Items[0].Authors)
+	})
+
+	t.Run("GetBooks of a merged author", func(t *testing.T) {
+		merged := uuid.New()
+		mockAuthors.EXPECT().GetOne(gomock.Any(), merged).Return(&author, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/authors/"+merged.String()+"/books?limit=5", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(merged.String())
+
+		handle(ctx, controller.GetBooks)
+		assert.Equal(t, http.StatusPermanentRedirect, rec.Code)
+		assert.Equal(t, "/api/authors/"+author.ID.String()+"/books?limit=5", rec.Header().Get(echo.HeaderLocation))
+	})
+
+	t.Run("GetBooks of a missing author", func(t *testing.T) {
+		missing := uuid.New()
+		mockAuthors.EXPECT().GetOne(gomock.Any(), missing).Return(nil, errs.NotFound("author doesn't exist"))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/authors/"+missing.String()+"/books", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(missing.String())
+
+		handle(ctx, controller.GetBooks)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/authors/42", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues("42")
+
+		handle(ctx, controller.GetOne)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"fmt"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/infrastructure/controllers/dto"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// batchStatuses are the statuses of successful batch operations, the same as of the separate requests.
+var batchStatuses = map[models.BookOperationType]int{
+	models.BookOperationCreate: http.StatusCreated,
+	models.BookOperationUpdate: http.StatusOK,
+	models.BookOperationDelete: http.StatusNoContent,
+}
+
+// batchOperation converts a batch operation to the model, validating it the same way as the separate request.
+func (c *Controller) batchOperation(ctx echo.Context, item dto.BookOperationDto) (models.BookOperation, error) {
+	op := models.BookOperation{Type: models.BookOperationType(item.Op), Version: item.Version}
+	if err := ctx.Validate(item); err != nil {
+		return op, err
+	}
+
+	if op.Type == models.BookOperationCreate {
+		if item.ID != "" {
+			return op, errs.InvalidFields(errs.FieldError{Field: "id", Message: "is assigned by the server"})
+		}
+	} else {
+		ID, err := uuid.Parse(item.ID)
+		if err != nil {
+			return op, errs.InvalidFields(errs.FieldError{Field: "id", Message: "must be a book ID"})
+		}
+		op.ID = ID
+		if c.requireIfMatch && op.Version == 0 {
+			return op, errs.PreconditionRequired("version of the book is required")
+		}
+	}
+
+	if op.Type != models.BookOperationDelete {
+		doc := dto.BookDocumentDto{
+			Title:     item.Title,
+			Author:    item.Author,
+			Authors:   item.Authors,
+			Year:      item.Year,
+			ISBN:      item.ISBN,
+			Publisher: item.Publisher,
+			Language:  item.Language,
+			Format:    item.Format,
+			Genres:    item.Genres,
+			Tags:      item.Tags,
+		}
+		if err := ctx.Validate(doc); err != nil {
+			return op, err
+		}
+		op.Book = models.Book{
+			Title:     doc.Title,
+			Author:    doc.Author,
+			Authors:   dto.Credits(doc.Authors),
+			Year:      doc.Year,
+			ISBN:      doc.ISBN,
+			Publisher: doc.Publisher,
+			Language:  doc.Language,
+			Format:    models.BookFormat(doc.Format),
+			Genres:    dto.Genres(doc.Genres),
+			Tags:      doc.Tags,
+		}
+	}
+	return op, nil
+}
+
+// batchResult converts the outcome of a batch operation to the response item.
+func batchResult(opType models.BookOperationType, result models.BookOperationResult) dto.BookOperationResultDto {
+	if result.Err != nil {
+		problem := NewProblem(result.Err)
+		return dto.BookOperationResultDto{Status: problem.Status, Error: &problem}
+	}
+	return dto.BookOperationResultDto{Status: batchStatuses[opType], Book: result.Book}
+}
+
+// operationError prefixes the message and the fields of an error with the position of the invalid operation.
+func operationError(index int, err error) error {
+	fields := errs.FieldsOf(err)
+	for i, field := range fields {
+		fields[i].Field = fmt.Sprintf("operations[%d].%s", index, field.Field)
+	}
+	return &errs.Error{
+		Kind:    errs.KindOf(err),
+		Message: fmt.Sprintf("operation %d: %s", index, errs.MessageOf(err)),
+		Fields:  fields,
+	}
+}
@@ -1,152 +1,893 @@
 package controllers
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"github.com/KinitaL/testovoye/internal/errs"
 	"github.com/KinitaL/testovoye/internal/infrastructure/controllers/dto"
 	"github.com/KinitaL/testovoye/internal/models"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"io"
+	"iter"
+	"mime"
 	"net/http"
+	"path"
+	"slices"
+	"strconv"
+)
+
+// Headers of paginated responses in formats other than JSON.
+const (
+	HeaderLink       = "Link"
+	HeaderTotalCount = "X-Total-Count"
 )
 
 // Controller struct handles HTTP requests and interacts with the usecase layer.
 type (
 	Controller struct {
-		u usecase
+		u              usecase
+		requireIfMatch bool // Whether PATCH and DELETE must carry If-Match
 	}
 
+	// Option configures a Controller.
+	Option func(c *Controller)
+
 	// usecase defines the business logic layer interface for book operations.
 	usecase interface {
-		GetAll(ctx context.Context) ([]models.Book, error)                // Retrieves all books
-		GetOne(ctx context.Context, ID uuid.UUID) (*models.Book, error)   // Retrieves a book by ID
-		Create(ctx context.Context, book models.Book) error               // Creates a new book
-		Update(ctx context.Context, ID uuid.UUID, book models.Book) error // Updates an existing book
-		Delete(ctx context.Context, ID uuid.UUID) error                   // Deletes a book by ID
+		GetAll(ctx context.Context, params models.BookListParams) (*models.BookPage, error)                       // Retrieves a page of books
+		Export(ctx context.Context, filter models.BookFilter) (iter.Seq2[models.Book, error], error)              // Streams all books matching the filter
+		Search(ctx context.Context, query models.BookSearchQuery) (*models.BookSearchResult, error)               // Full-text search over books
+		GetOne(ctx context.Context, ID uuid.UUID) (*models.Book, error)                                           // Retrieves a book by ID
+		GetByISBN(ctx context.Context, number string) (*models.Book, error)                                       // Retrieves a book by ISBN-10 or ISBN-13
+		Create(ctx context.Context, book models.Book) (*models.Book, error)                                       // Creates a new book
+		Update(ctx context.Context, ID uuid.UUID, book models.Book) (*models.Book, error)                         // Replaces an existing book of book.Version, if it is set
+		Patch(ctx context.Context, ID uuid.UUID, version uint64, patch models.BookPatch) (*models.Book, error)    // Changes an existing book of the version (any if 0)
+		Batch(ctx context.Context, ops []models.BookOperation, atomic bool) ([]models.BookOperationResult, error) // Applies many operations, all or nothing if atomic
+		Import(ctx context.Context, books []models.Book, dryRun bool) ([]models.BookImportResult, error)          // Creates books that aren't in the catalog yet
+		Delete(ctx context.Context, ID uuid.UUID, version uint64) error                                           // Moves a book of the version (any if 0) to the trash
+		GetTrash(ctx context.Context, params models.TrashParams) (*models.BookPage, error)                        // Retrieves a page of deleted books
+		Restore(ctx context.Context, ID uuid.UUID) (*models.Book, error)                                          // Moves a book back from the trash
+		Purge(ctx context.Context, ID uuid.UUID) error                                                            // Permanently deletes a book from the trash
 	}
 )
 
 // NewController initializes a new Controller instance.
-func NewController(usecase usecase) *Controller {
-	return &Controller{u: usecase}
+func NewController(usecase usecase, options ...Option) *Controller {
+	c := &Controller{u: usecase}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// RequireIfMatch makes the If-Match header mandatory for requests that modify a book,
+// so that clients can't overwrite changes they haven't seen.
+func RequireIfMatch(required bool) Option {
+	return func(c *Controller) {
+		c.requireIfMatch = required
+	}
 }
 
-// GetAll handles HTTP GET requests to retrieve all books.
-// @Summary Get all books
-// @Description Retrieves a list of all books.
+// GetAll handles HTTP GET requests to retrieve a page of books.
+// @Summary Get a page of books
+// @Description Retrieves books using keyset pagination, with optional sorting and filtering.
+// @Description With format=bibtex, ris or csl-json the books of the page are returned as references,
+// @Description and the URL of the next page is in the Link header.
 // @Tags books
-// @Produce json
-// @Success 200 {array} models.Book
-// @Failure 500 {object} map[string]string "error"
+// @Produce json,application/x-bibtex,application/x-research-info-systems,application/vnd.citationstyles.csl+json,application/problem+json
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param cursor query string false "Cursor returned as next_cursor with the previous page"
+// @Param sort query string false "Sort field" Enums(title, author, year, created_at)
+// @Param order query string false "Sort direction" Enums(asc, desc)
+// @Param locale query string false "Collation of titles and authors, en by default" Enums(en, ru)
+// @Param author query string false "Exact author name, case-insensitive"
+// @Param year_from query int false "Inclusive lower bound of the year"
+// @Param year_to query int false "Inclusive upper bound of the year"
+// @Param title_prefix query string false "Case-insensitive title prefix"
+// @Param with_total query bool false "Whether to return the total number of matching books"
+// @Param one_per_work query bool false "Whether to collapse editions of a work into the earliest matching one"
+// @Param genre query string false "Genre ID, books classified under the genre or its subgenres"
+// @Param tag query string false "Tag, case-insensitive"
+// @Param facets query bool false "Whether to return numbers of matching books by genre, tag and decade"
+// @Param format query string false "Representation of the books, json by default" Enums(json, bibtex, ris, csl-json)
+// @Success 200 {object} dto.BookListDto
+// @Header 200 {string} Link "URL of the next page of references"
+// @Header 200 {integer} X-Total-Count "Total number of matching references, if requested"
+// @Failure 400 {object} dto.Problem "Invalid query parameters"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
 // @Router /api/books [get]
 func (c *Controller) GetAll(ctx echo.Context) error {
-	books, err := c.u.GetAll(ctx.Request().Context())
+	var query dto.ListBooksQuery
+	if err := ctx.Bind(&query); err != nil {
+		return errs.Validation("invalid query parameters")
+	}
+	if err := ctx.Validate(query); err != nil {
+		return err
+	}
+	genreID, _ := uuid.Parse(query.Genre) // validated with the uuid rule, empty means any
+	page, err := c.u.GetAll(ctx.Request().Context(), models.BookListParams{
+		BookFilter: models.BookFilter{
+			Author:      query.Author,
+			YearFrom:    query.YearFrom,
+			YearTo:      query.YearTo,
+			TitlePrefix: query.TitlePrefix,
+			OnePerWork:  query.OnePerWork,
+			GenreID:     genreID,
+			Tag:         query.Tag,
+		},
+		SortBy:     models.BookSortField(query.Sort),
+		Desc:       query.Order == "desc",
+		Locale:     models.Locale(query.Locale),
+		Limit:      query.Limit,
+		Cursor:     query.Cursor,
+		WithTotal:  query.WithTotal,
+		WithFacets: query.Facets,
+	})
+	if err != nil {
+		return err
+	}
+	if format, ok := exportFormats[query.Format]; ok && query.Format != "json" {
+		return c.writeReferences(ctx, format, page)
+	}
+	return ctx.JSON(http.StatusOK, dto.BookListDto{
+		Items:      page.Items,
+		NextCursor: page.NextCursor,
+		Total:      page.Total,
+		Facets:     page.Facets,
+	})
+}
+
+// writeReferences sends a page of books in a citation format. As the format has no place for
+// pagination, the next page is linked in the Link header (RFC 8288) and the total is sent in X-Total-Count.
+func (c *Controller) writeReferences(ctx echo.Context, format exportFormat, page *models.BookPage) error {
+	var body bytes.Buffer
+	w := format.newWriter(&body)
+	for _, book := range page.Items {
+		if err := w.Write(book); err != nil {
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	if page.NextCursor != "" {
+		ctx.Response().Header().Set(HeaderLink, "<"+requestURIWith(ctx, "cursor", page.NextCursor)+`>; rel="next"`)
+	}
+	if page.Total != nil {
+		ctx.Response().Header().Set(HeaderTotalCount, strconv.FormatInt(*page.Total, 10))
+	}
+	return ctx.Blob(http.StatusOK, format.contentType, body.Bytes())
+}
+
+// requestURIWith returns the URI of the request with the query parameter set to the value,
+// or removed if the value is empty.
+func requestURIWith(ctx echo.Context, name, value string) string {
+	uri := *ctx.Request().URL
+	query := uri.Query()
+	if value == "" {
+		query.Del(name)
+	} else {
+		query.Set(name, value)
+	}
+	uri.RawQuery = query.Encode()
+	return uri.RequestURI()
+}
+
+// Export handles HTTP GET requests to download the catalog.
+// @Summary Export books
+// @Description Streams all books matching the filter in the order of creation as a file attachment.
+// @Description If the export fails midway, the connection is closed without completing the response.
+// @Tags books
+// @Produce json,text/csv,application/x-ndjson,application/marc,application/marcxml+xml,application/x-bibtex,application/x-research-info-systems,application/vnd.citationstyles.csl+json,application/problem+json
+// @Param format query string false "File format, json by default" Enums(csv, ndjson, json, marc, marcxml, bibtex, ris, csl-json)
+// @Param author query string false "Exact author name, case-insensitive"
+// @Param year_from query int false "Inclusive lower bound of the year"
+// @Param year_to query int false "Inclusive upper bound of the year"
+// @Param title_prefix query string false "Case-insensitive title prefix"
+// @Success 200 {array} models.Book
+// @Header 200 {string} Content-Disposition "attachment; filename=books.<format>"
+// @Failure 400 {object} dto.Problem "Invalid query parameters"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/books/export [get]
+func (c *Controller) Export(ctx echo.Context) error {
+	var query dto.ExportBooksQuery
+	if err := ctx.Bind(&query); err != nil {
+		return errs.Validation("invalid query parameters")
+	}
+	if err := ctx.Validate(query); err != nil {
+		return err
+	}
+	format, ok := exportFormats[query.Format]
+	if !ok {
+		format = exportFormats["json"]
+	}
+	books, err := c.u.Export(ctx.Request().Context(), models.BookFilter{
+		Author:      query.Author,
+		YearFrom:    query.YearFrom,
+		YearTo:      query.YearTo,
+		TitlePrefix: query.TitlePrefix,
+	})
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return err
 	}
-	return ctx.JSON(http.StatusOK, books)
+
+	// the response is started only after the first book is read, so that
+	// a failure to start the export is still reported with an error status
+	res := ctx.Response()
+	var w bookWriter
+	start := func() {
+		res.Header().Set(echo.HeaderContentType, format.contentType)
+		res.Header().Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{
+			"filename": "books." + format.extension,
+		}))
+		res.WriteHeader(http.StatusOK)
+		w = format.newWriter(res)
+	}
+	written := 0
+	for book, err := range books {
+		if err != nil {
+			if w == nil {
+				return err
+			}
+			// the status has already been sent, so closing the connection
+			// is the only way to let the client know the file is incomplete
+			ctx.Logger().Error(err)
+			panic(http.ErrAbortHandler)
+		}
+		if w == nil {
+			start()
+		}
+		if err := w.Write(book); err != nil {
+			return err
+		}
+		if written++; written%exportFlushSize == 0 {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			res.Flush()
+		}
+	}
+	if w == nil {
+		start()
+	}
+	return w.Close()
+}
+
+// Search handles HTTP GET requests to find books by text.
+// @Summary Search books
+// @Description Performs ranked full-text search over titles and authors. Cyrillic and Latin spellings of a name match each other.
+// @Description Highlights are HTML-escaped, and matched terms are wrapped in <mark> tags.
+// @Description If nothing is found, suggestions contain the closest known titles and authors.
+// @Description Citation formats contain only the found books, without highlights and suggestions.
+// @Tags books
+// @Produce json,application/x-bibtex,application/x-research-info-systems,application/vnd.citationstyles.csl+json,application/problem+json
+// @Param q query string true "Search query"
+// @Param fuzzy query bool false "Whether to also match titles and authors with typos"
+// @Param threshold query number false "Minimal similarity (0-1] of fuzzy matches and suggestions, 0.3 by default"
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param offset query int false "Number of results to skip"
+// @Param format query string false "Representation of the found books, json by default" Enums(json, bibtex, ris, csl-json)
+// @Success 200 {object} dto.BookSearchDto
+// @Failure 400 {object} dto.Problem "Invalid query parameters"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/books/search [get]
+func (c *Controller) Search(ctx echo.Context) error {
+	var query dto.SearchBooksQuery
+	if err := ctx.Bind(&query); err != nil {
+		return errs.Validation("invalid query parameters")
+	}
+	if err := ctx.Validate(query); err != nil {
+		return err
+	}
+	result, err := c.u.Search(ctx.Request().Context(), models.BookSearchQuery{
+		Query:     query.Q,
+		Fuzzy:     query.Fuzzy,
+		Threshold: query.Threshold,
+		Limit:     query.Limit,
+		Offset:    query.Offset,
+	})
+	if err != nil {
+		return err
+	}
+	if format, ok := exportFormats[query.Format]; ok && query.Format != "json" {
+		page := &models.BookPage{Items: make([]models.Book, len(result.Hits))}
+		for i, hit := range result.Hits {
+			page.Items[i] = hit.Book
+		}
+		return c.writeReferences(ctx, format, page)
+	}
+
+	response := dto.BookSearchDto{
+		Items:       make([]dto.BookSearchHitDto, len(result.Hits)),
+		Suggestions: make([]dto.BookSuggestionDto, len(result.Suggestions)),
+	}
+	for i, hit := range result.Hits {
+		response.Items[i] = dto.BookSearchHitDto{
+			Book: hit.Book,
+			Rank: hit.Rank,
+			Highlight: dto.BookHighlightDto{
+				Title:  hit.Highlight.Title,
+				Author: hit.Highlight.Author,
+			},
+		}
+	}
+	for i, suggestion := range result.Suggestions {
+		response.Suggestions[i] = dto.BookSuggestionDto{
+			Text:  suggestion.Text,
+			Field: string(suggestion.Field),
+			Score: suggestion.Score,
+		}
+	}
+	return ctx.JSON(http.StatusOK, response)
 }
 
 // GetOne handles HTTP GET requests to retrieve a book by its ID.
 // @Summary Get a single book
-// @Description Retrieves a book by its unique ID.
+// @Description Retrieves a book by its unique ID. The ETag header holds the version of the book
+// @Description to be sent in If-Match of further updates. With format=marc or format=marcxml the book
+// @Description is returned as a MARC 21 record, with format=bibtex, ris or csl-json as a reference
+// @Description whose citation key is made of the author, the year and the first word of the title.
 // @Tags books
-// @Produce json
+// @Produce json,application/marc,application/marcxml+xml,application/x-bibtex,application/x-research-info-systems,application/vnd.citationstyles.csl+json,application/problem+json
 // @Param id path string true "Book ID"
+// @Param format query string false "Representation of the book, json by default" Enums(json, marc, marcxml, bibtex, ris, csl-json)
+// @Param If-None-Match header string false "ETag of a cached copy of the book"
 // @Success 200 {object} models.Book
-// @Failure 400 {object} map[string]string "Invalid book ID"
-// @Failure 404 {object} map[string]string "Book not found"
-// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Header 200 {string} ETag "Version of the book"
+// @Success 304 "The cached copy is up to date"
+// @Failure 400 {object} dto.Problem "Invalid book ID or format"
+// @Failure 404 {object} dto.Problem "Book not found"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
 // @Router /api/books/{id} [get]
 func (c *Controller) GetOne(ctx echo.Context) error {
-	ID, err := uuid.Parse(ctx.Param("id"))
+	ID, err := parseID(ctx)
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "invalid book ID"})
+		return err
+	}
+	var query dto.GetBookQuery
+	if err := ctx.Bind(&query); err != nil {
+		return errs.Validation("invalid query parameters")
+	}
+	if err := ctx.Validate(query); err != nil {
+		return err
 	}
 	book, err := c.u.GetOne(ctx.Request().Context(), ID)
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return err
+	}
+	return writeBook(ctx, book, query.Format)
+}
+
+// GetByISBN handles HTTP GET requests to retrieve a book by its ISBN.
+// @Summary Get a book by ISBN
+// @Description Retrieves a book by its ISBN-10 or ISBN-13, with or without hyphens. Books store ISBN-13,
+// @Description so "0-14-044913-2" and "978-0-14-044913-6" find the same book. The representations are the same as of GET /books/{id}.
+// @Tags books
+// @Produce json,application/marc,application/marcxml+xml,application/x-bibtex,application/x-research-info-systems,application/vnd.citationstyles.csl+json,application/problem+json
+// @Param isbn path string true "ISBN-10 or ISBN-13"
+// @Param format query string false "Representation of the book, json by default" Enums(json, marc, marcxml, bibtex, ris, csl-json)
+// @Param If-None-Match header string false "ETag of a cached copy of the book"
+// @Success 200 {object} models.Book
+// @Header 200 {string} ETag "Version of the book"
+// @Success 304 "The cached copy is up to date"
+// @Failure 400 {object} dto.Problem "Invalid ISBN or format"
+// @Failure 404 {object} dto.Problem "Book not found"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/books/isbn/{isbn} [get]
+func (c *Controller) GetByISBN(ctx echo.Context) error {
+	var query dto.GetBookQuery
+	if err := ctx.Bind(&query); err != nil {
+		return errs.Validation("invalid query parameters")
+	}
+	if err := ctx.Validate(query); err != nil {
+		return err
+	}
+	book, err := c.u.GetByISBN(ctx.Request().Context(), ctx.Param("isbn"))
+	if err != nil {
+		return err
 	}
+	return writeBook(ctx, book, query.Format)
+}
+
+// writeBook responds with a book in the requested format, or with 304 if the client's copy is up to date.
+func writeBook(ctx echo.Context, book *models.Book, format string) error {
 	if book == nil {
-		return ctx.JSON(http.StatusNotFound, map[string]string{"error": "book not found"})
+		return errs.NotFound("book not found")
+	}
+
+	tag := etag(book)
+	ctx.Response().Header().Set(HeaderETag, tag)
+	if header := ctx.Request().Header.Get(HeaderIfNoneMatch); header != "" {
+		if tags, wildcard := parseETags(header); wildcard || weakMatch(tags, tag) {
+			return ctx.NoContent(http.StatusNotModified)
+		}
+	}
+	if format, ok := bookFormats[format]; ok {
+		data, err := format.marshal(*book)
+		if err != nil {
+			return err
+		}
+		return ctx.Blob(http.StatusOK, format.contentType, data)
 	}
 	return ctx.JSON(http.StatusOK, book)
 }
 
 // Create handles HTTP POST requests to create a new book.
 // @Summary Create a new book
-// @Description Adds a new book to the database.
+// @Description Adds a new book to the database and returns it.
 // @Tags books
 // @Accept json
-// @Produce json
+// @Produce json,application/problem+json
 // @Param book body dto.CreateBookDto true "Book Data"
-// @Success 200
-// @Failure 400 {object} map[string]string "Invalid request body"
-// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Param Idempotency-Key header string false "Key that makes retries of the request return the first response"
+// @Success 201 {object} models.Book
+// @Header 201 {string} Location "URL of the created book"
+// @Header 201 {string} ETag "Version of the book"
+// @Failure 400 {object} dto.Problem "Invalid request body"
+// @Failure 409 {object} dto.Problem "Book already exists or a request with the same Idempotency-Key is in progress"
+// @Failure 422 {object} dto.Problem "Idempotency-Key has been used for a different request"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
 // @Router /api/books [post]
 func (c *Controller) Create(ctx echo.Context) error {
 	var book dto.CreateBookDto
 	if err := ctx.Bind(&book); err != nil {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return errs.Validation("invalid request body")
 	}
 	if err := ctx.Validate(book); err != nil {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return err
 	}
-	if err := c.u.Create(ctx.Request().Context(), models.Book{
-		Title:  book.Title,
-		Author: book.Author,
-		Year:   book.Year,
-	}); err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	created, err := c.u.Create(ctx.Request().Context(), models.Book{
+		Title:     book.Title,
+		Author:    book.Author,
+		Authors:   dto.Credits(book.Authors),
+		Year:      book.Year,
+		ISBN:      book.ISBN,
+		Publisher: book.Publisher,
+		Language:  book.Language,
+		Format:    models.BookFormat(book.Format),
+		Genres:    dto.Genres(book.Genres),
+		Tags:      book.Tags,
+	})
+	if err != nil {
+		return err
 	}
-	return ctx.NoContent(http.StatusOK)
+	ctx.Response().Header().Set(echo.HeaderLocation, path.Join(ctx.Request().URL.Path, created.ID.String()))
+	ctx.Response().Header().Set(HeaderETag, etag(created))
+	return ctx.JSON(http.StatusCreated, created)
+}
+
+// Replace handles HTTP PUT requests to replace all fields of an existing book.
+// @Summary Replace an existing book
+// @Description Replaces all editable fields of an existing book.
+// @Tags books
+// @Accept json
+// @Produce json,application/problem+json
+// @Param id path string true "Book ID"
+// @Param If-Match header string false "ETag of the book the changes are based on, may be required by the server"
+// @Param book body dto.BookDocumentDto true "New Book Data"
+// @Success 200 {object} models.Book
+// @Header 200 {string} ETag "New version of the book"
+// @Failure 400 {object} dto.Problem "Invalid book ID / Invalid request body"
+// @Failure 404 {object} dto.Problem "Book not found"
+// @Failure 412 {object} dto.Problem "Book has been modified since the version in If-Match"
+// @Failure 428 {object} dto.Problem "If-Match is required"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/books/{id} [put]
+func (c *Controller) Replace(ctx echo.Context) error {
+	id, err := parseID(ctx)
+	if err != nil {
+		return err
+	}
+	var doc dto.BookDocumentDto
+	if err := ctx.Bind(&doc); err != nil {
+		return errs.Validation("invalid request body")
+	}
+	if err := ctx.Validate(doc); err != nil {
+		return err
+	}
+	version, err := c.ifMatch(ctx, id)
+	if err != nil {
+		return err
+	}
+	book, err := c.u.Update(ctx.Request().Context(), id, models.Book{
+		Title:     doc.Title,
+		Author:    doc.Author,
+		Authors:   dto.Credits(doc.Authors),
+		Year:      doc.Year,
+		ISBN:      doc.ISBN,
+		Publisher: doc.Publisher,
+		Language:  doc.Language,
+		Format:    models.BookFormat(doc.Format),
+		Genres:    dto.Genres(doc.Genres),
+		Tags:      doc.Tags,
+		Version:   version,
+	})
+	if err != nil {
+		return err
+	}
+	ctx.Response().Header().Set(HeaderETag, etag(book))
+	return ctx.JSON(http.StatusOK, book)
 }
 
 // Update handles HTTP PATCH requests to update an existing book.
 // @Summary Update an existing book
-// @Description Modifies the details of an existing book.
+// @Description Changes an existing book with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902).
+// @Description Both are applied to the document {"title", "author", "authors", "year", "isbn", "publisher", "language", "format",
+// @Description "genres", "tags"},
+// @Description which must stay valid.
+// @Description If only "author" is changed, the credits are made of its names anew.
+// @Description A plain JSON body is treated as a merge patch.
 // @Tags books
-// @Accept json
-// @Produce json
+// @Accept json,application/merge-patch+json,application/json-patch+json
+// @Produce json,application/problem+json
 // @Param id path string true "Book ID"
-// @Param book body models.Book true "Updated Book Data"
-// @Success 200
-// @Failure 400 {object} map[string]string "Invalid book ID / Invalid request body"
-// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Param If-Match header string false "ETag of the book the changes are based on, may be required by the server"
+// @Param book body dto.UpdateBookDto true "Merge patch, or an array of JSON Patch operations"
+// @Success 200 {object} models.Book
+// @Header 200 {string} ETag "New version of the book"
+// @Failure 400 {object} dto.Problem "Invalid book ID / Invalid patch / Patched book is invalid"
+// @Failure 404 {object} dto.Problem "Book not found"
+// @Failure 409 {object} dto.Problem "Patch can't be applied to the book"
+// @Failure 412 {object} dto.Problem "Book has been modified since the version in If-Match"
+// @Failure 415 {object} dto.Problem "Unsupported patch format"
+// @Failure 428 {object} dto.Problem "If-Match is required"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
 // @Router /api/books/{id} [patch]
 func (c *Controller) Update(ctx echo.Context) error {
-	id, err := uuid.Parse(ctx.Param("id"))
+	id, err := parseID(ctx)
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "invalid book ID"})
+		return err
 	}
-	var book models.Book
-	if err := ctx.Bind(&book); err != nil {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	apply, err := patchFormat(ctx)
+	if err != nil {
+		return err
 	}
-	if err := ctx.Validate(book); err != nil {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	body, err := io.ReadAll(ctx.Request().Body)
+	if err != nil {
+		return errs.Validation("invalid request body")
 	}
-	if err := c.u.Update(ctx.Request().Context(), id, book); err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	version, err := c.ifMatch(ctx, id)
+	if err != nil {
+		return err
 	}
-	return ctx.NoContent(http.StatusOK)
+
+	book, err := c.u.Patch(ctx.Request().Context(), id, version, func(book models.Book) (models.Book, error) {
+		credits := dto.CreditsOf(book.Authors)
+		doc, err := json.Marshal(dto.BookDocumentDto{
+			Title:     book.Title,
+			Author:    book.Author,
+			Authors:   credits,
+			Year:      book.Year,
+			ISBN:      book.ISBN,
+			Publisher: book.Publisher,
+			Language:  book.Language,
+			Format:    string(book.Format),
+			Genres:    dto.GenresOf(book.Genres),
+			Tags:      book.Tags,
+		})
+		if err != nil {
+			return models.Book{}, errs.Internal(err)
+		}
+		patched, err := apply(doc, body)
+		if err != nil {
+			return models.Book{}, patchError(err)
+		}
+		result, err := decodeDocument(patched)
+		if err != nil {
+			return models.Book{}, err
+		}
+		if err := ctx.Validate(result); err != nil {
+			return models.Book{}, err
+		}
+		if result.Author != book.Author && slices.Equal(result.Authors, credits) {
+			// only the text has been changed, so the credits are made of it anew
+			result.Authors = nil
+		}
+		return models.Book{
+			Title:     result.Title,
+			Author:    result.Author,
+			Authors:   dto.Credits(result.Authors),
+			Year:      result.Year,
+			ISBN:      result.ISBN,
+			Publisher: result.Publisher,
+			Language:  result.Language,
+			Format:    models.BookFormat(result.Format),
+			Genres:    dto.Genres(result.Genres),
+			Tags:      result.Tags,
+		}, nil
+	})
+	if err != nil {
+		return err
+	}
+	ctx.Response().Header().Set(HeaderETag, etag(book))
+	return ctx.JSON(http.StatusOK, book)
 }
 
 // Delete handles HTTP DELETE requests to remove a book by ID.
 // @Summary Delete a book
-// @Description Removes a book from the database using its ID.
+// @Description Moves a book to the trash. It can be restored until it is purged.
 // @Tags books
+// @Produce application/problem+json
 // @Param id path string true "Book ID"
+// @Param If-Match header string false "ETag of the book, may be required by the server"
 // @Success 200
-// @Failure 400 {object} map[string]string "Invalid book ID"
-// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Failure 400 {object} dto.Problem "Invalid book ID"
+// @Failure 404 {object} dto.Problem "Book not found"
+// @Failure 412 {object} dto.Problem "Book has been modified since the version in If-Match"
+// @Failure 428 {object} dto.Problem "If-Match is required"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
 // @Router /api/books/{id} [delete]
 func (c *Controller) Delete(ctx echo.Context) error {
-	id, err := uuid.Parse(ctx.Param("id"))
+	id, err := parseID(ctx)
+	if err != nil {
+		return err
+	}
+	version, err := c.ifMatch(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := c.u.Delete(ctx.Request().Context(), id, version); err != nil {
+		return err
+	}
+	return ctx.NoContent(http.StatusOK)
+}
+
+// Batch handles HTTP POST requests to create, replace and delete many books at once.
+// @Summary Change many books at once
+// @Description Applies a list of create, update (full replace) and delete operations.
+// @Description In the atomic mode (default) either all operations are applied or none, and the first failure is returned as the error.
+// @Description In the best-effort mode each operation is applied on its own, and its status is reported in the result with the same index.
+// @Tags books
+// @Accept json
+// @Produce json,application/problem+json
+// @Param batch body dto.BatchRequestDto true "Operations"
+// @Param Idempotency-Key header string false "Key that makes retries of the request return the first response"
+// @Success 200 {object} dto.BatchResultDto
+// @Failure 400 {object} dto.Problem "Invalid request body or operation"
+// @Failure 404 {object} dto.Problem "Book of an atomic operation doesn't exist"
+// @Failure 409 {object} dto.Problem "Request with the same Idempotency-Key is in progress"
+// @Failure 412 {object} dto.Problem "Book of an atomic operation has been modified since the version"
+// @Failure 422 {object} dto.Problem "Idempotency-Key has been used for a different request"
+// @Failure 428 {object} dto.Problem "Version of an atomic operation is required"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/books:batch [post]
+func (c *Controller) Batch(ctx echo.Context) error {
+	var req dto.BatchRequestDto
+	if err := ctx.Bind(&req); err != nil {
+		return errs.Validation("invalid request body")
+	}
+	if err := ctx.Validate(req); err != nil {
+		return err
+	}
+	atomic := req.Mode != dto.BatchModeBestEffort
+
+	// invalid operations fail the whole atomic batch, otherwise only themselves
+	results := make([]dto.BookOperationResultDto, len(req.Operations))
+	ops := make([]models.BookOperation, 0, len(req.Operations))
+	positions := make([]int, 0, len(req.Operations))
+	for i, item := range req.Operations {
+		op, err := c.batchOperation(ctx, item)
+		switch {
+		case err != nil && atomic:
+			return operationError(i, err)
+		case err != nil:
+			results[i] = batchResult(op.Type, models.BookOperationResult{Err: err})
+		default:
+			ops = append(ops, op)
+			positions = append(positions, i)
+		}
+	}
+
+	if len(ops) > 0 {
+		applied, err := c.u.Batch(ctx.Request().Context(), ops, atomic)
+		if err != nil {
+			return err
+		}
+		for k, i := range positions {
+			results[i] = batchResult(ops[k].Type, applied[k])
+		}
+	}
+	return ctx.JSON(http.StatusOK, dto.BatchResultDto{Results: results})
+}
+
+// Import handles HTTP POST requests to create books from an uploaded file of MARC 21 records.
+// @Summary Import books from MARC records
+// @Description Creates books from an ISO 2709 (application/marc) or MARCXML (application/marcxml+xml) file of up to 1000 records.
+// @Description The author is taken from field 100, the title from 245 and the year from 264 or 260.
+// @Description Each record is reported in the result with the same index: 201 with the created book,
+// @Description 409 if the book is already in the catalog or earlier in the file, or the problem of an invalid record.
+// @Tags books
+// @Accept application/marc,application/marcxml+xml
+// @Produce json,application/problem+json
+// @Param dry_run query bool false "Whether to only check the records without creating books"
+// @Param Idempotency-Key header string false "Key that makes retries of the request return the first response"
+// @Success 200 {object} dto.BatchResultDto
+// @Failure 400 {object} dto.Problem "Malformed file or too many records"
+// @Failure 409 {object} dto.Problem "Request with the same Idempotency-Key is in progress"
+// @Failure 415 {object} dto.Problem "Unsupported file format"
+// @Failure 422 {object} dto.Problem "Idempotency-Key has been used for a different request"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/books:import [post]
+func (c *Controller) Import(ctx echo.Context) error {
+	// the body is a file, so only the query is bound
+	var query dto.ImportBooksQuery
+	if err := (&echo.DefaultBinder{}).BindQueryParams(ctx, &query); err != nil {
+		return errs.Validation("invalid query parameters")
+	}
+	records, err := newMARCReader(ctx)
+	if err != nil {
+		return err
+	}
+
+	// invalid records are reported on their own, the valid ones are imported at once
+	var (
+		results   []dto.BookOperationResultDto
+		batch     []models.Book
+		positions []int
+	)
+	for i := 0; ; i++ {
+		record, err := records.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return errs.Validation("record %d: %v", i, err)
+		}
+		if i == maxImportRecords {
+			return errs.Validation("file must have at most %d records", maxImportRecords)
+		}
+
+		book, err := marcBook(ctx, record)
+		if err != nil {
+			problem := NewProblem(err)
+			results = append(results, dto.BookOperationResultDto{Status: problem.Status, Error: &problem})
+			continue
+		}
+		results = append(results, dto.BookOperationResultDto{})
+		batch = append(batch, book)
+		positions = append(positions, i)
+	}
+	if len(results) == 0 {
+		return errs.Validation("file has no records")
+	}
+
+	if len(batch) > 0 {
+		imported, err := c.u.Import(ctx.Request().Context(), batch, query.DryRun)
+		if err != nil {
+			return err
+		}
+		for k, i := range positions {
+			results[i] = importResult(imported[k])
+		}
+	}
+	return ctx.JSON(http.StatusOK, dto.BatchResultDto{Results: results})
+}
+
+// GetTrash handles HTTP GET requests to retrieve a page of deleted books.
+// @Summary Get a page of deleted books
+// @Description Retrieves books in the trash, most recently deleted first.
+// @Tags trash
+// @Produce json,application/problem+json
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param cursor query string false "Cursor returned as next_cursor with the previous page"
+// @Success 200 {object} dto.BookListDto
+// @Failure 400 {object} dto.Problem "Invalid query parameters"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/trash/books [get]
+func (c *Controller) GetTrash(ctx echo.Context) error {
+	var query dto.ListTrashQuery
+	if err := ctx.Bind(&query); err != nil {
+		return errs.Validation("invalid query parameters")
+	}
+	if err := ctx.Validate(query); err != nil {
+		return err
+	}
+	page, err := c.u.GetTrash(ctx.Request().Context(), models.TrashParams{
+		Limit:  query.Limit,
+		Cursor: query.Cursor,
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, dto.BookListDto{
+		Items:      page.Items,
+		NextCursor: page.NextCursor,
+	})
+}
+
+// Restore handles HTTP POST requests to move a book back from the trash.
+// @Summary Restore a deleted book
+// @Description Moves a book from the trash back to the catalog.
+// @Tags trash
+// @Produce json,application/problem+json
+// @Param id path string true "Book ID"
+// @Param Idempotency-Key header string false "Key that makes retries of the request return the first response"
+// @Success 200 {object} models.Book
+// @Failure 400 {object} dto.Problem "Invalid book ID"
+// @Failure 404 {object} dto.Problem "Book is not in the trash"
+// @Failure 409 {object} dto.Problem "Request with the same Idempotency-Key is in progress"
+// @Failure 422 {object} dto.Problem "Idempotency-Key has been used for a different request"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/books/{id}/restore [post]
+func (c *Controller) Restore(ctx echo.Context) error {
+	id, err := parseID(ctx)
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"error": "invalid book ID"})
+		return err
 	}
-	if err := c.u.Delete(ctx.Request().Context(), id); err != nil {
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	book, err := c.u.Restore(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, book)
+}
+
+// Purge handles HTTP DELETE requests to permanently remove a book from the trash.
+// @Summary Purge a deleted book
+// @Description Permanently removes a book that is in the trash. It can't be restored afterwards.
+// @Tags trash
+// @Produce application/problem+json
+// @Param id path string true "Book ID"
+// @Success 200
+// @Failure 400 {object} dto.Problem "Invalid book ID"
+// @Failure 404 {object} dto.Problem "Book is not in the trash"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/trash/books/{id} [delete]
+func (c *Controller) Purge(ctx echo.Context) error {
+	id, err := parseID(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.u.Purge(ctx.Request().Context(), id); err != nil {
+		return err
 	}
 	return ctx.NoContent(http.StatusOK)
 }
+
+// ifMatch returns the version of the book required by the If-Match header, 0 if any version will do.
+func (c *Controller) ifMatch(ctx echo.Context, ID uuid.UUID) (uint64, error) {
+	header := ctx.Request().Header.Get(HeaderIfMatch)
+	if header == "" {
+		if c.requireIfMatch {
+			return 0, errs.PreconditionRequired("If-Match header with the ETag of the book is required")
+		}
+		return 0, nil
+	}
+
+	tags, wildcard := parseETags(header)
+	if wildcard {
+		return 0, nil
+	}
+	if len(tags) == 1 {
+		version, ok := versionOf(tags[0])
+		if !ok {
+			return 0, errs.PreconditionFailed("If-Match doesn't match the current version of the book")
+		}
+		return version, nil
+	}
+
+	// with several tags the current version must be one of them,
+	// the write is then conditioned on that version to stay atomic
+	book, err := c.u.GetOne(ctx.Request().Context(), ID)
+	if err != nil {
+		return 0, err
+	}
+	for _, tag := range tags {
+		if version, ok := versionOf(tag); ok && version == book.Version {
+			return version, nil
+		}
+	}
+	return 0, errs.PreconditionFailed("If-Match doesn't match the current version of the book")
+}
+
+// parseID extracts the book ID from the path.
+func parseID(ctx echo.Context) (uuid.UUID, error) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return uuid.Nil, errs.Validation("invalid book ID")
+	}
+	return id, nil
+}
@@ -2,26 +2,42 @@ package controllers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"github.com/KinitaL/testovoye/internal/errs"
 	"github.com/KinitaL/testovoye/internal/infrastructure/controllers/dto"
 	"github.com/KinitaL/testovoye/internal/models"
 	usecase_mock "github.com/KinitaL/testovoye/internal/usecases/books"
+	"github.com/KinitaL/testovoye/pkg/citation"
+	"github.com/KinitaL/testovoye/pkg/marc"
 	"github.com/KinitaL/testovoye/pkg/validator"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
+	"iter"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
+// handle runs the handler and renders a returned error the same way the server does.
+func handle(ctx echo.Context, h echo.HandlerFunc) {
+	if err := h(ctx); err != nil {
+		HTTPErrorHandler(err, ctx)
+	}
+}
+
 // TestGetAll tests the GetAll controller method
 func TestGetAll(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	e := echo.New()
+	e.Validator = validator.New()
 	mockUsecase := usecase_mock.NewMockBooks(ctrl)
 	controller := NewController(mockUsecase)
 
@@ -30,9 +46,14 @@ func TestGetAll(t *testing.T) {
 		{ID: uuid.New(), Title: "Book 2", Author: "Author 2", Year: 2022},
 	}
 
-	mockUsecase.EXPECT().GetAll(gomock.Any()).Return(books, nil).AnyTimes()
+	mockUsecase.EXPECT().GetAll(gomock.Any(), models.BookListParams{
+		BookFilter: models.BookFilter{Author: "Author 1"},
+		SortBy:     models.BookSortYear,
+		Desc:       true,
+		Limit:      10,
+	}).Return(&models.BookPage{Items: books, NextCursor: "next"}, nil).AnyTimes()
 
-	req := httptest.NewRequest(http.MethodGet, "/books", nil)
+	req := httptest.NewRequest(http.MethodGet, "/books?limit=10&sort=year&order=desc&author=Author+1", nil)
 	rec := httptest.NewRecorder()
 	ctx := e.NewContext(req, rec)
 
@@ -40,10 +61,277 @@ func TestGetAll(t *testing.T) {
 	assert.Equal(t, err, nil)
 	assert.Equal(t, rec.Code, http.StatusOK)
 
-	var response []models.Book
+	var response dto.BookListDto
 	err = json.Unmarshal(rec.Body.Bytes(), &response)
 	assert.Equal(t, err, nil)
-	assert.Equal(t, len(response), len(books))
+	assert.Equal(t, len(response.Items), len(books))
+	assert.Equal(t, response.NextCursor, "next")
+
+	t.Run("BibTeX", func(t *testing.T) {
+		total := int64(5)
+		mockUsecase.EXPECT().GetAll(gomock.Any(), models.BookListParams{Limit: 2, WithTotal: true}).
+			Return(&models.BookPage{Items: books, NextCursor: "next", Total: &total}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/books?limit=2&with_total=true&format=bibtex", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		err := controller.GetAll(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, rec.Code, http.StatusOK)
+		assert.Equal(t, rec.Header().Get(echo.HeaderContentType), citation.MIMEBibTeX)
+		assert.Equal(t, rec.Header().Get(HeaderLink), `</books?cursor=next&format=bibtex&limit=2&with_total=true>; rel="next"`)
+		assert.Equal(t, rec.Header().Get(HeaderTotalCount), "5")
+
+		reader := citation.NewBibTeXReader(rec.Body)
+		for _, book := range books {
+			entry, err := reader.Read()
+			assert.Equal(t, err, nil)
+			assert.Equal(t, entry.Key, citation.Key(book))
+			assert.Equal(t, citation.FromBibTeX(entry), models.Book{Title: book.Title, Author: book.Author, Year: book.Year})
+		}
+	})
+
+	t.Run("Genre, tag and facets", func(t *testing.T) {
+		genreID := uuid.New()
+		facets := &models.BookFacets{
+			Genres:  []models.GenreFacet{{ID: genreID, Name: "Science fiction", Count: 2}},
+			Tags:    []models.TagFacet{{Tag: "classics", Count: 1}},
+			Decades: []models.DecadeFacet{{Decade: 2020, Count: 2}},
+		}
+		mockUsecase.EXPECT().GetAll(gomock.Any(), models.BookListParams{
+			BookFilter: models.BookFilter{GenreID: genreID, Tag: "classics"},
+			WithFacets: true,
+		}).Return(&models.BookPage{Items: books, Facets: facets}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/books?genre="+genreID.String()+"&tag=classics&facets=true", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.GetAll)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response dto.BookListDto
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, facets, response.Facets)
+	})
+
+	t.Run("Invalid genre", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/books?genre=fiction", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.GetAll)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Invalid sort", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/books?sort=isbn", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.GetAll)
+		assert.Equal(t, rec.Code, http.StatusBadRequest)
+	})
+}
+
+// TestSearch tests the Search controller method
+func TestSearch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	e.Validator = validator.New()
+	mockUsecase := usecase_mock.NewMockBooks(ctrl)
+	controller := NewController(mockUsecase)
+
+	hit := models.BookSearchHit{
+		Book:      models.Book{ID: uuid.New(), Title: "War and Peace", Author: "Leo Tolstoy", Year: 1869},
+		Rank:      0.5,
+		Highlight: models.BookHighlight{Title: "<mark>War</mark> and Peace", Author: "Leo Tolstoy"},
+	}
+
+	mockUsecase.EXPECT().Search(gomock.Any(), models.BookSearchQuery{Query: "war", Limit: 5}).
+		Return(&models.BookSearchResult{Hits: []models.BookSearchHit{hit}}, nil).AnyTimes()
+
+	req := httptest.NewRequest(http.MethodGet, "/books/search?q=war&limit=5", nil)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+
+	err := controller.Search(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var response dto.BookSearchDto
+	err = json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(response.Items), 1)
+	assert.Equal(t, response.Items[0].Book.ID, hit.Book.ID)
+	assert.Equal(t, response.Items[0].Highlight.Title, hit.Highlight.Title)
+
+	t.Run("Missing query", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/books/search", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.Search)
+		assert.Equal(t, rec.Code, http.StatusBadRequest)
+	})
+}
+
+// TestExport tests streaming of books in the supported formats
+func TestExport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	e.Validator = validator.New()
+	mockUsecase := usecase_mock.NewMockBooks(ctrl)
+	controller := NewController(mockUsecase)
+
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	work := uuid.New()
+	books := []models.Book{
+		{ID: uuid.New(), Title: "Book 1", Author: "Author, Jr.", Year: 2021, Version: 1, CreatedAt: created, UpdatedAt: created},
+		{
+			ID: uuid.New(), Title: "Book 2", Author: "Author", Year: 2022, ISBN: "9780140449136",
+			Publisher: "Penguin", Language: "en", Format: models.BookFormatPaperback, WorkID: &work,
+			Version: 2, CreatedAt: created, UpdatedAt: created,
+		},
+	}
+	stream := func(books []models.Book, err error) iter.Seq2[models.Book, error] {
+		return func(yield func(models.Book, error) bool) {
+			for _, book := range books {
+				if !yield(book, nil) {
+					return
+				}
+			}
+			if err != nil {
+				yield(models.Book{}, err)
+			}
+		}
+	}
+
+	t.Run("CSV", func(t *testing.T) {
+		mockUsecase.EXPECT().Export(gomock.Any(), models.BookFilter{YearFrom: 2020}).Return(stream(books, nil), nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/books/export?format=csv&year_from=2020", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		err := controller.Export(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, rec.Code, http.StatusOK)
+		assert.Equal(t, rec.Header().Get(echo.HeaderContentType), "text/csv; charset=utf-8")
+		assert.Equal(t, rec.Header().Get(echo.HeaderContentDisposition), `attachment; filename=books.csv`)
+		assert.Equal(t, rec.Body.String(), "id,title,author,year,isbn,publisher,language,format,work_id,version,created_at,updated_at\n"+
+			books[0].ID.String()+`,Book 1,"Author, Jr.",2021,,,,,,1,2024-05-01T12:00:00Z,2024-05-01T12:00:00Z`+"\n"+
+			books[1].ID.String()+`,Book 2,Author,2022,9780140449136,Penguin,en,paperback,`+work.String()+`,2,2024-05-01T12:00:00Z,2024-05-01T12:00:00Z`+"\n")
+	})
+
+	t.Run("NDJSON", func(t *testing.T) {
+		mockUsecase.EXPECT().Export(gomock.Any(), models.BookFilter{}).Return(stream(books, nil), nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/books/export?format=ndjson", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		err := controller.Export(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, rec.Header().Get(echo.HeaderContentType), "application/x-ndjson")
+
+		decoder := json.NewDecoder(rec.Body)
+		for _, book := range books {
+			var exported models.Book
+			assert.Equal(t, decoder.Decode(&exported), nil)
+			assert.Equal(t, exported.ID, book.ID)
+		}
+		assert.False(t, decoder.More())
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		mockUsecase.EXPECT().Export(gomock.Any(), models.BookFilter{Author: "Author"}).Return(stream(books, nil), nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/books/export?author=Author", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		err := controller.Export(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, rec.Header().Get(echo.HeaderContentType), "application/json")
+		assert.Equal(t, rec.Header().Get(echo.HeaderContentDisposition), `attachment; filename=books.json`)
+
+		var exported []models.Book
+		assert.Equal(t, json.Unmarshal(rec.Body.Bytes(), &exported), nil)
+		assert.Equal(t, len(exported), len(books))
+	})
+
+	t.Run("CSL-JSON", func(t *testing.T) {
+		twins := []models.Book{books[1], books[1]}
+		mockUsecase.EXPECT().Export(gomock.Any(), models.BookFilter{}).Return(stream(twins, nil), nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/books/export?format=csl-json", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		err := controller.Export(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, rec.Header().Get(echo.HeaderContentType), citation.MIMECSLJSON)
+		assert.Equal(t, rec.Header().Get(echo.HeaderContentDisposition), `attachment; filename=books.csl.json`)
+
+		var items []citation.Item
+		assert.Equal(t, json.Unmarshal(rec.Body.Bytes(), &items), nil)
+		assert.Equal(t, len(items), 2)
+		assert.Equal(t, items[0].ID, "author2022book")
+		assert.Equal(t, items[1].ID, "author2022booka")
+		assert.Equal(t, items[0].Author, []citation.ItemName{{Family: "Author"}})
+	})
+
+	t.Run("Empty JSON", func(t *testing.T) {
+		mockUsecase.EXPECT().Export(gomock.Any(), models.BookFilter{}).Return(stream(nil, nil), nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/books/export?format=json", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		err := controller.Export(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, rec.Code, http.StatusOK)
+		assert.Equal(t, rec.Body.String(), "[]\n")
+	})
+
+	t.Run("Invalid format", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/books/export?format=xml", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.Export)
+		assert.Equal(t, rec.Code, http.StatusBadRequest)
+	})
+
+	t.Run("Failure before the first book", func(t *testing.T) {
+		mockUsecase.EXPECT().Export(gomock.Any(), models.BookFilter{}).Return(stream(nil, errors.New("connection refused")), nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/books/export?format=csv", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.Export)
+		assert.Equal(t, rec.Code, http.StatusInternalServerError)
+		assert.Equal(t, rec.Header().Get(echo.HeaderContentDisposition), "")
+	})
+
+	t.Run("Failure midway", func(t *testing.T) {
+		mockUsecase.EXPECT().Export(gomock.Any(), models.BookFilter{}).Return(stream(books, errors.New("connection reset")), nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/books/export?format=ndjson", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			_ = controller.Export(ctx)
+		})
+	})
 }
 
 // TestGetOne tests GetOne with a valid and invalid UUID
@@ -52,11 +340,12 @@ func TestGetOne(t *testing.T) {
 	defer ctrl.Finish()
 
 	e := echo.New()
+	e.Validator = validator.New()
 	mockUsecase := usecase_mock.NewMockBooks(ctrl)
 	controller := NewController(mockUsecase)
 
 	bookID := uuid.New()
-	book := &models.Book{ID: bookID, Title: "Test Book", Author: "Test Author", Year: 2023}
+	book := &models.Book{ID: bookID, Title: "Test Book", Author: "Test Author", Year: 2023, Version: 3}
 
 	t.Run("Success", func(t *testing.T) {
 		mockUsecase.EXPECT().GetOne(gomock.Any(), bookID).Return(book, nil).AnyTimes()
@@ -75,6 +364,82 @@ func TestGetOne(t *testing.T) {
 		err = json.Unmarshal(rec.Body.Bytes(), &response)
 		assert.Equal(t, err, nil)
 		assert.Equal(t, response.ID, book.ID)
+		assert.Equal(t, rec.Header().Get(HeaderETag), `"3"`)
+	})
+
+	t.Run("Not Modified", func(t *testing.T) {
+		for _, header := range []string{`"3"`, `W/"3"`, `"2", "3"`, "*"} {
+			req := httptest.NewRequest(http.MethodGet, "/books/"+bookID.String(), nil)
+			req.Header.Set(HeaderIfNoneMatch, header)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(bookID.String())
+
+			err := controller.GetOne(ctx)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, rec.Code, http.StatusNotModified, header)
+			assert.Equal(t, rec.Header().Get(HeaderETag), `"3"`)
+			assert.Empty(t, rec.Body.String())
+		}
+	})
+
+	t.Run("Modified", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/books/"+bookID.String(), nil)
+		req.Header.Set(HeaderIfNoneMatch, `"2"`)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(bookID.String())
+
+		err := controller.GetOne(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, rec.Code, http.StatusOK)
+	})
+
+	t.Run("MARCXML", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/books/"+bookID.String()+"?format=marcxml", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(bookID.String())
+
+		err := controller.GetOne(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, rec.Code, http.StatusOK)
+		assert.Equal(t, rec.Header().Get(echo.HeaderContentType), marc.MIMEMARCXML)
+		assert.Equal(t, rec.Header().Get(HeaderETag), `"3"`)
+
+		record, err := marc.NewXMLReader(rec.Body).Read()
+		assert.Equal(t, err, nil)
+		assert.Equal(t, record.ControlField(marc.TagControlNumber), bookID.String())
+		assert.Equal(t, marc.ToBook(record), models.Book{Title: book.Title, Author: book.Author, Year: book.Year})
+	})
+
+	t.Run("RIS", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/books/"+bookID.String()+"?format=ris", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(bookID.String())
+
+		err := controller.GetOne(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, rec.Code, http.StatusOK)
+		assert.Equal(t, rec.Header().Get(echo.HeaderContentType), citation.MIMERIS)
+		assert.Equal(t, rec.Body.String(), "TY  - BOOK\r\nID  - author2023test\r\nAU  - Author, Test\r\n"+
+			"TI  - Test Book\r\nPY  - 2023\r\nER  - \r\n\r\n")
+	})
+
+	t.Run("Invalid format", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/books/"+bookID.String()+"?format=mods", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(bookID.String())
+
+		handle(ctx, controller.GetOne)
+		assert.Equal(t, rec.Code, http.StatusBadRequest)
 	})
 
 	t.Run("Invalid UUID", func(t *testing.T) {
@@ -84,14 +449,13 @@ func TestGetOne(t *testing.T) {
 		ctx.SetParamNames("id")
 		ctx.SetParamValues("invalid-uuid")
 
-		err := controller.GetOne(ctx)
-		assert.Equal(t, err, nil)
+		handle(ctx, controller.GetOne)
 		assert.Equal(t, rec.Code, http.StatusBadRequest)
 	})
 
 	t.Run("Not Found", func(t *testing.T) {
 		bookID := uuid.New()
-		mockUsecase.EXPECT().GetOne(gomock.Any(), bookID).Return(nil, nil).AnyTimes()
+		mockUsecase.EXPECT().GetOne(gomock.Any(), bookID).Return(nil, errs.NotFound("book doesn't exist")).AnyTimes()
 
 		req := httptest.NewRequest(http.MethodGet, "/books/"+bookID.String(), nil)
 		rec := httptest.NewRecorder()
@@ -99,8 +463,90 @@ func TestGetOne(t *testing.T) {
 		ctx.SetParamNames("id")
 		ctx.SetParamValues(bookID.String())
 
-		err := controller.GetOne(ctx)
+		handle(ctx, controller.GetOne)
+		assert.Equal(t, rec.Code, http.StatusNotFound)
+	})
+
+	t.Run("Internal Error", func(t *testing.T) {
+		bookID := uuid.New()
+		mockUsecase.EXPECT().GetOne(gomock.Any(), bookID).Return(nil, errs.Internal(errors.New("connection refused"))).AnyTimes()
+
+		req := httptest.NewRequest(http.MethodGet, "/books/"+bookID.String(), nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(bookID.String())
+
+		handle(ctx, controller.GetOne)
+		assert.Equal(t, rec.Code, http.StatusInternalServerError)
+		assert.NotContains(t, rec.Body.String(), "connection refused")
+	})
+}
+
+// TestGetByISBN tests the GetByISBN controller method
+func TestGetByISBN(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	e.Validator = validator.New()
+	mockUsecase := usecase_mock.NewMockBooks(ctrl)
+	controller := NewController(mockUsecase)
+
+	book := &models.Book{ID: uuid.New(), Title: "War and Peace", Author: "Leo Tolstoy", Year: 1869, ISBN: "9780140449136", Version: 2}
+
+	request := func(number, query string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodGet, "/books/isbn/"+number+query, nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("isbn")
+		ctx.SetParamValues(number)
+		return ctx, rec
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockUsecase.EXPECT().GetByISBN(gomock.Any(), "0-14-044913-2").Return(book, nil)
+
+		ctx, rec := request("0-14-044913-2", "")
+		err := controller.GetByISBN(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, rec.Code, http.StatusOK)
+		assert.Equal(t, rec.Header().Get(HeaderETag), `"2"`)
+
+		var response models.Book
+		err = json.Unmarshal(rec.Body.Bytes(), &response)
 		assert.Equal(t, err, nil)
+		assert.Equal(t, response.ID, book.ID)
+		assert.Equal(t, response.ISBN, book.ISBN)
+	})
+
+	t.Run("MARC", func(t *testing.T) {
+		mockUsecase.EXPECT().GetByISBN(gomock.Any(), book.ISBN).Return(book, nil)
+
+		ctx, rec := request(book.ISBN, "?format=marc")
+		err := controller.GetByISBN(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, rec.Header().Get(echo.HeaderContentType), marc.MIMEMARC)
+
+		record, err := marc.NewReader(rec.Body).Read()
+		assert.Equal(t, err, nil)
+		assert.Equal(t, record.DataFields(marc.TagISBN)[0].Subfield('a'), book.ISBN)
+		assert.Equal(t, marc.ToBook(record).ISBN, book.ISBN)
+	})
+
+	t.Run("Invalid ISBN", func(t *testing.T) {
+		mockUsecase.EXPECT().GetByISBN(gomock.Any(), "123").Return(nil, errs.Validation("%q is not a valid ISBN", "123"))
+
+		ctx, rec := request("123", "")
+		handle(ctx, controller.GetByISBN)
+		assert.Equal(t, rec.Code, http.StatusBadRequest)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		mockUsecase.EXPECT().GetByISBN(gomock.Any(), "9785170903351").Return(nil, errs.NotFound("book with ISBN 9785170903351 doesn't exist"))
+
+		ctx, rec := request("9785170903351", "")
+		handle(ctx, controller.GetByISBN)
 		assert.Equal(t, rec.Code, http.StatusNotFound)
 	})
 }
@@ -118,10 +564,15 @@ func TestCreate(t *testing.T) {
 	payload := dto.CreateBookDto{Title: "New Book", Author: "New Author", Year: 2023}
 	book := models.Book{Title: payload.Title, Author: payload.Author, Year: payload.Year}
 
-	mockUsecase.EXPECT().Create(gomock.Any(), book).Return(nil).AnyTimes()
+	created := book
+	created.ID = uuid.New()
+	created.CreatedAt = time.Now().UTC()
+	created.UpdatedAt = created.CreatedAt
+	created.Version = 1
+	mockUsecase.EXPECT().Create(gomock.Any(), book).Return(&created, nil).AnyTimes()
 
 	body, _ := json.Marshal(payload)
-	req := httptest.NewRequest(http.MethodPost, "/books", bytes.NewReader(body))
+	req := httptest.NewRequest(http.MethodPost, "/api/books", bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
 
 	rec := httptest.NewRecorder()
@@ -129,7 +580,59 @@ func TestCreate(t *testing.T) {
 
 	err := controller.Create(ctx)
 	assert.Equal(t, err, nil)
-	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Code, http.StatusCreated)
+	assert.Equal(t, "/api/books/"+created.ID.String(), rec.Header().Get(echo.HeaderLocation))
+	assert.Equal(t, `"1"`, rec.Header().Get(HeaderETag))
+
+	var response models.Book
+	err = json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, created.ID, response.ID)
+	assert.True(t, created.CreatedAt.Equal(response.CreatedAt))
+	assert.Equal(t, created.Title, response.Title)
+
+	t.Run("Invalid body", func(t *testing.T) {
+		body, _ := json.Marshal(dto.CreateBookDto{Title: "New Book"})
+		req := httptest.NewRequest(http.MethodPost, "/books", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(echo.HeaderXRequestID, "test-request")
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.Create)
+		assert.Equal(t, rec.Code, http.StatusBadRequest)
+		assert.Equal(t, rec.Header().Get(echo.HeaderContentType), MIMEApplicationProblemJSON)
+
+		var problem dto.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &problem)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, problem.Status, http.StatusBadRequest)
+		assert.Equal(t, problem.Instance, "test-request")
+		assert.ElementsMatch(t, problem.Errors, []errs.FieldError{
+			{Field: "author", Message: "is required"},
+			{Field: "year", Message: "is required"},
+		})
+	})
+
+	t.Run("Invalid ISBN", func(t *testing.T) {
+		body, _ := json.Marshal(dto.CreateBookDto{Title: "New Book", Author: "New Author", Year: 2023, ISBN: "0-14-044913-3"})
+		req := httptest.NewRequest(http.MethodPost, "/books", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.Create)
+		assert.Equal(t, rec.Code, http.StatusBadRequest)
+
+		var problem dto.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &problem)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, problem.Errors, []errs.FieldError{
+			{Field: "isbn", Message: "must be an ISBN-10 or ISBN-13 with a valid check digit"},
+		})
+	})
 }
 
 // TestUpdate tests the Update controller method
@@ -143,22 +646,415 @@ func TestUpdate(t *testing.T) {
 	controller := NewController(mockUsecase)
 
 	bookID := uuid.New()
-	book := models.Book{Title: "Updated Title", Author: "Updated Author", Year: 2024}
+	credits := []models.BookAuthor{{AuthorID: uuid.New(), Name: "Author", Role: models.AuthorRoleAuthor}}
+	current := models.Book{ID: bookID, Title: "Title", Author: "Author", Authors: credits, Year: 2000, Version: 3}
+
+	// the usecase applies the patch to the current book
+	mockUsecase.EXPECT().Patch(gomock.Any(), bookID, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ uuid.UUID, _ uint64, patch models.BookPatch) (*models.Book, error) {
+			book, err := patch(current)
+			if err != nil {
+				return nil, err
+			}
+			book.ID, book.Version = bookID, current.Version+1
+			return &book, nil
+		}).AnyTimes()
+
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+
+		code     int
+		expected models.Book
+	}{
+		{
+			name:        "Merge patch",
+			contentType: "application/merge-patch+json",
+			body:        `{"title": "New title"}`,
+			code:        http.StatusOK,
+			expected:    models.Book{ID: bookID, Title: "New title", Author: "Author", Authors: credits, Year: 2000, Version: 4},
+		},
+		{
+			name:        "Plain JSON is a merge patch",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"year": 2001}`,
+			code:        http.StatusOK,
+			expected:    models.Book{ID: bookID, Title: "Title", Author: "Author", Authors: credits, Year: 2001, Version: 4},
+		},
+		{
+			name:        "Merge patch of credits",
+			contentType: "application/merge-patch+json",
+			body:        `{"authors": [{"name": "Editor", "role": "editor"}]}`,
+			code:        http.StatusOK,
+			expected: models.Book{ID: bookID, Title: "Title", Author: "Author", Year: 2000, Version: 4,
+				Authors: []models.BookAuthor{{Name: "Editor", Role: models.AuthorRoleEditor}}},
+		},
+		{
+			name:        "Merge patch with an unknown role",
+			contentType: "application/merge-patch+json",
+			body:        `{"authors": [{"name": "Reader", "role": "reader"}]}`,
+			code:        http.StatusBadRequest,
+		},
+		{
+			name:        "Merge patch removing a required field",
+			contentType: "application/merge-patch+json",
+			body:        `{"author": null, "authors": null}`,
+			code:        http.StatusBadRequest,
+		},
+		{
+			name:        "Merge patch with an unknown field",
+			contentType: "application/merge-patch+json",
+			body:        `{"isbn": "123"}`,
+			code:        http.StatusBadRequest,
+		},
+		{
+			name:        "Merge patch with a wrong type",
+			contentType: "application/merge-patch+json",
+			body:        `{"year": "2001"}`,
+			code:        http.StatusBadRequest,
+		},
+		{
+			name:        "JSON patch",
+			contentType: "application/json-patch+json",
+			body:        `[{"op": "test", "path": "/year", "value": 2000}, {"op": "replace", "path": "/author", "value": "New author"}]`,
+			code:        http.StatusOK,
+			expected:    models.Book{ID: bookID, Title: "Title", Author: "New author", Year: 2000, Version: 4},
+		},
+		{
+			name:        "JSON patch with a failed test",
+			contentType: "application/json-patch+json",
+			body:        `[{"op": "test", "path": "/year", "value": 1999}, {"op": "replace", "path": "/author", "value": "New author"}]`,
+			code:        http.StatusConflict,
+		},
+		{
+			name:        "Malformed JSON patch",
+			contentType: "application/json-patch+json",
+			body:        `{"op": "replace"}`,
+			code:        http.StatusBadRequest,
+		},
+		{
+			name:        "Unsupported format",
+			contentType: "text/plain",
+			body:        `title=New`,
+			code:        http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/books/"+bookID.String(), bytes.NewBufferString(testCase.body))
+			req.Header.Set("Content-Type", testCase.contentType)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(bookID.String())
+
+			handle(ctx, controller.Update)
+			assert.Equal(t, testCase.code, rec.Code, rec.Body.String())
+			if testCase.code != http.StatusOK {
+				assert.Equal(t, MIMEApplicationProblemJSON, rec.Header().Get(echo.HeaderContentType))
+				return
+			}
+
+			var response models.Book
+			err := json.Unmarshal(rec.Body.Bytes(), &response)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, testCase.expected, response)
+			assert.Equal(t, rec.Header().Get(HeaderETag), `"4"`)
+		})
+	}
+
+	t.Run("If-Match", func(t *testing.T) {
+		bookID := uuid.New()
+		mockUsecase.EXPECT().Patch(gomock.Any(), bookID, uint64(7), gomock.Any()).
+			Return(&models.Book{ID: bookID, Version: 8}, nil).Times(1)
+
+		req := httptest.NewRequest(http.MethodPatch, "/books/"+bookID.String(), bytes.NewBufferString(`{"title": "New title"}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		req.Header.Set(HeaderIfMatch, `"7"`)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(bookID.String())
+
+		handle(ctx, controller.Update)
+		assert.Equal(t, rec.Code, http.StatusOK)
+		assert.Equal(t, rec.Header().Get(HeaderETag), `"8"`)
+	})
+
+	t.Run("Weak If-Match", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPatch, "/books/"+bookID.String(), bytes.NewBufferString(`{"title": "New title"}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		req.Header.Set(HeaderIfMatch, `W/"7"`)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(bookID.String())
+
+		handle(ctx, controller.Update)
+		assert.Equal(t, rec.Code, http.StatusPreconditionFailed)
+	})
+
+	t.Run("If-Match required", func(t *testing.T) {
+		controller := NewController(mockUsecase, RequireIfMatch(true))
+
+		req := httptest.NewRequest(http.MethodPatch, "/books/"+bookID.String(), bytes.NewBufferString(`{"title": "New title"}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(bookID.String())
+
+		handle(ctx, controller.Update)
+		assert.Equal(t, rec.Code, http.StatusPreconditionRequired)
+	})
+}
+
+// TestReplace tests the Replace controller method
+func TestReplace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	e.Validator = validator.New()
+	mockUsecase := usecase_mock.NewMockBooks(ctrl)
+	controller := NewController(mockUsecase)
+
+	bookID := uuid.New()
+	book := models.Book{Title: "Updated Title", Author: "Updated Author", Year: 2024, Version: 2}
+	stored := book
+	stored.ID, stored.Version = bookID, 3
 
-	mockUsecase.EXPECT().Update(gomock.Any(), bookID, book).Return(nil).AnyTimes()
+	mockUsecase.EXPECT().Update(gomock.Any(), bookID, book).Return(&stored, nil).AnyTimes()
 
-	body, _ := json.Marshal(book)
-	req := httptest.NewRequest(http.MethodPatch, "/books/"+bookID.String(), bytes.NewReader(body))
+	body, _ := json.Marshal(dto.BookDocumentDto{Title: book.Title, Author: book.Author, Year: book.Year})
+	req := httptest.NewRequest(http.MethodPut, "/books/"+bookID.String(), bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderIfMatch, `"2"`)
 
 	rec := httptest.NewRecorder()
 	ctx := e.NewContext(req, rec)
 	ctx.SetParamNames("id")
 	ctx.SetParamValues(bookID.String())
 
-	err := controller.Update(ctx)
+	err := controller.Replace(ctx)
 	assert.Equal(t, err, nil)
 	assert.Equal(t, rec.Code, http.StatusOK)
+	assert.Equal(t, rec.Header().Get(HeaderETag), `"3"`)
+
+	t.Run("Missing field", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/books/"+bookID.String(), bytes.NewBufferString(`{"title": "Title", "year": 2024}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(bookID.String())
+
+		handle(ctx, controller.Replace)
+		assert.Equal(t, rec.Code, http.StatusBadRequest)
+
+		var problem dto.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &problem)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, []errs.FieldError{{Field: "author", Message: "is required"}}, problem.Errors)
+	})
+}
+
+// TestBatch tests the Batch controller method
+func TestBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	e.Validator = validator.New()
+	mockUsecase := usecase_mock.NewMockBooks(ctrl)
+	controller := NewController(mockUsecase)
+
+	bookID := uuid.New()
+	created := models.Book{ID: uuid.New(), Title: "New Book", Author: "New Author", Year: 2023, Version: 1}
+
+	batch := func(body string) (*httptest.ResponseRecorder, dto.BatchResultDto) {
+		req := httptest.NewRequest(http.MethodPost, "/api/books:batch", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handle(e.NewContext(req, rec), controller.Batch)
+
+		var result dto.BatchResultDto
+		if rec.Code == http.StatusOK {
+			_ = json.Unmarshal(rec.Body.Bytes(), &result)
+		}
+		return rec, result
+	}
+
+	t.Run("Atomic", func(t *testing.T) {
+		mockUsecase.EXPECT().Batch(gomock.Any(), []models.BookOperation{
+			{Type: models.BookOperationCreate, Book: models.Book{Title: "New Book", Author: "New Author", Year: 2023}},
+			{Type: models.BookOperationDelete, ID: bookID, Version: 2},
+		}, true).Return([]models.BookOperationResult{{Book: &created}, {}}, nil)
+
+		rec, result := batch(`{"operations": [
+			{"op": "create", "title": "New Book", "author": "New Author", "year": 2023},
+			{"op": "delete", "id": "` + bookID.String() + `", "version": 2}
+		]}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, []dto.BookOperationResultDto{
+			{Status: http.StatusCreated, Book: &created},
+			{Status: http.StatusNoContent},
+		}, result.Results)
+	})
+
+	t.Run("Atomic with invalid operation", func(t *testing.T) {
+		rec, _ := batch(`{"operations": [
+			{"op": "create", "title": "New Book", "author": "New Author", "year": 2023},
+			{"op": "update", "id": "` + bookID.String() + `", "title": "Title", "year": 2023}
+		]}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		var problem dto.Problem
+		err := json.Unmarshal(rec.Body.Bytes(), &problem)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, []errs.FieldError{{Field: "operations[1].author", Message: "is required"}}, problem.Errors)
+	})
+
+	t.Run("Atomic failure", func(t *testing.T) {
+		mockUsecase.EXPECT().Batch(gomock.Any(), gomock.Any(), true).Return(nil, errs.NotFound("operation 0: book doesn't exist"))
+
+		rec, _ := batch(`{"operations": [{"op": "delete", "id": "` + bookID.String() + `"}]}`)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Best effort", func(t *testing.T) {
+		mockUsecase.EXPECT().Batch(gomock.Any(), []models.BookOperation{
+			{Type: models.BookOperationDelete, ID: bookID},
+		}, false).Return([]models.BookOperationResult{{Err: errs.NotFound("book doesn't exist")}}, nil)
+
+		rec, result := batch(`{"mode": "best-effort", "operations": [
+			{"op": "update", "id": "not-a-uuid", "title": "Title", "author": "Author", "year": 2023},
+			{"op": "delete", "id": "` + bookID.String() + `"}
+		]}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, result.Results, 2)
+		assert.Equal(t, http.StatusBadRequest, result.Results[0].Status)
+		assert.Equal(t, []errs.FieldError{{Field: "id", Message: "must be a book ID"}}, result.Results[0].Error.Errors)
+		assert.Equal(t, http.StatusNotFound, result.Results[1].Status)
+		assert.Equal(t, "book doesn't exist", result.Results[1].Error.Detail)
+	})
+
+	t.Run("Version required", func(t *testing.T) {
+		controller := NewController(mockUsecase, RequireIfMatch(true))
+		req := httptest.NewRequest(http.MethodPost, "/api/books:batch", bytes.NewBufferString(`{"operations": [{"op": "delete", "id": "`+bookID.String()+`"}]}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handle(e.NewContext(req, rec), controller.Batch)
+		assert.Equal(t, http.StatusPreconditionRequired, rec.Code)
+	})
+
+	t.Run("Empty batch", func(t *testing.T) {
+		rec, _ := batch(`{"operations": []}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+// TestImport tests creation of books from uploaded MARC records
+func TestImport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	e.Validator = validator.New()
+	mockUsecase := usecase_mock.NewMockBooks(ctrl)
+	controller := NewController(mockUsecase)
+
+	record := func(author, title, date string) marc.Record {
+		record := marc.NewRecord()
+		record.AddDataField(marc.TagPersonalName, '1', ' ', marc.Subfield{Code: 'a', Value: author})
+		record.AddDataField(marc.TagTitle, '1', '0',
+			marc.Subfield{Code: 'a', Value: title},
+			marc.Subfield{Code: 'c', Value: author},
+		)
+		record.AddDataField(marc.TagPublication, ' ', ' ', marc.Subfield{Code: 'c', Value: date})
+		return record
+	}
+	var file bytes.Buffer
+	writer := marc.NewWriter(&file)
+	for _, r := range []marc.Record{
+		record("Толстой, Лев,", "Война и мир /", "1869."),
+		record("Tolstoy, Leo,", "War and peace /", "[1869?]"),
+		record("", "Anonymous /", "1900"),
+	} {
+		assert.Equal(t, writer.Write(r), nil)
+	}
+
+	created := models.Book{ID: uuid.New(), Title: "Война и мир", Author: "Толстой, Лев", Year: 1869}
+	t.Run("Success", func(t *testing.T) {
+		mockUsecase.EXPECT().Import(gomock.Any(), []models.Book{
+			{Title: "Война и мир", Author: "Толстой, Лев", Year: 1869},
+			{Title: "War and peace", Author: "Tolstoy, Leo", Year: 1869},
+		}, true).Return([]models.BookImportResult{{Book: &created}, {Duplicate: true}}, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/books:import?dry_run=true", bytes.NewReader(file.Bytes()))
+		req.Header.Set(echo.HeaderContentType, marc.MIMEMARC)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		err := controller.Import(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, rec.Code, http.StatusOK)
+
+		var response dto.BatchResultDto
+		assert.Equal(t, json.Unmarshal(rec.Body.Bytes(), &response), nil)
+		assert.Equal(t, len(response.Results), 3)
+		assert.Equal(t, response.Results[0].Status, http.StatusCreated)
+		assert.Equal(t, response.Results[0].Book.ID, created.ID)
+		assert.Equal(t, response.Results[1].Status, http.StatusConflict)
+		assert.Equal(t, response.Results[2].Status, http.StatusBadRequest)
+		assert.Equal(t, response.Results[2].Error.Errors[0].Field, "author")
+	})
+
+	t.Run("MARCXML", func(t *testing.T) {
+		mockUsecase.EXPECT().Import(gomock.Any(), []models.Book{
+			{Title: "Война и мир", Author: "Толстой, Лев", Year: 1869},
+		}, false).Return([]models.BookImportResult{{Book: &created}}, nil)
+
+		body := `<?xml version="1.0" encoding="UTF-8"?>
+<marc:collection xmlns:marc="http://www.loc.gov/MARC21/slim">
+  <marc:record>
+    <marc:leader>00000nam a2200000 i 4500</marc:leader>
+    <marc:datafield tag="100" ind1="1" ind2=" "><marc:subfield code="a">Толстой, Лев,</marc:subfield></marc:datafield>
+    <marc:datafield tag="245" ind1="1" ind2="0"><marc:subfield code="a">Война и мир.</marc:subfield></marc:datafield>
+    <marc:datafield tag="264" ind1=" " ind2="1"><marc:subfield code="c">1869</marc:subfield></marc:datafield>
+  </marc:record>
+</marc:collection>`
+		req := httptest.NewRequest(http.MethodPost, "/books:import", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, marc.MIMEMARCXML)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		err := controller.Import(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, rec.Code, http.StatusOK)
+	})
+
+	t.Run("Malformed file", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/books:import", strings.NewReader("00042nam"))
+		req.Header.Set(echo.HeaderContentType, marc.MIMEMARC)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.Import)
+		assert.Equal(t, rec.Code, http.StatusBadRequest)
+	})
+
+	t.Run("Unsupported format", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/books:import", strings.NewReader("title,author,year"))
+		req.Header.Set(echo.HeaderContentType, "text/csv")
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.Import)
+		assert.Equal(t, rec.Code, http.StatusUnsupportedMediaType)
+	})
 }
 
 // TestDelete tests the Delete controller method
@@ -172,7 +1068,7 @@ func TestDelete(t *testing.T) {
 
 	bookID := uuid.New()
 
-	mockUsecase.EXPECT().Delete(gomock.Any(), bookID).Return(nil).AnyTimes()
+	mockUsecase.EXPECT().Delete(gomock.Any(), bookID, uint64(0)).Return(nil).AnyTimes()
 
 	req := httptest.NewRequest(http.MethodDelete, "/books/"+bookID.String(), nil)
 	rec := httptest.NewRecorder()
@@ -183,4 +1079,154 @@ func TestDelete(t *testing.T) {
 	err := controller.Delete(ctx)
 	assert.Equal(t, err, nil)
 	assert.Equal(t, rec.Code, http.StatusOK)
+
+	t.Run("Not Found", func(t *testing.T) {
+		bookID := uuid.New()
+		mockUsecase.EXPECT().Delete(gomock.Any(), bookID, uint64(0)).Return(errs.NotFound("book doesn't exist")).AnyTimes()
+
+		req := httptest.NewRequest(http.MethodDelete, "/books/"+bookID.String(), nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(bookID.String())
+
+		handle(ctx, controller.Delete)
+		assert.Equal(t, rec.Code, http.StatusNotFound)
+	})
+
+	t.Run("Modified", func(t *testing.T) {
+		bookID := uuid.New()
+		mockUsecase.EXPECT().GetOne(gomock.Any(), bookID).Return(&models.Book{ID: bookID, Version: 5}, nil).AnyTimes()
+
+		req := httptest.NewRequest(http.MethodDelete, "/books/"+bookID.String(), nil)
+		req.Header.Set(HeaderIfMatch, `"3", "4"`)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(bookID.String())
+
+		handle(ctx, controller.Delete)
+		assert.Equal(t, rec.Code, http.StatusPreconditionFailed)
+	})
+}
+
+// TestGetTrash tests the GetTrash controller method
+func TestGetTrash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	e.Validator = validator.New()
+	mockUsecase := usecase_mock.NewMockBooks(ctrl)
+	controller := NewController(mockUsecase)
+
+	deletedAt := time.Now().UTC().Truncate(time.Second)
+	books := []models.Book{
+		{ID: uuid.New(), Title: "Book 1", Author: "Author 1", Year: 2021, DeletedAt: &deletedAt},
+	}
+
+	mockUsecase.EXPECT().GetTrash(gomock.Any(), models.TrashParams{Limit: 5, Cursor: "abc"}).
+		Return(&models.BookPage{Items: books, NextCursor: "next"}, nil).AnyTimes()
+
+	req := httptest.NewRequest(http.MethodGet, "/trash/books?limit=5&cursor=abc", nil)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+
+	err := controller.GetTrash(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var response dto.BookListDto
+	err = json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, books, response.Items)
+	assert.Equal(t, response.NextCursor, "next")
+
+	t.Run("Invalid limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/trash/books?limit=1000", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.GetTrash)
+		assert.Equal(t, rec.Code, http.StatusBadRequest)
+	})
+}
+
+// TestRestore tests the Restore controller method
+func TestRestore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	mockUsecase := usecase_mock.NewMockBooks(ctrl)
+	controller := NewController(mockUsecase)
+
+	book := models.Book{ID: uuid.New(), Title: "Book", Author: "Author", Year: 2000}
+
+	mockUsecase.EXPECT().Restore(gomock.Any(), book.ID).Return(&book, nil).AnyTimes()
+
+	req := httptest.NewRequest(http.MethodPost, "/books/"+book.ID.String()+"/restore", nil)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(book.ID.String())
+
+	err := controller.Restore(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	var response models.Book
+	err = json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, book.ID, response.ID)
+	assert.Nil(t, response.DeletedAt)
+
+	t.Run("Not in trash", func(t *testing.T) {
+		bookID := uuid.New()
+		mockUsecase.EXPECT().Restore(gomock.Any(), bookID).Return(nil, errs.NotFound("book is not in the trash")).AnyTimes()
+
+		req := httptest.NewRequest(http.MethodPost, "/books/"+bookID.String()+"/restore", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(bookID.String())
+
+		handle(ctx, controller.Restore)
+		assert.Equal(t, rec.Code, http.StatusNotFound)
+	})
+}
+
+// TestPurge tests the Purge controller method
+func TestPurge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	mockUsecase := usecase_mock.NewMockBooks(ctrl)
+	controller := NewController(mockUsecase)
+
+	bookID := uuid.New()
+
+	mockUsecase.EXPECT().Purge(gomock.Any(), bookID).Return(nil).AnyTimes()
+
+	req := httptest.NewRequest(http.MethodDelete, "/trash/books/"+bookID.String(), nil)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(bookID.String())
+
+	err := controller.Purge(ctx)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, rec.Code, http.StatusOK)
+
+	t.Run("Invalid UUID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/trash/books/invalid", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues("invalid")
+
+		handle(ctx, controller.Purge)
+		assert.Equal(t, rec.Code, http.StatusBadRequest)
+	})
 }
@@ -0,0 +1,42 @@
+package dto
+
+import "github.com/KinitaL/testovoye/internal/models"
+
+type (
+	// AuthorDto is the editable representation of an author: the body of POST and PUT.
+	AuthorDto struct {
+		Name     string   `json:"name" validate:"required"`
+		Variants []string `json:"variants,omitempty" validate:"omitempty,dive,required"`
+	}
+
+	// MergeAuthorsDto is the body of the merge of duplicate authors into one.
+	MergeAuthorsDto struct {
+		Duplicates []string `json:"duplicates" validate:"required,min=1,max=100,dive,uuid"`
+	}
+)
+
+type (
+	// ListAuthorsQuery holds query parameters of the author listing.
+	ListAuthorsQuery struct {
+		Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+		Cursor string `query:"cursor"`
+		Name   string `query:"name"`
+	}
+
+	// LookupAuthorQuery holds query parameters of the lookup of an author by name.
+	LookupAuthorQuery struct {
+		Name string `query:"name" validate:"required"`
+	}
+
+	// AuthorListDto is a page of authors returned by the listing.
+	AuthorListDto struct {
+		Items      []models.Author `json:"items"`
+		NextCursor string          `json:"next_cursor,omitempty"`
+	}
+
+	// AuthorBooksQuery holds query parameters of the listing of books credited to an author.
+	AuthorBooksQuery struct {
+		Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+		Cursor string `query:"cursor"`
+	}
+)
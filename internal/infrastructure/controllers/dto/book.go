@@ -1,14 +1,245 @@
 package dto
 
+import (
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/google/uuid"
+)
+
 type (
 	CreateBookDto struct {
-		Title  string `json:"title" validate:"required"`
-		Author string `json:"author" validate:"required"`
-		Year   uint16 `json:"year" validate:"required"`
+		Title     string          `json:"title" validate:"required"`
+		Author    string          `json:"author" validate:"required_without=Authors"` // Names joined with " and ", ignored if there are credits
+		Authors   []BookAuthorDto `json:"authors,omitempty" validate:"omitempty,dive"`
+		Year      uint16          `json:"year" validate:"required"`
+		ISBN      string          `json:"isbn,omitempty" validate:"omitempty,isbn"` // ISBN-10 or ISBN-13, hyphens allowed
+		Publisher string          `json:"publisher,omitempty"`
+		Language  string          `json:"language,omitempty"` // ISO 639 code
+		Format    string          `json:"format,omitempty" validate:"omitempty,oneof=hardcover paperback ebook audiobook"`
+		Genres    []string        `json:"genres,omitempty" validate:"omitempty,dive,uuid"` // IDs of genres
+		Tags      []string        `json:"tags,omitempty" validate:"omitempty,dive,required"`
 	}
+	// BookAuthorDto is a credit of an author for a book: an existing author referred to by ID,
+	// or a name, which is linked to the author with that name or creates one.
+	BookAuthorDto struct {
+		AuthorID string `json:"author_id,omitempty" validate:"omitempty,uuid"`
+		Name     string `json:"name,omitempty"`
+		Role     string `json:"role,omitempty" validate:"omitempty,oneof=author editor translator illustrator"` // author by default
+	}
+	// UpdateBookDto is a JSON Merge Patch of a book: absent members are left unchanged,
+	// null removes a member, which fails validation for required fields.
 	UpdateBookDto struct {
-		Title  string `json:"title,omitempty"`
-		Author string `json:"author,omitempty"`
-		Year   uint16 `json:"year,omitempty"`
+		Title     *string          `json:"title,omitempty"`
+		Author    *string          `json:"author,omitempty"`
+		Authors   *[]BookAuthorDto `json:"authors,omitempty"`
+		Year      *uint16          `json:"year,omitempty"`
+		ISBN      *string          `json:"isbn,omitempty"`
+		Publisher *string          `json:"publisher,omitempty"`
+		Language  *string          `json:"language,omitempty"`
+		Format    *string          `json:"format,omitempty"`
+		Genres    *[]string        `json:"genres,omitempty"`
+		Tags      *[]string        `json:"tags,omitempty"`
+	}
+	// BookDocumentDto is the editable representation of a book: the body of PUT
+	// and the document PATCH is applied to.
+	BookDocumentDto struct {
+		Title     string          `json:"title" validate:"required"`
+		Author    string          `json:"author" validate:"required_without=Authors"`
+		Authors   []BookAuthorDto `json:"authors,omitempty" validate:"omitempty,dive"`
+		Year      uint16          `json:"year" validate:"required"`
+		ISBN      string          `json:"isbn,omitempty" validate:"omitempty,isbn"`
+		Publisher string          `json:"publisher,omitempty"`
+		Language  string          `json:"language,omitempty"`
+		Format    string          `json:"format,omitempty" validate:"omitempty,oneof=hardcover paperback ebook audiobook"`
+		Genres    []string        `json:"genres,omitempty" validate:"omitempty,dive,uuid"` // IDs of genres
+		Tags      []string        `json:"tags,omitempty" validate:"omitempty,dive,required"`
+	}
+)
+
+type (
+	// ListBooksQuery holds query parameters of the book listing.
+	ListBooksQuery struct {
+		Limit       int    `query:"limit" validate:"omitempty,min=1,max=100"`
+		Cursor      string `query:"cursor"`
+		Sort        string `query:"sort" validate:"omitempty,oneof=title author year created_at"`
+		Order       string `query:"order" validate:"omitempty,oneof=asc desc"`
+		Locale      string `query:"locale" validate:"omitempty,oneof=en ru"`
+		Author      string `query:"author"`
+		YearFrom    uint16 `query:"year_from"`
+		YearTo      uint16 `query:"year_to"`
+		TitlePrefix string `query:"title_prefix"`
+		WithTotal   bool   `query:"with_total"`
+		OnePerWork  bool   `query:"one_per_work"`
+		Genre       string `query:"genre" validate:"omitempty,uuid"`
+		Tag         string `query:"tag"`
+		Facets      bool   `query:"facets"`
+		Format      string `query:"format" validate:"omitempty,oneof=json bibtex ris csl-json"`
+	}
+
+	// BookListDto is a page of books returned by the listing.
+	BookListDto struct {
+		Items      []models.Book      `json:"items"`
+		NextCursor string             `json:"next_cursor,omitempty"`
+		Total      *int64             `json:"total,omitempty"`
+		Facets     *models.BookFacets `json:"facets,omitempty"`
+	}
+)
+
+// GetBookQuery holds query parameters of a single book.
+type GetBookQuery struct {
+	Format string `query:"format" validate:"omitempty,oneof=json marc marcxml bibtex ris csl-json"`
+}
+
+// ImportBooksQuery holds query parameters of an upload of MARC records.
+type ImportBooksQuery struct {
+	DryRun bool `query:"dry_run"`
+}
+
+// ExportBooksQuery holds query parameters of the export.
+type ExportBooksQuery struct {
+	Format      string `query:"format" validate:"omitempty,oneof=csv ndjson json marc marcxml bibtex ris csl-json"`
+	Author      string `query:"author"`
+	YearFrom    uint16 `query:"year_from"`
+	YearTo      uint16 `query:"year_to"`
+	TitlePrefix string `query:"title_prefix"`
+}
+
+// ListTrashQuery holds query parameters of the trash listing.
+type ListTrashQuery struct {
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor string `query:"cursor"`
+}
+
+type (
+	// SearchBooksQuery holds query parameters of the full-text search.
+	SearchBooksQuery struct {
+		Q         string  `query:"q" validate:"required"`
+		Fuzzy     bool    `query:"fuzzy"`
+		Threshold float64 `query:"threshold" validate:"omitempty,gt=0,lte=1"`
+		Limit     int     `query:"limit" validate:"omitempty,min=1,max=100"`
+		Offset    int     `query:"offset" validate:"omitempty,min=0"`
+		Format    string  `query:"format" validate:"omitempty,oneof=json bibtex ris csl-json"`
+	}
+
+	// BookHighlightDto holds HTML-escaped fields with matched terms wrapped in <mark> tags.
+	BookHighlightDto struct {
+		Title  string `json:"title"`
+		Author string `json:"author"`
+	}
+
+	// BookSearchHitDto is a single book found by the full-text search.
+	BookSearchHitDto struct {
+		Book      models.Book      `json:"book"`
+		Rank      float64          `json:"rank"`
+		Highlight BookHighlightDto `json:"highlight"`
+	}
+
+	// BookSuggestionDto is a known title or author spelled similarly to the query.
+	BookSuggestionDto struct {
+		Text  string  `json:"text"`
+		Field string  `json:"field" enums:"title,author"`
+		Score float64 `json:"score"`
+	}
+
+	// BookSearchDto is the result of the full-text search.
+	BookSearchDto struct {
+		Items       []BookSearchHitDto  `json:"items"`
+		Suggestions []BookSuggestionDto `json:"suggestions,omitempty"`
+	}
+)
+
+// Modes of a batch.
+const (
+	BatchModeAtomic     = "atomic"      // All operations are applied or none
+	BatchModeBestEffort = "best-effort" // Each operation is applied on its own
+)
+
+type (
+	// BatchRequestDto is a list of operations applied at once.
+	BatchRequestDto struct {
+		Mode       string             `json:"mode" validate:"omitempty,oneof=atomic best-effort"`
+		Operations []BookOperationDto `json:"operations" validate:"required,min=1,max=1000"`
+	}
+
+	// BookOperationDto is a single operation of a batch. ID is required for update and delete,
+	// book fields for create and update; version works like If-Match.
+	BookOperationDto struct {
+		Op        string          `json:"op" validate:"required,oneof=create update delete"`
+		ID        string          `json:"id,omitempty"`
+		Version   uint64          `json:"version,omitempty"`
+		Title     string          `json:"title,omitempty"`
+		Author    string          `json:"author,omitempty"`
+		Authors   []BookAuthorDto `json:"authors,omitempty"`
+		Year      uint16          `json:"year,omitempty"`
+		ISBN      string          `json:"isbn,omitempty"`
+		Publisher string          `json:"publisher,omitempty"`
+		Language  string          `json:"language,omitempty"`
+		Format    string          `json:"format,omitempty"`
+		Genres    []string        `json:"genres,omitempty"`
+		Tags      []string        `json:"tags,omitempty"`
+	}
+
+	// BookOperationResultDto is the outcome of a batch operation: the status it would have as a separate request
+	// and either the created or updated book, or the problem.
+	BookOperationResultDto struct {
+		Status int          `json:"status"`
+		Book   *models.Book `json:"book,omitempty"`
+		Error  *Problem     `json:"error,omitempty"`
+	}
+
+	// BatchResultDto holds outcomes of batch operations in the order of the operations.
+	BatchResultDto struct {
+		Results []BookOperationResultDto `json:"results"`
 	}
 )
+
+// Credits converts validated credits to the model, nil if there are none.
+func Credits(items []BookAuthorDto) []models.BookAuthor {
+	if len(items) == 0 {
+		return nil
+	}
+	credits := make([]models.BookAuthor, len(items))
+	for i, item := range items {
+		ID, _ := uuid.Parse(item.AuthorID) // validated with the uuid rule, empty means none
+		credits[i] = models.BookAuthor{AuthorID: ID, Name: item.Name, Role: models.AuthorRole(item.Role)}
+	}
+	return credits
+}
+
+// CreditsOf converts credits of a book to their representation in requests.
+func CreditsOf(credits []models.BookAuthor) []BookAuthorDto {
+	if len(credits) == 0 {
+		return nil
+	}
+	items := make([]BookAuthorDto, len(credits))
+	for i, credit := range credits {
+		items[i] = BookAuthorDto{AuthorID: credit.AuthorID.String(), Name: credit.Name, Role: string(credit.Role)}
+		if credit.AuthorID == uuid.Nil {
+			items[i].AuthorID = ""
+		}
+	}
+	return items
+}
+
+// Genres converts validated genre IDs to the model, nil if there are none.
+func Genres(items []string) []uuid.UUID {
+	if len(items) == 0 {
+		return nil
+	}
+	genres := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		genres[i], _ = uuid.Parse(item) // validated with the uuid rule
+	}
+	return genres
+}
+
+// GenresOf converts genres of a book to their representation in requests.
+func GenresOf(genres []uuid.UUID) []string {
+	if len(genres) == 0 {
+		return nil
+	}
+	items := make([]string, len(genres))
+	for i, genre := range genres {
+		items[i] = genre.String()
+	}
+	return items
+}
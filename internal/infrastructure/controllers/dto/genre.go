@@ -0,0 +1,7 @@
+package dto
+
+// GenreDto is the editable representation of a genre: the body of POST and PUT.
+type GenreDto struct {
+	Name     string `json:"name" validate:"required"`
+	ParentID string `json:"parent_id,omitempty" validate:"omitempty,uuid"` // Top-level genre if empty
+}
@@ -0,0 +1,16 @@
+package dto
+
+type (
+	// OPDSFeedQuery holds query parameters of a page of an OPDS acquisition feed.
+	OPDSFeedQuery struct {
+		Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+		Cursor string `query:"cursor"`
+	}
+
+	// OPDSSearchQuery holds query parameters of an OPDS search feed.
+	OPDSSearchQuery struct {
+		Q      string `query:"q" validate:"required"`
+		Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+		Offset int    `query:"offset" validate:"omitempty,min=0"`
+	}
+)
@@ -0,0 +1,15 @@
+package dto
+
+import "github.com/KinitaL/testovoye/internal/errs"
+
+type (
+	// Problem is an RFC 7807 error response body.
+	Problem struct {
+		Type     string            `json:"type" example:"/problems/not-found"`
+		Title    string            `json:"title" example:"Not Found"`
+		Status   int               `json:"status" example:"404"`
+		Detail   string            `json:"detail,omitempty" example:"book doesn't exist"`
+		Instance string            `json:"instance,omitempty" example:"rZDyYLFuLrBcSoJMJZMhZ4ezMQHcYPyH"`
+		Errors   []errs.FieldError `json:"errors,omitempty"`
+	}
+)
@@ -0,0 +1,14 @@
+package dto
+
+type (
+	// ListTagsQuery holds query parameters of the tag listing.
+	ListTagsQuery struct {
+		Limit  int    `query:"limit" validate:"omitempty,min=1,max=500"`
+		Prefix string `query:"prefix"`
+	}
+
+	// RenameTagDto is the body of the rename of a tag.
+	RenameTagDto struct {
+		Name string `json:"name" validate:"required"`
+	}
+)
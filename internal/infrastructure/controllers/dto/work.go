@@ -0,0 +1,30 @@
+package dto
+
+import "github.com/KinitaL/testovoye/internal/models"
+
+// WorkDto is the editable representation of a work: the body of POST and PUT.
+type WorkDto struct {
+	Title  string `json:"title" validate:"required"`
+	Author string `json:"author,omitempty"`
+}
+
+type (
+	// ListWorksQuery holds query parameters of the work listing.
+	ListWorksQuery struct {
+		Limit       int    `query:"limit" validate:"omitempty,min=1,max=100"`
+		Cursor      string `query:"cursor"`
+		TitlePrefix string `query:"title_prefix"`
+	}
+
+	// WorkListDto is a page of works returned by the listing.
+	WorkListDto struct {
+		Items      []models.Work `json:"items"`
+		NextCursor string        `json:"next_cursor,omitempty"`
+	}
+
+	// WorkEditionsQuery holds query parameters of the listing of editions of a work.
+	WorkEditionsQuery struct {
+		Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+		Cursor string `query:"cursor"`
+	}
+)
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/infrastructure/controllers/dto"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// MIMEApplicationProblemJSON is the media type of RFC 7807 error responses.
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// HTTPErrorHandler is a central Echo error handler that renders errors as problem details.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	problem := NewProblem(err)
+	problem.Instance = requestID(c)
+
+	var sendErr error
+	if c.Request().Method == http.MethodHead {
+		sendErr = c.NoContent(problem.Status)
+	} else {
+		sendErr = writeProblem(c, problem)
+	}
+	if sendErr != nil {
+		c.Logger().Error(sendErr)
+	}
+}
+
+// NewProblem builds problem details for an error without leaking internal causes.
+func NewProblem(err error) dto.Problem {
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		p := dto.Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(he.Code),
+			Status: he.Code,
+		}
+		if msg, ok := he.Message.(string); ok && he.Code < http.StatusInternalServerError {
+			p.Detail = msg
+		}
+		return p
+	}
+
+	kind := errs.KindOf(err)
+	status := StatusOf(kind)
+	p := dto.Problem{
+		Type:   problemType(kind),
+		Title:  http.StatusText(status),
+		Status: status,
+		Errors: errs.FieldsOf(err),
+	}
+	if kind != errs.KindInternal {
+		p.Detail = errs.MessageOf(err)
+	}
+	return p
+}
+
+// StatusOf returns the HTTP status that corresponds to a domain error kind.
+func StatusOf(kind errs.Kind) int {
+	switch kind {
+	case errs.KindNotFound:
+		return http.StatusNotFound
+	case errs.KindConflict:
+		return http.StatusConflict
+	case errs.KindValidation:
+		return http.StatusBadRequest
+	case errs.KindPreconditionFailed:
+		return http.StatusPreconditionFailed
+	case errs.KindPreconditionRequired:
+		return http.StatusPreconditionRequired
+	case errs.KindUnprocessable:
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// problemType returns the problem type URI reference for a domain error kind.
+func problemType(kind errs.Kind) string {
+	switch kind {
+	case errs.KindNotFound:
+		return "/problems/not-found"
+	case errs.KindConflict:
+		return "/problems/conflict"
+	case errs.KindValidation:
+		return "/problems/validation"
+	case errs.KindPreconditionFailed:
+		return "/problems/precondition-failed"
+	case errs.KindPreconditionRequired:
+		return "/problems/precondition-required"
+	case errs.KindUnprocessable:
+		return "/problems/unprocessable"
+	default:
+		return "/problems/internal"
+	}
+}
+
+// requestID returns the ID assigned by middleware.RequestID or sent by the client.
+func requestID(c echo.Context) string {
+	if id := c.Response().Header().Get(echo.HeaderXRequestID); id != "" {
+		return id
+	}
+	return c.Request().Header.Get(echo.HeaderXRequestID)
+}
+
+// writeProblem sends problem details with the application/problem+json content type.
+func writeProblem(c echo.Context, problem dto.Problem) error {
+	body, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+	return c.Blob(problem.Status, MIMEApplicationProblemJSON, body)
+}
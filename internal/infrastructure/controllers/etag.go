@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"github.com/KinitaL/testovoye/internal/models"
+	"strconv"
+	"strings"
+)
+
+// Headers of conditional requests (RFC 9110, section 13), echo doesn't define them.
+const (
+	HeaderETag        = "ETag"
+	HeaderIfMatch     = "If-Match"
+	HeaderIfNoneMatch = "If-None-Match"
+)
+
+// etag returns the strong entity tag of a book, derived from its version.
+func etag(book *models.Book) string {
+	return `"` + strconv.FormatUint(book.Version, 10) + `"`
+}
+
+// parseETags splits the value of If-Match or If-None-Match into entity tags.
+// It returns wildcard = true for "*".
+func parseETags(header string) (tags []string, wildcard bool) {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		switch tag {
+		case "":
+		case "*":
+			return nil, true
+		default:
+			tags = append(tags, tag)
+		}
+	}
+	return tags, false
+}
+
+// versionOf returns the book version a strong entity tag stands for.
+// Weak tags never match for If-Match, which uses strong comparison.
+func versionOf(tag string) (uint64, bool) {
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	version, err := strconv.ParseUint(tag[1:len(tag)-1], 10, 64)
+	if err != nil || version == 0 {
+		return 0, false
+	}
+	return version, true
+}
+
+// weakMatch reports whether any of the tags matches the entity tag ignoring the weakness indicator,
+// the comparison If-None-Match uses.
+func weakMatch(tags []string, etag string) bool {
+	for _, tag := range tags {
+		if strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
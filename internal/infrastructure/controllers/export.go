@@ -0,0 +1,284 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/pkg/citation"
+	"github.com/KinitaL/testovoye/pkg/marc"
+	"io"
+	"strconv"
+	"time"
+)
+
+// exportFlushSize is the number of books after which the exported data is sent to the client.
+const exportFlushSize = 100
+
+type (
+	// exportFormat describes how books are exported in a format.
+	exportFormat struct {
+		contentType string
+		extension   string
+		newWriter   func(w io.Writer) bookWriter
+	}
+
+	// bookWriter encodes a stream of books.
+	bookWriter interface {
+		Write(book models.Book) error
+		Flush() error // Writes buffered data to the underlying writer
+		Close() error // Completes the document, nothing can be written after it
+	}
+)
+
+// exportFormats maps values of the format query parameter to export formats.
+var exportFormats = map[string]exportFormat{
+	"csv":      {contentType: "text/csv; charset=utf-8", extension: "csv", newWriter: newCSVBookWriter},
+	"ndjson":   {contentType: "application/x-ndjson", extension: "ndjson", newWriter: newNDJSONBookWriter},
+	"json":     {contentType: "application/json", extension: "json", newWriter: newJSONBookWriter},
+	"marc":     {contentType: marc.MIMEMARC, extension: "mrc", newWriter: newMARCBookWriter},
+	"marcxml":  {contentType: marc.MIMEMARCXML, extension: "xml", newWriter: newMARCXMLBookWriter},
+	"bibtex":   {contentType: citation.MIMEBibTeX, extension: "bib", newWriter: newBibTeXBookWriter},
+	"ris":      {contentType: citation.MIMERIS, extension: "ris", newWriter: newRISBookWriter},
+	"csl-json": {contentType: citation.MIMECSLJSON, extension: "csl.json", newWriter: newCSLBookWriter},
+}
+
+// bookFormats maps values of the format query parameter to representations of a single book other than JSON.
+var bookFormats = map[string]struct {
+	contentType string
+	marshal     func(book models.Book) ([]byte, error)
+}{
+	"marc": {contentType: marc.MIMEMARC, marshal: func(book models.Book) ([]byte, error) {
+		return marc.Marshal(marc.FromBook(book))
+	}},
+	"marcxml": {contentType: marc.MIMEMARCXML, marshal: func(book models.Book) ([]byte, error) {
+		return marc.MarshalXML(marc.FromBook(book))
+	}},
+	"bibtex": {contentType: citation.MIMEBibTeX, marshal: func(book models.Book) ([]byte, error) {
+		var b bytes.Buffer
+		err := citation.NewBibTeXWriter(&b).Write(citation.BibTeX(book, citation.Key(book)))
+		return b.Bytes(), err
+	}},
+	"ris": {contentType: citation.MIMERIS, marshal: func(book models.Book) ([]byte, error) {
+		var b bytes.Buffer
+		err := citation.NewRISWriter(&b).Write(citation.RIS(book, citation.Key(book)))
+		return b.Bytes(), err
+	}},
+	"csl-json": {contentType: citation.MIMECSLJSON, marshal: func(book models.Book) ([]byte, error) {
+		return json.Marshal(citation.CSL(book, citation.Key(book)))
+	}},
+}
+
+// csvHeader is the first record of exported CSV files.
+var csvHeader = []string{"id", "title", "author", "year", "isbn", "publisher", "language", "format", "work_id", "version", "created_at", "updated_at"}
+
+// csvBookWriter writes books as CSV records preceded by csvHeader.
+type csvBookWriter struct {
+	w      *csv.Writer
+	header bool // Whether the header has been written
+}
+
+func newCSVBookWriter(w io.Writer) bookWriter {
+	return &csvBookWriter{w: csv.NewWriter(w)}
+}
+
+func (w *csvBookWriter) Write(book models.Book) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	var work string
+	if book.WorkID != nil {
+		work = book.WorkID.String()
+	}
+	return w.w.Write([]string{
+		book.ID.String(),
+		book.Title,
+		book.Author,
+		strconv.FormatUint(uint64(book.Year), 10),
+		book.ISBN,
+		book.Publisher,
+		book.Language,
+		string(book.Format),
+		work,
+		strconv.FormatUint(book.Version, 10),
+		book.CreatedAt.Format(time.RFC3339Nano),
+		book.UpdatedAt.Format(time.RFC3339Nano),
+	})
+}
+
+func (w *csvBookWriter) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func (w *csvBookWriter) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// writeHeader writes csvHeader once, so that an empty export still has it.
+func (w *csvBookWriter) writeHeader() error {
+	if w.header {
+		return nil
+	}
+	w.header = true
+	return w.w.Write(csvHeader)
+}
+
+// ndjsonBookWriter writes books as JSON objects, one per line.
+type ndjsonBookWriter struct {
+	enc *json.Encoder
+}
+
+func newNDJSONBookWriter(w io.Writer) bookWriter {
+	return &ndjsonBookWriter{enc: json.NewEncoder(w)}
+}
+
+func (w *ndjsonBookWriter) Write(book models.Book) error {
+	return w.enc.Encode(book)
+}
+
+func (w *ndjsonBookWriter) Flush() error {
+	return nil
+}
+
+func (w *ndjsonBookWriter) Close() error {
+	return nil
+}
+
+// jsonBookWriter writes books as elements of a JSON array.
+type jsonBookWriter struct {
+	w       io.Writer
+	element func(book models.Book) any // Converts a book to the element of the array
+	count   int                        // Number of written books
+}
+
+func newJSONBookWriter(w io.Writer) bookWriter {
+	return &jsonBookWriter{w: w, element: func(book models.Book) any { return book }}
+}
+
+// newCSLBookWriter returns a writer of a CSL-JSON array of books with unique citation keys.
+func newCSLBookWriter(w io.Writer) bookWriter {
+	keys := make(citation.Keys)
+	return &jsonBookWriter{w: w, element: func(book models.Book) any {
+		return citation.CSL(book, keys.Unique(citation.Key(book)))
+	}}
+}
+
+func (w *jsonBookWriter) Write(book models.Book) error {
+	data, err := json.Marshal(w.element(book))
+	if err != nil {
+		return err
+	}
+	separator := ",\n"
+	if w.count == 0 {
+		separator = "[\n"
+	}
+	w.count++
+	if _, err := io.WriteString(w.w, separator); err != nil {
+		return err
+	}
+	_, err = w.w.Write(data)
+	return err
+}
+
+func (w *jsonBookWriter) Flush() error {
+	return nil
+}
+
+func (w *jsonBookWriter) Close() error {
+	end := "\n]\n"
+	if w.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(w.w, end)
+	return err
+}
+
+// marcBookWriter writes books as ISO 2709 records.
+type marcBookWriter struct {
+	w *marc.Writer
+}
+
+func newMARCBookWriter(w io.Writer) bookWriter {
+	return &marcBookWriter{w: marc.NewWriter(w)}
+}
+
+func (w *marcBookWriter) Write(book models.Book) error {
+	return w.w.Write(marc.FromBook(book))
+}
+
+func (w *marcBookWriter) Flush() error {
+	return nil
+}
+
+func (w *marcBookWriter) Close() error {
+	return nil
+}
+
+// marcXMLBookWriter writes books as a MARCXML collection.
+type marcXMLBookWriter struct {
+	w *marc.XMLWriter
+}
+
+func newMARCXMLBookWriter(w io.Writer) bookWriter {
+	return &marcXMLBookWriter{w: marc.NewXMLWriter(w)}
+}
+
+func (w *marcXMLBookWriter) Write(book models.Book) error {
+	return w.w.Write(marc.FromBook(book))
+}
+
+func (w *marcXMLBookWriter) Flush() error {
+	return nil
+}
+
+func (w *marcXMLBookWriter) Close() error {
+	return w.w.Close()
+}
+
+// bibTeXBookWriter writes books as BibTeX entries with unique citation keys.
+type bibTeXBookWriter struct {
+	w    *citation.BibTeXWriter
+	keys citation.Keys
+}
+
+func newBibTeXBookWriter(w io.Writer) bookWriter {
+	return &bibTeXBookWriter{w: citation.NewBibTeXWriter(w), keys: make(citation.Keys)}
+}
+
+func (w *bibTeXBookWriter) Write(book models.Book) error {
+	return w.w.Write(citation.BibTeX(book, w.keys.Unique(citation.Key(book))))
+}
+
+func (w *bibTeXBookWriter) Flush() error {
+	return nil
+}
+
+func (w *bibTeXBookWriter) Close() error {
+	return nil
+}
+
+// risBookWriter writes books as RIS references with unique citation keys.
+type risBookWriter struct {
+	w    *citation.RISWriter
+	keys citation.Keys
+}
+
+func newRISBookWriter(w io.Writer) bookWriter {
+	return &risBookWriter{w: citation.NewRISWriter(w), keys: make(citation.Keys)}
+}
+
+func (w *risBookWriter) Write(book models.Book) error {
+	return w.w.Write(citation.RIS(book, w.keys.Unique(citation.Key(book))))
+}
+
+func (w *risBookWriter) Flush() error {
+	return nil
+}
+
+func (w *risBookWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/infrastructure/controllers/dto"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"path"
+)
+
+type (
+	// GenresController handles HTTP requests on the genre taxonomy.
+	GenresController struct {
+		u genresUsecase
+	}
+
+	// genresUsecase defines the business logic layer interface for genre operations.
+	genresUsecase interface {
+		GetAll(ctx context.Context) ([]models.GenreNode, error)                              // Retrieves the taxonomy as a tree
+		GetOne(ctx context.Context, ID uuid.UUID) (*models.Genre, error)                     // Retrieves a genre by ID
+		Create(ctx context.Context, genre models.Genre) (*models.Genre, error)               // Creates a new genre
+		Update(ctx context.Context, ID uuid.UUID, genre models.Genre) (*models.Genre, error) // Renames or moves a genre
+		Delete(ctx context.Context, ID uuid.UUID) error                                      // Deletes a genre without subgenres and books
+	}
+)
+
+// NewGenresController initializes a new GenresController instance.
+func NewGenresController(usecase genresUsecase) *GenresController {
+	return &GenresController{u: usecase}
+}
+
+// GetAll handles HTTP GET requests to retrieve the genre taxonomy.
+// @Summary Get the genre taxonomy
+// @Description Retrieves all genres as a tree: top-level genres with their subgenres, siblings ordered by name.
+// @Tags genres
+// @Produce json,application/problem+json
+// @Success 200 {array} models.GenreNode
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/genres [get]
+func (c *GenresController) GetAll(ctx echo.Context) error {
+	tree, err := c.u.GetAll(ctx.Request().Context())
+	if err != nil {
+		return err
+	}
+	if tree == nil {
+		tree = []models.GenreNode{}
+	}
+	return ctx.JSON(http.StatusOK, tree)
+}
+
+// GetOne handles HTTP GET requests to retrieve a genre by ID.
+// @Summary Get a genre by ID
+// @Description Retrieves a single genre by its ID.
+// @Tags genres
+// @Produce json,application/problem+json
+// @Param id path string true "Genre ID"
+// @Success 200 {object} models.Genre
+// @Failure 400 {object} dto.Problem "Invalid genre ID"
+// @Failure 404 {object} dto.Problem "Genre not found"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/genres/{id} [get]
+func (c *GenresController) GetOne(ctx echo.Context) error {
+	id, err := parseGenreID(ctx)
+	if err != nil {
+		return err
+	}
+	genre, err := c.u.GetOne(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, genre)
+}
+
+// Create handles HTTP POST requests to create a new genre.
+// @Summary Create a new genre
+// @Description Adds a genre to the taxonomy, at the top level or under an existing parent.
+// @Description Names are unique among siblings regardless of case.
+// @Tags genres
+// @Accept json
+// @Produce json,application/problem+json
+// @Param genre body dto.GenreDto true "Genre Data"
+// @Success 201 {object} models.Genre
+// @Header 201 {string} Location "URL of the created genre"
+// @Failure 400 {object} dto.Problem "Invalid request body / Parent not found"
+// @Failure 409 {object} dto.Problem "Parent has a subgenre with the same name"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/genres [post]
+func (c *GenresController) Create(ctx echo.Context) error {
+	genre, err := bindGenre(ctx)
+	if err != nil {
+		return err
+	}
+	created, err := c.u.Create(ctx.Request().Context(), genre)
+	if err != nil {
+		return err
+	}
+	ctx.Response().Header().Set(echo.HeaderLocation, path.Join(ctx.Request().URL.Path, created.ID.String()))
+	return ctx.JSON(http.StatusCreated, created)
+}
+
+// Replace handles HTTP PUT requests to replace a genre.
+// @Summary Replace a genre
+// @Description Renames a genre or moves it along with its subgenres under another parent.
+// @Description Books classified under the genre keep it.
+// @Tags genres
+// @Accept json
+// @Produce json,application/problem+json
+// @Param id path string true "Genre ID"
+// @Param genre body dto.GenreDto true "New Genre Data"
+// @Success 200 {object} models.Genre
+// @Failure 400 {object} dto.Problem "Invalid genre ID / Invalid request body / Parent not found or inside the genre"
+// @Failure 404 {object} dto.Problem "Genre not found"
+// @Failure 409 {object} dto.Problem "Parent has a subgenre with the same name"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/genres/{id} [put]
+func (c *GenresController) Replace(ctx echo.Context) error {
+	id, err := parseGenreID(ctx)
+	if err != nil {
+		return err
+	}
+	genre, err := bindGenre(ctx)
+	if err != nil {
+		return err
+	}
+	updated, err := c.u.Update(ctx.Request().Context(), id, genre)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, updated)
+}
+
+// Delete handles HTTP DELETE requests to remove a genre by ID.
+// @Summary Delete a genre
+// @Description Deletes a genre that has neither subgenres nor books, including the books in the trash.
+// @Tags genres
+// @Produce application/problem+json
+// @Param id path string true "Genre ID"
+// @Success 200
+// @Failure 400 {object} dto.Problem "Invalid genre ID"
+// @Failure 404 {object} dto.Problem "Genre not found"
+// @Failure 409 {object} dto.Problem "Genre has subgenres or books"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/genres/{id} [delete]
+func (c *GenresController) Delete(ctx echo.Context) error {
+	id, err := parseGenreID(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.u.Delete(ctx.Request().Context(), id); err != nil {
+		return err
+	}
+	return ctx.NoContent(http.StatusOK)
+}
+
+// bindGenre reads and validates the body of POST and PUT.
+func bindGenre(ctx echo.Context) (models.Genre, error) {
+	var body dto.GenreDto
+	if err := ctx.Bind(&body); err != nil {
+		return models.Genre{}, errs.Validation("invalid request body")
+	}
+	if err := ctx.Validate(body); err != nil {
+		return models.Genre{}, err
+	}
+	genre := models.Genre{Name: body.Name}
+	if body.ParentID != "" {
+		parentID := uuid.MustParse(body.ParentID) // validated with the uuid rule
+		genre.ParentID = &parentID
+	}
+	return genre, nil
+}
+
+// parseGenreID extracts the genre ID from the path.
+func parseGenreID(ctx echo.Context) (uuid.UUID, error) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return uuid.Nil, errs.Validation("invalid genre ID")
+	}
+	return id, nil
+}
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/infrastructure/controllers/dto"
+	"github.com/KinitaL/testovoye/internal/models"
+	genres_mock "github.com/KinitaL/testovoye/internal/usecases/genres"
+	"github.com/KinitaL/testovoye/pkg/validator"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestGenres tests the GenresController methods
+func TestGenres(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	e.Validator = validator.New()
+	mockGenres := genres_mock.NewMockGenres(ctrl)
+	controller := NewGenresController(mockGenres)
+
+	fiction := models.Genre{ID: uuid.New(), Name: "Fiction"}
+	fantasy := models.Genre{ID: uuid.New(), Name: "Fantasy", ParentID: &fiction.ID}
+
+	t.Run("GetAll", func(t *testing.T) {
+		tree := []models.GenreNode{{Genre: fiction, Children: []models.GenreNode{{Genre: fantasy}}}}
+		mockGenres.EXPECT().GetAll(gomock.Any()).Return(tree, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/genres", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.GetAll)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response []models.GenreNode
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, tree, response)
+	})
+
+	t.Run("GetAll without genres", func(t *testing.T) {
+		mockGenres.EXPECT().GetAll(gomock.Any()).Return(nil, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/genres", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.GetAll)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `[]`, rec.Body.String())
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		mockGenres.EXPECT().Create(gomock.Any(), models.Genre{Name: fantasy.Name, ParentID: &fiction.ID}).Return(&fantasy, nil)
+
+		body, _ := json.Marshal(dto.GenreDto{Name: fantasy.Name, ParentID: fiction.ID.String()})
+		req := httptest.NewRequest(http.MethodPost, "/api/genres", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.Create)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "/api/genres/"+fantasy.ID.String(), rec.Header().Get(echo.HeaderLocation))
+	})
+
+	t.Run("Create with invalid parent", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/genres", bytes.NewBufferString(`{"name": "Fantasy", "parent_id": "fiction"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.Create)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		var problem dto.Problem
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+		assert.Equal(t, []errs.FieldError{{Field: "parent_id", Message: "must be a UUID"}}, problem.Errors)
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		moved := models.Genre{ID: fantasy.ID, Name: fantasy.Name}
+		mockGenres.EXPECT().Update(gomock.Any(), fantasy.ID, models.Genre{Name: fantasy.Name}).Return(&moved, nil)
+
+		req := httptest.NewRequest(http.MethodPut, "/api/genres/"+fantasy.ID.String(), bytes.NewBufferString(`{"name": "Fantasy"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(fantasy.ID.String())
+
+		handle(ctx, controller.Replace)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response models.Genre
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Nil(t, response.ParentID)
+	})
+
+	t.Run("Replace with the genre under its subgenre", func(t *testing.T) {
+		mockGenres.EXPECT().Update(gomock.Any(), fiction.ID, gomock.Any()).
+			Return(nil, errs.InvalidFields(errs.FieldError{Field: "parent_id", Message: "must not be the genre or its subgenre"}))
+
+		body, _ := json.Marshal(dto.GenreDto{Name: fiction.Name, ParentID: fantasy.ID.String()})
+		req := httptest.NewRequest(http.MethodPut, "/api/genres/"+fiction.ID.String(), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(fiction.ID.String())
+
+		handle(ctx, controller.Replace)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Delete with subgenres", func(t *testing.T) {
+		mockGenres.EXPECT().Delete(gomock.Any(), fiction.ID).Return(errs.Conflict("genre has subgenres"))
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/genres/"+fiction.ID.String(), nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(fiction.ID.String())
+
+		handle(ctx, controller.Delete)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/genres/fiction", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues("fiction")
+
+		handle(ctx, controller.GetOne)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/labstack/echo/v4"
+	"io"
+	"net/http"
+)
+
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"     // Client-chosen key that makes retries of a request safe
+	HeaderIdempotentReplayed = "Idempotent-Replayed" // Marks a response recorded for an earlier request with the same key
+)
+
+// maxIdempotentBodySize is the largest body of a request with an Idempotency-Key, which is read
+// into memory to be hashed. It leaves room for an import of the largest allowed MARC file.
+const maxIdempotentBodySize = 64 << 20
+
+// replayedHeaders are the response headers that are recorded along with the body.
+var replayedHeaders = []string{echo.HeaderContentType, echo.HeaderLocation, HeaderETag}
+
+type (
+	// idempotencyKeys defines the methods of the idempotency key usecase that the middleware needs.
+	idempotencyKeys interface {
+		Begin(ctx context.Context, key, fingerprint string) (*models.IdempotentResponse, error)
+		Complete(ctx context.Context, key string, response models.IdempotentResponse) error
+		Release(ctx context.Context, key string) error
+	}
+
+	// bodyRecorder copies everything written to the response.
+	bodyRecorder struct {
+		http.ResponseWriter
+		body bytes.Buffer
+	}
+)
+
+// Write sends data to the client and keeps a copy of it.
+func (r *bodyRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+// Idempotency is an Echo middleware that honors the Idempotency-Key header of the routes it is attached to.
+// The first response to a key is recorded and replayed for retries with the same method, path, query and body.
+// Server errors and panics aren't recorded, so a request that failed with one can be retried.
+func Idempotency(keys idempotencyKeys) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			key := ctx.Request().Header.Get(HeaderIdempotencyKey)
+			if key == "" {
+				return next(ctx)
+			}
+			req := ctx.Request()
+			req.Body = http.MaxBytesReader(ctx.Response().Writer, req.Body, maxIdempotentBodySize)
+			fingerprint, err := requestFingerprint(req)
+			if err != nil {
+				if errors.As(err, new(*http.MaxBytesError)) {
+					return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "request body is too large")
+				}
+				return errs.Validation("invalid request body")
+			}
+
+			recorded, err := keys.Begin(ctx.Request().Context(), key, fingerprint)
+			if err != nil {
+				return err
+			}
+			if recorded != nil {
+				return replay(ctx, recorded)
+			}
+
+			recorder := &bodyRecorder{ResponseWriter: ctx.Response().Writer}
+			ctx.Response().Writer = recorder
+			defer func() {
+				// a panicking handler has no outcome to record, so the key is freed for retries
+				if recovered := recover(); recovered != nil {
+					ctx.Response().Writer = recorder.ResponseWriter
+					if err := keys.Release(context.WithoutCancel(ctx.Request().Context()), key); err != nil {
+						ctx.Logger().Error(err)
+					}
+					panic(recovered)
+				}
+			}()
+			err = next(ctx)
+			if err != nil {
+				ctx.Error(err) // render the error now, so that it is recorded too
+			}
+			ctx.Response().Writer = recorder.ResponseWriter
+
+			// the outcome must be saved even if the client has gone, otherwise its retries would get 409
+			saveCtx := context.WithoutCancel(ctx.Request().Context())
+			res := ctx.Response()
+			var saveErr error
+			if !res.Committed || res.Status >= http.StatusInternalServerError {
+				saveErr = keys.Release(saveCtx, key)
+			} else {
+				response := models.IdempotentResponse{
+					Status: res.Status,
+					Header: make(map[string][]string),
+					Body:   recorder.body.Bytes(),
+				}
+				for _, name := range replayedHeaders {
+					if values := res.Header().Values(name); len(values) > 0 {
+						response.Header[name] = values
+					}
+				}
+				saveErr = keys.Complete(saveCtx, key, response)
+			}
+			if saveErr != nil {
+				ctx.Logger().Error(saveErr)
+			}
+			return err
+		}
+	}
+}
+
+// requestFingerprint hashes the method, the path, the query and the body of the request, leaving the body readable.
+// Query parameters are sorted, so their order doesn't matter.
+func requestFingerprint(req *http.Request) (string, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return "", err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	hash := sha256.New()
+	hash.Write([]byte(req.Method + " " + req.URL.Path + "?" + req.URL.Query().Encode() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// replay sends a recorded response.
+func replay(ctx echo.Context, response *models.IdempotentResponse) error {
+	for name, values := range response.Header {
+		for _, value := range values {
+			ctx.Response().Header().Add(name, value)
+		}
+	}
+	ctx.Response().Header().Set(HeaderIdempotentReplayed, "true")
+	ctx.Response().WriteHeader(response.Status)
+	_, err := ctx.Response().Write(response.Body)
+	return err
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	keys_mock "github.com/KinitaL/testovoye/internal/usecases/idempotency"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestIdempotency tests the Idempotency middleware
+func TestIdempotency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	mockKeys := keys_mock.NewMockKeys(ctrl)
+	middleware := Idempotency(mockKeys)
+
+	newRequest := func(key, body string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodPost, "/api/books", bytes.NewReader([]byte(body)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if key != "" {
+			req.Header.Set(HeaderIdempotencyKey, key)
+		}
+		rec := httptest.NewRecorder()
+		return e.NewContext(req, rec), rec
+	}
+	created := func(ctx echo.Context) error {
+		body, _ := io.ReadAll(ctx.Request().Body)
+		ctx.Response().Header().Set(echo.HeaderLocation, "/api/books/1")
+		return ctx.JSONBlob(http.StatusCreated, body)
+	}
+
+	t.Run("Without key", func(t *testing.T) {
+		ctx, rec := newRequest("", `{"title":"A"}`)
+		handle(ctx, middleware(created))
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "", rec.Header().Get(HeaderIdempotentReplayed))
+	})
+
+	t.Run("First request", func(t *testing.T) {
+		var fingerprint string
+		mockKeys.EXPECT().Begin(gomock.Any(), "key", gomock.Any()).DoAndReturn(func(_ context.Context, _, f string) (*models.IdempotentResponse, error) {
+			fingerprint = f
+			return nil, nil
+		})
+		mockKeys.EXPECT().Complete(gomock.Any(), "key", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, response models.IdempotentResponse) error {
+			assert.Equal(t, http.StatusCreated, response.Status)
+			assert.Equal(t, []string{"/api/books/1"}, response.Header[echo.HeaderLocation])
+			assert.Equal(t, `{"title":"A"}`, string(response.Body))
+			return nil
+		})
+
+		ctx, rec := newRequest("key", `{"title":"A"}`)
+		handle(ctx, middleware(created))
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, `{"title":"A"}`, rec.Body.String())
+
+		// the same request has the same fingerprint, a different body has a different one
+		mockKeys.EXPECT().Begin(gomock.Any(), "key", fingerprint).Return(nil, errs.Unprocessable("used"))
+		ctx, _ = newRequest("key", `{"title":"A"}`)
+		assert.ErrorIs(t, middleware(created)(ctx), errs.ErrUnprocessable)
+
+		mockKeys.EXPECT().Begin(gomock.Any(), "key", gomock.Not(fingerprint)).Return(nil, nil)
+		mockKeys.EXPECT().Complete(gomock.Any(), "key", gomock.Any()).Return(nil)
+		ctx, _ = newRequest("key", `{"title":"B"}`)
+		assert.NoError(t, middleware(created)(ctx))
+	})
+
+	t.Run("Query is part of the fingerprint", func(t *testing.T) {
+		newImport := func(target string) echo.Context {
+			req := httptest.NewRequest(http.MethodPost, target, bytes.NewReader([]byte(`records`)))
+			req.Header.Set(HeaderIdempotencyKey, "key")
+			return e.NewContext(req, httptest.NewRecorder())
+		}
+		var fingerprint string
+		mockKeys.EXPECT().Begin(gomock.Any(), "key", gomock.Any()).DoAndReturn(func(_ context.Context, _, f string) (*models.IdempotentResponse, error) {
+			fingerprint = f
+			return nil, nil
+		})
+		mockKeys.EXPECT().Complete(gomock.Any(), "key", gomock.Any()).Return(nil)
+		assert.NoError(t, middleware(created)(newImport("/api/books:import?dry_run=true&limit=1")))
+
+		// the order of parameters doesn't matter, their values do
+		mockKeys.EXPECT().Begin(gomock.Any(), "key", fingerprint).Return(nil, errs.Unprocessable("used"))
+		assert.ErrorIs(t, middleware(created)(newImport("/api/books:import?limit=1&dry_run=true")), errs.ErrUnprocessable)
+
+		mockKeys.EXPECT().Begin(gomock.Any(), "key", gomock.Not(fingerprint)).Return(nil, errs.Unprocessable("used"))
+		assert.ErrorIs(t, middleware(created)(newImport("/api/books:import?limit=1")), errs.ErrUnprocessable)
+	})
+
+	t.Run("Panic releases the key", func(t *testing.T) {
+		mockKeys.EXPECT().Begin(gomock.Any(), "key", gomock.Any()).Return(nil, nil)
+		mockKeys.EXPECT().Release(gomock.Any(), "key").Return(nil)
+
+		ctx, _ := newRequest("key", `{}`)
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			_ = middleware(func(echo.Context) error {
+				panic(http.ErrAbortHandler)
+			})(ctx)
+		})
+	})
+
+	t.Run("Replay", func(t *testing.T) {
+		mockKeys.EXPECT().Begin(gomock.Any(), "key", gomock.Any()).Return(&models.IdempotentResponse{
+			Status: http.StatusCreated,
+			Header: map[string][]string{echo.HeaderLocation: {"/api/books/1"}},
+			Body:   []byte(`{"title":"A"}`),
+		}, nil)
+
+		ctx, rec := newRequest("key", `{"title":"A"}`)
+		handle(ctx, middleware(func(echo.Context) error {
+			t.Fatal("replayed request must not be processed")
+			return nil
+		}))
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "/api/books/1", rec.Header().Get(echo.HeaderLocation))
+		assert.Equal(t, "true", rec.Header().Get(HeaderIdempotentReplayed))
+		assert.Equal(t, `{"title":"A"}`, rec.Body.String())
+	})
+
+	t.Run("Mismatched request", func(t *testing.T) {
+		mockKeys.EXPECT().Begin(gomock.Any(), "key", gomock.Any()).Return(nil, errs.Unprocessable("used"))
+
+		ctx, rec := newRequest("key", `{"title":"B"}`)
+		handle(ctx, middleware(created))
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Equal(t, MIMEApplicationProblemJSON, rec.Header().Get(echo.HeaderContentType))
+	})
+
+	t.Run("Client error is recorded", func(t *testing.T) {
+		mockKeys.EXPECT().Begin(gomock.Any(), "key", gomock.Any()).Return(nil, nil)
+		mockKeys.EXPECT().Complete(gomock.Any(), "key", gomock.Any()).DoAndReturn(func(_ context.Context, _ string, response models.IdempotentResponse) error {
+			assert.Equal(t, http.StatusBadRequest, response.Status)
+			assert.Equal(t, []string{MIMEApplicationProblemJSON}, response.Header[echo.HeaderContentType])
+			return nil
+		})
+
+		ctx, rec := newRequest("key", `{}`)
+		handle(ctx, middleware(func(echo.Context) error {
+			return errs.Validation("invalid request body")
+		}))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Server error releases the key", func(t *testing.T) {
+		mockKeys.EXPECT().Begin(gomock.Any(), "key", gomock.Any()).Return(nil, nil)
+		mockKeys.EXPECT().Release(gomock.Any(), "key").Return(nil)
+
+		ctx, rec := newRequest("key", `{}`)
+		handle(ctx, middleware(func(echo.Context) error {
+			return errors.New("database is down")
+		}))
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/infrastructure/controllers/dto"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/pkg/marc"
+	"github.com/labstack/echo/v4"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// maxImportRecords is the largest number of records in an uploaded file, the same as of operations in a batch.
+const maxImportRecords = 1000
+
+// marcReader reads MARC records and returns io.EOF after the last one.
+type marcReader interface {
+	Read() (marc.Record, error)
+}
+
+// marcReaders maps media types of uploaded files to constructors of their readers.
+var marcReaders = map[string]func(r io.Reader) marcReader{
+	marc.MIMEMARC:           func(r io.Reader) marcReader { return marc.NewReader(r) },
+	marc.MIMEMARCXML:        func(r io.Reader) marcReader { return marc.NewXMLReader(r) },
+	echo.MIMEApplicationXML: func(r io.Reader) marcReader { return marc.NewXMLReader(r) },
+	echo.MIMETextXML:        func(r io.Reader) marcReader { return marc.NewXMLReader(r) },
+}
+
+// newMARCReader picks the reader of the request body by its content type.
+func newMARCReader(ctx echo.Context) (marcReader, error) {
+	mediaType, _, err := mime.ParseMediaType(ctx.Request().Header.Get(echo.HeaderContentType))
+	if newReader, ok := marcReaders[mediaType]; err == nil && ok {
+		return newReader(ctx.Request().Body), nil
+	}
+	return nil, echo.NewHTTPError(http.StatusUnsupportedMediaType,
+		"file must be "+marc.MIMEMARC+" or "+marc.MIMEMARCXML)
+}
+
+// marcBook converts a record to a book, validating it the same way as the body of a creation request.
+func marcBook(ctx echo.Context, record marc.Record) (models.Book, error) {
+	book := marc.ToBook(record)
+	if err := ctx.Validate(dto.CreateBookDto{Title: book.Title, Author: book.Author, Year: book.Year, ISBN: book.ISBN}); err != nil {
+		return book, err
+	}
+	return book, nil
+}
+
+// importResult converts the outcome of importing a record to the response item.
+func importResult(result models.BookImportResult) dto.BookOperationResultDto {
+	if result.Err != nil {
+		problem := NewProblem(result.Err)
+		return dto.BookOperationResultDto{Status: problem.Status, Error: &problem}
+	}
+	if result.Duplicate {
+		problem := NewProblem(errs.Conflict("book is already in the catalog"))
+		return dto.BookOperationResultDto{Status: problem.Status, Error: &problem}
+	}
+	return dto.BookOperationResultDto{Status: http.StatusCreated, Book: result.Book}
+}
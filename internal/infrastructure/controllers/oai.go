@@ -0,0 +1,366 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/pkg/dc"
+	"github.com/KinitaL/testovoye/pkg/marc"
+	"github.com/KinitaL/testovoye/pkg/oai"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// oaiPageSize is the number of records in a part of an incomplete list.
+const oaiPageSize = 100
+
+type (
+	// OAIRepository describes the catalog to harvesters.
+	OAIRepository struct {
+		Name       string
+		AdminEmail string
+		// Identifier is a domain name that prefixes OAI identifiers of books, as in "oai:<domain>:<id>".
+		Identifier string
+		// PersistentDeletions tells whether deleted books are reported forever or only until they are purged from the trash.
+		PersistentDeletions bool
+	}
+
+	// OAIController serves the catalog to metadata harvesters over OAI-PMH.
+	OAIController struct {
+		u          oaiUsecase
+		repository OAIRepository
+	}
+
+	// oaiUsecase defines the methods of the book usecase that harvesting needs.
+	oaiUsecase interface {
+		GetAny(ctx context.Context, ID uuid.UUID) (*models.Book, error)
+		Harvest(ctx context.Context, params models.HarvestParams) (*models.HarvestPage, error)
+		EarliestDatestamp(ctx context.Context) (time.Time, error)
+	}
+
+	// oaiFormat is a metadata format records are disseminated in.
+	oaiFormat struct {
+		oai.MetadataFormat
+		metadata func(book models.Book) any
+	}
+)
+
+// oaiFormats are the supported metadata formats by their prefixes.
+var oaiFormats = map[string]oaiFormat{
+	"oai_dc": {
+		MetadataFormat: oai.MetadataFormat{Prefix: "oai_dc", Schema: oai.SchemaOAIDC, Namespace: oai.NamespaceOAIDC},
+		metadata:       func(book models.Book) any { return oai.NewDC(dc.FromBook(book)) },
+	},
+	"marc21": {
+		MetadataFormat: oai.MetadataFormat{Prefix: "marc21", Schema: "http://www.loc.gov/standards/marcxml/schema/MARC21slim.xsd", Namespace: marc.Namespace},
+		metadata:       func(book models.Book) any { return marc.FromBook(book) },
+	},
+}
+
+// oaiArguments lists the arguments of every verb: true for required ones, false for optional ones.
+// The resumption token is exclusive: it can't be combined with other arguments.
+var oaiArguments = map[string]map[string]bool{
+	oai.VerbIdentify:            {},
+	oai.VerbListMetadataFormats: {"identifier": false},
+	oai.VerbListSets:            {"resumptionToken": false},
+	oai.VerbGetRecord:           {"identifier": true, "metadataPrefix": true},
+	oai.VerbListIdentifiers:     {"metadataPrefix": true, "from": false, "until": false, "set": false, "resumptionToken": false},
+	oai.VerbListRecords:         {"metadataPrefix": true, "from": false, "until": false, "set": false, "resumptionToken": false},
+}
+
+// NewOAIController initializes a new OAIController instance.
+func NewOAIController(usecase oaiUsecase, repository OAIRepository) *OAIController {
+	return &OAIController{u: usecase, repository: repository}
+}
+
+// Handle handles OAI-PMH requests, sent either as GET with a query or as POST with a form.
+// Errors of the protocol are reported in the response with status 200, as OAI-PMH requires.
+func (c *OAIController) Handle(ctx echo.Context) error {
+	args := ctx.QueryParams()
+	if ctx.Request().Method == http.MethodPost {
+		if err := ctx.Request().ParseForm(); err != nil {
+			return errs.Validation("invalid form")
+		}
+		args = ctx.Request().PostForm
+	}
+
+	response := oai.Response{Date: time.Now(), Request: oai.Request{BaseURL: absoluteURL(ctx, ctx.Request().URL.Path)}}
+	verb, err := c.checkArguments(args)
+	if err != nil {
+		// the request is echoed without arguments, as some of them are wrong
+		response.Errors = oaiErrors(err)
+		return c.write(ctx, response)
+	}
+	response.Request = oai.Request{
+		BaseURL:         response.Request.BaseURL,
+		Verb:            verb,
+		Identifier:      args.Get("identifier"),
+		MetadataPrefix:  args.Get("metadataPrefix"),
+		From:            args.Get("from"),
+		Until:           args.Get("until"),
+		Set:             args.Get("set"),
+		ResumptionToken: args.Get("resumptionToken"),
+	}
+
+	switch verb {
+	case oai.VerbIdentify:
+		err = c.identify(ctx, &response)
+	case oai.VerbListMetadataFormats:
+		err = c.listMetadataFormats(ctx, &response)
+	case oai.VerbListSets:
+		err = oai.Error{Code: oai.CodeNoSetHierarchy, Message: "the catalog has no sets"}
+	case oai.VerbGetRecord:
+		err = c.getRecord(ctx, &response)
+	default:
+		err = c.list(ctx, &response)
+	}
+	if err != nil {
+		response.Errors = oaiErrors(err)
+		if len(response.Errors) == 0 {
+			return err
+		}
+	}
+	return c.write(ctx, response)
+}
+
+// checkArguments returns the verb of a request if its arguments are legal, or errors of the protocol otherwise.
+func (c *OAIController) checkArguments(args url.Values) (string, error) {
+	verbs := args["verb"]
+	if len(verbs) != 1 {
+		return "", oai.Error{Code: oai.CodeBadVerb, Message: "the request must have a single verb"}
+	}
+	legal, ok := oaiArguments[verbs[0]]
+	if !ok {
+		return "", oai.Error{Code: oai.CodeBadVerb, Message: "illegal verb " + verbs[0]}
+	}
+
+	var problems []error
+	for _, name := range slices.Sorted(maps.Keys(args)) {
+		if _, ok := legal[name]; !ok && name != "verb" {
+			problems = append(problems, oai.Error{Code: oai.CodeBadArgument, Message: "illegal argument " + name})
+		} else if len(args[name]) > 1 {
+			problems = append(problems, oai.Error{Code: oai.CodeBadArgument, Message: "repeated argument " + name})
+		}
+	}
+	if args.Has("resumptionToken") {
+		if len(args) > 2 {
+			problems = append(problems, oai.Error{Code: oai.CodeBadArgument, Message: "resumptionToken is an exclusive argument"})
+		}
+	} else {
+		for _, name := range slices.Sorted(maps.Keys(legal)) {
+			if legal[name] && !args.Has(name) {
+				problems = append(problems, oai.Error{Code: oai.CodeBadArgument, Message: "missing argument " + name})
+			}
+		}
+	}
+	return verbs[0], errors.Join(problems...)
+}
+
+// identify describes the repository.
+func (c *OAIController) identify(ctx echo.Context, response *oai.Response) error {
+	earliest, err := c.u.EarliestDatestamp(ctx.Request().Context())
+	if err != nil {
+		return err
+	}
+	if earliest.IsZero() {
+		earliest = response.Date
+	}
+	deletedRecord := oai.DeletedRecordTransient
+	if c.repository.PersistentDeletions {
+		deletedRecord = oai.DeletedRecordPersistent
+	}
+	response.Identify = &oai.Identify{
+		RepositoryName:    c.repository.Name,
+		BaseURL:           response.Request.BaseURL,
+		EarliestDatestamp: earliest,
+		DeletedRecord:     deletedRecord,
+		AdminEmails:       []string{c.repository.AdminEmail},
+	}
+	return nil
+}
+
+// listMetadataFormats lists the formats of all records, or of a single one, which are the same.
+func (c *OAIController) listMetadataFormats(ctx echo.Context, response *oai.Response) error {
+	if response.Request.Identifier != "" {
+		if _, err := c.book(ctx, response.Request.Identifier); err != nil {
+			return err
+		}
+	}
+	for _, prefix := range []string{"oai_dc", "marc21"} {
+		response.MetadataFormats = append(response.MetadataFormats, oaiFormats[prefix].MetadataFormat)
+	}
+	return nil
+}
+
+// getRecord returns a single record.
+func (c *OAIController) getRecord(ctx echo.Context, response *oai.Response) error {
+	format, err := c.format(response.Request.MetadataPrefix)
+	if err != nil {
+		return err
+	}
+	book, err := c.book(ctx, response.Request.Identifier)
+	if err != nil {
+		return err
+	}
+	response.Records = []oai.Record{c.record(*book, format)}
+	return nil
+}
+
+// list returns a part of the list of ListIdentifiers or ListRecords. The resumption token is the metadata prefix
+// followed by the cursor of harvested books, which keeps the bounds of datestamps.
+func (c *OAIController) list(ctx echo.Context, response *oai.Response) error {
+	request := response.Request
+	prefix, params := request.MetadataPrefix, models.HarvestParams{Limit: oaiPageSize}
+	if request.ResumptionToken != "" {
+		var ok bool
+		prefix, params.Cursor, ok = strings.Cut(request.ResumptionToken, ":")
+		if !ok || params.Cursor == "" {
+			return oai.Error{Code: oai.CodeBadResumptionToken, Message: "invalid resumption token"}
+		}
+	} else {
+		if request.Set != "" {
+			return oai.Error{Code: oai.CodeNoSetHierarchy, Message: "the catalog has no sets"}
+		}
+		var err error
+		if params.From, params.Until, err = oaiRange(request.From, request.Until); err != nil {
+			return err
+		}
+	}
+	format, err := c.format(prefix)
+	if err != nil {
+		return err
+	}
+
+	page, err := c.u.Harvest(ctx.Request().Context(), params)
+	switch {
+	case errors.Is(err, errs.ErrValidation) && params.Cursor != "":
+		return oai.Error{Code: oai.CodeBadResumptionToken, Message: "invalid resumption token"}
+	case errors.Is(err, errs.ErrValidation):
+		return oai.Error{Code: oai.CodeBadArgument, Message: err.Error()}
+	case err != nil:
+		return err
+	case len(page.Items) == 0 && params.Cursor == "":
+		return oai.Error{Code: oai.CodeNoRecordsMatch, Message: "no records match the request"}
+	}
+
+	for _, book := range page.Items {
+		record := c.record(book, format)
+		if request.Verb == oai.VerbListIdentifiers {
+			response.Headers = append(response.Headers, record.Header)
+		} else {
+			response.Records = append(response.Records, record)
+		}
+	}
+	if page.NextCursor != "" {
+		response.ResumptionToken = &oai.ResumptionToken{Token: prefix + ":" + page.NextCursor}
+	} else if params.Cursor != "" {
+		response.ResumptionToken = &oai.ResumptionToken{}
+	}
+	return nil
+}
+
+// format returns the metadata format of the prefix.
+func (c *OAIController) format(prefix string) (oaiFormat, error) {
+	format, ok := oaiFormats[prefix]
+	if !ok {
+		return format, oai.Error{Code: oai.CodeCannotDisseminateFormat, Message: "unsupported metadata format " + prefix}
+	}
+	return format, nil
+}
+
+// book returns the book of an OAI identifier, deleted or not.
+func (c *OAIController) book(ctx echo.Context, identifier string) (*models.Book, error) {
+	missing := oai.Error{Code: oai.CodeIDDoesNotExist, Message: "unknown identifier " + identifier}
+	ID, err := uuid.Parse(strings.TrimPrefix(identifier, c.identifierPrefix()))
+	if err != nil || !strings.HasPrefix(identifier, c.identifierPrefix()) {
+		return nil, missing
+	}
+	book, err := c.u.GetAny(ctx.Request().Context(), ID)
+	if errors.Is(err, errs.ErrNotFound) {
+		return nil, missing
+	}
+	return book, err
+}
+
+// record returns the record of a book in the format.
+func (c *OAIController) record(book models.Book, format oaiFormat) oai.Record {
+	record := oai.Record{Header: oai.Header{
+		Identifier: c.identifierPrefix() + book.ID.String(),
+		Datestamp:  book.Datestamp(),
+		Deleted:    book.DeletedAt != nil,
+	}}
+	if !record.Header.Deleted {
+		record.Metadata = format.metadata(book)
+	}
+	return record
+}
+
+// identifierPrefix returns the part of OAI identifiers that precedes IDs of books.
+func (c *OAIController) identifierPrefix() string {
+	return "oai:" + c.repository.Identifier + ":"
+}
+
+// write sends a response.
+func (c *OAIController) write(ctx echo.Context, response oai.Response) error {
+	body, err := oai.Marshal(response)
+	if err != nil {
+		return err
+	}
+	return ctx.Blob(http.StatusOK, echo.MIMETextXMLCharsetUTF8, body)
+}
+
+// oaiRange parses the bounds of datestamps. Both must have the same granularity, and until is inclusive,
+// so it is turned into the beginning of the next day or second.
+func oaiRange(from, until string) (*time.Time, *time.Time, error) {
+	var layout string
+	var bounds [2]*time.Time
+	for i, value := range []string{from, until} {
+		if value == "" {
+			continue
+		}
+		valueLayout := oai.DateFormat
+		if len(value) > len(oai.DateFormat) {
+			valueLayout = oai.TimeFormat
+		}
+		if layout != "" && layout != valueLayout {
+			return nil, nil, oai.Error{Code: oai.CodeBadArgument, Message: "from and until must have the same granularity"}
+		}
+		layout = valueLayout
+		moment, err := time.Parse(layout, value)
+		if err != nil {
+			return nil, nil, oai.Error{Code: oai.CodeBadArgument, Message: "invalid datestamp " + value}
+		}
+		bounds[i] = &moment
+	}
+	if bounds[1] != nil {
+		next := bounds[1].Add(time.Second)
+		if layout == oai.DateFormat {
+			next = bounds[1].AddDate(0, 0, 1)
+		}
+		bounds[1] = &next
+	}
+	return bounds[0], bounds[1], nil
+}
+
+// oaiErrors returns the errors of the protocol an error holds, which are none for other errors.
+func oaiErrors(err error) []oai.Error {
+	var result []oai.Error
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range joined.Unwrap() {
+			result = append(result, oaiErrors(err)...)
+		}
+		return result
+	}
+	var protocolErr oai.Error
+	if errors.As(err, &protocolErr) {
+		result = append(result, protocolErr)
+	}
+	return result
+}
@@ -0,0 +1,248 @@
+package controllers
+
+import (
+	"encoding/xml"
+	"errors"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	usecase_mock "github.com/KinitaL/testovoye/internal/usecases/books"
+	"github.com/KinitaL/testovoye/pkg/oai"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type (
+	// testOAIResponse is the part of an OAI-PMH response checked by the tests.
+	testOAIResponse struct {
+		Request struct {
+			Verb    string `xml:"verb,attr"`
+			BaseURL string `xml:",chardata"`
+		} `xml:"request"`
+		Errors []struct {
+			Code string `xml:"code,attr"`
+		} `xml:"error"`
+		Identify struct {
+			RepositoryName    string `xml:"repositoryName"`
+			EarliestDatestamp string `xml:"earliestDatestamp"`
+			DeletedRecord     string `xml:"deletedRecord"`
+			AdminEmail        string `xml:"adminEmail"`
+		} `xml:"Identify"`
+		ListIdentifiers struct {
+			Headers         []testOAIHeader `xml:"header"`
+			ResumptionToken *string         `xml:"resumptionToken"`
+		} `xml:"ListIdentifiers"`
+		ListRecords struct {
+			Records         []testOAIRecord `xml:"record"`
+			ResumptionToken *string         `xml:"resumptionToken"`
+		} `xml:"ListRecords"`
+		GetRecord struct {
+			Records []testOAIRecord `xml:"record"`
+		} `xml:"GetRecord"`
+		Formats []string `xml:"ListMetadataFormats>metadataFormat>metadataPrefix"`
+	}
+
+	testOAIHeader struct {
+		Status     string `xml:"status,attr"`
+		Identifier string `xml:"identifier"`
+		Datestamp  string `xml:"datestamp"`
+	}
+
+	testOAIRecord struct {
+		Header   testOAIHeader    `xml:"header"`
+		Metadata *testOAIMetadata `xml:"metadata"`
+	}
+
+	testOAIMetadata struct {
+		Title   string    `xml:"dc>title"`
+		Creator string    `xml:"dc>creator"`
+		Date    string    `xml:"dc>date"`
+		MARC    *struct{} `xml:"record"`
+	}
+)
+
+// errorCodes returns the codes of errors of a response.
+func (r testOAIResponse) errorCodes() []string {
+	codes := make([]string, len(r.Errors))
+	for i, err := range r.Errors {
+		codes[i] = err.Code
+	}
+	return codes
+}
+
+// TestOAI tests the OAI-PMH endpoint
+func TestOAI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	mockUsecase := usecase_mock.NewMockBooks(ctrl)
+	controller := NewOAIController(mockUsecase, OAIRepository{
+		Name:       "Books",
+		AdminEmail: "admin@books.example",
+		Identifier: "books.example",
+	})
+
+	updated := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	deleted := updated.Add(time.Hour)
+	book := models.Book{ID: uuid.New(), Title: "War and Peace", Author: "Leo Tolstoy", Year: 1869, UpdatedAt: updated}
+	gone := models.Book{ID: uuid.New(), Title: "Anna Karenina", Author: "Leo Tolstoy", Year: 1878, UpdatedAt: updated, DeletedAt: &deleted}
+
+	request := func(t *testing.T, query string) testOAIResponse {
+		req := httptest.NewRequest(http.MethodGet, "/oai?"+query, nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		err := controller.Handle(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, rec.Code, http.StatusOK)
+		assert.Equal(t, rec.Header().Get(echo.HeaderContentType), echo.MIMETextXMLCharsetUTF8)
+
+		var response testOAIResponse
+		assert.Equal(t, xml.Unmarshal(rec.Body.Bytes(), &response), nil)
+		return response
+	}
+
+	t.Run("Identify", func(t *testing.T) {
+		mockUsecase.EXPECT().EarliestDatestamp(gomock.Any()).Return(updated, nil)
+
+		response := request(t, "verb=Identify")
+		assert.Empty(t, response.Errors)
+		assert.Equal(t, response.Request.Verb, oai.VerbIdentify)
+		assert.Equal(t, response.Request.BaseURL, "http://example.com/oai")
+		assert.Equal(t, response.Identify.RepositoryName, "Books")
+		assert.Equal(t, response.Identify.EarliestDatestamp, "2024-05-01T12:00:00Z")
+		assert.Equal(t, response.Identify.DeletedRecord, oai.DeletedRecordTransient)
+		assert.Equal(t, response.Identify.AdminEmail, "admin@books.example")
+	})
+
+	t.Run("ListMetadataFormats", func(t *testing.T) {
+		response := request(t, "verb=ListMetadataFormats")
+		assert.Equal(t, response.Formats, []string{"oai_dc", "marc21"})
+	})
+
+	t.Run("ListRecords", func(t *testing.T) {
+		from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+		until := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+		mockUsecase.EXPECT().Harvest(gomock.Any(), models.HarvestParams{From: &from, Until: &until, Limit: oaiPageSize}).
+			Return(&models.HarvestPage{Items: []models.Book{book, gone}, NextCursor: "next"}, nil)
+
+		response := request(t, "verb=ListRecords&metadataPrefix=oai_dc&from=2024-05-01&until=2024-05-01")
+		assert.Empty(t, response.Errors)
+		records := response.ListRecords.Records
+		assert.Equal(t, len(records), 2)
+		assert.Equal(t, records[0].Header.Identifier, "oai:books.example:"+book.ID.String())
+		assert.Equal(t, records[0].Header.Datestamp, "2024-05-01T12:00:00Z")
+		assert.Equal(t, records[0].Metadata.Title, book.Title)
+		assert.Equal(t, records[0].Metadata.Creator, book.Author)
+		assert.Equal(t, records[0].Metadata.Date, "1869")
+		assert.Equal(t, records[1].Header.Status, "deleted")
+		assert.Equal(t, records[1].Header.Datestamp, "2024-05-01T13:00:00Z")
+		assert.Nil(t, records[1].Metadata)
+		assert.Equal(t, *response.ListRecords.ResumptionToken, "oai_dc:next")
+	})
+
+	t.Run("ListIdentifiers resumed", func(t *testing.T) {
+		mockUsecase.EXPECT().Harvest(gomock.Any(), models.HarvestParams{Cursor: "next", Limit: oaiPageSize}).
+			Return(&models.HarvestPage{Items: []models.Book{gone}}, nil)
+
+		response := request(t, "verb=ListIdentifiers&resumptionToken=marc21:next")
+		assert.Empty(t, response.Errors)
+		assert.Equal(t, len(response.ListIdentifiers.Headers), 1)
+		assert.Equal(t, response.ListIdentifiers.Headers[0].Identifier, "oai:books.example:"+gone.ID.String())
+		// the last part of a resumed list has an empty token
+		assert.Equal(t, *response.ListIdentifiers.ResumptionToken, "")
+	})
+
+	t.Run("GetRecord", func(t *testing.T) {
+		mockUsecase.EXPECT().GetAny(gomock.Any(), book.ID).Return(&book, nil)
+
+		response := request(t, "verb=GetRecord&metadataPrefix=marc21&identifier=oai:books.example:"+book.ID.String())
+		assert.Empty(t, response.Errors)
+		assert.Equal(t, len(response.GetRecord.Records), 1)
+		assert.NotNil(t, response.GetRecord.Records[0].Metadata.MARC)
+	})
+
+	for _, testCase := range []struct {
+		name  string
+		query string
+		setup func()
+		codes []string
+	}{
+		{name: "Missing verb", query: "", codes: []string{oai.CodeBadVerb}},
+		{name: "Illegal verb", query: "verb=Harvest", codes: []string{oai.CodeBadVerb}},
+		{
+			name:  "Illegal and missing arguments",
+			query: "verb=GetRecord&identifier=x&identifier=y&format=oai_dc",
+			codes: []string{oai.CodeBadArgument, oai.CodeBadArgument, oai.CodeBadArgument},
+		},
+		{name: "Exclusive resumption token", query: "verb=ListRecords&metadataPrefix=oai_dc&resumptionToken=x", codes: []string{oai.CodeBadArgument}},
+		{name: "Mixed granularity", query: "verb=ListRecords&metadataPrefix=oai_dc&from=2024-05-01&until=2024-05-01T00:00:00Z", codes: []string{oai.CodeBadArgument}},
+		{name: "Unsupported format", query: "verb=ListRecords&metadataPrefix=mods", codes: []string{oai.CodeCannotDisseminateFormat}},
+		{name: "Sets", query: "verb=ListSets", codes: []string{oai.CodeNoSetHierarchy}},
+		{name: "Set", query: "verb=ListIdentifiers&metadataPrefix=oai_dc&set=novels", codes: []string{oai.CodeNoSetHierarchy}},
+		{name: "Foreign identifier", query: "verb=GetRecord&metadataPrefix=oai_dc&identifier=oai:other.example:" + book.ID.String(), codes: []string{oai.CodeIDDoesNotExist}},
+		{
+			name:  "Unknown identifier",
+			query: "verb=ListMetadataFormats&identifier=oai:books.example:" + book.ID.String(),
+			setup: func() {
+				mockUsecase.EXPECT().GetAny(gomock.Any(), book.ID).Return(nil, errs.NotFound("book doesn't exist"))
+			},
+			codes: []string{oai.CodeIDDoesNotExist},
+		},
+		{
+			name:  "No records",
+			query: "verb=ListIdentifiers&metadataPrefix=oai_dc",
+			setup: func() {
+				mockUsecase.EXPECT().Harvest(gomock.Any(), models.HarvestParams{Limit: oaiPageSize}).
+					Return(&models.HarvestPage{Items: []models.Book{}}, nil)
+			},
+			codes: []string{oai.CodeNoRecordsMatch},
+		},
+		{
+			name:  "Bad resumption token",
+			query: "verb=ListRecords&resumptionToken=oai_dc:garbage",
+			setup: func() {
+				mockUsecase.EXPECT().Harvest(gomock.Any(), models.HarvestParams{Cursor: "garbage", Limit: oaiPageSize}).
+					Return(nil, errs.Validation("invalid cursor"))
+			},
+			codes: []string{oai.CodeBadResumptionToken},
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			if testCase.setup != nil {
+				testCase.setup()
+			}
+			response := request(t, testCase.query)
+			assert.Equal(t, response.errorCodes(), testCase.codes)
+		})
+	}
+
+	t.Run("POST", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/oai", strings.NewReader("verb=ListSets"))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		err := controller.Handle(ctx)
+		assert.Equal(t, err, nil)
+		assert.Contains(t, rec.Body.String(), `<error code="noSetHierarchy">`)
+	})
+
+	t.Run("Internal Error", func(t *testing.T) {
+		mockUsecase.EXPECT().EarliestDatestamp(gomock.Any()).Return(time.Time{}, errs.Internal(errors.New("connection refused")))
+
+		req := httptest.NewRequest(http.MethodGet, "/oai?verb=Identify", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.Handle)
+		assert.Equal(t, rec.Code, http.StatusInternalServerError)
+	})
+}
@@ -0,0 +1,270 @@
+package controllers
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/infrastructure/controllers/dto"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/pkg/opds"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// opdsPageSize is the number of books on a page of an acquisition feed unless the client asks for another.
+const opdsPageSize = 20
+
+type (
+	// OPDSController serves the catalog to e-reader applications as OPDS feeds.
+	OPDSController struct {
+		u opdsUsecase
+	}
+
+	// opdsUsecase defines the methods of the book usecase that the feeds are built from.
+	opdsUsecase interface {
+		GetAll(ctx context.Context, params models.BookListParams) (*models.BookPage, error)
+		Search(ctx context.Context, query models.BookSearchQuery) (*models.BookSearchResult, error)
+	}
+
+	// opdsVersion is a variant of the feeds served under its own root path.
+	opdsVersion struct {
+		root            string // Path of the root navigation feed
+		navigationType  string
+		acquisitionType string
+		marshal         func(feed opds.Feed) ([]byte, error)
+		search          []opds.Link // Links to search of the catalog, the same in every feed
+	}
+)
+
+var (
+	// opdsAtom serves OPDS 1.2 feeds, which are searched through the OpenSearch description.
+	opdsAtom = opdsVersion{
+		root:            "/opds",
+		navigationType:  opds.MIMEAtomNavigation,
+		acquisitionType: opds.MIMEAtomAcquisition,
+		marshal:         opds.MarshalAtom,
+		search: []opds.Link{
+			{Rel: opds.RelSearch, Href: "/opds/opensearch.xml", Type: opds.MIMEOpenSearch},
+			{Rel: opds.RelSearch, Href: "/opds/search?q={searchTerms}", Type: opds.MIMEAtomAcquisition},
+		},
+	}
+
+	// opdsJSON serves OPDS 2.0 feeds.
+	opdsJSON = opdsVersion{
+		root:            "/opds/v2",
+		navigationType:  opds.MIMEOPDS2,
+		acquisitionType: opds.MIMEOPDS2,
+		marshal:         opds.MarshalJSON,
+		search: []opds.Link{
+			{Rel: opds.RelSearch, Href: "/opds/v2/search{?q}", Type: opds.MIMEOPDS2, Templated: true},
+		},
+	}
+)
+
+// opdsRecordFormats are the representations of a book offered for download. The catalog holds
+// no book files, so e-readers get bibliographic records of the book instead.
+var opdsRecordFormats = []struct {
+	format string
+	title  string
+}{
+	{format: "marcxml", title: "MARCXML"},
+	{format: "bibtex", title: "BibTeX"},
+	{format: "ris", title: "RIS"},
+}
+
+// NewOPDSController initializes a new OPDSController instance.
+func NewOPDSController(usecase opdsUsecase) *OPDSController {
+	return &OPDSController{u: usecase}
+}
+
+// Root returns the handler of the root navigation feed, which leads to the acquisition feeds.
+func (c *OPDSController) Root(version opdsVersion) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		feed := opds.Feed{
+			ID:      absoluteURL(ctx, version.root),
+			Title:   "Books",
+			Updated: time.Now(),
+			Links: append([]opds.Link{
+				{Rel: opds.RelSelf, Href: version.root, Type: version.navigationType},
+				{Rel: opds.RelStart, Href: version.root, Type: version.navigationType},
+			}, version.search...),
+			Navigation: []opds.Navigation{
+				{
+					ID:      absoluteURL(ctx, version.root+"/new"),
+					Title:   "Recently added",
+					Summary: "Books in the order they were added, newest first",
+					Link:    opds.Link{Rel: opds.RelSortNew, Href: version.root + "/new", Type: version.acquisitionType},
+				},
+				{
+					ID:      absoluteURL(ctx, version.root+"/books"),
+					Title:   "All books",
+					Summary: "Books in the order of titles",
+					Link:    opds.Link{Rel: opds.RelSubsection, Href: version.root + "/books", Type: version.acquisitionType},
+				},
+			},
+		}
+		return c.render(ctx, version, version.navigationType, feed)
+	}
+}
+
+// New returns the handler of the acquisition feed of books, most recently added first.
+func (c *OPDSController) New(version opdsVersion) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		return c.acquisition(ctx, version, "Recently added", models.BookSortCreatedAt, true)
+	}
+}
+
+// Books returns the handler of the acquisition feed of all books in the order of titles.
+func (c *OPDSController) Books(version opdsVersion) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		return c.acquisition(ctx, version, "All books", models.BookSortTitle, false)
+	}
+}
+
+// acquisition sends a page of books in the order. Pages are linked with the cursors of the listing.
+func (c *OPDSController) acquisition(ctx echo.Context, version opdsVersion, title string, sortBy models.BookSortField, desc bool) error {
+	var query dto.OPDSFeedQuery
+	if err := ctx.Bind(&query); err != nil {
+		return errs.Validation("invalid query parameters")
+	}
+	if err := ctx.Validate(query); err != nil {
+		return err
+	}
+	if query.Limit == 0 {
+		query.Limit = opdsPageSize
+	}
+	page, err := c.u.GetAll(ctx.Request().Context(), models.BookListParams{
+		SortBy:    sortBy,
+		Desc:      desc,
+		Limit:     query.Limit,
+		Cursor:    query.Cursor,
+		WithTotal: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	feed := c.acquisitionFeed(ctx, version, title, page.Items)
+	feed.Total = page.Total
+	if query.Cursor != "" {
+		feed.Links = append(feed.Links, opds.Link{Rel: opds.RelFirst, Href: requestURIWith(ctx, "cursor", ""), Type: version.acquisitionType})
+	}
+	if page.NextCursor != "" {
+		feed.Links = append(feed.Links, opds.Link{Rel: opds.RelNext, Href: requestURIWith(ctx, "cursor", page.NextCursor), Type: version.acquisitionType})
+	}
+	return c.render(ctx, version, version.acquisitionType, feed)
+}
+
+// Search returns the handler of the acquisition feed of books found by text. Pages are linked with offsets.
+func (c *OPDSController) Search(version opdsVersion) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		var query dto.OPDSSearchQuery
+		if err := ctx.Bind(&query); err != nil {
+			return errs.Validation("invalid query parameters")
+		}
+		if err := ctx.Validate(query); err != nil {
+			return err
+		}
+		if query.Limit == 0 {
+			query.Limit = opdsPageSize
+		}
+		result, err := c.u.Search(ctx.Request().Context(), models.BookSearchQuery{
+			Query:  query.Q,
+			Limit:  query.Limit,
+			Offset: query.Offset,
+		})
+		if err != nil {
+			return err
+		}
+
+		books := make([]models.Book, len(result.Hits))
+		for i, hit := range result.Hits {
+			books[i] = hit.Book
+		}
+		feed := c.acquisitionFeed(ctx, version, "Search: "+query.Q, books)
+		// a full page means there may be more results
+		if len(books) == query.Limit {
+			next := requestURIWith(ctx, "offset", strconv.Itoa(query.Offset+query.Limit))
+			feed.Links = append(feed.Links, opds.Link{Rel: opds.RelNext, Href: next, Type: version.acquisitionType})
+		}
+		return c.render(ctx, version, version.acquisitionType, feed)
+	}
+}
+
+// OpenSearch handles HTTP GET requests for the OpenSearch description of both versions of the search feed.
+// Templates are absolute, as clients don't resolve them against the URL of the description.
+func (c *OPDSController) OpenSearch(ctx echo.Context) error {
+	body, err := opds.MarshalOpenSearch(opds.OpenSearch{
+		ShortName:   "Books",
+		Description: "Search books by title and author",
+		URLs: []opds.OpenSearchURL{
+			{Type: opdsAtom.acquisitionType, Template: absoluteURL(ctx, opdsAtom.root+"/search?q={searchTerms}")},
+			{Type: opdsJSON.acquisitionType, Template: absoluteURL(ctx, opdsJSON.root+"/search?q={searchTerms}")},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.Blob(http.StatusOK, opds.MIMEOpenSearch, body)
+}
+
+// acquisitionFeed returns the feed of a page of books with links every acquisition feed has.
+func (c *OPDSController) acquisitionFeed(ctx echo.Context, version opdsVersion, title string, books []models.Book) opds.Feed {
+	feed := opds.Feed{
+		ID:    absoluteURL(ctx, ctx.Request().URL.RequestURI()),
+		Title: title,
+		Links: append([]opds.Link{
+			{Rel: opds.RelSelf, Href: ctx.Request().URL.RequestURI(), Type: version.acquisitionType},
+			{Rel: opds.RelStart, Href: version.root, Type: version.navigationType},
+			{Rel: opds.RelUp, Href: version.root, Type: version.navigationType},
+		}, version.search...),
+		PerPage: len(books),
+	}
+	for _, book := range books {
+		feed.Publications = append(feed.Publications, opdsPublication(book))
+		if book.UpdatedAt.After(feed.Updated) {
+			feed.Updated = book.UpdatedAt
+		}
+	}
+	if feed.Updated.IsZero() {
+		feed.Updated = time.Now()
+	}
+	return feed
+}
+
+// opdsPublication converts a book to an entry of an acquisition feed.
+func opdsPublication(book models.Book) opds.Publication {
+	href := "/api/books/" + book.ID.String()
+	publication := opds.Publication{
+		ID:      "urn:uuid:" + book.ID.String(),
+		Title:   book.Title,
+		Authors: []string{book.Author},
+		Year:    book.Year,
+		Updated: book.UpdatedAt,
+		Links:   []opds.Link{{Rel: opds.RelAlternate, Href: href, Type: echo.MIMEApplicationJSON}},
+	}
+	for _, record := range opdsRecordFormats {
+		publication.Links = append(publication.Links, opds.Link{
+			Rel:   opds.RelOpenAccess,
+			Href:  href + "?format=" + record.format,
+			Type:  bookFormats[record.format].contentType,
+			Title: record.title,
+		})
+	}
+	return publication
+}
+
+// render sends a feed in the representation of the version.
+func (c *OPDSController) render(ctx echo.Context, version opdsVersion, contentType string, feed opds.Feed) error {
+	body, err := version.marshal(feed)
+	if err != nil {
+		return err
+	}
+	return ctx.Blob(http.StatusOK, contentType, body)
+}
+
+// absoluteURL returns the URL of a path on the server the request came to.
+func absoluteURL(ctx echo.Context, path string) string {
+	return ctx.Scheme() + "://" + ctx.Request().Host + path
+}
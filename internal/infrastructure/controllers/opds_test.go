@@ -0,0 +1,275 @@
+package controllers
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"github.com/KinitaL/testovoye/internal/models"
+	usecase_mock "github.com/KinitaL/testovoye/internal/usecases/books"
+	"github.com/KinitaL/testovoye/pkg/opds"
+	"github.com/KinitaL/testovoye/pkg/validator"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type (
+	// testAtomFeed is the part of an OPDS 1.2 feed checked by the tests.
+	testAtomFeed struct {
+		Title        string          `xml:"title"`
+		Links        []testAtomLink  `xml:"link"`
+		TotalResults int64           `xml:"totalResults"`
+		Entries      []testAtomEntry `xml:"entry"`
+	}
+
+	testAtomLink struct {
+		Rel  string `xml:"rel,attr"`
+		Href string `xml:"href,attr"`
+		Type string `xml:"type,attr"`
+	}
+
+	testAtomEntry struct {
+		ID      string         `xml:"id"`
+		Title   string         `xml:"title"`
+		Author  string         `xml:"author>name"`
+		Issued  string         `xml:"issued"`
+		Content string         `xml:"content"`
+		Links   []testAtomLink `xml:"link"`
+	}
+)
+
+// link returns the href of the first link with the relation, or an empty string.
+func (f testAtomFeed) link(rel string) string {
+	for _, link := range f.Links {
+		if link.Rel == rel {
+			return link.Href
+		}
+	}
+	return ""
+}
+
+// TestOPDSRoot tests the root navigation feed
+func TestOPDSRoot(t *testing.T) {
+	e := echo.New()
+	controller := NewOPDSController(nil)
+
+	t.Run("Atom", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/opds", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		err := controller.Root(opdsAtom)(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, rec.Code, http.StatusOK)
+		assert.Equal(t, rec.Header().Get(echo.HeaderContentType), opds.MIMEAtomNavigation)
+
+		var feed testAtomFeed
+		assert.Equal(t, xml.Unmarshal(rec.Body.Bytes(), &feed), nil)
+		assert.Equal(t, feed.link(opds.RelStart), "/opds")
+		assert.Equal(t, feed.link(opds.RelSearch), "/opds/opensearch.xml")
+		assert.Equal(t, len(feed.Entries), 2)
+		assert.Equal(t, feed.Entries[0].Links, []testAtomLink{{Rel: opds.RelSortNew, Href: "/opds/new", Type: opds.MIMEAtomAcquisition}})
+		assert.Equal(t, feed.Entries[1].Links, []testAtomLink{{Rel: opds.RelSubsection, Href: "/opds/books", Type: opds.MIMEAtomAcquisition}})
+	})
+
+	t.Run("OPDS 2.0", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/opds/v2", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		err := controller.Root(opdsJSON)(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, rec.Header().Get(echo.HeaderContentType), opds.MIMEOPDS2)
+
+		var feed struct {
+			Links      []map[string]any `json:"links"`
+			Navigation []map[string]any `json:"navigation"`
+		}
+		assert.Equal(t, json.Unmarshal(rec.Body.Bytes(), &feed), nil)
+		assert.Contains(t, feed.Links, map[string]any{"rel": "search", "href": "/opds/v2/search{?q}", "type": opds.MIMEOPDS2, "templated": true})
+		assert.Equal(t, feed.Navigation[0], map[string]any{"rel": opds.RelSortNew, "href": "/opds/v2/new", "type": opds.MIMEOPDS2, "title": "Recently added"})
+	})
+}
+
+// TestOPDSBooks tests the acquisition feeds of books
+func TestOPDSBooks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	e.Validator = validator.New()
+	mockUsecase := usecase_mock.NewMockBooks(ctrl)
+	controller := NewOPDSController(mockUsecase)
+
+	updated := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	book := models.Book{ID: uuid.New(), Title: "War and Peace", Author: "Leo Tolstoy", Year: 1869, UpdatedAt: updated}
+	total := int64(3)
+
+	t.Run("Atom", func(t *testing.T) {
+		mockUsecase.EXPECT().GetAll(gomock.Any(), models.BookListParams{
+			SortBy:    models.BookSortCreatedAt,
+			Desc:      true,
+			Limit:     opdsPageSize,
+			Cursor:    "current",
+			WithTotal: true,
+		}).Return(&models.BookPage{Items: []models.Book{book}, NextCursor: "next", Total: &total}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/opds/new?cursor=current", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		err := controller.New(opdsAtom)(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, rec.Code, http.StatusOK)
+		assert.Equal(t, rec.Header().Get(echo.HeaderContentType), opds.MIMEAtomAcquisition)
+
+		var feed testAtomFeed
+		assert.Equal(t, xml.Unmarshal(rec.Body.Bytes(), &feed), nil)
+		assert.Equal(t, feed.link(opds.RelSelf), "/opds/new?cursor=current")
+		assert.Equal(t, feed.link(opds.RelFirst), "/opds/new")
+		assert.Equal(t, feed.link(opds.RelNext), "/opds/new?cursor=next")
+		assert.Equal(t, feed.TotalResults, total)
+		assert.Equal(t, len(feed.Entries), 1)
+
+		entry := feed.Entries[0]
+		assert.Equal(t, entry.ID, "urn:uuid:"+book.ID.String())
+		assert.Equal(t, entry.Title, book.Title)
+		assert.Equal(t, entry.Author, book.Author)
+		assert.Equal(t, entry.Issued, "1869")
+		assert.Contains(t, entry.Links, testAtomLink{
+			Rel:  opds.RelOpenAccess,
+			Href: "/api/books/" + book.ID.String() + "?format=marcxml",
+			Type: "application/marcxml+xml",
+		})
+	})
+
+	t.Run("OPDS 2.0", func(t *testing.T) {
+		mockUsecase.EXPECT().GetAll(gomock.Any(), models.BookListParams{
+			SortBy:    models.BookSortTitle,
+			Limit:     1,
+			WithTotal: true,
+		}).Return(&models.BookPage{Items: []models.Book{book}, Total: &total}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/opds/v2/books?limit=1", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		err := controller.Books(opdsJSON)(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, rec.Header().Get(echo.HeaderContentType), opds.MIMEOPDS2)
+
+		var feed struct {
+			Metadata struct {
+				NumberOfItems int64 `json:"numberOfItems"`
+			} `json:"metadata"`
+			Links        []map[string]any `json:"links"`
+			Publications []struct {
+				Metadata map[string]any `json:"metadata"`
+			} `json:"publications"`
+		}
+		assert.Equal(t, json.Unmarshal(rec.Body.Bytes(), &feed), nil)
+		assert.Equal(t, feed.Metadata.NumberOfItems, total)
+		for _, link := range feed.Links {
+			assert.NotEqual(t, link["rel"], opds.RelNext)
+		}
+		assert.Equal(t, feed.Publications[0].Metadata, map[string]any{
+			"@type":      "http://schema.org/Book",
+			"identifier": "urn:uuid:" + book.ID.String(),
+			"title":      book.Title,
+			"author":     []any{map[string]any{"name": book.Author}},
+			"published":  "1869",
+			"modified":   "2024-05-01T12:00:00Z",
+		})
+	})
+
+	t.Run("Invalid limit", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/opds/books?limit=1000", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.Books(opdsAtom))
+		assert.Equal(t, rec.Code, http.StatusBadRequest)
+	})
+}
+
+// TestOPDSSearch tests the search feed and its OpenSearch description
+func TestOPDSSearch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	e.Validator = validator.New()
+	mockUsecase := usecase_mock.NewMockBooks(ctrl)
+	controller := NewOPDSController(mockUsecase)
+
+	hit := models.BookSearchHit{Book: models.Book{ID: uuid.New(), Title: "War and Peace", Author: "Leo Tolstoy", Year: 1869}}
+
+	t.Run("Full page", func(t *testing.T) {
+		mockUsecase.EXPECT().Search(gomock.Any(), models.BookSearchQuery{Query: "war", Limit: 1, Offset: 2}).
+			Return(&models.BookSearchResult{Hits: []models.BookSearchHit{hit}}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/opds/search?q=war&limit=1&offset=2", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		err := controller.Search(opdsAtom)(ctx)
+		assert.Equal(t, err, nil)
+
+		var feed testAtomFeed
+		assert.Equal(t, xml.Unmarshal(rec.Body.Bytes(), &feed), nil)
+		assert.Equal(t, feed.Title, "Search: war")
+		assert.Equal(t, feed.link(opds.RelNext), "/opds/search?limit=1&offset=3&q=war")
+		assert.Equal(t, len(feed.Entries), 1)
+	})
+
+	t.Run("Last page", func(t *testing.T) {
+		mockUsecase.EXPECT().Search(gomock.Any(), models.BookSearchQuery{Query: "war", Limit: opdsPageSize}).
+			Return(&models.BookSearchResult{Hits: []models.BookSearchHit{hit}}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/opds/search?q=war", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		err := controller.Search(opdsAtom)(ctx)
+		assert.Equal(t, err, nil)
+
+		var feed testAtomFeed
+		assert.Equal(t, xml.Unmarshal(rec.Body.Bytes(), &feed), nil)
+		assert.Equal(t, feed.link(opds.RelNext), "")
+	})
+
+	t.Run("Missing query", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/opds/v2/search", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.Search(opdsJSON))
+		assert.Equal(t, rec.Code, http.StatusBadRequest)
+	})
+
+	t.Run("OpenSearch", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/opds/opensearch.xml", nil)
+		req.Host = "books.example"
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		err := controller.OpenSearch(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, rec.Header().Get(echo.HeaderContentType), opds.MIMEOpenSearch)
+
+		var description struct {
+			URLs []struct {
+				Type     string `xml:"type,attr"`
+				Template string `xml:"template,attr"`
+			} `xml:"Url"`
+		}
+		assert.Equal(t, xml.Unmarshal(rec.Body.Bytes(), &description), nil)
+		assert.Equal(t, description.URLs[0].Type, opds.MIMEAtomAcquisition)
+		assert.Equal(t, description.URLs[0].Template, "http://books.example/opds/search?q={searchTerms}")
+		assert.Equal(t, description.URLs[1].Template, "http://books.example/opds/v2/search?q={searchTerms}")
+	})
+}
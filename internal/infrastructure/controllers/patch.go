@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/infrastructure/controllers/dto"
+	"github.com/KinitaL/testovoye/pkg/jsonpatch"
+	"github.com/labstack/echo/v4"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// HeaderAcceptPatch lists patch formats the server supports (RFC 5789, section 3.1).
+const HeaderAcceptPatch = "Accept-Patch"
+
+// patchFormats maps media types of PATCH bodies to functions applying them to a JSON document.
+// Plain JSON is treated as a merge patch, which is how partial updates worked before.
+var patchFormats = map[string]func(doc, patch []byte) ([]byte, error){
+	jsonpatch.MIMEMergePatch: jsonpatch.MergePatch,
+	jsonpatch.MIMEJSONPatch:  jsonpatch.Apply,
+	echo.MIMEApplicationJSON: jsonpatch.MergePatch,
+}
+
+// patchFormat picks the function applying the request body by its content type.
+func patchFormat(ctx echo.Context) (func(doc, patch []byte) ([]byte, error), error) {
+	mediaType, _, err := mime.ParseMediaType(ctx.Request().Header.Get(echo.HeaderContentType))
+	if apply, ok := patchFormats[mediaType]; err == nil && ok {
+		return apply, nil
+	}
+	ctx.Response().Header().Set(HeaderAcceptPatch, strings.Join([]string{jsonpatch.MIMEMergePatch, jsonpatch.MIMEJSONPatch}, ", "))
+	return nil, echo.NewHTTPError(http.StatusUnsupportedMediaType,
+		"patch must be "+jsonpatch.MIMEMergePatch+" or "+jsonpatch.MIMEJSONPatch)
+}
+
+// patchError converts an error of applying a patch to a domain error.
+func patchError(err error) error {
+	if errors.Is(err, jsonpatch.ErrNotApplicable) {
+		return errs.Conflict("%v", err)
+	}
+	return errs.Validation("%v", err)
+}
+
+// decodeDocument parses a patched book, rejecting members that aren't book fields.
+func decodeDocument(data []byte) (dto.BookDocumentDto, error) {
+	var doc dto.BookDocumentDto
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&doc)
+
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+		return doc, nil
+	case errors.As(err, &typeErr):
+		return doc, errs.InvalidFields(errs.FieldError{Field: typeErr.Field, Message: "has a wrong type"})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return doc, errs.InvalidFields(errs.FieldError{Field: field, Message: "is not a field of a book"})
+	default:
+		return doc, errs.Validation("patched book must be a JSON object")
+	}
+}
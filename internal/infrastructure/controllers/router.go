@@ -1,7 +1,7 @@
 package controllers
 
 import (
-	_ "github.com/KinitaL/testovoye/docs"
+	_ "github.com/KinitaL/testovoye/docs/swagger/api"
 	"github.com/KinitaL/testovoye/internal/usecases"
 	"github.com/labstack/echo/v4"
 	"github.com/swaggo/echo-swagger"
@@ -15,17 +15,94 @@ import (
 // @version 1.0
 // @description Сервис книг
 // @basePath /api
-func Register(server *echo.Echo, registry *usecases.Registry) {
+func Register(server *echo.Echo, registry *usecases.Registry, repository OAIRepository, database SRUDatabase, options ...Option) {
+	server.HTTPErrorHandler = HTTPErrorHandler
 
 	api := server.Group("/api")
 
 	{
-		books := NewController(registry.Books)
-		api.POST("/books", books.Create)
+		idempotent := Idempotency(registry.Idempotency)
+
+		books := NewController(registry.Books, options...)
+		api.POST("/books", books.Create, idempotent)
+		api.POST(`/books\:batch`, books.Batch, idempotent)
+		api.POST(`/books\:import`, books.Import, idempotent)
 		api.GET("/books", books.GetAll)
+		api.GET("/books/search", books.Search)
+		api.GET("/books/export", books.Export)
+		api.GET("/books/isbn/:isbn", books.GetByISBN)
 		api.GET("/books/:id", books.GetOne)
+		api.PUT("/books/:id", books.Replace)
 		api.PATCH("/books/:id", books.Update)
 		api.DELETE("/books/:id", books.Delete)
+		api.POST("/books/:id/restore", books.Restore, idempotent)
+		api.GET("/trash/books", books.GetTrash)
+		api.DELETE("/trash/books/:id", books.Purge)
+	}
+
+	{
+		idempotent := Idempotency(registry.Idempotency)
+
+		authors := NewAuthorsController(registry.Authors, registry.Books)
+		api.GET("/authors", authors.GetAll)
+		api.POST("/authors", authors.Create)
+		api.GET("/authors/lookup", authors.Lookup)
+		api.GET("/authors/:id", authors.GetOne)
+		api.PUT("/authors/:id", authors.Replace)
+		api.DELETE("/authors/:id", authors.Delete)
+		api.GET("/authors/:id/books", authors.GetBooks)
+		api.POST("/authors/:id/merge", authors.Merge, idempotent)
+	}
+
+	{
+		works := NewWorksController(registry.Works, registry.Books)
+		api.GET("/works", works.GetAll)
+		api.POST("/works", works.Create)
+		api.GET("/works/:id", works.GetOne)
+		api.PUT("/works/:id", works.Replace)
+		api.DELETE("/works/:id", works.Delete)
+		api.GET("/works/:id/editions", works.GetEditions)
+		api.PUT("/works/:id/editions/:book_id", works.Attach)
+		api.DELETE("/works/:id/editions/:book_id", works.Detach)
+	}
+
+	{
+		genres := NewGenresController(registry.Genres)
+		api.GET("/genres", genres.GetAll)
+		api.POST("/genres", genres.Create)
+		api.GET("/genres/:id", genres.GetOne)
+		api.PUT("/genres/:id", genres.Replace)
+		api.DELETE("/genres/:id", genres.Delete)
+	}
+
+	{
+		tags := NewTagsController(registry.Tags)
+		api.GET("/tags", tags.GetAll)
+		api.PUT("/tags/:tag", tags.Rename)
+		api.DELETE("/tags/:tag", tags.Delete)
+	}
+
+	{
+		feeds := NewOPDSController(registry.Books)
+		for _, version := range []opdsVersion{opdsAtom, opdsJSON} {
+			server.GET(version.root, feeds.Root(version))
+			server.GET(version.root+"/new", feeds.New(version))
+			server.GET(version.root+"/books", feeds.Books(version))
+			server.GET(version.root+"/search", feeds.Search(version))
+		}
+		server.GET("/opds/opensearch.xml", feeds.OpenSearch)
+	}
+
+	{
+		harvesting := NewOAIController(registry.Books, repository)
+		server.GET("/oai", harvesting.Handle)
+		server.POST("/oai", harvesting.Handle)
+	}
+
+	{
+		retrieval := NewSRUController(registry.Books, database)
+		server.GET("/sru", retrieval.Handle)
+		server.POST("/sru", retrieval.Handle)
 	}
 
 	server.GET("/swagger/*", echoSwagger.WrapHandler)
@@ -0,0 +1,436 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/pkg/cql"
+	"github.com/KinitaL/testovoye/pkg/dc"
+	"github.com/KinitaL/testovoye/pkg/marc"
+	"github.com/KinitaL/testovoye/pkg/sru"
+	"github.com/labstack/echo/v4"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	sruDefaultRecords = 10  // Number of records returned if maximumRecords is absent
+	sruMaximumRecords = 100 // Largest number of records returned at once
+)
+
+type (
+	// SRUDatabase describes the catalog to library clients.
+	SRUDatabase struct {
+		// Title names the catalog in explain responses.
+		Title string
+	}
+
+	// SRUController serves the catalog to library clients over SRU with CQL queries.
+	SRUController struct {
+		u        sruUsecase
+		database SRUDatabase
+	}
+
+	// sruUsecase defines the methods of the book usecase that SRU needs.
+	sruUsecase interface {
+		Match(ctx context.Context, query models.BookMatchQuery) (*models.BookMatchResult, error)
+	}
+
+	// sruSchema is a record schema books are returned in.
+	sruSchema struct {
+		sru.Schema
+		record func(book models.Book) any
+	}
+)
+
+// sruSchemas are the supported record schemas, in the order of preference.
+var sruSchemas = []sruSchema{
+	{
+		Schema: sru.Schema{Identifier: sru.SchemaDC, Name: "dc", Title: "Dublin Core"},
+		record: func(book models.Book) any { return sru.NewDC(dc.FromBook(book)) },
+	},
+	{
+		Schema: sru.Schema{Identifier: sru.SchemaMARCXML, Name: "marcxml", Title: "MARCXML"},
+		record: func(book models.Book) any { return marc.FromBook(book) },
+	},
+}
+
+// sruBooleans maps CQL boolean operators to nodes of conditions. Proximity isn't supported.
+var sruBooleans = map[string]models.BookConditionOp{
+	cql.And: models.BookConditionAnd,
+	cql.Or:  models.BookConditionOr,
+	cql.Not: models.BookConditionNot,
+}
+
+// sruIndexes maps CQL indexes, with or without the "dc." prefix, to fields of books.
+var sruIndexes = map[string]models.BookConditionField{
+	"dc.title":   models.BookConditionTitle,
+	"dc.creator": models.BookConditionAuthor,
+	"dc.date":    models.BookConditionYear,
+}
+
+// sruTextRelations maps CQL relations of single terms to relations of text fields.
+// "=" and "adj" match the term as a phrase anywhere in the field.
+var sruTextRelations = map[string]models.BookRelation{
+	"=":   models.BookRelationContains,
+	"adj": models.BookRelationContains,
+	"scr": models.BookRelationContains,
+	"==":  models.BookRelationEqual,
+	"<>":  models.BookRelationNotEqual,
+}
+
+// sruYearRelations maps CQL relations of single terms to relations of years.
+var sruYearRelations = map[string]models.BookRelation{
+	"=":   models.BookRelationEqual,
+	"scr": models.BookRelationEqual,
+	"==":  models.BookRelationEqual,
+	"<>":  models.BookRelationNotEqual,
+	"<":   models.BookRelationLess,
+	"<=":  models.BookRelationLessOrEqual,
+	">":   models.BookRelationGreater,
+	">=":  models.BookRelationGreaterOrEqual,
+}
+
+// NewSRUController initializes a new SRUController instance.
+func NewSRUController(usecase sruUsecase, database SRUDatabase) *SRUController {
+	return &SRUController{u: usecase, database: database}
+}
+
+// Handle handles SRU requests, sent either as GET with a query or as POST with a form.
+// A request with a query is a searchRetrieve one, any other is an explain one.
+// Errors of the protocol are reported as diagnostics in the response with status 200.
+func (c *SRUController) Handle(ctx echo.Context) error {
+	args := ctx.QueryParams()
+	if ctx.Request().Method == http.MethodPost {
+		if err := ctx.Request().ParseForm(); err != nil {
+			return errs.Validation("invalid form")
+		}
+		args = ctx.Request().PostForm
+	}
+
+	// SRU 2.0 tells operations apart by the query, older clients name them
+	operation := args.Get("operation")
+	search := operation == "searchRetrieve" || operation == "" && args.Has("query")
+	var diagnostic *sru.Diagnostic
+	switch {
+	case args.Has("version") && args.Get("version") != sru.Version:
+		diagnostic = &sru.Diagnostic{Code: sru.DiagnosticUnsupportedVersion, Details: sru.Version, Message: "only SRU 2.0 is supported"}
+	case operation != "" && operation != "searchRetrieve" && operation != "explain":
+		diagnostic = &sru.Diagnostic{Code: sru.DiagnosticUnsupportedOperation, Details: operation, Message: "unsupported operation"}
+	case search && !args.Has("query"):
+		diagnostic = &sru.Diagnostic{Code: sru.DiagnosticMandatoryParameter, Details: "query", Message: "missing query"}
+	case search:
+		return c.searchRetrieve(ctx, args.Get)
+	}
+
+	if search {
+		return c.write(ctx, sru.SearchRetrieveResponse{Diagnostics: []sru.Diagnostic{*diagnostic}})
+	}
+	return c.explain(ctx, diagnostic)
+}
+
+// searchRetrieve returns the books matching a query.
+func (c *SRUController) searchRetrieve(ctx echo.Context, arg func(string) string) error {
+	response := sru.SearchRetrieveResponse{}
+	query, schema, escaping, err := c.parseRequest(arg)
+	var result *models.BookMatchResult
+	if err == nil {
+		result, err = c.u.Match(ctx.Request().Context(), query)
+	}
+	if err == nil {
+		response.NumberOfRecords = result.Total
+		for i, book := range result.Items {
+			response.Records = append(response.Records, sru.Record{
+				Schema:   schema.Identifier,
+				Escaping: escaping,
+				Data:     schema.record(book),
+				Position: query.Offset + i + 1,
+			})
+		}
+		if next := query.Offset + len(result.Items); len(result.Items) > 0 && int64(next) < result.Total {
+			response.NextRecordPosition = next + 1
+		}
+		// maximumRecords=0 asks only for the number of records
+		if len(result.Items) == 0 && result.Total > 0 && query.Limit > 0 {
+			err = sru.Diagnostic{Code: sru.DiagnosticFirstRecordOutOfRange, Details: arg("startRecord"), Message: "startRecord is beyond the last record"}
+		}
+	}
+
+	var diagnostic sru.Diagnostic
+	switch {
+	case errors.As(err, &diagnostic):
+		response.Diagnostics = []sru.Diagnostic{diagnostic}
+	case errors.Is(err, errs.ErrValidation):
+		response.Diagnostics = []sru.Diagnostic{{Code: sru.DiagnosticQuerySyntax, Details: arg("query"), Message: errs.MessageOf(err)}}
+	case err != nil:
+		return err
+	}
+	return c.write(ctx, response)
+}
+
+// parseRequest checks the arguments of a searchRetrieve request and translates its query to a condition.
+func (c *SRUController) parseRequest(arg func(string) string) (models.BookMatchQuery, sruSchema, string, error) {
+	query := models.BookMatchQuery{Limit: sruDefaultRecords}
+	schema := sruSchemas[0]
+
+	if queryType := arg("queryType"); queryType != "" && queryType != "cql" {
+		return query, schema, "", sru.Diagnostic{Code: sru.DiagnosticUnsupportedParameterValue, Details: "queryType", Message: "only CQL queries are supported"}
+	}
+	if arg("sortKeys") != "" {
+		return query, schema, "", sru.Diagnostic{Code: sru.DiagnosticSortUnsupported, Details: arg("sortKeys"), Message: "sorting is not supported"}
+	}
+	if packing := arg("recordPacking"); packing != "" && packing != "packed" {
+		return query, schema, "", sru.Diagnostic{Code: sru.DiagnosticUnsupportedParameterValue, Details: "recordPacking", Message: "only packed records are supported"}
+	}
+	escaping := arg("recordXMLEscaping")
+	if escaping != "" && escaping != sru.EscapingXML && escaping != sru.EscapingString {
+		return query, schema, "", sru.Diagnostic{Code: sru.DiagnosticUnsupportedEscaping, Details: escaping, Message: "unsupported recordXMLEscaping"}
+	}
+	if name := arg("recordSchema"); name != "" {
+		found := false
+		for _, candidate := range sruSchemas {
+			if name == candidate.Name || name == candidate.Identifier {
+				schema, found = candidate, true
+			}
+		}
+		if !found {
+			return query, schema, "", sru.Diagnostic{Code: sru.DiagnosticUnknownSchema, Details: name, Message: "unknown record schema"}
+		}
+	}
+
+	for _, parameter := range []struct {
+		name  string
+		value *int
+		min   int
+	}{
+		{name: "startRecord", value: &query.Offset, min: 1},
+		{name: "maximumRecords", value: &query.Limit, min: 0},
+	} {
+		text := arg(parameter.name)
+		if text == "" {
+			continue
+		}
+		value, err := strconv.Atoi(text)
+		if err != nil || value < parameter.min {
+			return query, schema, "", sru.Diagnostic{Code: sru.DiagnosticUnsupportedParameterValue, Details: parameter.name, Message: "invalid " + parameter.name}
+		}
+		*parameter.value = value
+	}
+	if query.Offset > 0 {
+		query.Offset--
+	}
+	query.Limit = min(query.Limit, sruMaximumRecords)
+
+	parsed, err := cql.Parse(arg("query"))
+	var syntaxErr *cql.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return query, schema, "", sru.Diagnostic{Code: sru.DiagnosticQuerySyntax, Details: arg("query"), Message: syntaxErr.Error()}
+	}
+	if err != nil {
+		return query, schema, "", err
+	}
+	if len(parsed.SortKeys) > 0 {
+		return query, schema, "", sru.Diagnostic{Code: sru.DiagnosticSortUnsupported, Details: parsed.SortKeys[0].Index, Message: "sorting is not supported"}
+	}
+	query.Condition, err = sruCondition(parsed.Root)
+	return query, schema, escaping, err
+}
+
+// explain describes the server, reporting a diagnostic of the request if there is one.
+func (c *SRUController) explain(ctx echo.Context, diagnostic *sru.Diagnostic) error {
+	host, port := ctx.Request().Host, 80
+	if ctx.Scheme() == "https" {
+		port = 443
+	}
+	if name, portText, err := net.SplitHostPort(host); err == nil {
+		host = name
+		port, _ = strconv.Atoi(portText)
+	}
+
+	explain := sru.Explain{
+		Host:     host,
+		Port:     port,
+		Database: strings.TrimPrefix(ctx.Request().URL.Path, "/"),
+		Title:    c.database.Title,
+		Indexes: []sru.Index{
+			{Title: "Title", Set: "dc", Name: "title"},
+			{Title: "Author", Set: "dc", Name: "creator"},
+			{Title: "Year of publication", Set: "dc", Name: "date"},
+			{Title: "Title or author", Set: "cql", Name: "serverChoice"},
+			{Title: "All records", Set: "cql", Name: "allRecords"},
+		},
+		DefaultRecords: sruDefaultRecords,
+		MaximumRecords: sruMaximumRecords,
+	}
+	for _, schema := range sruSchemas {
+		explain.Schemas = append(explain.Schemas, schema.Schema)
+	}
+
+	var diagnostics []sru.Diagnostic
+	if diagnostic != nil {
+		diagnostics = append(diagnostics, *diagnostic)
+	}
+	body, err := sru.MarshalExplain(explain, diagnostics)
+	if err != nil {
+		return err
+	}
+	return ctx.Blob(http.StatusOK, sru.MIME, body)
+}
+
+// write sends a searchRetrieve response.
+func (c *SRUController) write(ctx echo.Context, response sru.SearchRetrieveResponse) error {
+	body, err := sru.MarshalSearchRetrieve(response)
+	if err != nil {
+		return err
+	}
+	return ctx.Blob(http.StatusOK, sru.MIME, body)
+}
+
+// sruCondition translates a CQL query to a condition of books, or returns the diagnostic of an unsupported feature.
+func sruCondition(node cql.Node) (*models.BookCondition, error) {
+	switch node := node.(type) {
+	case cql.Boolean:
+		if len(node.Modifiers) > 0 {
+			return nil, sru.Diagnostic{Code: sru.DiagnosticUnsupportedBooleanModifier, Details: node.Modifiers[0].Name, Message: "boolean modifiers are not supported"}
+		}
+		op, ok := sruBooleans[node.Operator]
+		if !ok {
+			return nil, sru.Diagnostic{Code: sru.DiagnosticUnsupportedBoolean, Details: node.Operator, Message: "unsupported boolean operator"}
+		}
+		left, err := sruCondition(node.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := sruCondition(node.Right)
+		if err != nil {
+			return nil, err
+		}
+		return &models.BookCondition{Op: op, Left: left, Right: right}, nil
+	case cql.Clause:
+		return sruClause(node)
+	default:
+		return nil, sru.Diagnostic{Code: sru.DiagnosticQuerySyntax, Message: "unknown query node"}
+	}
+}
+
+// sruClause translates a search clause. The server's choice is the title or the author; "any" and "all" match
+// every word of the term separately, and "within" takes the first and the last year of a range.
+func sruClause(clause cql.Clause) (*models.BookCondition, error) {
+	if len(clause.Relation.Modifiers) > 0 {
+		return nil, sru.Diagnostic{Code: sru.DiagnosticUnsupportedRelationModifier, Details: clause.Relation.Modifiers[0].Name, Message: "relation modifiers are not supported"}
+	}
+
+	index := clause.Index
+	if !strings.Contains(index, ".") {
+		index = "dc." + index
+	}
+	var fields []models.BookConditionField
+	switch field, ok := sruIndexes[index]; {
+	case clause.Index == "cql.allrecords":
+		return &models.BookCondition{Op: models.BookConditionAll}, nil
+	case clause.Index == cql.ServerChoice || clause.Index == "cql.anywhere":
+		fields = []models.BookConditionField{models.BookConditionTitle, models.BookConditionAuthor}
+	case ok:
+		fields = []models.BookConditionField{field}
+	default:
+		return nil, sru.Diagnostic{Code: sru.DiagnosticUnsupportedIndex, Details: clause.Index, Message: "unsupported index"}
+	}
+
+	relations := []string{clause.Relation.Comparitor}
+	words, op := []string{clause.Term}, models.BookConditionAnd
+	switch clause.Relation.Comparitor {
+	case "any":
+		relations, words, op = []string{"="}, strings.Fields(clause.Term), models.BookConditionOr
+	case "all":
+		relations, words = []string{"="}, strings.Fields(clause.Term)
+	case "within":
+		relations, words = []string{">=", "<="}, strings.Fields(clause.Term)
+		if len(words) != 2 {
+			return nil, sru.Diagnostic{Code: sru.DiagnosticInvalidTerm, Details: clause.Term, Message: "within needs two values"}
+		}
+	}
+	if len(words) == 0 {
+		return nil, sru.Diagnostic{Code: sru.DiagnosticEmptyTerm, Message: "empty terms are not supported"}
+	}
+
+	var result *models.BookCondition
+	for i, word := range words {
+		// the fields of the server's choice are alternatives for the same word
+		var alternatives *models.BookCondition
+		for _, field := range fields {
+			match, err := sruMatch(field, relations[min(i, len(relations)-1)], word)
+			if errors.Is(err, errSRURelation) {
+				return nil, sru.Diagnostic{Code: sru.DiagnosticUnsupportedRelation, Details: clause.Relation.Comparitor, Message: "unsupported relation for " + clause.Index}
+			}
+			if err != nil {
+				return nil, err
+			}
+			alternatives = sruJoin(alternatives, models.BookConditionOr, match)
+		}
+		result = sruJoin(result, op, alternatives)
+	}
+	return result, nil
+}
+
+// errSRURelation is returned by sruMatch for relations the field doesn't support.
+var errSRURelation = errors.New("unsupported relation")
+
+// sruMatch compares a field with a single word or phrase.
+func sruMatch(field models.BookConditionField, relation string, term string) (*models.BookCondition, error) {
+	match := &models.BookCondition{Op: models.BookConditionMatch, Field: field}
+	var ok bool
+	var err error
+	if field == models.BookConditionYear {
+		if match.Relation, ok = sruYearRelations[relation]; !ok {
+			return nil, errSRURelation
+		}
+		match.Value, err = sruYear(term)
+	} else {
+		if match.Relation, ok = sruTextRelations[relation]; !ok {
+			return nil, errSRURelation
+		}
+		match.Value, err = sruText(term, match.Relation)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+// sruJoin joins a condition with the next one, or returns the next one if there is no condition yet.
+func sruJoin(condition *models.BookCondition, op models.BookConditionOp, next *models.BookCondition) *models.BookCondition {
+	if condition == nil {
+		return next
+	}
+	return &models.BookCondition{Op: op, Left: condition, Right: next}
+}
+
+// sruText resolves the masking characters of a text term. Truncation is implied by phrase matching,
+// so it is allowed only there.
+func sruText(term string, relation models.BookRelation) (string, error) {
+	text, truncated, err := cql.Unmask(term)
+	var maskErr *cql.MaskError
+	if errors.As(err, &maskErr) || truncated && relation != models.BookRelationContains {
+		code := sru.DiagnosticMaskingUnsupported
+		if maskErr != nil && maskErr.Character == '^' {
+			code = sru.DiagnosticAnchoringUnsupported
+		}
+		return "", sru.Diagnostic{Code: code, Details: term, Message: "masking characters are not supported"}
+	}
+	if strings.TrimSpace(text) == "" {
+		return "", sru.Diagnostic{Code: sru.DiagnosticEmptyTerm, Message: "empty terms are not supported"}
+	}
+	return text, nil
+}
+
+// sruYear parses a year term.
+func sruYear(term string) (uint16, error) {
+	year, err := strconv.ParseUint(strings.TrimSpace(term), 10, 16)
+	if err != nil {
+		return 0, sru.Diagnostic{Code: sru.DiagnosticInvalidTerm, Details: term, Message: "dc.date needs a year"}
+	}
+	return uint16(year), nil
+}
@@ -0,0 +1,321 @@
+package controllers
+
+import (
+	"encoding/xml"
+	"github.com/KinitaL/testovoye/internal/models"
+	usecase_mock "github.com/KinitaL/testovoye/internal/usecases/books"
+	"github.com/KinitaL/testovoye/pkg/cql"
+	"github.com/KinitaL/testovoye/pkg/sru"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+type (
+	// testSRUResponse is the part of an SRU response checked by the tests.
+	testSRUResponse struct {
+		XMLName            xml.Name
+		NumberOfRecords    int64           `xml:"numberOfRecords"`
+		Records            []testSRURecord `xml:"records>record"`
+		NextRecordPosition int             `xml:"nextRecordPosition"`
+		Diagnostics        []string        `xml:"diagnostics>diagnostic>uri"`
+		Explain            struct {
+			Host     string   `xml:"serverInfo>host"`
+			Port     int      `xml:"serverInfo>port"`
+			Database string   `xml:"serverInfo>database"`
+			Title    string   `xml:"databaseInfo>title"`
+			Indexes  []string `xml:"indexInfo>index>map>name"`
+			Schemas  []struct {
+				Name string `xml:"name,attr"`
+			} `xml:"schemaInfo>schema"`
+		} `xml:"record>recordData>explain"`
+	}
+
+	testSRURecord struct {
+		Schema   string `xml:"recordSchema"`
+		Escaping string `xml:"recordXMLEscaping"`
+		Data     struct {
+			Text  string    `xml:",chardata"`
+			Title string    `xml:"dc>title"`
+			MARC  *struct{} `xml:"record"`
+		} `xml:"recordData"`
+		Position int `xml:"recordPosition"`
+	}
+)
+
+// testMatch returns a leaf of a condition.
+func testMatch(field models.BookConditionField, relation models.BookRelation, value any) *models.BookCondition {
+	return &models.BookCondition{Op: models.BookConditionMatch, Field: field, Relation: relation, Value: value}
+}
+
+// TestSRU tests the SRU endpoint
+func TestSRU(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	mockUsecase := usecase_mock.NewMockBooks(ctrl)
+	controller := NewSRUController(mockUsecase, SRUDatabase{Title: "Library catalog"})
+
+	book := models.Book{ID: uuid.New(), Title: "War and Peace", Author: "Leo Tolstoy", Year: 1869}
+
+	request := func(t *testing.T, args url.Values) testSRUResponse {
+		req := httptest.NewRequest(http.MethodGet, "/sru?"+args.Encode(), nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		err := controller.Handle(ctx)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, rec.Code, http.StatusOK)
+		assert.Equal(t, rec.Header().Get(echo.HeaderContentType), sru.MIME)
+
+		var response testSRUResponse
+		assert.Equal(t, xml.Unmarshal(rec.Body.Bytes(), &response), nil)
+		return response
+	}
+
+	t.Run("Explain", func(t *testing.T) {
+		response := request(t, url.Values{})
+		assert.Equal(t, response.XMLName, xml.Name{Space: sru.Namespace, Local: "explainResponse"})
+		assert.Empty(t, response.Diagnostics)
+		assert.Equal(t, response.Explain.Host, "example.com")
+		assert.Equal(t, response.Explain.Port, 80)
+		assert.Equal(t, response.Explain.Database, "sru")
+		assert.Equal(t, response.Explain.Title, "Library catalog")
+		assert.Contains(t, response.Explain.Indexes, "creator")
+		assert.Equal(t, len(response.Explain.Schemas), 2)
+	})
+
+	t.Run("Dublin Core", func(t *testing.T) {
+		mockUsecase.EXPECT().Match(gomock.Any(), models.BookMatchQuery{
+			Condition: &models.BookCondition{
+				Op: models.BookConditionAnd,
+				Left: &models.BookCondition{
+					Op:    models.BookConditionOr,
+					Left:  testMatch(models.BookConditionTitle, models.BookRelationContains, "war"),
+					Right: testMatch(models.BookConditionTitle, models.BookRelationContains, "peace"),
+				},
+				Right: testMatch(models.BookConditionYear, models.BookRelationLess, uint16(1900)),
+			},
+			Limit:  1,
+			Offset: 1,
+		}).Return(&models.BookMatchResult{Items: []models.Book{book}, Total: 3}, nil)
+
+		response := request(t, url.Values{
+			"query":          {`dc.title any "war peace" and dc.date < 1900`},
+			"startRecord":    {"2"},
+			"maximumRecords": {"1"},
+		})
+		assert.Equal(t, response.XMLName, xml.Name{Space: sru.Namespace, Local: "searchRetrieveResponse"})
+		assert.Empty(t, response.Diagnostics)
+		assert.Equal(t, response.NumberOfRecords, int64(3))
+		assert.Equal(t, response.NextRecordPosition, 3)
+		assert.Equal(t, len(response.Records), 1)
+		assert.Equal(t, response.Records[0].Schema, sru.SchemaDC)
+		assert.Equal(t, response.Records[0].Escaping, sru.EscapingXML)
+		assert.Equal(t, response.Records[0].Position, 2)
+		assert.Equal(t, response.Records[0].Data.Title, book.Title)
+	})
+
+	t.Run("MARCXML", func(t *testing.T) {
+		mockUsecase.EXPECT().Match(gomock.Any(), models.BookMatchQuery{
+			Condition: &models.BookCondition{
+				Op:    models.BookConditionOr,
+				Left:  testMatch(models.BookConditionTitle, models.BookRelationContains, "tolst"),
+				Right: testMatch(models.BookConditionAuthor, models.BookRelationContains, "tolst"),
+			},
+			Limit: sruDefaultRecords,
+		}).Return(&models.BookMatchResult{Items: []models.Book{book}, Total: 1}, nil)
+
+		response := request(t, url.Values{"query": {"tolst*"}, "recordSchema": {"marcxml"}})
+		assert.Equal(t, response.NextRecordPosition, 0)
+		assert.Equal(t, response.Records[0].Schema, sru.SchemaMARCXML)
+		assert.NotNil(t, response.Records[0].Data.MARC)
+	})
+
+	t.Run("Escaped records", func(t *testing.T) {
+		mockUsecase.EXPECT().Match(gomock.Any(), models.BookMatchQuery{
+			Condition: &models.BookCondition{
+				Op:    models.BookConditionAnd,
+				Left:  testMatch(models.BookConditionYear, models.BookRelationGreaterOrEqual, uint16(1800)),
+				Right: testMatch(models.BookConditionYear, models.BookRelationLessOrEqual, uint16(1899)),
+			},
+			Limit: sruDefaultRecords,
+		}).Return(&models.BookMatchResult{Items: []models.Book{book}, Total: 1}, nil)
+
+		response := request(t, url.Values{"query": {`date within "1800 1899"`}, "recordXMLEscaping": {"string"}})
+		assert.Equal(t, response.Records[0].Escaping, sru.EscapingString)
+		assert.True(t, strings.HasPrefix(response.Records[0].Data.Text, "<srw_dc:dc"))
+	})
+
+	t.Run("Count only", func(t *testing.T) {
+		mockUsecase.EXPECT().Match(gomock.Any(), models.BookMatchQuery{
+			Condition: &models.BookCondition{
+				Op:    models.BookConditionNot,
+				Left:  &models.BookCondition{Op: models.BookConditionAll},
+				Right: testMatch(models.BookConditionAuthor, models.BookRelationEqual, "Leo Tolstoy"),
+			},
+		}).Return(&models.BookMatchResult{Items: []models.Book{}, Total: 5}, nil)
+
+		response := request(t, url.Values{"query": {`cql.allRecords = 1 not dc.creator == "Leo Tolstoy"`}, "maximumRecords": {"0"}})
+		assert.Empty(t, response.Diagnostics)
+		assert.Equal(t, response.NumberOfRecords, int64(5))
+		assert.Empty(t, response.Records)
+	})
+
+	t.Run("First record out of range", func(t *testing.T) {
+		mockUsecase.EXPECT().Match(gomock.Any(), gomock.Any()).Return(&models.BookMatchResult{Items: []models.Book{}, Total: 5}, nil)
+
+		response := request(t, url.Values{"query": {"war"}, "startRecord": {"6"}})
+		assert.Equal(t, response.Diagnostics, []string{"info:srw/diagnostic/1/61"})
+		assert.Equal(t, response.NumberOfRecords, int64(5))
+	})
+
+	for _, testCase := range []struct {
+		name string
+		args url.Values
+		code int
+	}{
+		{name: "Syntax error", args: url.Values{"query": {`dc.title = "war`}}, code: sru.DiagnosticQuerySyntax},
+		{name: "Unsupported index", args: url.Values{"query": {"dc.subject = history"}}, code: sru.DiagnosticUnsupportedIndex},
+		{name: "Ordered title", args: url.Values{"query": {"dc.title < war"}}, code: sru.DiagnosticUnsupportedRelation},
+		{name: "Relation modifier", args: url.Values{"query": {"dc.title =/stem war"}}, code: sru.DiagnosticUnsupportedRelationModifier},
+		{name: "Proximity", args: url.Values{"query": {"war prox peace"}}, code: sru.DiagnosticUnsupportedBoolean},
+		{name: "Masking", args: url.Values{"query": {"dc.creator == tol*"}}, code: sru.DiagnosticMaskingUnsupported},
+		{name: "Empty term", args: url.Values{"query": {`dc.title = ""`}}, code: sru.DiagnosticEmptyTerm},
+		{name: "Invalid year", args: url.Values{"query": {"dc.date > long-ago"}}, code: sru.DiagnosticInvalidTerm},
+		{name: "Sorting", args: url.Values{"query": {"war sortBy dc.date"}}, code: sru.DiagnosticSortUnsupported},
+		{name: "Unknown schema", args: url.Values{"query": {"war"}, "recordSchema": {"mods"}}, code: sru.DiagnosticUnknownSchema},
+		{name: "Invalid start", args: url.Values{"query": {"war"}, "startRecord": {"0"}}, code: sru.DiagnosticUnsupportedParameterValue},
+		{name: "Missing query", args: url.Values{"operation": {"searchRetrieve"}}, code: sru.DiagnosticMandatoryParameter},
+		{name: "Old version", args: url.Values{"query": {"war"}, "version": {"1.2"}}, code: sru.DiagnosticUnsupportedVersion},
+		{name: "Scan", args: url.Values{"operation": {"scan"}}, code: sru.DiagnosticUnsupportedOperation},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			response := request(t, testCase.args)
+			assert.Equal(t, response.Diagnostics, []string{sru.Diagnostic{Code: testCase.code}.URI()})
+			assert.Empty(t, response.Records)
+		})
+	}
+
+	t.Run("POST", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/sru", strings.NewReader("query=dc.title+%3C+war"))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		err := controller.Handle(ctx)
+		assert.Equal(t, err, nil)
+		assert.Contains(t, rec.Body.String(), "<uri>info:srw/diagnostic/1/19</uri>")
+	})
+}
+
+// TestSRUCondition tests the translation of CQL queries to conditions of books
+func TestSRUCondition(t *testing.T) {
+	title := func(relation models.BookRelation, value string) *models.BookCondition {
+		return testMatch(models.BookConditionTitle, relation, value)
+	}
+	anywhere := func(value string) *models.BookCondition {
+		return &models.BookCondition{
+			Op:    models.BookConditionOr,
+			Left:  title(models.BookRelationContains, value),
+			Right: testMatch(models.BookConditionAuthor, models.BookRelationContains, value),
+		}
+	}
+
+	for _, testCase := range []struct {
+		name string
+
+		query     string
+		condition *models.BookCondition
+		err       error
+	}{
+		{
+			name: "Operators are applied left to right",
+
+			query: "war or peace and title = anna",
+			condition: &models.BookCondition{
+				Op:    models.BookConditionAnd,
+				Left:  &models.BookCondition{Op: models.BookConditionOr, Left: anywhere("war"), Right: anywhere("peace")},
+				Right: title(models.BookRelationContains, "anna"),
+			},
+		},
+		{
+			name: "Quoted phrase",
+
+			query:     `dc.title == "war and peace"`,
+			condition: title(models.BookRelationEqual, "war and peace"),
+		},
+		{
+			name: "Any word",
+
+			query: `dc.title any "war peace"`,
+			condition: &models.BookCondition{
+				Op:    models.BookConditionOr,
+				Left:  title(models.BookRelationContains, "war"),
+				Right: title(models.BookRelationContains, "peace"),
+			},
+		},
+		{
+			name: "Range of years",
+
+			query: `dc.date within "1860 1870"`,
+			condition: &models.BookCondition{
+				Op:    models.BookConditionAnd,
+				Left:  testMatch(models.BookConditionYear, models.BookRelationGreaterOrEqual, uint16(1860)),
+				Right: testMatch(models.BookConditionYear, models.BookRelationLessOrEqual, uint16(1870)),
+			},
+		},
+		{
+			name: "Unsupported index",
+
+			query: "dc.subject = history",
+			err:   sru.Diagnostic{Code: sru.DiagnosticUnsupportedIndex, Details: "dc.subject", Message: "unsupported index"},
+		},
+		{
+			name: "Unsupported index of another context set",
+
+			query: "bath.isbn = 9780140449136",
+			err:   sru.Diagnostic{Code: sru.DiagnosticUnsupportedIndex, Details: "bath.isbn", Message: "unsupported index"},
+		},
+		{
+			name: "Ordered relation of a text index",
+
+			query: "dc.title >= war",
+			err:   sru.Diagnostic{Code: sru.DiagnosticUnsupportedRelation, Details: ">=", Message: "unsupported relation for dc.title"},
+		},
+		{
+			name: "Text relation of a year",
+
+			query: "dc.date adj 1869",
+			err:   sru.Diagnostic{Code: sru.DiagnosticUnsupportedRelation, Details: "adj", Message: "unsupported relation for dc.date"},
+		},
+		{
+			name: "Unknown relation",
+
+			query: "dc.creator encloses tolstoy",
+			err:   sru.Diagnostic{Code: sru.DiagnosticUnsupportedRelation, Details: "encloses", Message: "unsupported relation for dc.creator"},
+		},
+		{
+			name: "Unsupported index inside a boolean",
+
+			query: "war and (peace or dc.subject = history)",
+			err:   sru.Diagnostic{Code: sru.DiagnosticUnsupportedIndex, Details: "dc.subject", Message: "unsupported index"},
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			query, err := cql.Parse(testCase.query)
+			assert.NoError(t, err)
+
+			condition, err := sruCondition(query.Root)
+			assert.Equal(t, testCase.err, err)
+			assert.Equal(t, testCase.condition, condition)
+		})
+	}
+}
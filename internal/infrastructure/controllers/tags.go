@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/infrastructure/controllers/dto"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"net/url"
+)
+
+type (
+	// TagsController handles HTTP requests on tags of books.
+	TagsController struct {
+		u tagsUsecase
+	}
+
+	// tagsUsecase defines the business logic layer interface for tag operations.
+	tagsUsecase interface {
+		GetAll(ctx context.Context, query models.TagQuery) ([]models.TagCount, error) // Retrieves the most used tags
+		Rename(ctx context.Context, tag string, name string) error                    // Renames a tag on every book
+		Delete(ctx context.Context, tag string) error                                 // Removes a tag from every book
+	}
+)
+
+// NewTagsController initializes a new TagsController instance.
+func NewTagsController(usecase tagsUsecase) *TagsController {
+	return &TagsController{u: usecase}
+}
+
+// GetAll handles HTTP GET requests to retrieve the most used tags.
+// @Summary Get tags
+// @Description Retrieves tags of books outside the trash with the numbers of books, the most used first.
+// @Tags tags
+// @Produce json,application/problem+json
+// @Param limit query int false "Number of tags (1-500, default 50)"
+// @Param prefix query string false "Case-insensitive tag prefix"
+// @Success 200 {array} models.TagCount
+// @Failure 400 {object} dto.Problem "Invalid query parameters"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/tags [get]
+func (c *TagsController) GetAll(ctx echo.Context) error {
+	var query dto.ListTagsQuery
+	if err := ctx.Bind(&query); err != nil {
+		return errs.Validation("invalid query parameters")
+	}
+	if err := ctx.Validate(query); err != nil {
+		return err
+	}
+	tags, err := c.u.GetAll(ctx.Request().Context(), models.TagQuery{Prefix: query.Prefix, Limit: query.Limit})
+	if err != nil {
+		return err
+	}
+	if tags == nil {
+		tags = []models.TagCount{}
+	}
+	return ctx.JSON(http.StatusOK, tags)
+}
+
+// Rename handles HTTP PUT requests to rename a tag.
+// @Summary Rename a tag
+// @Description Replaces a tag with another one on every book having it, including the books in the trash.
+// @Description Books having both keep one. The books get new versions.
+// @Tags tags
+// @Accept json
+// @Produce application/problem+json
+// @Param tag path string true "Tag"
+// @Param name body dto.RenameTagDto true "New name of the tag"
+// @Success 200
+// @Failure 400 {object} dto.Problem "Invalid tag / Invalid request body"
+// @Failure 404 {object} dto.Problem "No book has the tag"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/tags/{tag} [put]
+func (c *TagsController) Rename(ctx echo.Context) error {
+	tag, err := parseTag(ctx)
+	if err != nil {
+		return err
+	}
+	var body dto.RenameTagDto
+	if err := ctx.Bind(&body); err != nil {
+		return errs.Validation("invalid request body")
+	}
+	if err := ctx.Validate(body); err != nil {
+		return err
+	}
+	if err := c.u.Rename(ctx.Request().Context(), tag, body.Name); err != nil {
+		return err
+	}
+	return ctx.NoContent(http.StatusOK)
+}
+
+// Delete handles HTTP DELETE requests to remove a tag.
+// @Summary Delete a tag
+// @Description Removes a tag from every book having it, including the books in the trash. The books get new versions.
+// @Tags tags
+// @Produce application/problem+json
+// @Param tag path string true "Tag"
+// @Success 200
+// @Failure 400 {object} dto.Problem "Invalid tag"
+// @Failure 404 {object} dto.Problem "No book has the tag"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/tags/{tag} [delete]
+func (c *TagsController) Delete(ctx echo.Context) error {
+	tag, err := parseTag(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.u.Delete(ctx.Request().Context(), tag); err != nil {
+		return err
+	}
+	return ctx.NoContent(http.StatusOK)
+}
+
+// parseTag extracts the tag from the path. Echo matches routes against the raw path
+// if the request has one, and then the parameters stay escaped.
+func parseTag(ctx echo.Context) (string, error) {
+	tag := ctx.Param("tag")
+	if ctx.Request().URL.RawPath == "" {
+		return tag, nil
+	}
+	unescaped, err := url.PathUnescape(tag)
+	if err != nil {
+		return "", errs.Validation("invalid tag")
+	}
+	return unescaped, nil
+}
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"encoding/json"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	tags_mock "github.com/KinitaL/testovoye/internal/usecases/tags"
+	"github.com/KinitaL/testovoye/pkg/validator"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestTags tests the TagsController methods
+func TestTags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	e.Validator = validator.New()
+	mockTags := tags_mock.NewMockTags(ctrl)
+	controller := NewTagsController(mockTags)
+
+	t.Run("GetAll", func(t *testing.T) {
+		tags := []models.TagCount{{Tag: "classics", Books: 3}, {Tag: "class struggle", Books: 1}}
+		mockTags.EXPECT().GetAll(gomock.Any(), models.TagQuery{Prefix: "class", Limit: 10}).Return(tags, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/tags?prefix=class&limit=10", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.GetAll)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response []models.TagCount
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, tags, response)
+	})
+
+	t.Run("Rename", func(t *testing.T) {
+		mockTags.EXPECT().Rename(gomock.Any(), "sci-fi", "science fiction").Return(nil)
+
+		req := httptest.NewRequest(http.MethodPut, "/api/tags/sci-fi", strings.NewReader(`{"name": "science fiction"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("tag")
+		ctx.SetParamValues("sci-fi")
+
+		handle(ctx, controller.Rename)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Rename without a name", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPut, "/api/tags/sci-fi", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("tag")
+		ctx.SetParamValues("sci-fi")
+
+		handle(ctx, controller.Rename)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("Delete escaped tag", func(t *testing.T) {
+		mockTags.EXPECT().Delete(gomock.Any(), "fiction/fantasy").Return(nil)
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/tags/fiction%2Ffantasy", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("tag")
+		ctx.SetParamValues("fiction%2Ffantasy")
+
+		handle(ctx, controller.Delete)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Delete unknown tag", func(t *testing.T) {
+		mockTags.EXPECT().Delete(gomock.Any(), "classics").Return(errs.NotFound("tag doesn't exist"))
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/tags/classics", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("tag")
+		ctx.SetParamValues("classics")
+
+		handle(ctx, controller.Delete)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,307 @@
+package controllers
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/infrastructure/controllers/dto"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"path"
+)
+
+type (
+	// WorksController handles HTTP requests on works and their editions.
+	WorksController struct {
+		u     worksUsecase
+		books workBooksUsecase
+	}
+
+	// worksUsecase defines the business logic layer interface for work operations.
+	worksUsecase interface {
+		GetAll(ctx context.Context, params models.WorkListParams) (*models.WorkPage, error) // Retrieves a page of works
+		GetOne(ctx context.Context, ID uuid.UUID) (*models.Work, error)                     // Retrieves a work by ID
+		Create(ctx context.Context, work models.Work) (*models.Work, error)                 // Creates a new work
+		Update(ctx context.Context, ID uuid.UUID, work models.Work) (*models.Work, error)   // Replaces the title and the creators
+		Delete(ctx context.Context, ID uuid.UUID) error                                     // Deletes a work without editions
+		Attach(ctx context.Context, ID uuid.UUID, bookID uuid.UUID) error                   // Makes a book an edition of the work
+		Detach(ctx context.Context, ID uuid.UUID, bookID uuid.UUID) error                   // Makes an edition a standalone book
+	}
+
+	// workBooksUsecase defines the methods of the book usecase that the editions of a work are retrieved with.
+	workBooksUsecase interface {
+		GetAll(ctx context.Context, params models.BookListParams) (*models.BookPage, error)
+		GetOne(ctx context.Context, ID uuid.UUID) (*models.Book, error)
+	}
+)
+
+// NewWorksController initializes a new WorksController instance.
+func NewWorksController(usecase worksUsecase, books workBooksUsecase) *WorksController {
+	return &WorksController{u: usecase, books: books}
+}
+
+// GetAll handles HTTP GET requests to retrieve a page of works.
+// @Summary Get a page of works
+// @Description Retrieves works ordered by title using keyset pagination.
+// @Tags works
+// @Produce json,application/problem+json
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param cursor query string false "Cursor returned as next_cursor with the previous page"
+// @Param title_prefix query string false "Case-insensitive title prefix"
+// @Success 200 {object} dto.WorkListDto
+// @Failure 400 {object} dto.Problem "Invalid query parameters"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/works [get]
+func (c *WorksController) GetAll(ctx echo.Context) error {
+	var query dto.ListWorksQuery
+	if err := ctx.Bind(&query); err != nil {
+		return errs.Validation("invalid query parameters")
+	}
+	if err := ctx.Validate(query); err != nil {
+		return err
+	}
+	page, err := c.u.GetAll(ctx.Request().Context(), models.WorkListParams{
+		TitlePrefix: query.TitlePrefix,
+		Limit:       query.Limit,
+		Cursor:      query.Cursor,
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, dto.WorkListDto{
+		Items:      page.Items,
+		NextCursor: page.NextCursor,
+	})
+}
+
+// GetOne handles HTTP GET requests to retrieve a work by ID.
+// @Summary Get a work by ID
+// @Description Retrieves a single work by its ID.
+// @Tags works
+// @Produce json,application/problem+json
+// @Param id path string true "Work ID"
+// @Success 200 {object} models.Work
+// @Failure 400 {object} dto.Problem "Invalid work ID"
+// @Failure 404 {object} dto.Problem "Work not found"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/works/{id} [get]
+func (c *WorksController) GetOne(ctx echo.Context) error {
+	id, err := parseWorkID(ctx)
+	if err != nil {
+		return err
+	}
+	work, err := c.u.GetOne(ctx.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, work)
+}
+
+// Create handles HTTP POST requests to create a new work.
+// @Summary Create a new work
+// @Description Adds a new work, which books can then be attached to as editions.
+// @Tags works
+// @Accept json
+// @Produce json,application/problem+json
+// @Param work body dto.WorkDto true "Work Data"
+// @Success 201 {object} models.Work
+// @Header 201 {string} Location "URL of the created work"
+// @Failure 400 {object} dto.Problem "Invalid request body"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/works [post]
+func (c *WorksController) Create(ctx echo.Context) error {
+	var work dto.WorkDto
+	if err := ctx.Bind(&work); err != nil {
+		return errs.Validation("invalid request body")
+	}
+	if err := ctx.Validate(work); err != nil {
+		return err
+	}
+	created, err := c.u.Create(ctx.Request().Context(), models.Work{Title: work.Title, Author: work.Author})
+	if err != nil {
+		return err
+	}
+	ctx.Response().Header().Set(echo.HeaderLocation, path.Join(ctx.Request().URL.Path, created.ID.String()))
+	return ctx.JSON(http.StatusCreated, created)
+}
+
+// Replace handles HTTP PUT requests to replace a work.
+// @Summary Replace a work
+// @Description Replaces the title and the creators of a work. The editions keep their own titles and credits.
+// @Tags works
+// @Accept json
+// @Produce json,application/problem+json
+// @Param id path string true "Work ID"
+// @Param work body dto.WorkDto true "New Work Data"
+// @Success 200 {object} models.Work
+// @Failure 400 {object} dto.Problem "Invalid work ID / Invalid request body"
+// @Failure 404 {object} dto.Problem "Work not found"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/works/{id} [put]
+func (c *WorksController) Replace(ctx echo.Context) error {
+	id, err := parseWorkID(ctx)
+	if err != nil {
+		return err
+	}
+	var work dto.WorkDto
+	if err := ctx.Bind(&work); err != nil {
+		return errs.Validation("invalid request body")
+	}
+	if err := ctx.Validate(work); err != nil {
+		return err
+	}
+	updated, err := c.u.Update(ctx.Request().Context(), id, models.Work{Title: work.Title, Author: work.Author})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, updated)
+}
+
+// Delete handles HTTP DELETE requests to remove a work by ID.
+// @Summary Delete a work
+// @Description Deletes a work that has no editions, including the books in the trash.
+// @Tags works
+// @Produce application/problem+json
+// @Param id path string true "Work ID"
+// @Success 200
+// @Failure 400 {object} dto.Problem "Invalid work ID"
+// @Failure 404 {object} dto.Problem "Work not found"
+// @Failure 409 {object} dto.Problem "Work has editions"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/works/{id} [delete]
+func (c *WorksController) Delete(ctx echo.Context) error {
+	id, err := parseWorkID(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.u.Delete(ctx.Request().Context(), id); err != nil {
+		return err
+	}
+	return ctx.NoContent(http.StatusOK)
+}
+
+// GetEditions handles HTTP GET requests to retrieve a page of editions of a work.
+// @Summary Get editions of a work
+// @Description Retrieves the books attached to the work, in the order of creation.
+// @Tags works
+// @Produce json,application/problem+json
+// @Param id path string true "Work ID"
+// @Param limit query int false "Page size (1-100, default 20)"
+// @Param cursor query string false "Cursor returned as next_cursor with the previous page"
+// @Success 200 {object} dto.BookListDto
+// @Failure 400 {object} dto.Problem "Invalid work ID / Invalid query parameters"
+// @Failure 404 {object} dto.Problem "Work not found"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/works/{id}/editions [get]
+func (c *WorksController) GetEditions(ctx echo.Context) error {
+	id, err := parseWorkID(ctx)
+	if err != nil {
+		return err
+	}
+	var query dto.WorkEditionsQuery
+	if err := ctx.Bind(&query); err != nil {
+		return errs.Validation("invalid query parameters")
+	}
+	if err := ctx.Validate(query); err != nil {
+		return err
+	}
+	if _, err := c.u.GetOne(ctx.Request().Context(), id); err != nil {
+		return err
+	}
+	page, err := c.books.GetAll(ctx.Request().Context(), models.BookListParams{
+		BookFilter: models.BookFilter{WorkID: id},
+		Limit:      query.Limit,
+		Cursor:     query.Cursor,
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, dto.BookListDto{
+		Items:      page.Items,
+		NextCursor: page.NextCursor,
+	})
+}
+
+// Attach handles HTTP PUT requests to make a book an edition of a work.
+// @Summary Attach an edition to a work
+// @Description Makes a book an edition of the work, which gives the book a new version.
+// @Description Attaching an edition of the same work again changes nothing; an edition of another work
+// @Description has to be detached from it first.
+// @Tags works
+// @Produce json,application/problem+json
+// @Param id path string true "Work ID"
+// @Param book_id path string true "Book ID"
+// @Success 200 {object} models.Book
+// @Header 200 {string} ETag "Version of the book"
+// @Failure 400 {object} dto.Problem "Invalid work ID / Invalid book ID"
+// @Failure 404 {object} dto.Problem "Work or book not found"
+// @Failure 409 {object} dto.Problem "Book is an edition of another work"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/works/{id}/editions/{book_id} [put]
+func (c *WorksController) Attach(ctx echo.Context) error {
+	id, bookID, err := parseEditionIDs(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.u.Attach(ctx.Request().Context(), id, bookID); err != nil {
+		return err
+	}
+	return c.edition(ctx, bookID)
+}
+
+// Detach handles HTTP DELETE requests to make an edition of a work a standalone book.
+// @Summary Detach an edition from a work
+// @Description Makes an edition of the work a standalone book, which gives the book a new version.
+// @Tags works
+// @Produce json,application/problem+json
+// @Param id path string true "Work ID"
+// @Param book_id path string true "Book ID"
+// @Success 200 {object} models.Book
+// @Header 200 {string} ETag "Version of the book"
+// @Failure 400 {object} dto.Problem "Invalid work ID / Invalid book ID"
+// @Failure 404 {object} dto.Problem "Book isn't an edition of the work"
+// @Failure 500 {object} dto.Problem "Internal Server Error"
+// @Router /api/works/{id}/editions/{book_id} [delete]
+func (c *WorksController) Detach(ctx echo.Context) error {
+	id, bookID, err := parseEditionIDs(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.u.Detach(ctx.Request().Context(), id, bookID); err != nil {
+		return err
+	}
+	return c.edition(ctx, bookID)
+}
+
+// edition responds with the current state of a book after it has been attached or detached.
+func (c *WorksController) edition(ctx echo.Context, bookID uuid.UUID) error {
+	book, err := c.books.GetOne(ctx.Request().Context(), bookID)
+	if err != nil {
+		return err
+	}
+	ctx.Response().Header().Set(HeaderETag, etag(book))
+	return ctx.JSON(http.StatusOK, book)
+}
+
+// parseWorkID extracts the work ID from the path.
+func parseWorkID(ctx echo.Context) (uuid.UUID, error) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return uuid.Nil, errs.Validation("invalid work ID")
+	}
+	return id, nil
+}
+
+// parseEditionIDs extracts the work ID and the book ID from the path.
+func parseEditionIDs(ctx echo.Context) (uuid.UUID, uuid.UUID, error) {
+	id, err := parseWorkID(ctx)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+	bookID, err := uuid.Parse(ctx.Param("book_id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errs.Validation("invalid book ID")
+	}
+	return id, bookID, nil
+}
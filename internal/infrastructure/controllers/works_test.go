@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/infrastructure/controllers/dto"
+	"github.com/KinitaL/testovoye/internal/models"
+	usecase_mock "github.com/KinitaL/testovoye/internal/usecases/books"
+	works_mock "github.com/KinitaL/testovoye/internal/usecases/works"
+	"github.com/KinitaL/testovoye/pkg/validator"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestWorks tests the WorksController methods
+func TestWorks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := echo.New()
+	e.Validator = validator.New()
+	mockWorks := works_mock.NewMockWorks(ctrl)
+	mockBooks := usecase_mock.NewMockBooks(ctrl)
+	controller := NewWorksController(mockWorks, mockBooks)
+
+	work := models.Work{ID: uuid.New(), Title: "War and Peace", Author: "Leo Tolstoy"}
+	edition := models.Book{ID: uuid.New(), Title: "War and Peace", Author: "Leo Tolstoy", Year: 2007,
+		Publisher: "Vintage", Language: "en", Format: models.BookFormatPaperback, WorkID: &work.ID, Version: 2}
+
+	t.Run("GetAll", func(t *testing.T) {
+		mockWorks.EXPECT().GetAll(gomock.Any(), models.WorkListParams{TitlePrefix: "war", Limit: 10}).
+			Return(&models.WorkPage{Items: []models.Work{work}, NextCursor: "next"}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/works?title_prefix=war&limit=10", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.GetAll)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response dto.WorkListDto
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, []models.Work{work}, response.Items)
+		assert.Equal(t, "next", response.NextCursor)
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		mockWorks.EXPECT().Create(gomock.Any(), models.Work{Title: work.Title, Author: work.Author}).Return(&work, nil)
+
+		body, _ := json.Marshal(dto.WorkDto{Title: work.Title, Author: work.Author})
+		req := httptest.NewRequest(http.MethodPost, "/api/works", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.Create)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "/api/works/"+work.ID.String(), rec.Header().Get(echo.HeaderLocation))
+	})
+
+	t.Run("Create without a title", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/works", bytes.NewBufferString(`{"author": "Leo Tolstoy"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+
+		handle(ctx, controller.Create)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		var problem dto.Problem
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+		assert.Equal(t, []errs.FieldError{{Field: "title", Message: "is required"}}, problem.Errors)
+	})
+
+	t.Run("Delete with editions", func(t *testing.T) {
+		mockWorks.EXPECT().Delete(gomock.Any(), work.ID).Return(errs.Conflict("work has editions"))
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/works/"+work.ID.String(), nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(work.ID.String())
+
+		handle(ctx, controller.Delete)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("GetEditions", func(t *testing.T) {
+		mockWorks.EXPECT().GetOne(gomock.Any(), work.ID).Return(&work, nil)
+		mockBooks.EXPECT().GetAll(gomock.Any(), models.BookListParams{
+			BookFilter: models.BookFilter{WorkID: work.ID},
+			Limit:      5,
+		}).Return(&models.BookPage{Items: []models.Book{edition}}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/works/"+work.ID.String()+"/editions?limit=5", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id")
+		ctx.SetParamValues(work.ID.String())
+
+		handle(ctx, controller.GetEditions)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response dto.BookListDto
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, &work.ID, response.Items[0].WorkID)
+		assert.Equal(t, "Vintage", response.Items[0].Publisher)
+	})
+
+	t.Run("Attach", func(t *testing.T) {
+		mockWorks.EXPECT().Attach(gomock.Any(), work.ID, edition.ID).Return(nil)
+		mockBooks.EXPECT().GetOne(gomock.Any(), edition.ID).Return(&edition, nil)
+
+		req := httptest.NewRequest(http.MethodPut, "/api/works/"+work.ID.String()+"/editions/"+edition.ID.String(), nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id", "book_id")
+		ctx.SetParamValues(work.ID.String(), edition.ID.String())
+
+		handle(ctx, controller.Attach)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `"2"`, rec.Header().Get(HeaderETag))
+	})
+
+	t.Run("Attach an edition of another work", func(t *testing.T) {
+		mockWorks.EXPECT().Attach(gomock.Any(), work.ID, edition.ID).Return(errs.Conflict("book is an edition of another work"))
+
+		req := httptest.NewRequest(http.MethodPut, "/api/works/"+work.ID.String()+"/editions/"+edition.ID.String(), nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id", "book_id")
+		ctx.SetParamValues(work.ID.String(), edition.ID.String())
+
+		handle(ctx, controller.Attach)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("Detach with an invalid book ID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/api/works/"+work.ID.String()+"/editions/42", nil)
+		rec := httptest.NewRecorder()
+		ctx := e.NewContext(req, rec)
+		ctx.SetParamNames("id", "book_id")
+		ctx.SetParamValues(work.ID.String(), "42")
+
+		handle(ctx, controller.Detach)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,232 @@
+package books
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/internal/usecases/authors"
+	"github.com/KinitaL/testovoye/internal/usecases/books"
+	"github.com/google/uuid"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// InMemoryAuthorsRepo is an in-memory implementation of the author repository. It keeps authors
+// in the book repository, so that renaming an author renames the credits of the books as well.
+type InMemoryAuthorsRepo struct {
+	books *InMemoryRepo
+}
+
+// NewInMemoryAuthorsRepo creates and returns a new instance of InMemoryAuthorsRepo sharing the storage
+// with a book repository created by NewInMemoryRepo.
+func NewInMemoryAuthorsRepo(repo books.Repository) authors.Repository {
+	return &InMemoryAuthorsRepo{books: repo.(*InMemoryRepo)}
+}
+
+// GetAll retrieves a page of authors ordered by name using keyset pagination.
+func (r *InMemoryAuthorsRepo) GetAll(_ context.Context, query models.AuthorQuery) ([]models.Author, error) {
+	r.books.RLock()
+	defer r.books.RUnlock()
+
+	// the same order as "ORDER BY name, id"
+	before := func(a, b models.Author) bool {
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID.String() < b.ID.String()
+	}
+
+	prefix := strings.ToLower(query.NamePrefix)
+	result := make([]models.Author, 0)
+	for _, author := range r.books.authors {
+		if !strings.HasPrefix(strings.ToLower(author.Name), prefix) {
+			continue
+		}
+		if query.After != nil && !before(models.Author{Name: query.After.Name, ID: query.After.ID}, author) {
+			continue
+		}
+		result = append(result, author)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return before(result[i], result[j])
+	})
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[:query.Limit]
+	}
+	return result, nil
+}
+
+// GetOne retrieves a single author by their UUID.
+func (r *InMemoryAuthorsRepo) GetOne(_ context.Context, ID uuid.UUID) (*models.Author, error) {
+	r.books.RLock()
+	defer r.books.RUnlock()
+
+	author, ok := r.books.authors[ID]
+	if !ok {
+		return nil, errs.NotFound("author with ID = %s doesn't exist", ID)
+	}
+	return &author, nil
+}
+
+// GetByName retrieves the author whose preferred name or variant is the name, ignoring case.
+// Preferred names are matched first, then the oldest author wins.
+func (r *InMemoryAuthorsRepo) GetByName(_ context.Context, name string) (*models.Author, error) {
+	r.books.RLock()
+	defer r.books.RUnlock()
+
+	author := r.books.authorNamed(name)
+	if author == nil {
+		return nil, errs.NotFound("author named %q doesn't exist", name)
+	}
+	return author, nil
+}
+
+// Redirect returns the ID of the author that the author with the ID has been merged into.
+func (r *InMemoryAuthorsRepo) Redirect(_ context.Context, ID uuid.UUID) (uuid.UUID, error) {
+	r.books.RLock()
+	defer r.books.RUnlock()
+
+	target, ok := r.books.redirects[ID]
+	if !ok {
+		return uuid.Nil, errs.NotFound("author with ID = %s hasn't been merged", ID)
+	}
+	return target, nil
+}
+
+// Create adds a new author to the repository and returns them as they were stored.
+func (r *InMemoryAuthorsRepo) Create(_ context.Context, author models.Author) (*models.Author, error) {
+	r.books.Lock()
+	defer r.books.Unlock()
+
+	if _, ok := r.books.authors[author.ID]; ok {
+		return nil, errs.Conflict("author with ID = %s already exists", author.ID)
+	}
+	author.Variants = slices.Clone(author.Variants)
+	author.CreatedAt = time.Now()
+	author.UpdatedAt = author.CreatedAt
+	r.books.authors[author.ID] = author
+	return &author, nil
+}
+
+// Update renames an author and replaces the variants. If the preferred name changes, the books crediting
+// the author, the ones in the trash included, get the new name and a new version.
+func (r *InMemoryAuthorsRepo) Update(_ context.Context, ID uuid.UUID, author models.Author) error {
+	r.books.Lock()
+	defer r.books.Unlock()
+
+	old, ok := r.books.authors[ID]
+	if !ok {
+		return errs.NotFound("author with ID = %s doesn't exist", ID)
+	}
+	now := time.Now()
+	renamed := old.Name != author.Name
+	old.Name = author.Name
+	old.Variants = slices.Clone(author.Variants)
+	old.UpdatedAt = now
+	r.books.authors[ID] = old
+
+	if renamed {
+		r.recredit([]uuid.UUID{ID}, old, now)
+	}
+	return nil
+}
+
+// Merge merges the duplicates into the author: the books crediting them credit the author instead,
+// their names and variants become variants of the author, and their IDs redirect to the author.
+func (r *InMemoryAuthorsRepo) Merge(_ context.Context, ID uuid.UUID, duplicates []uuid.UUID) error {
+	r.books.Lock()
+	defer r.books.Unlock()
+
+	target, ok := r.books.authors[ID]
+	if !ok {
+		return errs.NotFound("author with ID = %s doesn't exist", ID)
+	}
+	for _, duplicate := range duplicates {
+		if _, ok := r.books.authors[duplicate]; !ok {
+			return errs.NotFound("author with ID = %s doesn't exist", duplicate)
+		}
+	}
+
+	now := time.Now()
+	variants := slices.Clone(target.Variants)
+	for _, duplicate := range duplicates {
+		author := r.books.authors[duplicate]
+		for _, name := range append([]string{author.Name}, author.Variants...) {
+			if !strings.EqualFold(name, target.Name) && !slices.ContainsFunc(variants, func(other string) bool {
+				return strings.EqualFold(other, name)
+			}) {
+				variants = append(variants, name)
+			}
+		}
+	}
+	target.Variants = variants
+	target.UpdatedAt = now
+	r.books.authors[ID] = target
+
+	r.recredit(duplicates, target, now)
+	for from, to := range r.books.redirects {
+		if slices.Contains(duplicates, to) {
+			r.books.redirects[from] = ID
+		}
+	}
+	for _, duplicate := range duplicates {
+		r.books.redirects[duplicate] = ID
+		delete(r.books.authors, duplicate)
+	}
+	return nil
+}
+
+// recredit makes the books crediting any of the authors with the IDs, the ones in the trash included,
+// credit the author instead. Credits that become identical are dropped, and the books get a new version.
+func (r *InMemoryAuthorsRepo) recredit(IDs []uuid.UUID, author models.Author, now time.Time) {
+	for bookID, book := range r.books.books {
+		if !slices.ContainsFunc(IDs, func(ID uuid.UUID) bool { return r.credits(book, ID) }) {
+			continue
+		}
+		credits := make([]models.BookAuthor, 0, len(book.Authors))
+		for _, credit := range book.Authors {
+			if slices.Contains(IDs, credit.AuthorID) {
+				credit.AuthorID = author.ID
+				credit.Name = author.Name
+			}
+			if !slices.Contains(credits, credit) {
+				credits = append(credits, credit)
+			}
+		}
+		book.Authors = credits
+		book.Author = models.AuthorText(book.Authors)
+		book.Version++
+		book.UpdatedAt = now
+		r.books.books[bookID] = book
+		if book.DeletedAt == nil {
+			r.books.index.add(book)
+		}
+	}
+}
+
+// Delete removes an author who isn't credited for any book.
+func (r *InMemoryAuthorsRepo) Delete(_ context.Context, ID uuid.UUID) error {
+	r.books.Lock()
+	defer r.books.Unlock()
+
+	if _, ok := r.books.authors[ID]; !ok {
+		return errs.NotFound("author with ID = %s doesn't exist", ID)
+	}
+	for _, book := range r.books.books {
+		if r.credits(book, ID) {
+			return errs.Conflict("author with ID = %s is credited for books", ID)
+		}
+	}
+	delete(r.books.authors, ID)
+	return nil
+}
+
+// credits reports whether the book credits the author in any role.
+func (r *InMemoryAuthorsRepo) credits(book models.Book, ID uuid.UUID) bool {
+	return slices.ContainsFunc(book.Authors, func(credit models.BookAuthor) bool {
+		return credit.AuthorID == ID
+	})
+}
@@ -0,0 +1,143 @@
+package books
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/internal/usecases/books"
+	"github.com/KinitaL/testovoye/internal/usecases/genres"
+	"github.com/google/uuid"
+	"slices"
+	"strings"
+	"time"
+)
+
+// InMemoryGenresRepo is an in-memory implementation of the genre repository. It keeps the taxonomy
+// in the book repository, so that books are checked against it and filtered by subtrees.
+type InMemoryGenresRepo struct {
+	books *InMemoryRepo
+}
+
+// NewInMemoryGenresRepo creates and returns a new instance of InMemoryGenresRepo sharing the storage
+// with a book repository created by NewInMemoryRepo.
+func NewInMemoryGenresRepo(repo books.Repository) genres.Repository {
+	return &InMemoryGenresRepo{books: repo.(*InMemoryRepo)}
+}
+
+// GetAll retrieves all genres ordered by name.
+func (r *InMemoryGenresRepo) GetAll(_ context.Context) ([]models.Genre, error) {
+	r.books.RLock()
+	defer r.books.RUnlock()
+
+	result := make([]models.Genre, 0, len(r.books.genres))
+	for _, genre := range r.books.genres {
+		result = append(result, genre)
+	}
+	// the same order as "ORDER BY name, id"
+	slices.SortFunc(result, func(a, b models.Genre) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID.String(), b.ID.String())
+	})
+	return result, nil
+}
+
+// GetOne retrieves a single genre by its UUID.
+func (r *InMemoryGenresRepo) GetOne(_ context.Context, ID uuid.UUID) (*models.Genre, error) {
+	r.books.RLock()
+	defer r.books.RUnlock()
+
+	genre, ok := r.books.genres[ID]
+	if !ok {
+		return nil, errs.NotFound("genre with ID = %s doesn't exist", ID)
+	}
+	return &genre, nil
+}
+
+// Create adds a new genre under an existing parent and returns it as it was stored.
+func (r *InMemoryGenresRepo) Create(_ context.Context, genre models.Genre) (*models.Genre, error) {
+	r.books.Lock()
+	defer r.books.Unlock()
+
+	if _, ok := r.books.genres[genre.ID]; ok {
+		return nil, errs.Conflict("genre with ID = %s already exists", genre.ID)
+	}
+	if err := r.checkPlace(genre); err != nil {
+		return nil, err
+	}
+	genre.CreatedAt = time.Now()
+	genre.UpdatedAt = genre.CreatedAt
+	r.books.genres[genre.ID] = genre
+	return &genre, nil
+}
+
+// Update renames a genre and moves it along with its subgenres under another parent.
+func (r *InMemoryGenresRepo) Update(_ context.Context, ID uuid.UUID, genre models.Genre) error {
+	r.books.Lock()
+	defer r.books.Unlock()
+
+	old, ok := r.books.genres[ID]
+	if !ok {
+		return errs.NotFound("genre with ID = %s doesn't exist", ID)
+	}
+	genre.ID = ID
+	if err := r.checkPlace(genre); err != nil {
+		return err
+	}
+	if genre.ParentID != nil && r.books.isDescendant(*genre.ParentID, ID) {
+		return errs.InvalidFields(errs.FieldError{Field: "parent_id", Message: "must not be the genre or its subgenre"})
+	}
+	old.Name = genre.Name
+	old.ParentID = genre.ParentID
+	old.UpdatedAt = time.Now()
+	r.books.genres[ID] = old
+	return nil
+}
+
+// Delete removes a genre that has neither subgenres nor books, the ones in the trash included.
+func (r *InMemoryGenresRepo) Delete(_ context.Context, ID uuid.UUID) error {
+	r.books.Lock()
+	defer r.books.Unlock()
+
+	if _, ok := r.books.genres[ID]; !ok {
+		return errs.NotFound("genre with ID = %s doesn't exist", ID)
+	}
+	for _, genre := range r.books.genres {
+		if genre.ParentID != nil && *genre.ParentID == ID {
+			return errs.Conflict("genre with ID = %s has subgenres", ID)
+		}
+	}
+	for _, book := range r.books.books {
+		if slices.Contains(book.Genres, ID) {
+			return errs.Conflict("genre with ID = %s has books", ID)
+		}
+	}
+	delete(r.books.genres, ID)
+	return nil
+}
+
+// checkPlace returns an error if the parent of a genre doesn't exist or has another subgenre
+// with the same name regardless of case, the same way the unique index of the genres table does.
+func (r *InMemoryGenresRepo) checkPlace(genre models.Genre) error {
+	if genre.ParentID != nil {
+		if _, ok := r.books.genres[*genre.ParentID]; !ok {
+			return errs.InvalidFields(errs.FieldError{Field: "parent_id", Message: "must refer to an existing genre"})
+		}
+	}
+	for _, sibling := range r.books.genres {
+		if sibling.ID != genre.ID && sameGenre(sibling.ParentID, genre.ParentID) &&
+			strings.ToLower(sibling.Name) == strings.ToLower(genre.Name) {
+			return errs.Conflict("genre with the same name already exists under the parent")
+		}
+	}
+	return nil
+}
+
+// sameGenre reports whether two optional genre references are equal.
+func sameGenre(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,157 @@
+package books
+
+import (
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/pkg/textnorm"
+	"github.com/google/uuid"
+	"unicode/utf8"
+)
+
+// searchIndex is an inverted index over search keys of titles and authors that mimics PostgreSQL full-text search.
+type searchIndex struct {
+	postings map[string]map[uuid.UUID]int // Term -> book ID -> number of occurrences
+	lengths  map[uuid.UUID]int            // Book ID -> number of indexed terms
+	keys     map[uuid.UUID]bookKeys       // Book ID -> search keys used for fuzzy matching
+}
+
+// bookKeys holds search keys of separate fields of a book.
+type bookKeys struct {
+	title  string
+	author string
+}
+
+// newSearchIndex creates an empty index.
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		postings: make(map[string]map[uuid.UUID]int),
+		lengths:  make(map[uuid.UUID]int),
+		keys:     make(map[uuid.UUID]bookKeys),
+	}
+}
+
+// add indexes the book, replacing its previous version if there is one.
+func (i *searchIndex) add(book models.Book) {
+	i.remove(book.ID)
+
+	keys := bookKeys{title: textnorm.Key(book.Title), author: textnorm.Key(book.Author)}
+	terms := append(textnorm.Words(keys.title), textnorm.Words(keys.author)...)
+	for _, term := range terms {
+		if i.postings[term] == nil {
+			i.postings[term] = make(map[uuid.UUID]int)
+		}
+		i.postings[term][book.ID]++
+	}
+	i.lengths[book.ID] = len(terms)
+	i.keys[book.ID] = keys
+}
+
+// remove drops the book from the index.
+func (i *searchIndex) remove(ID uuid.UUID) {
+	if _, ok := i.lengths[ID]; !ok {
+		return
+	}
+	for term, books := range i.postings {
+		delete(books, ID)
+		if len(books) == 0 {
+			delete(i.postings, term)
+		}
+	}
+	delete(i.lengths, ID)
+	delete(i.keys, ID)
+}
+
+// search returns IDs of books containing all the terms along with their rank.
+func (i *searchIndex) search(terms []string) map[uuid.UUID]float64 {
+	ranks := make(map[uuid.UUID]float64)
+	if len(terms) == 0 {
+		return ranks
+	}
+
+	for ID, count := range i.postings[terms[0]] {
+		ranks[ID] = float64(count)
+	}
+	for _, term := range terms[1:] {
+		books := i.postings[term]
+		for ID := range ranks {
+			count, ok := books[ID]
+			if !ok {
+				delete(ranks, ID)
+				continue
+			}
+			ranks[ID] += float64(count)
+		}
+	}
+
+	// normalize by document length, so that short exact matches come first
+	for ID := range ranks {
+		ranks[ID] /= float64(1 + i.lengths[ID])
+	}
+	return ranks
+}
+
+// fuzzy returns IDs of books whose title or author is spelled similarly to the query
+// along with the similarity, the same way the <% operator of pg_trgm does.
+func (i *searchIndex) fuzzy(query string, threshold float64) map[uuid.UUID]float64 {
+	result := make(map[uuid.UUID]float64)
+	for ID, keys := range i.keys {
+		score := max(wordSimilarity(query, keys.title), wordSimilarity(query, keys.author))
+		if score >= threshold {
+			result[ID] = score
+		}
+	}
+	return result
+}
+
+// wordSimilarity returns how well words of the query are matched by words of the text:
+// the best similarity for every query word, averaged. 1 means all words are found as is.
+func wordSimilarity(query, text string) float64 {
+	queryWords, textWords := textnorm.Words(query), textnorm.Words(text)
+	if len(queryWords) == 0 || len(textWords) == 0 {
+		return 0
+	}
+
+	var total float64
+	for _, q := range queryWords {
+		var best float64
+		for _, t := range textWords {
+			best = max(best, similarity(q, t))
+		}
+		total += best
+	}
+	return total / float64(len(queryWords))
+}
+
+// similarity turns the Levenshtein distance into a score from 0 (nothing in common) to 1 (equal).
+// The ratio is squared to bring it closer to trigram similarity, which drops faster with every typo,
+// so that the same threshold works for both repositories.
+func similarity(a, b string) float64 {
+	longest := max(utf8.RuneCountInString(a), utf8.RuneCountInString(b))
+	if longest == 0 {
+		return 1
+	}
+	ratio := 1 - float64(levenshtein(a, b))/float64(longest)
+	return ratio * ratio
+}
+
+// levenshtein returns the minimal number of single-rune insertions, deletions and substitutions
+// needed to turn one string into the other.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
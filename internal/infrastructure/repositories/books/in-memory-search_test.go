@@ -0,0 +1,244 @@
+package books
+
+import (
+	"context"
+	"fmt"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/pkg/textnorm"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// testCatalog returns a repository with books whose IDs follow their order, so that ties are broken predictably.
+func testCatalog(t *testing.T) *InMemoryRepo {
+	repo := NewInMemoryRepo().(*InMemoryRepo)
+	for i, book := range []models.Book{
+		{Title: "War and Peace", Author: "Leo Tolstoy"},
+		{Title: "Peace", Author: "Somebody"},
+		{Title: "Anna Karenina", Author: "Leo Tolstoy"},
+		{Title: "Война и мир", Author: "Лев Толстой"},
+		{Title: "Brothers Karamazov", Author: "Fyodor Dostoevsky"},
+		{Title: "Crime and Punishment", Author: "Fyodor Dostoevsky"},
+		{Title: "Demons", Author: "Dostoevsky"},
+	} {
+		book.ID = uuid.MustParse(fmt.Sprintf("00000000-0000-0000-0000-%012d", i+1))
+		book.Year = 1900
+		_, err := repo.Create(context.Background(), book)
+		assert.NoError(t, err)
+	}
+	return repo
+}
+
+func TestSearch(t *testing.T) {
+	repo := testCatalog(t)
+
+	cases := []struct {
+		name string
+
+		query  models.BookSearchQuery
+		titles []string
+	}{
+		{
+			name: "Shorter match first",
+
+			query:  models.BookSearchQuery{Query: "peace"},
+			titles: []string{"Peace", "War and Peace"},
+		},
+		{
+			name: "Same author in both scripts",
+
+			query:  models.BookSearchQuery{Query: "tolstoy"},
+			titles: []string{"Anna Karenina", "War and Peace", "Война и мир"},
+		},
+		{
+			name: "All words must match",
+
+			query:  models.BookSearchQuery{Query: "leo tolstoy"},
+			titles: []string{"Anna Karenina", "War and Peace"},
+		},
+		{
+			name: "Transliterated query",
+
+			query:  models.BookSearchQuery{Query: textnorm.Key("Voyna i mir")},
+			titles: []string{"Война и мир"},
+		},
+		{
+			name: "Words of the title and the author together",
+
+			query:  models.BookSearchQuery{Query: "demons dostoevsky"},
+			titles: []string{"Demons"},
+		},
+		{
+			name: "Typo without fuzzy matching",
+
+			query:  models.BookSearchQuery{Query: "tolstio"},
+			titles: []string{},
+		},
+		{
+			name: "Typo",
+
+			query:  models.BookSearchQuery{Query: "karamazof", Fuzzy: true, Threshold: 0.3},
+			titles: []string{"Brothers Karamazov"},
+		},
+		{
+			name: "Typo in one of the words",
+
+			query:  models.BookSearchQuery{Query: textnorm.Key("Fyodor Dostoevksy"), Fuzzy: true, Threshold: 0.3},
+			titles: []string{"Brothers Karamazov", "Crime and Punishment", "Demons"},
+		},
+		{
+			name: "Typo below the threshold",
+
+			query:  models.BookSearchQuery{Query: textnorm.Key("Fyodor Dostoevksy"), Fuzzy: true, Threshold: 0.5},
+			titles: []string{"Brothers Karamazov", "Crime and Punishment"},
+		},
+		{
+			name: "Exact matches keep their order with fuzzy matching",
+
+			query:  models.BookSearchQuery{Query: "tolstoy", Fuzzy: true, Threshold: 0.3},
+			titles: []string{"Anna Karenina", "War and Peace", "Война и мир"},
+		},
+		{
+			name: "Page",
+
+			query:  models.BookSearchQuery{Query: "tolstoy", Offset: 1, Limit: 1},
+			titles: []string{"War and Peace"},
+		},
+		{
+			name: "Offset past the end",
+
+			query:  models.BookSearchQuery{Query: "tolstoy", Offset: 3},
+			titles: []string{},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			hits, err := repo.Search(context.Background(), testCase.query)
+			assert.NoError(t, err)
+			titles := make([]string, len(hits))
+			for i, hit := range hits {
+				titles[i] = hit.Book.Title
+				if i > 0 {
+					assert.GreaterOrEqual(t, hits[i-1].Rank, hit.Rank)
+				}
+			}
+			assert.Equal(t, testCase.titles, titles)
+		})
+	}
+}
+
+func TestSearchIndexUpdates(t *testing.T) {
+	ctx := context.Background()
+	repo := testCatalog(t)
+	search := func(query string) []string {
+		hits, err := repo.Search(ctx, models.BookSearchQuery{Query: query})
+		assert.NoError(t, err)
+		titles := make([]string, len(hits))
+		for i, hit := range hits {
+			titles[i] = hit.Book.Title
+		}
+		return titles
+	}
+
+	peace := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	assert.NoError(t, repo.Update(ctx, peace, models.Book{ID: peace, Title: "Resurrection", Author: "Leo Tolstoy", Year: 1899, Version: 1}))
+	assert.Equal(t, []string{"War and Peace"}, search("peace"))
+	assert.Equal(t, []string{"Resurrection"}, search("resurrection"))
+
+	assert.NoError(t, repo.Delete(ctx, peace, 2))
+	assert.Equal(t, []string{}, search("resurrection"))
+}
+
+func TestSuggest(t *testing.T) {
+	repo := testCatalog(t)
+
+	cases := []struct {
+		name string
+
+		query       models.BookSearchQuery
+		suggestions []models.BookSuggestion
+	}{
+		{
+			name: "Author",
+
+			query: models.BookSearchQuery{Query: "dostoevksy", Threshold: 0.3, Limit: 5},
+			suggestions: []models.BookSuggestion{
+				{Text: "Dostoevsky", Field: models.BookSuggestionAuthor, Score: 0.64},
+				{Text: "Fyodor Dostoevsky", Field: models.BookSuggestionAuthor, Score: 0.64},
+			},
+		},
+		{
+			name: "Author in both scripts",
+
+			query: models.BookSearchQuery{Query: "tolstio", Threshold: 0.3, Limit: 5},
+			suggestions: []models.BookSuggestion{
+				{Text: "Leo Tolstoy", Field: models.BookSuggestionAuthor, Score: 0.51},
+				{Text: "Лев Толстой", Field: models.BookSuggestionAuthor, Score: 0.51},
+			},
+		},
+		{
+			name: "Titles",
+
+			query: models.BookSearchQuery{Query: "pease", Threshold: 0.3, Limit: 5},
+			suggestions: []models.BookSuggestion{
+				{Text: "Peace", Field: models.BookSuggestionTitle, Score: 0.64},
+				{Text: "War and Peace", Field: models.BookSuggestionTitle, Score: 0.64},
+			},
+		},
+		{
+			name: "Limit",
+
+			query: models.BookSearchQuery{Query: "pease", Threshold: 0.3, Limit: 1},
+			suggestions: []models.BookSuggestion{
+				{Text: "Peace", Field: models.BookSuggestionTitle, Score: 0.64},
+			},
+		},
+		{
+			name: "Below the threshold",
+
+			query:       models.BookSearchQuery{Query: "tolstio", Threshold: 0.6, Limit: 5},
+			suggestions: []models.BookSuggestion{},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			suggestions, err := repo.Suggest(context.Background(), testCase.query)
+			assert.NoError(t, err)
+			if assert.Len(t, suggestions, len(testCase.suggestions)) {
+				for i, suggestion := range suggestions {
+					assert.Equal(t, testCase.suggestions[i].Text, suggestion.Text)
+					assert.Equal(t, testCase.suggestions[i].Field, suggestion.Field)
+					assert.InDelta(t, testCase.suggestions[i].Score, suggestion.Score, 0.01)
+				}
+			}
+		})
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	cases := []struct {
+		name string
+
+		a, b     string
+		distance int
+		score    float64
+	}{
+		{name: "Equal", a: "tolstoy", b: "tolstoy", distance: 0, score: 1},
+		{name: "Transposition", a: "dostoevksy", b: "dostoevsky", distance: 2, score: 0.64},
+		{name: "Insertion", a: "tolstoy", b: "tolstoyy", distance: 1, score: 0.77},
+		{name: "Cyrillic letters count once", a: "толстой", b: "толстый", distance: 1, score: 0.73},
+		{name: "Nothing in common", a: "abc", b: "xyz", distance: 3, score: 0},
+		{name: "Empty", a: "", b: "abc", distance: 3, score: 0},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.distance, levenshtein(testCase.a, testCase.b))
+			assert.Equal(t, testCase.distance, levenshtein(testCase.b, testCase.a))
+			assert.InDelta(t, testCase.score, similarity(testCase.a, testCase.b), 0.01)
+		})
+	}
+}
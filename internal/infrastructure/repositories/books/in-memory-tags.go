@@ -0,0 +1,102 @@
+package books
+
+import (
+	"cmp"
+	"context"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/internal/usecases/books"
+	"github.com/KinitaL/testovoye/internal/usecases/tags"
+	"slices"
+	"strings"
+	"time"
+)
+
+// InMemoryTagsRepo is an in-memory implementation of the tag repository. Tags are kept
+// on books of the book repository the repository shares the storage with.
+type InMemoryTagsRepo struct {
+	books *InMemoryRepo
+}
+
+// NewInMemoryTagsRepo creates and returns a new instance of InMemoryTagsRepo sharing the storage
+// with a book repository created by NewInMemoryRepo.
+func NewInMemoryTagsRepo(repo books.Repository) tags.Repository {
+	return &InMemoryTagsRepo{books: repo.(*InMemoryRepo)}
+}
+
+// GetAll retrieves the most used tags of books outside the trash.
+func (r *InMemoryTagsRepo) GetAll(_ context.Context, query models.TagQuery) ([]models.TagCount, error) {
+	r.books.RLock()
+	defer r.books.RUnlock()
+
+	counts := make(map[string]int64)
+	for _, book := range r.books.books {
+		if book.DeletedAt != nil {
+			continue
+		}
+		for _, tag := range book.Tags {
+			if strings.HasPrefix(tag, query.Prefix) {
+				counts[tag]++
+			}
+		}
+	}
+
+	result := make([]models.TagCount, 0, len(counts))
+	for tag, count := range counts {
+		result = append(result, models.TagCount{Tag: tag, Books: count})
+	}
+	slices.SortFunc(result, func(a, b models.TagCount) int {
+		if c := cmp.Compare(b.Books, a.Books); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Tag, b.Tag)
+	})
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[:query.Limit]
+	}
+	return result, nil
+}
+
+// Rename replaces a tag with another one on every book having it, the ones in the trash included.
+func (r *InMemoryTagsRepo) Rename(_ context.Context, tag string, name string) error {
+	return r.retag(tag, func(tags []string) []string {
+		tags = slices.DeleteFunc(slices.Clone(tags), func(t string) bool { return t == tag })
+		tags = append(tags, name)
+		slices.Sort(tags)
+		return slices.Compact(tags)
+	})
+}
+
+// Delete removes a tag from every book having it, the ones in the trash included.
+func (r *InMemoryTagsRepo) Delete(_ context.Context, tag string) error {
+	return r.retag(tag, func(tags []string) []string {
+		tags = slices.DeleteFunc(slices.Clone(tags), func(t string) bool { return t == tag })
+		if len(tags) == 0 {
+			return nil
+		}
+		return tags
+	})
+}
+
+// retag changes the tags of the books having a tag, bumping their versions.
+func (r *InMemoryTagsRepo) retag(tag string, change func(tags []string) []string) error {
+	r.books.Lock()
+	defer r.books.Unlock()
+
+	now := time.Now()
+	found := false
+	for ID, book := range r.books.books {
+		if !slices.Contains(book.Tags, tag) {
+			continue
+		}
+		found = true
+		book.Tags = change(book.Tags)
+		book.Version++
+		book.UpdatedAt = now
+		r.books.books[ID] = book
+	}
+	if !found {
+		return errs.NotFound("tag %q doesn't exist", tag)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package books
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/internal/usecases/books"
+	"github.com/KinitaL/testovoye/internal/usecases/works"
+	"github.com/google/uuid"
+	"sort"
+	"strings"
+	"time"
+)
+
+// InMemoryWorksRepo is an in-memory implementation of the work repository. It keeps works
+// in the book repository, so that attaching and detaching editions change the books.
+type InMemoryWorksRepo struct {
+	books *InMemoryRepo
+}
+
+// NewInMemoryWorksRepo creates and returns a new instance of InMemoryWorksRepo sharing the storage
+// with a book repository created by NewInMemoryRepo.
+func NewInMemoryWorksRepo(repo books.Repository) works.Repository {
+	return &InMemoryWorksRepo{books: repo.(*InMemoryRepo)}
+}
+
+// GetAll retrieves a page of works ordered by title using keyset pagination.
+func (r *InMemoryWorksRepo) GetAll(_ context.Context, query models.WorkQuery) ([]models.Work, error) {
+	r.books.RLock()
+	defer r.books.RUnlock()
+
+	// the same order as "ORDER BY title, id"
+	before := func(a, b models.Work) bool {
+		if a.Title != b.Title {
+			return a.Title < b.Title
+		}
+		return a.ID.String() < b.ID.String()
+	}
+
+	prefix := strings.ToLower(query.TitlePrefix)
+	result := make([]models.Work, 0)
+	for _, work := range r.books.works {
+		if !strings.HasPrefix(strings.ToLower(work.Title), prefix) {
+			continue
+		}
+		if query.After != nil && !before(models.Work{Title: query.After.Title, ID: query.After.ID}, work) {
+			continue
+		}
+		result = append(result, work)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return before(result[i], result[j])
+	})
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[:query.Limit]
+	}
+	return result, nil
+}
+
+// GetOne retrieves a single work by its UUID.
+func (r *InMemoryWorksRepo) GetOne(_ context.Context, ID uuid.UUID) (*models.Work, error) {
+	r.books.RLock()
+	defer r.books.RUnlock()
+
+	work, ok := r.books.works[ID]
+	if !ok {
+		return nil, errs.NotFound("work with ID = %s doesn't exist", ID)
+	}
+	return &work, nil
+}
+
+// Create adds a new work to the repository and returns it as it was stored.
+func (r *InMemoryWorksRepo) Create(_ context.Context, work models.Work) (*models.Work, error) {
+	r.books.Lock()
+	defer r.books.Unlock()
+
+	if _, ok := r.books.works[work.ID]; ok {
+		return nil, errs.Conflict("work with ID = %s already exists", work.ID)
+	}
+	work.CreatedAt = time.Now()
+	work.UpdatedAt = work.CreatedAt
+	r.books.works[work.ID] = work
+	return &work, nil
+}
+
+// Update replaces the title and the creators of a work.
+func (r *InMemoryWorksRepo) Update(_ context.Context, ID uuid.UUID, work models.Work) error {
+	r.books.Lock()
+	defer r.books.Unlock()
+
+	old, ok := r.books.works[ID]
+	if !ok {
+		return errs.NotFound("work with ID = %s doesn't exist", ID)
+	}
+	old.Title = work.Title
+	old.Author = work.Author
+	old.UpdatedAt = time.Now()
+	r.books.works[ID] = old
+	return nil
+}
+
+// Delete removes a work that has no editions, the ones in the trash included.
+func (r *InMemoryWorksRepo) Delete(_ context.Context, ID uuid.UUID) error {
+	r.books.Lock()
+	defer r.books.Unlock()
+
+	if _, ok := r.books.works[ID]; !ok {
+		return errs.NotFound("work with ID = %s doesn't exist", ID)
+	}
+	for _, book := range r.books.books {
+		if book.WorkID != nil && *book.WorkID == ID {
+			return errs.Conflict("work with ID = %s has editions", ID)
+		}
+	}
+	delete(r.books.works, ID)
+	return nil
+}
+
+// Attach makes a book outside the trash an edition of the work; the book gets a new version.
+func (r *InMemoryWorksRepo) Attach(_ context.Context, ID uuid.UUID, bookID uuid.UUID) error {
+	r.books.Lock()
+	defer r.books.Unlock()
+
+	if _, ok := r.books.works[ID]; !ok {
+		return errs.NotFound("work with ID = %s doesn't exist", ID)
+	}
+	book, ok := r.books.books[bookID]
+	if !ok || book.DeletedAt != nil {
+		return errs.NotFound("book with ID = %s doesn't exist", bookID)
+	}
+	switch {
+	case book.WorkID == nil:
+	case *book.WorkID == ID:
+		return nil
+	default:
+		return errs.Conflict("book with ID = %s is an edition of work %s", bookID, *book.WorkID)
+	}
+	r.setWork(book, &ID)
+	return nil
+}
+
+// Detach makes an edition of the work a standalone book; the book gets a new version.
+func (r *InMemoryWorksRepo) Detach(_ context.Context, ID uuid.UUID, bookID uuid.UUID) error {
+	r.books.Lock()
+	defer r.books.Unlock()
+
+	book, ok := r.books.books[bookID]
+	if !ok || book.DeletedAt != nil || book.WorkID == nil || *book.WorkID != ID {
+		return errs.NotFound("book with ID = %s isn't an edition of work %s", bookID, ID)
+	}
+	r.setWork(book, nil)
+	return nil
+}
+
+// setWork changes the work of a book and bumps its version.
+func (r *InMemoryWorksRepo) setWork(book models.Book, ID *uuid.UUID) {
+	book.WorkID = ID
+	book.Version++
+	book.UpdatedAt = time.Now()
+	r.books.books[book.ID] = book
+}
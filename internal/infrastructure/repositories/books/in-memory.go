@@ -1,94 +1,940 @@
 package books
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"github.com/KinitaL/testovoye/internal/errs"
 	"github.com/KinitaL/testovoye/internal/models"
 	"github.com/KinitaL/testovoye/internal/usecases/books"
+	"github.com/KinitaL/testovoye/pkg/textnorm"
 	"github.com/google/uuid"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+	"iter"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"time"
+	"unicode"
 )
 
 // InMemoryRepo is a thread-safe in-memory implementation of the book repository.
 type InMemoryRepo struct {
 	sync.RWMutex
-	books map[uuid.UUID]models.Book // Map to store books using UUID as the key
+	books     map[uuid.UUID]models.Book   // Map to store books using UUID as the key, including deleted ones
+	authors   map[uuid.UUID]models.Author // Authors credited for books, see InMemoryAuthorsRepo
+	redirects map[uuid.UUID]uuid.UUID     // IDs of merged authors to the authors they have been merged into
+	works     map[uuid.UUID]models.Work   // Works that books are editions of, see InMemoryWorksRepo
+	genres    map[uuid.UUID]models.Genre  // Genre taxonomy, a tree of genres linked to their parents, see InMemoryGenresRepo
+	index     *searchIndex                // Full-text index over titles and authors of books that aren't deleted
 }
 
+// collations maps locales to languages whose collation rules are used to order titles and authors.
+var collations = map[models.Locale]language.Tag{
+	models.LocaleEnglish: language.English,
+	models.LocaleRussian: language.Russian,
+}
+
+// exportPageSize is the number of books Export copies under the lock at once.
+const exportPageSize = 500
+
 // NewInMemoryRepo creates and returns a new instance of InMemoryRepo.
 func NewInMemoryRepo() books.Repository {
 	return &InMemoryRepo{
-		RWMutex: sync.RWMutex{},
-		books:   make(map[uuid.UUID]models.Book),
+		RWMutex:   sync.RWMutex{},
+		books:     make(map[uuid.UUID]models.Book),
+		authors:   make(map[uuid.UUID]models.Author),
+		redirects: make(map[uuid.UUID]uuid.UUID),
+		works:     make(map[uuid.UUID]models.Work),
+		genres:    make(map[uuid.UUID]models.Genre),
+		index:     newSearchIndex(),
 	}
 }
 
-// GetAll retrieves all books from the repository.
-func (r *InMemoryRepo) GetAll(_ context.Context) ([]models.Book, error) {
+// GetAll retrieves a page of books using keyset pagination.
+func (r *InMemoryRepo) GetAll(_ context.Context, query models.BookQuery) ([]models.Book, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	// a collator keeps internal buffers, so it can't be shared between concurrent calls
+	collator := collate.New(language.English)
+	if tag, ok := collations[query.Locale]; ok {
+		collator = collate.New(tag)
+	}
+
 	result := make([]models.Book, 0, len(r.books))
+	for _, b := range r.books {
+		if b.DeletedAt != nil || !r.matches(b, query.BookFilter) {
+			continue
+		}
+		if query.After != nil && r.compare(collator, b, *query.After, query) <= 0 {
+			continue
+		}
+		result = append(result, b)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		after := models.BookCursor{Value: r.sortValue(result[j], query.SortBy), ID: result[j].ID}
+		return r.compare(collator, result[i], after, query) < 0
+	})
 
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[:query.Limit]
+	}
+	return result, nil
+}
+
+// Count returns the number of books matching the filter.
+func (r *InMemoryRepo) Count(_ context.Context, filter models.BookFilter) (int64, error) {
 	r.RLock()
 	defer r.RUnlock()
 
+	var total int64
 	for _, b := range r.books {
-		result = append(result, b)
+		if b.DeletedAt == nil && r.matches(b, filter) {
+			total++
+		}
+	}
+	return total, nil
+}
+
+// Facets counts books matching the filter by genre, with the books of subgenres included, by tag and by decade.
+func (r *InMemoryRepo) Facets(_ context.Context, filter models.BookFilter) (*models.BookFacets, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	genres := make(map[uuid.UUID]int64)
+	tags := make(map[string]int64)
+	decades := make(map[uint16]int64)
+	for _, b := range r.books {
+		if b.DeletedAt != nil || !r.matches(b, filter) {
+			continue
+		}
+		// a book is counted once for a genre even if it is classified under several of its subgenres
+		ancestors := make(map[uuid.UUID]struct{})
+		for _, ID := range b.Genres {
+			for {
+				ancestors[ID] = struct{}{}
+				parent := r.genres[ID].ParentID
+				if parent == nil {
+					break
+				}
+				ID = *parent
+			}
+		}
+		for ID := range ancestors {
+			genres[ID]++
+		}
+		for _, tag := range b.Tags {
+			tags[tag]++
+		}
+		decades[b.Year/10*10]++
+	}
+
+	facets := &models.BookFacets{}
+	for ID, count := range genres {
+		genre := r.genres[ID]
+		facets.Genres = append(facets.Genres, models.GenreFacet{ID: ID, Name: genre.Name, ParentID: genre.ParentID, Count: count})
+	}
+	slices.SortFunc(facets.Genres, func(a, b models.GenreFacet) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID.String(), b.ID.String())
+	})
+	for tag, count := range tags {
+		facets.Tags = append(facets.Tags, models.TagFacet{Tag: tag, Count: count})
+	}
+	slices.SortFunc(facets.Tags, func(a, b models.TagFacet) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Tag, b.Tag)
+	})
+	if len(facets.Tags) > models.MaxTagFacets {
+		facets.Tags = facets.Tags[:models.MaxTagFacets]
+	}
+	for decade, count := range decades {
+		facets.Decades = append(facets.Decades, models.DecadeFacet{Decade: decade, Count: count})
+	}
+	slices.SortFunc(facets.Decades, func(a, b models.DecadeFacet) int { return cmp.Compare(a.Decade, b.Decade) })
+	return facets, nil
+}
+
+// Export yields books matching the filter page by page, so that the lock isn't held while the caller consumes them.
+func (r *InMemoryRepo) Export(ctx context.Context, filter models.BookFilter) iter.Seq2[models.Book, error] {
+	return func(yield func(models.Book, error) bool) {
+		query := models.BookQuery{BookFilter: filter, SortBy: models.BookSortCreatedAt, Limit: exportPageSize}
+		for {
+			page, err := r.GetAll(ctx, query)
+			if err != nil {
+				yield(models.Book{}, err)
+				return
+			}
+			for _, book := range page {
+				if !yield(book, nil) {
+					return
+				}
+			}
+			if len(page) < exportPageSize {
+				return
+			}
+			last := page[len(page)-1]
+			query.After = &models.BookCursor{Value: last.CreatedAt, ID: last.ID}
+		}
+	}
+}
+
+// Search performs ranked full-text search over titles and authors.
+// The query is expected to be normalized with textnorm.Key, the same way the index is.
+func (r *InMemoryRepo) Search(_ context.Context, query models.BookSearchQuery) ([]models.BookSearchHit, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	ranks := r.index.search(textnorm.Words(query.Query))
+	if query.Fuzzy {
+		for ID, score := range r.index.fuzzy(query.Query, query.Threshold) {
+			ranks[ID] += score
+		}
+	}
+	result := make([]models.BookSearchHit, 0, len(ranks))
+	for ID, rank := range ranks {
+		result = append(result, models.BookSearchHit{
+			Book: r.books[ID],
+			Rank: rank,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Rank != result[j].Rank {
+			return result[i].Rank > result[j].Rank
+		}
+		return result[i].Book.ID.String() < result[j].Book.ID.String()
+	})
+
+	if query.Offset >= len(result) {
+		return []models.BookSearchHit{}, nil
+	}
+	result = result[query.Offset:]
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[:query.Limit]
+	}
+	return result, nil
+}
+
+// Suggest returns known titles and authors spelled similarly to the query, closest first.
+// The query is expected to be normalized with textnorm.Key.
+func (r *InMemoryRepo) Suggest(_ context.Context, query models.BookSearchQuery) ([]models.BookSuggestion, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	seen := make(map[string]struct{})
+	result := make([]models.BookSuggestion, 0)
+	suggest := func(text, key string, field models.BookSuggestionField) {
+		if _, ok := seen[string(field)+key]; ok {
+			return
+		}
+		seen[string(field)+key] = struct{}{}
+		if score := wordSimilarity(query.Query, key); score >= query.Threshold {
+			result = append(result, models.BookSuggestion{Text: text, Field: field, Score: score})
+		}
+	}
+	for ID, keys := range r.index.keys {
+		suggest(r.books[ID].Author, keys.author, models.BookSuggestionAuthor)
+		suggest(r.books[ID].Title, keys.title, models.BookSuggestionTitle)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].Text < result[j].Text
+	})
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[:query.Limit]
+	}
+	return result, nil
+}
+
+// Match retrieves a page of books satisfying the condition in the order of creation.
+func (r *InMemoryRepo) Match(_ context.Context, query models.BookMatchQuery) ([]models.Book, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	result, err := r.satisfying(query.Condition)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.Before(result[j].CreatedAt)
+		}
+		return result[i].ID.String() < result[j].ID.String()
+	})
+
+	result = result[min(query.Offset, len(result)):]
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[:query.Limit]
 	}
 	return result, nil
 }
 
+// CountMatches returns the number of books satisfying the condition.
+func (r *InMemoryRepo) CountMatches(_ context.Context, condition *models.BookCondition) (int64, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	result, err := r.satisfying(condition)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(result)), nil
+}
+
 // GetOne retrieves a single book by its UUID.
 func (r *InMemoryRepo) GetOne(_ context.Context, ID uuid.UUID) (*models.Book, error) {
 	r.RLock()
 	defer r.RUnlock()
 
+	book, ok := r.books[ID]
+	if !ok || book.DeletedAt != nil {
+		return nil, errs.NotFound("book with ID = %s doesn't exist", ID)
+	}
+	return &book, nil
+}
+
+// GetByISBN retrieves the book outside the trash that has the ISBN-13.
+func (r *InMemoryRepo) GetByISBN(_ context.Context, isbn string) (*models.Book, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	for _, book := range r.books {
+		if book.DeletedAt == nil && book.ISBN == isbn {
+			return &book, nil
+		}
+	}
+	return nil, errs.NotFound("book with ISBN %s doesn't exist", isbn)
+}
+
+// GetAny retrieves a single book by its UUID, including a deleted one.
+func (r *InMemoryRepo) GetAny(_ context.Context, ID uuid.UUID) (*models.Book, error) {
+	r.RLock()
+	defer r.RUnlock()
+
 	book, ok := r.books[ID]
 	if !ok {
-		return nil, fmt.Errorf("book with ID = %s doesn't exist", ID)
+		return nil, errs.NotFound("book with ID = %s doesn't exist", ID)
 	}
 	return &book, nil
 }
 
-// Create adds a new book to the repository.
-func (r *InMemoryRepo) Create(_ context.Context, book models.Book) error {
+// Harvest retrieves a page of books, deleted ones included, ordered by datestamps with keyset pagination.
+func (r *InMemoryRepo) Harvest(_ context.Context, query models.HarvestQuery) ([]models.Book, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	// the same order as "ORDER BY GREATEST(updated_at, deleted_at), id"
+	before := func(a, b models.Book) bool {
+		if !a.Datestamp().Equal(b.Datestamp()) {
+			return a.Datestamp().Before(b.Datestamp())
+		}
+		return a.ID.String() < b.ID.String()
+	}
+
+	result := make([]models.Book, 0)
+	for _, b := range r.books {
+		datestamp := b.Datestamp()
+		if query.From != nil && datestamp.Before(*query.From) || query.Until != nil && !datestamp.Before(*query.Until) {
+			continue
+		}
+		if query.After != nil {
+			after, _ := query.After.Value.(time.Time)
+			if !before(models.Book{ID: query.After.ID, UpdatedAt: after}, b) {
+				continue
+			}
+		}
+		result = append(result, b)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return before(result[i], result[j])
+	})
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[:query.Limit]
+	}
+	return result, nil
+}
+
+// Existing returns those of the identities that books outside the trash have.
+func (r *InMemoryRepo) Existing(_ context.Context, identities []models.BookIdentity) ([]models.BookIdentity, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	present := make(map[models.BookIdentity]struct{}, len(r.index.keys))
+	for _, keys := range r.index.keys {
+		present[models.BookIdentity{TitleKey: keys.title, AuthorKey: keys.author}] = struct{}{}
+	}
+	result := make([]models.BookIdentity, 0)
+	for _, identity := range identities {
+		if _, ok := present[identity]; ok {
+			result = append(result, identity)
+			delete(present, identity) // report each identity once
+		}
+	}
+	return result, nil
+}
+
+// ExistingISBNs returns those of the ISBNs that books outside the trash have.
+func (r *InMemoryRepo) ExistingISBNs(_ context.Context, isbns []string) ([]string, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	result := []string{}
+	for _, isbn := range isbns {
+		if isbn != "" && r.isbnTaken(isbn, uuid.Nil) && !slices.Contains(result, isbn) {
+			result = append(result, isbn)
+		}
+	}
+	return result, nil
+}
+
+// Create adds a new book to the repository and returns it as it was stored.
+func (r *InMemoryRepo) Create(_ context.Context, book models.Book) (*models.Book, error) {
 	r.Lock()
 	defer r.Unlock()
+
+	if _, ok := r.books[book.ID]; ok {
+		return nil, errs.Conflict("book with ID = %s already exists", book.ID)
+	}
+	if r.isbnTaken(book.ISBN, book.ID) {
+		return nil, errs.Conflict("book with ISBN %s already exists", book.ISBN)
+	}
+	if err := r.checkCredits(book.Authors); err != nil {
+		return nil, err
+	}
+	if err := r.checkGenres(book.Genres); err != nil {
+		return nil, err
+	}
+	book.CreatedAt = time.Now()
+	book.UpdatedAt = book.CreatedAt
+	r.resolveCredits(&book, book.CreatedAt)
+	book.Version = 1
 	r.books[book.ID] = book
-	return nil
+	r.index.add(book)
+	return &book, nil
 }
 
-// Update modifies an existing book in the repository.
+// CreateBatch adds books to the repository, either all of them or none.
+func (r *InMemoryRepo) CreateBatch(_ context.Context, batch []models.Book) ([]models.Book, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	isbns := make(map[string]struct{}, len(batch))
+	for _, book := range batch {
+		if _, ok := r.books[book.ID]; ok {
+			return nil, errs.Conflict("book with ID = %s already exists", book.ID)
+		}
+		if _, ok := isbns[book.ISBN]; ok || r.isbnTaken(book.ISBN, book.ID) {
+			return nil, errs.Conflict("book with ISBN %s already exists", book.ISBN)
+		}
+		if book.ISBN != "" {
+			isbns[book.ISBN] = struct{}{}
+		}
+		if err := r.checkCredits(book.Authors); err != nil {
+			return nil, err
+		}
+		if err := r.checkGenres(book.Genres); err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now()
+	result := make([]models.Book, len(batch))
+	for i, book := range batch {
+		book.CreatedAt = now
+		book.UpdatedAt = now
+		r.resolveCredits(&book, now)
+		book.Version = 1
+		r.books[book.ID] = book
+		r.index.add(book)
+		result[i] = book
+	}
+	return result, nil
+}
+
+// Update replaces fields of an existing book in the repository.
+// If the book has a version, it is updated only if the stored book still has that version.
 func (r *InMemoryRepo) Update(_ context.Context, ID uuid.UUID, book models.Book) error {
 	r.Lock()
 	defer r.Unlock()
 
 	old, ok := r.books[ID]
-	if !ok {
-		return fmt.Errorf("book with ID = %s doesn't exist", ID)
+	if !ok || old.DeletedAt != nil {
+		return errs.NotFound("book with ID = %s doesn't exist", ID)
+	}
+	if book.Version != 0 && book.Version != old.Version {
+		return errs.PreconditionFailed("book with ID = %s has been modified since version %d", ID, book.Version)
+	}
+	if r.isbnTaken(book.ISBN, ID) {
+		return errs.Conflict("book with ISBN %s already exists", book.ISBN)
+	}
+	if err := r.checkCredits(book.Authors); err != nil {
+		return err
+	}
+	if err := r.checkGenres(book.Genres); err != nil {
+		return err
+	}
+
+	book.CreatedAt = old.CreatedAt
+	book.WorkID = old.WorkID // changed only by InMemoryWorksRepo
+	book.Version = old.Version + 1
+	book.UpdatedAt = time.Now()
+	r.resolveCredits(&book, book.UpdatedAt)
+	r.books[ID] = book
+	r.index.add(book)
+	return nil
+}
+
+// Delete marks a book as deleted, moving it to the trash.
+// If version isn't zero, the book is deleted only if it still has that version.
+func (r *InMemoryRepo) Delete(_ context.Context, ID uuid.UUID, version uint64) error {
+	r.Lock()
+	defer r.Unlock()
+
+	book, ok := r.books[ID]
+	if !ok || book.DeletedAt != nil {
+		return errs.NotFound("book with ID = %s doesn't exist", ID)
+	}
+	if version != 0 && version != book.Version {
+		return errs.PreconditionFailed("book with ID = %s has been modified since version %d", ID, version)
+	}
+	now := time.Now()
+	book.DeletedAt = &now
+	r.books[ID] = book
+	r.index.remove(ID)
+	return nil
+}
+
+// GetTrash retrieves a page of deleted books, most recently deleted first.
+func (r *InMemoryRepo) GetTrash(_ context.Context, query models.TrashQuery) ([]models.Book, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	// the same order as "ORDER BY deleted_at DESC, id DESC"
+	before := func(a, b models.Book) bool {
+		if !a.DeletedAt.Equal(*b.DeletedAt) {
+			return a.DeletedAt.After(*b.DeletedAt)
+		}
+		return a.ID.String() > b.ID.String()
+	}
+
+	result := make([]models.Book, 0)
+	for _, b := range r.books {
+		if b.DeletedAt == nil {
+			continue
+		}
+		if query.After != nil {
+			deletedAt, _ := query.After.Value.(time.Time)
+			if !before(models.Book{ID: query.After.ID, DeletedAt: &deletedAt}, b) {
+				continue
+			}
+		}
+		result = append(result, b)
 	}
 
-	r.fillEmptyFields(&old, &book)
+	sort.Slice(result, func(i, j int) bool {
+		return before(result[i], result[j])
+	})
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[:query.Limit]
+	}
+	return result, nil
+}
+
+// Restore clears the deletion mark of a book in the trash.
+func (r *InMemoryRepo) Restore(_ context.Context, ID uuid.UUID) error {
+	r.Lock()
+	defer r.Unlock()
+
+	book, ok := r.books[ID]
+	if !ok || book.DeletedAt == nil {
+		return errs.NotFound("book with ID = %s is not in the trash", ID)
+	}
+	if r.isbnTaken(book.ISBN, ID) {
+		return errs.Conflict("book with ISBN %s already exists", book.ISBN)
+	}
+	book.DeletedAt = nil
+	book.UpdatedAt = time.Now()
 	r.books[ID] = book
+	r.index.add(book)
 	return nil
 }
 
-// Delete removes a book from the repository by its UUID.
-func (r *InMemoryRepo) Delete(_ context.Context, ID uuid.UUID) error {
+// Purge permanently removes a book that is in the trash.
+func (r *InMemoryRepo) Purge(_ context.Context, ID uuid.UUID) error {
 	r.Lock()
 	defer r.Unlock()
 
+	book, ok := r.books[ID]
+	if !ok || book.DeletedAt == nil {
+		return errs.NotFound("book with ID = %s is not in the trash", ID)
+	}
 	delete(r.books, ID)
 	return nil
 }
 
-// fillEmptyFields copies missing fields from the old book to the new one.
-func (r *InMemoryRepo) fillEmptyFields(old, new *models.Book) {
-	if new.Title == "" {
-		new.Title = old.Title
+// PurgeDeletedBefore permanently removes books deleted before the given moment and returns their number.
+func (r *InMemoryRepo) PurgeDeletedBefore(_ context.Context, before time.Time) (int64, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	var purged int64
+	for ID, book := range r.books {
+		if book.DeletedAt != nil && book.DeletedAt.Before(before) {
+			delete(r.books, ID)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// Transaction runs fn and, if it fails, brings the books, the authors, the works and the genres back to the state
+// they had before it.
+// Unlike a database transaction, it doesn't isolate fn from concurrent changes.
+func (r *InMemoryRepo) Transaction(_ context.Context, fn func(repo books.Repository) error) error {
+	r.RLock()
+	snapshot := maps.Clone(r.books)
+	authors := maps.Clone(r.authors)
+	redirects := maps.Clone(r.redirects)
+	works := maps.Clone(r.works)
+	genres := maps.Clone(r.genres)
+	r.RUnlock()
+
+	if err := fn(r); err != nil {
+		r.Lock()
+		defer r.Unlock()
+		r.books = snapshot
+		r.authors = authors
+		r.redirects = redirects
+		r.works = works
+		r.genres = genres
+		r.index = newSearchIndex()
+		for _, book := range snapshot {
+			if book.DeletedAt == nil {
+				r.index.add(book)
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// matches reports whether the book satisfies the filter.
+func (r *InMemoryRepo) matches(book models.Book, filter models.BookFilter) bool {
+	if filter.Author != "" && !strings.EqualFold(book.Author, filter.Author) {
+		return false
+	}
+	if filter.AuthorID != uuid.Nil && !slices.ContainsFunc(book.Authors, func(credit models.BookAuthor) bool {
+		return credit.AuthorID == filter.AuthorID
+	}) {
+		return false
+	}
+	if filter.YearFrom != 0 && book.Year < filter.YearFrom {
+		return false
+	}
+	if filter.YearTo != 0 && book.Year > filter.YearTo {
+		return false
+	}
+	if filter.TitlePrefix != "" && !strings.HasPrefix(strings.ToLower(book.Title), strings.ToLower(filter.TitlePrefix)) {
+		return false
+	}
+	if filter.GenreID != uuid.Nil && !slices.ContainsFunc(book.Genres, func(genre uuid.UUID) bool {
+		return r.isDescendant(genre, filter.GenreID)
+	}) {
+		return false
+	}
+	if filter.Tag != "" && !slices.Contains(book.Tags, filter.Tag) {
+		return false
+	}
+	if filter.WorkID != uuid.Nil && (book.WorkID == nil || *book.WorkID != filter.WorkID) {
+		return false
+	}
+	if filter.OnePerWork && book.WorkID != nil {
+		return r.firstEdition(book, filter)
+	}
+	return true
+}
+
+// isDescendant reports whether a genre is the ancestor or one of its subgenres,
+// walking up the tree from the genre.
+func (r *InMemoryRepo) isDescendant(ID uuid.UUID, ancestor uuid.UUID) bool {
+	for {
+		if ID == ancestor {
+			return true
+		}
+		genre, ok := r.genres[ID]
+		if !ok || genre.ParentID == nil {
+			return false
+		}
+		ID = *genre.ParentID
+	}
+}
+
+// firstEdition reports whether the book is the earliest edition of its work among the books matching
+// the filter, the same way as "DISTINCT ON (work_id) ... ORDER BY work_id, created_at, id" does.
+func (r *InMemoryRepo) firstEdition(book models.Book, filter models.BookFilter) bool {
+	filter.OnePerWork = false
+	for _, other := range r.books {
+		if other.DeletedAt != nil || other.WorkID == nil || *other.WorkID != *book.WorkID || !r.matches(other, filter) {
+			continue
+		}
+		if other.CreatedAt.Before(book.CreatedAt) ||
+			other.CreatedAt.Equal(book.CreatedAt) && other.ID.String() < book.ID.String() {
+			return false
+		}
+	}
+	return true
+}
+
+// isbnTaken reports whether a book outside the trash other than the one with the ID has the ISBN,
+// the same way the unique index of the books table does.
+func (r *InMemoryRepo) isbnTaken(isbn string, ID uuid.UUID) bool {
+	if isbn == "" {
+		return false
+	}
+	for _, b := range r.books {
+		if b.ID != ID && b.DeletedAt == nil && b.ISBN == isbn {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCredits returns an error if a credit refers to a missing author.
+func (r *InMemoryRepo) checkCredits(credits []models.BookAuthor) error {
+	for _, credit := range credits {
+		if credit.AuthorID == uuid.Nil {
+			continue
+		}
+		_, exists := r.authors[credit.AuthorID]
+		_, merged := r.redirects[credit.AuthorID]
+		if !exists && !merged {
+			return errs.Validation("author with ID = %s doesn't exist", credit.AuthorID)
+		}
+	}
+	return nil
+}
+
+// checkGenres returns an error if a book is classified under a missing genre.
+func (r *InMemoryRepo) checkGenres(genres []uuid.UUID) error {
+	for _, ID := range genres {
+		if _, ok := r.genres[ID]; !ok {
+			return errs.Validation("genre with ID = %s doesn't exist", ID)
+		}
 	}
-	if new.Author == "" {
-		new.Author = old.Author
+	return nil
+}
+
+// resolveCredits links the credits of the book to authors the same way Repo.resolveCredits does:
+// credits referring to merged authors are redirected, and credits with names only get the author with
+// the preferred name or a variant matching the name, who is created if there is none. Credits get the
+// preferred names of the authors. The credits must have been checked with checkCredits.
+func (r *InMemoryRepo) resolveCredits(book *models.Book, now time.Time) {
+	if len(book.Authors) == 0 {
+		return
+	}
+	credits := make([]models.BookAuthor, len(book.Authors))
+	for i, credit := range book.Authors {
+		if credit.AuthorID == uuid.Nil {
+			if author := r.authorNamed(credit.Name); author != nil {
+				credit.AuthorID = author.ID
+			} else {
+				author := models.Author{ID: uuid.New(), Name: credit.Name, CreatedAt: now, UpdatedAt: now}
+				r.authors[author.ID] = author
+				credit.AuthorID = author.ID
+			}
+		} else if target, ok := r.redirects[credit.AuthorID]; ok {
+			credit.AuthorID = target
+		}
+		credit.Name = r.authors[credit.AuthorID].Name
+		credits[i] = credit
 	}
-	if new.Year == 0 {
-		new.Year = old.Year
+	book.Authors = credits
+	book.Author = models.AuthorText(credits)
+}
+
+// authorNamed returns the author with the preferred name or a variant, ignoring case the way LOWER does,
+// nil if there is none. Preferred names are matched first, then the oldest author wins.
+func (r *InMemoryRepo) authorNamed(name string) *models.Author {
+	key := strings.ToLower(name)
+	var (
+		found     *models.Author
+		preferred bool
+	)
+	for _, author := range r.authors {
+		isPreferred := strings.ToLower(author.Name) == key
+		if !isPreferred && !slices.ContainsFunc(author.Variants, func(variant string) bool {
+			return strings.ToLower(variant) == key
+		}) {
+			continue
+		}
+		if found == nil || isPreferred && !preferred || isPreferred == preferred && (author.CreatedAt.Before(found.CreatedAt) ||
+			author.CreatedAt.Equal(found.CreatedAt) && author.ID.String() < found.ID.String()) {
+			found, preferred = &author, isPreferred
+		}
+	}
+	return found
+}
+
+// satisfying returns the books outside the trash that satisfy the condition.
+func (r *InMemoryRepo) satisfying(condition *models.BookCondition) ([]models.Book, error) {
+	result := make([]models.Book, 0)
+	for _, b := range r.books {
+		if b.DeletedAt != nil {
+			continue
+		}
+		if condition != nil {
+			ok, err := r.satisfies(b, *condition)
+			if err != nil {
+				return nil, errs.Internal(err)
+			}
+			if !ok {
+				continue
+			}
+		}
+		result = append(result, b)
+	}
+	return result, nil
+}
+
+// satisfies reports whether the book satisfies the condition the same way the SQL translation of the condition does.
+func (r *InMemoryRepo) satisfies(book models.Book, condition models.BookCondition) (bool, error) {
+	switch condition.Op {
+	case models.BookConditionAnd, models.BookConditionOr, models.BookConditionNot:
+		if condition.Left == nil || condition.Right == nil {
+			return false, fmt.Errorf("condition %q lacks an operand", condition.Op)
+		}
+		left, err := r.satisfies(book, *condition.Left)
+		if err != nil {
+			return false, err
+		}
+		right, err := r.satisfies(book, *condition.Right)
+		if err != nil {
+			return false, err
+		}
+		switch condition.Op {
+		case models.BookConditionAnd:
+			return left && right, nil
+		case models.BookConditionOr:
+			return left || right, nil
+		default:
+			return left && !right, nil
+		}
+	case models.BookConditionAll:
+		return true, nil
+	case models.BookConditionMatch:
+	default:
+		return false, fmt.Errorf("unknown condition %q", condition.Op)
+	}
+
+	switch value := condition.Value.(type) {
+	case uint16:
+		if condition.Field != models.BookConditionYear {
+			return false, fmt.Errorf("unsupported value %d of %s", value, condition.Field)
+		}
+		switch condition.Relation {
+		case models.BookRelationEqual:
+			return book.Year == value, nil
+		case models.BookRelationNotEqual:
+			return book.Year != value, nil
+		case models.BookRelationLess:
+			return book.Year < value, nil
+		case models.BookRelationLessOrEqual:
+			return book.Year <= value, nil
+		case models.BookRelationGreater:
+			return book.Year > value, nil
+		case models.BookRelationGreaterOrEqual:
+			return book.Year >= value, nil
+		}
+	case string:
+		var field string
+		switch condition.Field {
+		case models.BookConditionTitle:
+			field = book.Title
+		case models.BookConditionAuthor:
+			field = book.Author
+		default:
+			return false, fmt.Errorf("unsupported value %q of %s", value, condition.Field)
+		}
+		switch condition.Relation {
+		case models.BookRelationContains:
+			return strings.Contains(strings.ToLower(field), strings.ToLower(value)), nil
+		case models.BookRelationEqual:
+			return strings.EqualFold(field, value), nil
+		case models.BookRelationNotEqual:
+			return !strings.EqualFold(field, value), nil
+		}
+	default:
+		return false, fmt.Errorf("unsupported value %v of %s", value, condition.Field)
+	}
+	return false, fmt.Errorf("unsupported comparison of %s %s %v", condition.Field, condition.Relation, condition.Value)
+}
+
+// compare orders a book against a keyset position the same way "ORDER BY field, id" does.
+func (r *InMemoryRepo) compare(collator *collate.Collator, book models.Book, position models.BookCursor, query models.BookQuery) int {
+	result := 0
+	switch value := r.sortValue(book, query.SortBy).(type) {
+	case string:
+		other, _ := position.Value.(string)
+		result = r.scriptOrder(value, query.Locale) - r.scriptOrder(other, query.Locale)
+		if result == 0 {
+			result = collator.CompareString(value, other)
+		}
+	case uint16:
+		other, _ := position.Value.(uint16)
+		result = int(value) - int(other)
+	case time.Time:
+		other, _ := position.Value.(time.Time)
+		result = value.Compare(other)
+	}
+	if result == 0 {
+		result = strings.Compare(book.ID.String(), position.ID.String())
+	}
+	if query.Desc {
+		result = -result
+	}
+	return result
+}
+
+// scriptOrder emulates ICU script reordering, which x/text/collate lacks:
+// the Russian collation puts Cyrillic before Latin, the English one uses the root order.
+func (r *InMemoryRepo) scriptOrder(s string, locale models.Locale) int {
+	if locale != models.LocaleRussian {
+		return 0
+	}
+	for _, c := range s {
+		switch {
+		case unicode.Is(unicode.Cyrillic, c):
+			return 0
+		case unicode.IsLetter(c):
+			return 1
+		}
+	}
+	return 0
+}
+
+// sortValue returns the value of the field the books are ordered by.
+func (r *InMemoryRepo) sortValue(book models.Book, sortBy models.BookSortField) any {
+	switch sortBy {
+	case models.BookSortTitle:
+		return book.Title
+	case models.BookSortAuthor:
+		return book.Author
+	case models.BookSortYear:
+		return book.Year
+	default:
+		return book.CreatedAt
 	}
 }
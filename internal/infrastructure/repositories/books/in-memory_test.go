@@ -0,0 +1,74 @@
+package books
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetAllCollation(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryRepo()
+	for _, title := range []string{"Banana", "ёлка", "apple", "Жук", "Еле", "Ёж"} {
+		_, err := repo.Create(ctx, models.Book{ID: uuid.New(), Title: title, Author: "Author", Year: 1900})
+		assert.NoError(t, err)
+	}
+
+	cases := []struct {
+		name string
+
+		query  models.BookQuery
+		titles []string
+	}{
+		{
+			name: "English",
+
+			query:  models.BookQuery{SortBy: models.BookSortTitle, Locale: models.LocaleEnglish},
+			titles: []string{"apple", "Banana", "Ёж", "Еле", "ёлка", "Жук"},
+		},
+		{
+			name: "Russian puts Cyrillic first",
+
+			query:  models.BookQuery{SortBy: models.BookSortTitle, Locale: models.LocaleRussian},
+			titles: []string{"Ёж", "Еле", "ёлка", "Жук", "apple", "Banana"},
+		},
+		{
+			name: "Russian in descending order",
+
+			query:  models.BookQuery{SortBy: models.BookSortTitle, Locale: models.LocaleRussian, Desc: true},
+			titles: []string{"Banana", "apple", "Жук", "ёлка", "Еле", "Ёж"},
+		},
+		{
+			name: "Russian after a Cyrillic title",
+
+			query:  models.BookQuery{SortBy: models.BookSortTitle, Locale: models.LocaleRussian, After: &models.BookCursor{Value: "Жук", ID: uuid.Max}},
+			titles: []string{"apple", "Banana"},
+		},
+		{
+			name: "English after a Latin title",
+
+			query:  models.BookQuery{SortBy: models.BookSortTitle, Locale: models.LocaleEnglish, After: &models.BookCursor{Value: "Banana", ID: uuid.Max}},
+			titles: []string{"Ёж", "Еле", "ёлка", "Жук"},
+		},
+		{
+			name: "Unknown locale uses the English order",
+
+			query:  models.BookQuery{SortBy: models.BookSortTitle},
+			titles: []string{"apple", "Banana", "Ёж", "Еле", "ёлка", "Жук"},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			books, err := repo.GetAll(ctx, testCase.query)
+			assert.NoError(t, err)
+			titles := make([]string, len(books))
+			for i, book := range books {
+				titles[i] = book.Title
+			}
+			assert.Equal(t, testCase.titles, titles)
+		})
+	}
+}
@@ -0,0 +1,340 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/internal/usecases/authors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"slices"
+	"strings"
+	"time"
+)
+
+// AuthorsRepo is a GORM-based implementation of the author repository. It lives next to Repo
+// because renaming and merging authors rewrite the credits and the Author text of books.
+type AuthorsRepo struct {
+	db    *gorm.DB
+	books *Repo
+}
+
+// NewAuthorsRepo creates and returns a new author repository instance using GORM and PostgreSQL.
+func NewAuthorsRepo(db *gorm.DB) authors.Repository {
+	return &AuthorsRepo{db: db, books: &Repo{db: db}}
+}
+
+// GetAll retrieves a page of authors ordered by name using keyset pagination.
+func (r *AuthorsRepo) GetAll(ctx context.Context, query models.AuthorQuery) ([]models.Author, error) {
+	db := r.db.WithContext(ctx).Model(&Author{})
+	if query.NamePrefix != "" {
+		db = db.Where("LOWER(name) LIKE LOWER(?)", likeEscaper.Replace(query.NamePrefix)+"%")
+	}
+	if query.After != nil {
+		db = db.Where("(name, id) > (?, ?)", query.After.Name, query.After.ID)
+	}
+
+	var rows []Author
+	if err := db.Order("name").Order("id").Limit(query.Limit).Find(&rows).Error; err != nil {
+		return nil, r.translateError(err)
+	}
+	result, err := r.fromEntitiesToModels(r.db.WithContext(ctx), rows)
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	return result, nil
+}
+
+// GetOne retrieves an author by their UUID.
+func (r *AuthorsRepo) GetOne(ctx context.Context, ID uuid.UUID) (*models.Author, error) {
+	var author Author
+	if err := r.db.WithContext(ctx).First(&author, "id = ?", ID).Error; err != nil {
+		return nil, r.translateError(err)
+	}
+	return r.toModel(ctx, author)
+}
+
+// GetByName retrieves the author with the preferred name or a variant, ignoring case.
+func (r *AuthorsRepo) GetByName(ctx context.Context, name string) (*models.Author, error) {
+	var rows []Author
+	err := r.db.WithContext(ctx).
+		Where("LOWER(name) = LOWER(?) OR id IN (SELECT author_id FROM author_variants WHERE LOWER(name) = LOWER(?))", name, name).
+		// preferred names first, then the oldest author
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "LOWER(name) = LOWER(?) DESC, created_at, id",
+			Vars:               []any{name},
+			WithoutParentheses: true,
+		}}).
+		Limit(1).
+		Find(&rows).Error
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	if len(rows) == 0 {
+		return nil, errs.NotFound("author named %q doesn't exist", name)
+	}
+	return r.toModel(ctx, rows[0])
+}
+
+// Redirect returns the ID of the author that a merged author has been merged into.
+func (r *AuthorsRepo) Redirect(ctx context.Context, ID uuid.UUID) (uuid.UUID, error) {
+	var redirect AuthorRedirect
+	if err := r.db.WithContext(ctx).First(&redirect, "id = ?", ID).Error; err != nil {
+		return uuid.Nil, r.translateError(err)
+	}
+	return redirect.AuthorID, nil
+}
+
+// Create inserts a new author with their variants into the database and returns them with the timestamps set on insert.
+func (r *AuthorsRepo) Create(ctx context.Context, model models.Author) (*models.Author, error) {
+	author := r.fromModelToEntity(model)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&author).Error; err != nil {
+			return err
+		}
+		return r.saveVariants(tx, author.ID, model.Variants)
+	})
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	created := r.fromEntityToModel(author)
+	created.Variants = model.Variants
+	return &created, nil
+}
+
+// Update replaces the names of an author. If the preferred name changes, the books crediting the author,
+// the ones in the trash included, get the new Author text and a new version in the same transaction.
+func (r *AuthorsRepo) Update(ctx context.Context, ID uuid.UUID, model models.Author) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing Author
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&existing, "id = ?", ID).Error; err != nil {
+			return err
+		}
+		author := r.fromModelToEntity(model)
+		author.CreatedAt = existing.CreatedAt
+		if err := tx.Save(&author).Error; err != nil {
+			return err
+		}
+		if err := r.saveVariants(tx, ID, model.Variants); err != nil {
+			return err
+		}
+		if author.Name == existing.Name {
+			return nil
+		}
+		return r.recredit(tx, []uuid.UUID{ID}, author)
+	})
+	return r.translateError(err)
+}
+
+// Delete removes an author who isn't credited for any book.
+func (r *AuthorsRepo) Delete(ctx context.Context, ID uuid.UUID) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var credits int64
+		if err := tx.Model(&BookAuthor{}).Where("author_id = ?", ID).Count(&credits).Error; err != nil {
+			return err
+		}
+		if credits > 0 {
+			return r.credited(ID)
+		}
+		res := tx.Where("id = ?", ID).Delete(&Author{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errs.NotFound("author with ID = %s doesn't exist", ID)
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		// a book has credited the author after the check
+		return r.credited(ID)
+	}
+	return r.translateError(err)
+}
+
+// Merge repoints the credits of the duplicates to the author, adds their names to the variants of the author
+// and replaces the duplicates with redirects to it, including the redirects of authors merged into the duplicates.
+func (r *AuthorsRepo) Merge(ctx context.Context, ID uuid.UUID, duplicates []uuid.UUID) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var target Author
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&target, "id = ?", ID).Error; err != nil {
+			return err
+		}
+		var rows []Author
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", duplicates).Find(&rows).Error; err != nil {
+			return err
+		}
+		for _, duplicate := range duplicates {
+			if !slices.ContainsFunc(rows, func(author Author) bool { return author.ID == duplicate }) {
+				return errs.NotFound("author with ID = %s doesn't exist", duplicate)
+			}
+		}
+
+		// the names of the duplicates become variants of the author
+		named, err := r.fromEntitiesToModels(tx, append([]Author{target}, rows...))
+		if err != nil {
+			return err
+		}
+		variants := named[0].Variants
+		for _, duplicate := range named[1:] {
+			for _, name := range append([]string{duplicate.Name}, duplicate.Variants...) {
+				if !strings.EqualFold(name, target.Name) && !slices.ContainsFunc(variants, func(variant string) bool {
+					return strings.EqualFold(variant, name)
+				}) {
+					variants = append(variants, name)
+				}
+			}
+		}
+		if err := r.saveVariants(tx, ID, variants); err != nil {
+			return err
+		}
+
+		if err := r.recredit(tx, duplicates, target); err != nil {
+			return err
+		}
+		err = tx.Model(&AuthorRedirect{}).Where("author_id IN ?", duplicates).Update("author_id", ID).Error
+		if err != nil {
+			return err
+		}
+		redirects := make([]AuthorRedirect, len(duplicates))
+		for i, duplicate := range duplicates {
+			redirects[i] = AuthorRedirect{ID: duplicate, AuthorID: ID}
+		}
+		if err := tx.Create(&redirects).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id IN ?", duplicates).Delete(&Author{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&target).Update("updated_at", time.Now()).Error
+	})
+	return r.translateError(err)
+}
+
+// recredit makes the books crediting any of the authors credit the author instead, under the author's
+// preferred name. A book crediting several of them in the same role keeps the first credit.
+// The books, the ones in the trash included, get the new Author text and a new version.
+func (r *AuthorsRepo) recredit(tx *gorm.DB, from []uuid.UUID, to Author) error {
+	var rows []Book
+	err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN (SELECT book_id FROM book_authors WHERE author_id IN ?)", from).
+		Order("id").
+		Find(&rows).Error
+	if err != nil {
+		return err
+	}
+	books, err := r.books.fromEntitiesToModels(tx, rows)
+	if err != nil {
+		return err
+	}
+	for i := range books {
+		credits := make([]models.BookAuthor, 0, len(books[i].Authors))
+		for _, credit := range books[i].Authors {
+			if slices.Contains(from, credit.AuthorID) {
+				credit.AuthorID, credit.Name = to.ID, to.Name
+			}
+			if !slices.Contains(credits, credit) {
+				credits = append(credits, credit)
+			}
+		}
+		books[i].Authors = credits
+		rows[i].Author = models.AuthorText(credits)
+		rows[i].Version++
+		if err := tx.Unscoped().Save(&rows[i]).Error; err != nil {
+			return err
+		}
+	}
+	return r.books.saveCredits(tx, books)
+}
+
+// saveVariants replaces the stored variants of an author.
+func (r *AuthorsRepo) saveVariants(tx *gorm.DB, ID uuid.UUID, variants []string) error {
+	if err := tx.Where("author_id = ?", ID).Delete(&AuthorVariant{}).Error; err != nil {
+		return err
+	}
+	if len(variants) == 0 {
+		return nil
+	}
+	rows := make([]AuthorVariant, len(variants))
+	for i, name := range variants {
+		rows[i] = AuthorVariant{AuthorID: ID, Name: name}
+	}
+	return tx.Create(&rows).Error
+}
+
+// credited returns the error of deleting an author who is credited for books.
+func (r *AuthorsRepo) credited(ID uuid.UUID) error {
+	return errs.Conflict("author with ID = %s is credited for books", ID)
+}
+
+// translateError converts GORM errors to domain errors.
+func (r *AuthorsRepo) translateError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, new(*errs.Error)):
+		return err // already translated inside a transaction
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return errs.NotFound("author doesn't exist")
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return errs.Conflict("author with the same ID already exists")
+	default:
+		return errs.Internal(err)
+	}
+}
+
+// toModel converts a single entity to a model along with the variants.
+func (r *AuthorsRepo) toModel(ctx context.Context, entity Author) (*models.Author, error) {
+	result, err := r.fromEntitiesToModels(r.db.WithContext(ctx), []Author{entity})
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	return &result[0], nil
+}
+
+// fromEntitiesToModels converts entities to models along with their variants, which are loaded with a single query.
+func (r *AuthorsRepo) fromEntitiesToModels(db *gorm.DB, entities []Author) ([]models.Author, error) {
+	result := make([]models.Author, len(entities))
+	IDs := make([]uuid.UUID, len(entities))
+	positions := make(map[uuid.UUID]int, len(entities))
+	for i, entity := range entities {
+		result[i] = r.fromEntityToModel(entity)
+		IDs[i] = entity.ID
+		positions[entity.ID] = i
+	}
+	if len(entities) == 0 {
+		return result, nil
+	}
+
+	var rows []AuthorVariant
+	if err := db.Where("author_id IN ?", IDs).Order("author_id").Order("name").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		author := &result[positions[row.AuthorID]]
+		author.Variants = append(author.Variants, row.Name)
+	}
+	return result, nil
+}
+
+// fromEntityToModel converts an entity to the model without the variants.
+func (r *AuthorsRepo) fromEntityToModel(entity Author) models.Author {
+	return models.Author{
+		ID:        entity.ID,
+		Name:      entity.Name,
+		CreatedAt: entity.CreatedAt,
+		UpdatedAt: entity.UpdatedAt,
+	}
+}
+
+// fromModelToEntity converts the model to an entity; the variants are stored separately.
+func (r *AuthorsRepo) fromModelToEntity(model models.Author) Author {
+	return Author{
+		ID:        model.ID,
+		Name:      model.Name,
+		CreatedAt: model.CreatedAt,
+		UpdatedAt: model.UpdatedAt,
+	}
+}
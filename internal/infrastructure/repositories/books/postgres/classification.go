@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// checkGenres returns an error if a book is classified under a missing genre.
+func (r *Repo) checkGenres(tx *gorm.DB, batch []models.Book) error {
+	var IDs []uuid.UUID
+	for _, book := range batch {
+		IDs = append(IDs, book.Genres...)
+	}
+	if len(IDs) == 0 {
+		return nil
+	}
+	var existing []uuid.UUID
+	if err := tx.Model(&Genre{}).Where("id IN ?", IDs).Pluck("id", &existing).Error; err != nil {
+		return err
+	}
+	known := make(map[uuid.UUID]struct{}, len(existing))
+	for _, ID := range existing {
+		known[ID] = struct{}{}
+	}
+	for _, ID := range IDs {
+		if _, ok := known[ID]; !ok {
+			return errs.Validation("genre with ID = %s doesn't exist", ID)
+		}
+	}
+	return nil
+}
+
+// saveClassification replaces the stored genres and tags of books.
+func (r *Repo) saveClassification(tx *gorm.DB, batch []models.Book) error {
+	IDs := make([]uuid.UUID, len(batch))
+	var (
+		genres []BookGenre
+		tags   []BookTag
+	)
+	for i, book := range batch {
+		IDs[i] = book.ID
+		for _, genre := range book.Genres {
+			genres = append(genres, BookGenre{BookID: book.ID, GenreID: genre})
+		}
+		for _, tag := range book.Tags {
+			tags = append(tags, BookTag{BookID: book.ID, Tag: tag})
+		}
+	}
+	if err := tx.Where("book_id IN ?", IDs).Delete(&BookGenre{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("book_id IN ?", IDs).Delete(&BookTag{}).Error; err != nil {
+		return err
+	}
+	if len(genres) > 0 {
+		if err := tx.CreateInBatches(&genres, insertBatchSize).Error; err != nil {
+			return err
+		}
+	}
+	if len(tags) > 0 {
+		return tx.CreateInBatches(&tags, insertBatchSize).Error
+	}
+	return nil
+}
+
+// loadClassification fills in the genres and the tags of books with a query per table.
+// Both are ordered the way the usecase sorts them, tags byte-wise.
+func (r *Repo) loadClassification(db *gorm.DB, books []models.Book, positions map[uuid.UUID]int) error {
+	IDs := make([]uuid.UUID, len(books))
+	for i, book := range books {
+		IDs[i] = book.ID
+	}
+	var genres []BookGenre
+	if err := db.Where("book_id IN ?", IDs).Order("book_id, genre_id").Find(&genres).Error; err != nil {
+		return err
+	}
+	for _, row := range genres {
+		book := &books[positions[row.BookID]]
+		book.Genres = append(book.Genres, row.GenreID)
+	}
+	var tags []BookTag
+	if err := db.Where("book_id IN ?", IDs).Order(`book_id, tag COLLATE "C"`).Find(&tags).Error; err != nil {
+		return err
+	}
+	for _, row := range tags {
+		book := &books[positions[row.BookID]]
+		book.Tags = append(book.Tags, row.Tag)
+	}
+	return nil
+}
+
+// Facets counts books matching the filter by genre, with the books of subgenres included, by tag and by decade.
+func (r *Repo) Facets(ctx context.Context, filter models.BookFilter) (*models.BookFacets, error) {
+	db := r.db.WithContext(ctx)
+	matching := r.applyFilter(db.Model(&Book{}), filter).Select("id")
+
+	var genres []genreFacetRow
+	err := db.Raw(`SELECT genres.id, genres.name, genres.parent_id, COUNT(DISTINCT book_genres.book_id) AS count
+		FROM book_genres
+		JOIN genre_closure ON genre_closure.descendant_id = book_genres.genre_id
+		JOIN genres ON genres.id = genre_closure.ancestor_id
+		WHERE book_genres.book_id IN (?)
+		GROUP BY genres.id
+		ORDER BY genres.name, genres.id`, matching).
+		Scan(&genres).Error
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	var tags []tagCountRow
+	err = db.Raw(`SELECT tag, COUNT(*) AS count FROM book_tags
+		WHERE book_id IN (?)
+		GROUP BY tag
+		ORDER BY count DESC, tag COLLATE "C"
+		LIMIT ?`, matching, models.MaxTagFacets).
+		Scan(&tags).Error
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	var decades []decadeFacetRow
+	err = r.applyFilter(db.Model(&Book{}), filter).
+		Select("year / 10 * 10 AS decade, COUNT(*) AS count").
+		Group("decade").
+		Order("decade").
+		Scan(&decades).Error
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+
+	facets := &models.BookFacets{
+		Genres:  make([]models.GenreFacet, len(genres)),
+		Tags:    make([]models.TagFacet, len(tags)),
+		Decades: make([]models.DecadeFacet, len(decades)),
+	}
+	for i, row := range genres {
+		facets.Genres[i] = models.GenreFacet{ID: row.ID, Name: row.Name, ParentID: row.ParentID, Count: row.Count}
+	}
+	for i, row := range tags {
+		facets.Tags[i] = models.TagFacet{Tag: row.Tag, Count: row.Count}
+	}
+	for i, row := range decades {
+		facets.Decades[i] = models.DecadeFacet{Decade: row.Decade, Count: row.Count}
+	}
+	return facets, nil
+}
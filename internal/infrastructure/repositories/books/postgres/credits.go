@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// resolveCredits links the credits of books to authors: credits referring to merged authors by ID are
+// redirected to the authors they have been merged into, and credits with names only get the author
+// with the preferred name or a variant matching the name, who is created if there is none.
+// Credits get the preferred names of the authors, and the Author text of the books is built from them.
+func (r *Repo) resolveCredits(tx *gorm.DB, batch []models.Book) error {
+	var (
+		IDs  []uuid.UUID
+		keys []string
+	)
+	for _, book := range batch {
+		for _, credit := range book.Authors {
+			if credit.AuthorID != uuid.Nil {
+				IDs = append(IDs, credit.AuthorID)
+			} else {
+				keys = append(keys, strings.ToLower(credit.Name))
+			}
+		}
+	}
+
+	redirects := make(map[uuid.UUID]uuid.UUID)
+	if len(IDs) > 0 {
+		var rows []AuthorRedirect
+		if err := tx.Where("id IN ?", IDs).Find(&rows).Error; err != nil {
+			return err
+		}
+		for _, redirect := range rows {
+			redirects[redirect.ID] = redirect.AuthorID
+		}
+	}
+	byKey := make(map[string]uuid.UUID, len(keys))
+	names := make(map[uuid.UUID]string)
+	if len(keys) > 0 {
+		var rows []nameMatch
+		err := tx.Raw(`SELECT DISTINCT ON (key) key, id FROM (
+				SELECT LOWER(name) AS key, id, created_at, 0 AS rank FROM authors WHERE LOWER(name) IN ?
+				UNION ALL
+				SELECT LOWER(author_variants.name), authors.id, authors.created_at, 1
+				FROM author_variants JOIN authors ON authors.id = author_variants.author_id
+				WHERE LOWER(author_variants.name) IN ?
+			) AS matches ORDER BY key, rank, created_at, id`, keys, keys).
+			Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+		for _, match := range rows {
+			byKey[match.Key] = match.ID
+		}
+
+		now := time.Now()
+		var missing []Author
+		for _, book := range batch {
+			for _, credit := range book.Authors {
+				key := strings.ToLower(credit.Name)
+				if _, ok := byKey[key]; credit.AuthorID != uuid.Nil || ok {
+					continue
+				}
+				author := Author{ID: uuid.New(), Name: credit.Name, CreatedAt: now, UpdatedAt: now}
+				byKey[key] = author.ID
+				names[author.ID] = author.Name
+				missing = append(missing, author)
+			}
+		}
+		if len(missing) > 0 {
+			if err := tx.CreateInBatches(&missing, insertBatchSize).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	var known []uuid.UUID
+	for _, ID := range IDs {
+		if target, ok := redirects[ID]; ok {
+			ID = target
+		}
+		known = append(known, ID)
+	}
+	for _, ID := range byKey {
+		if _, ok := names[ID]; !ok {
+			known = append(known, ID)
+		}
+	}
+	if len(known) > 0 {
+		var rows []Author
+		if err := tx.Where("id IN ?", known).Find(&rows).Error; err != nil {
+			return err
+		}
+		for _, author := range rows {
+			names[author.ID] = author.Name
+		}
+	}
+
+	for i := range batch {
+		credits := make([]models.BookAuthor, len(batch[i].Authors))
+		for k, credit := range batch[i].Authors {
+			if credit.AuthorID == uuid.Nil {
+				credit.AuthorID = byKey[strings.ToLower(credit.Name)]
+			} else if target, ok := redirects[credit.AuthorID]; ok {
+				credit.AuthorID = target
+			}
+			name, ok := names[credit.AuthorID]
+			if !ok {
+				return errs.Validation("author with ID = %s doesn't exist", credit.AuthorID)
+			}
+			credit.Name = name
+			credits[k] = credit
+		}
+		batch[i].Authors = credits
+		if len(credits) > 0 {
+			batch[i].Author = models.AuthorText(credits)
+		}
+	}
+	return nil
+}
+
+// saveCredits replaces the stored credits of books with the resolved ones.
+func (r *Repo) saveCredits(tx *gorm.DB, batch []models.Book) error {
+	IDs := make([]uuid.UUID, len(batch))
+	var rows []BookAuthor
+	for i, book := range batch {
+		IDs[i] = book.ID
+		for position, credit := range book.Authors {
+			rows = append(rows, BookAuthor{BookID: book.ID, Position: position, AuthorID: credit.AuthorID, Role: string(credit.Role)})
+		}
+	}
+	if err := tx.Where("book_id IN ?", IDs).Delete(&BookAuthor{}).Error; err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(&rows, insertBatchSize).Error
+}
+
+// fromEntitiesToModels converts entities to models along with their credits, which are loaded with a single query,
+// and their genres and tags.
+func (r *Repo) fromEntitiesToModels(db *gorm.DB, entities []Book) ([]models.Book, error) {
+	result := make([]models.Book, len(entities))
+	IDs := make([]uuid.UUID, len(entities))
+	positions := make(map[uuid.UUID]int, len(entities))
+	for i, entity := range entities {
+		result[i] = r.fromEntityToModel(entity)
+		IDs[i] = entity.ID
+		positions[entity.ID] = i
+	}
+	if len(entities) == 0 {
+		return result, nil
+	}
+
+	var rows []creditRow
+	err := db.Table("book_authors").
+		Select("book_authors.book_id, book_authors.author_id, authors.name, book_authors.role").
+		Joins("JOIN authors ON authors.id = book_authors.author_id").
+		Where("book_authors.book_id IN ?", IDs).
+		Order("book_authors.book_id, book_authors.position").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		book := &result[positions[row.BookID]]
+		book.Authors = append(book.Authors, models.BookAuthor{AuthorID: row.AuthorID, Name: row.Name, Role: models.AuthorRole(row.Role)})
+	}
+	if err := r.loadClassification(db, result, positions); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
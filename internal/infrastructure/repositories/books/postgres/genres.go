@@ -0,0 +1,236 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/internal/usecases/genres"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// GenresRepo is a GORM-based implementation of the genre repository. The taxonomy is kept
+// in the genres table along with its closure table, genre_closure, which links every genre
+// to all of its ancestors so that subtrees are found without recursion.
+type GenresRepo struct {
+	db *gorm.DB
+}
+
+// NewGenresRepo creates and returns a new genre repository instance using GORM and PostgreSQL.
+func NewGenresRepo(db *gorm.DB) genres.Repository {
+	return &GenresRepo{db: db}
+}
+
+// GetAll retrieves all genres ordered by name.
+func (r *GenresRepo) GetAll(ctx context.Context) ([]models.Genre, error) {
+	var rows []Genre
+	if err := r.db.WithContext(ctx).Order("name").Order("id").Find(&rows).Error; err != nil {
+		return nil, r.translateError(err)
+	}
+	result := make([]models.Genre, len(rows))
+	for i, row := range rows {
+		result[i] = r.fromEntityToModel(row)
+	}
+	return result, nil
+}
+
+// GetOne retrieves a genre by its UUID.
+func (r *GenresRepo) GetOne(ctx context.Context, ID uuid.UUID) (*models.Genre, error) {
+	var genre Genre
+	if err := r.db.WithContext(ctx).First(&genre, "id = ?", ID).Error; err != nil {
+		return nil, r.translateError(err)
+	}
+	model := r.fromEntityToModel(genre)
+	return &model, nil
+}
+
+// Create inserts a new genre along with its closure rows: one for the genre itself
+// and one for every ancestor of the parent.
+func (r *GenresRepo) Create(ctx context.Context, model models.Genre) (*models.Genre, error) {
+	genre := r.fromModelToEntity(model)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if genre.ParentID != nil {
+			if err := r.lockParent(tx, *genre.ParentID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&genre).Error; err != nil {
+			return err
+		}
+		return r.link(tx, genre.ID, genre.ParentID)
+	})
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	created := r.fromEntityToModel(genre)
+	return &created, nil
+}
+
+// Update renames a genre and, if the parent changes, moves the subtree of the genre under the new parent
+// by replacing the closure rows linking the subtree to its former ancestors.
+func (r *GenresRepo) Update(ctx context.Context, ID uuid.UUID, model models.Genre) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var genre Genre
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&genre, "id = ?", ID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errs.NotFound("genre with ID = %s doesn't exist", ID)
+		}
+		if err != nil {
+			return err
+		}
+
+		moved := !sameGenre(genre.ParentID, model.ParentID)
+		if moved {
+			// concurrent moves could make a cycle of genres each checked against the other's old place
+			if err := tx.Exec("LOCK TABLE genre_closure IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+				return err
+			}
+		}
+		if moved && model.ParentID != nil {
+			if err := r.lockParent(tx, *model.ParentID); err != nil {
+				return err
+			}
+			var descendant int64
+			err := tx.Model(&GenreClosure{}).
+				Where("ancestor_id = ? AND descendant_id = ?", ID, *model.ParentID).
+				Count(&descendant).Error
+			if err != nil {
+				return err
+			}
+			if descendant > 0 {
+				return errs.InvalidFields(errs.FieldError{Field: "parent_id", Message: "must not be the genre or its subgenre"})
+			}
+		}
+
+		err = tx.Model(&genre).Updates(map[string]any{
+			"name":       model.Name,
+			"parent_id":  model.ParentID,
+			"updated_at": time.Now(),
+		}).Error
+		if err != nil || !moved {
+			return err
+		}
+		err = tx.Exec(`DELETE FROM genre_closure
+			WHERE descendant_id IN (SELECT descendant_id FROM genre_closure WHERE ancestor_id = ?)
+			AND ancestor_id NOT IN (SELECT descendant_id FROM genre_closure WHERE ancestor_id = ?)`, ID, ID).Error
+		if err != nil || model.ParentID == nil {
+			return err
+		}
+		return tx.Exec(`INSERT INTO genre_closure (ancestor_id, descendant_id, depth)
+			SELECT above.ancestor_id, below.descendant_id, above.depth + below.depth + 1
+			FROM genre_closure AS above CROSS JOIN genre_closure AS below
+			WHERE above.descendant_id = ? AND below.ancestor_id = ?`, *model.ParentID, ID).Error
+	})
+	return r.translateError(err)
+}
+
+// Delete removes a genre that has neither subgenres nor books; its closure rows go along with it.
+func (r *GenresRepo) Delete(ctx context.Context, ID uuid.UUID) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var children int64
+		if err := tx.Model(&Genre{}).Where("parent_id = ?", ID).Count(&children).Error; err != nil {
+			return err
+		}
+		if children > 0 {
+			return errs.Conflict("genre with ID = %s has subgenres", ID)
+		}
+		var books int64
+		if err := tx.Model(&BookGenre{}).Where("genre_id = ?", ID).Count(&books).Error; err != nil {
+			return err
+		}
+		if books > 0 {
+			return r.hasBooks(ID)
+		}
+		res := tx.Where("id = ?", ID).Delete(&Genre{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errs.NotFound("genre with ID = %s doesn't exist", ID)
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		// a subgenre or a book has been added after the check
+		return errs.Conflict("genre with ID = %s has subgenres or books", ID)
+	}
+	return r.translateError(err)
+}
+
+// lockParent share-locks the parent of a genre, so that it isn't deleted before the genre is saved.
+func (r *GenresRepo) lockParent(tx *gorm.DB, ID uuid.UUID) error {
+	var parent Genre
+	err := tx.Clauses(clause.Locking{Strength: "SHARE"}).First(&parent, "id = ?", ID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errs.InvalidFields(errs.FieldError{Field: "parent_id", Message: "must refer to an existing genre"})
+	}
+	return err
+}
+
+// link adds the closure rows of a new genre.
+func (r *GenresRepo) link(tx *gorm.DB, ID uuid.UUID, parentID *uuid.UUID) error {
+	if err := tx.Create(&GenreClosure{AncestorID: ID, DescendantID: ID}).Error; err != nil {
+		return err
+	}
+	if parentID == nil {
+		return nil
+	}
+	return tx.Exec(`INSERT INTO genre_closure (ancestor_id, descendant_id, depth)
+		SELECT ancestor_id, ?, depth + 1 FROM genre_closure WHERE descendant_id = ?`, ID, *parentID).Error
+}
+
+// hasBooks returns the error of deleting a genre books are classified under.
+func (r *GenresRepo) hasBooks(ID uuid.UUID) error {
+	return errs.Conflict("genre with ID = %s has books", ID)
+}
+
+// sameGenre reports whether two optional genre references are equal.
+func sameGenre(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// translateError converts GORM errors to domain errors.
+func (r *GenresRepo) translateError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, new(*errs.Error)):
+		return err // already translated inside a transaction
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return errs.NotFound("genre doesn't exist")
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return errs.Conflict("genre with the same name already exists under the parent")
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return errs.Conflict("parent genre has been deleted meanwhile")
+	default:
+		return errs.Internal(err)
+	}
+}
+
+// fromEntityToModel converts an entity to a model (to the business logic layer from the db layer)
+func (r *GenresRepo) fromEntityToModel(entity Genre) models.Genre {
+	return models.Genre{
+		ID:        entity.ID,
+		Name:      entity.Name,
+		ParentID:  entity.ParentID,
+		CreatedAt: entity.CreatedAt,
+		UpdatedAt: entity.UpdatedAt,
+	}
+}
+
+// fromModelToEntity converts a model to an entity (from the business logic layer to the db layer)
+func (r *GenresRepo) fromModelToEntity(model models.Genre) Genre {
+	return Genre{
+		ID:        model.ID,
+		Name:      model.Name,
+		ParentID:  model.ParentID,
+		CreatedAt: model.CreatedAt,
+		UpdatedAt: model.UpdatedAt,
+	}
+}
@@ -2,10 +2,20 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/KinitaL/testovoye/internal/errs"
 	"github.com/KinitaL/testovoye/internal/models"
 	"github.com/KinitaL/testovoye/internal/usecases/books"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Repo is a GORM-based implementation of the book repository.
@@ -18,96 +28,725 @@ func NewPostgresRepo(db *gorm.DB) books.Repository {
 	return &Repo{db: db}
 }
 
-// GetAll retrieves all books from the database.
-func (r *Repo) GetAll(ctx context.Context) ([]models.Book, error) {
+// sortColumns maps sort fields to the columns of the books table.
+var sortColumns = map[models.BookSortField]string{
+	models.BookSortTitle:     "title",
+	models.BookSortAuthor:    "author",
+	models.BookSortYear:      "year",
+	models.BookSortCreatedAt: "created_at",
+}
+
+// insertBatchSize is the number of rows inserted by a single statement, which keeps it below the limit of 65535 parameters.
+const insertBatchSize = 1000
+
+// exportFetchSize is the number of rows fetched from the export cursor at once.
+const exportFetchSize = 500
+
+// collations maps locales to ICU collations shipped with PostgreSQL.
+var collations = map[models.Locale]string{
+	models.LocaleEnglish: `"en-x-icu"`,
+	models.LocaleRussian: `"ru-x-icu"`,
+}
+
+// GetAll retrieves a page of books using keyset pagination.
+func (r *Repo) GetAll(ctx context.Context, query models.BookQuery) ([]models.Book, error) {
+	column, ok := sortColumns[query.SortBy]
+	if !ok {
+		column = sortColumns[models.BookSortCreatedAt]
+	}
+	if collation, ok := collations[query.Locale]; ok && (query.SortBy == models.BookSortTitle || query.SortBy == models.BookSortAuthor) {
+		column += " COLLATE " + collation
+	}
+	direction, op := "ASC", ">"
+	if query.Desc {
+		direction, op = "DESC", "<"
+	}
+
+	db := r.applyFilter(r.db.WithContext(ctx).Model(&Book{}), query.BookFilter)
+	if query.After != nil {
+		db = db.Where(fmt.Sprintf("(%s, id) %s (?, ?)", column, op), query.After.Value, query.After.ID)
+	}
+
 	var rows []Book
-	if err := r.db.WithContext(ctx).Find(&rows).Error; err != nil {
+	err := db.Order(column + " " + direction).
+		Order("id " + direction).
+		Limit(query.Limit).
+		Find(&rows).Error
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	result, err := r.fromEntitiesToModels(r.db.WithContext(ctx), rows)
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	return result, nil
+}
+
+// Count returns the number of books matching the filter.
+func (r *Repo) Count(ctx context.Context, filter models.BookFilter) (int64, error) {
+	var total int64
+	if err := r.applyFilter(r.db.WithContext(ctx).Model(&Book{}), filter).Count(&total).Error; err != nil {
+		return 0, r.translateError(err)
+	}
+	return total, nil
+}
+
+// Export yields books matching the filter from a server-side cursor, fetching exportFetchSize rows at a time.
+// The cursor lives in a read-only transaction that stays open until the sequence is over.
+func (r *Repo) Export(ctx context.Context, filter models.BookFilter) iter.Seq2[models.Book, error] {
+	return func(yield func(models.Book, error) bool) {
+		stopped := false
+		err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			stmt := r.applyFilter(tx.Session(&gorm.Session{DryRun: true}).Model(&Book{}), filter).
+				Select("id, title, author, year, isbn, publisher, language, format, work_id, version, created_at, updated_at, deleted_at").
+				Order("created_at, id").
+				Find(&[]Book{}).Statement
+			if err := tx.Exec("DECLARE books_export NO SCROLL CURSOR FOR "+stmt.SQL.String(), stmt.Vars...).Error; err != nil {
+				return err
+			}
+
+			fetch := fmt.Sprintf("FETCH FORWARD %d FROM books_export", exportFetchSize)
+			for {
+				var rows []Book
+				if err := tx.Raw(fetch).Scan(&rows).Error; err != nil {
+					return err
+				}
+				books, err := r.fromEntitiesToModels(tx, rows)
+				if err != nil {
+					return err
+				}
+				for _, book := range books {
+					if !yield(book, nil) {
+						stopped = true
+						return nil
+					}
+				}
+				if len(rows) < exportFetchSize {
+					return nil
+				}
+			}
+		}, &sql.TxOptions{ReadOnly: true})
+		if err != nil && !stopped {
+			yield(models.Book{}, r.translateError(err))
+		}
+	}
+}
+
+// Search performs ranked full-text search over titles and authors.
+// The query is expected to be normalized with textnorm.Key, the same way search_text is.
+func (r *Repo) Search(ctx context.Context, query models.BookSearchQuery) ([]models.BookSearchHit, error) {
+	var rows []searchRow
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		db := tx.Table("books, websearch_to_tsquery('simple', ?) AS q", query.Query)
+		if query.Fuzzy {
+			if err := r.setSimilarityThreshold(tx, query.Threshold); err != nil {
+				return err
+			}
+			db = db.Select(`books.id, books.title, books.author, books.year, books.isbn, books.publisher, books.language, books.format,
+				books.work_id, books.version, books.created_at, books.updated_at,
+				ts_rank_cd(books.search_vector, q) +
+				GREATEST(word_similarity(?, books.title_key), word_similarity(?, books.author_key)) AS rank`,
+				query.Query, query.Query).
+				Where("books.deleted_at IS NULL AND (books.search_vector @@ q OR ? <% books.title_key OR ? <% books.author_key)",
+					query.Query, query.Query)
+		} else {
+			db = db.Select(`books.id, books.title, books.author, books.year, books.isbn, books.publisher, books.language, books.format,
+				books.work_id, books.version, books.created_at, books.updated_at,
+				ts_rank_cd(books.search_vector, q) AS rank`).
+				Where("books.deleted_at IS NULL AND books.search_vector @@ q")
+		}
+		return db.Order("rank DESC, books.id").
+			Limit(query.Limit).
+			Offset(query.Offset).
+			Scan(&rows).Error
+	})
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+
+	entities := make([]Book, len(rows))
+	for i, row := range rows {
+		entities[i] = row.Book
+	}
+	books, err := r.fromEntitiesToModels(r.db.WithContext(ctx), entities)
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	result := make([]models.BookSearchHit, len(rows))
+	for i, row := range rows {
+		result[i] = models.BookSearchHit{
+			Book: books[i],
+			Rank: row.Rank,
+		}
+	}
+	return result, nil
+}
+
+// Suggest returns known titles and authors spelled similarly to the query, closest first.
+// The query is expected to be normalized with textnorm.Key.
+func (r *Repo) Suggest(ctx context.Context, query models.BookSearchQuery) ([]models.BookSuggestion, error) {
+	var rows []suggestionRow
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := r.setSimilarityThreshold(tx, query.Threshold); err != nil {
+			return err
+		}
+		return tx.Raw(`
+			SELECT text, field, score FROM (
+				(SELECT DISTINCT ON (author_key) author AS text, 'author' AS field, word_similarity(?, author_key) AS score
+				FROM books WHERE deleted_at IS NULL AND ? <% author_key)
+				UNION ALL
+				(SELECT DISTINCT ON (title_key) title AS text, 'title' AS field, word_similarity(?, title_key) AS score
+				FROM books WHERE deleted_at IS NULL AND ? <% title_key)
+			) AS suggestions
+			ORDER BY score DESC, text
+			LIMIT ?`,
+			query.Query, query.Query, query.Query, query.Query, query.Limit,
+		).Scan(&rows).Error
+	})
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+
+	result := make([]models.BookSuggestion, len(rows))
+	for i, row := range rows {
+		result[i] = models.BookSuggestion{
+			Text:  row.Text,
+			Field: models.BookSuggestionField(row.Field),
+			Score: row.Score,
+		}
+	}
+	return result, nil
+}
+
+// setSimilarityThreshold sets the threshold of the <% operator until the end of the transaction.
+// Unlike comparing word_similarity() with a parameter, the operator can use trigram indexes.
+func (r *Repo) setSimilarityThreshold(tx *gorm.DB, threshold float64) error {
+	return tx.Exec("SELECT set_config('pg_trgm.word_similarity_threshold', ?, true)",
+		strconv.FormatFloat(threshold, 'f', -1, 64)).Error
+}
+
+// Match retrieves a page of books satisfying the condition in the order of creation.
+func (r *Repo) Match(ctx context.Context, query models.BookMatchQuery) ([]models.Book, error) {
+	db, err := r.applyCondition(r.db.WithContext(ctx).Model(&Book{}), query.Condition)
+	if err != nil {
 		return nil, err
 	}
-	result := make([]models.Book, len(rows))
-	for i, book := range rows {
-		result[i] = r.fromEntityToModel(book)
+
+	var rows []Book
+	err = db.Order("created_at").
+		Order("id").
+		Limit(query.Limit).
+		Offset(query.Offset).
+		Find(&rows).Error
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	result, err := r.fromEntitiesToModels(r.db.WithContext(ctx), rows)
+	if err != nil {
+		return nil, r.translateError(err)
 	}
 	return result, nil
 }
 
+// CountMatches returns the number of books satisfying the condition.
+func (r *Repo) CountMatches(ctx context.Context, condition *models.BookCondition) (int64, error) {
+	db, err := r.applyCondition(r.db.WithContext(ctx).Model(&Book{}), condition)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return 0, r.translateError(err)
+	}
+	return total, nil
+}
+
 // GetOne retrieves a single book by its UUID.
 func (r *Repo) GetOne(ctx context.Context, ID uuid.UUID) (*models.Book, error) {
 	var book Book
 	err := r.db.WithContext(ctx).First(&book, "id = ?", ID).Error
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	return r.toModel(ctx, book)
+}
+
+// GetByISBN retrieves the book outside the trash that has the ISBN-13.
+func (r *Repo) GetByISBN(ctx context.Context, isbn string) (*models.Book, error) {
+	var book Book
+	if err := r.db.WithContext(ctx).First(&book, "isbn = ?", isbn).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errs.NotFound("book with ISBN %s doesn't exist", isbn)
+		}
+		return nil, r.translateError(err)
+	}
+	return r.toModel(ctx, book)
+}
+
+// GetAny retrieves a single book by its UUID, including a soft-deleted one.
+func (r *Repo) GetAny(ctx context.Context, ID uuid.UUID) (*models.Book, error) {
+	var book Book
+	err := r.db.WithContext(ctx).Unscoped().First(&book, "id = ?", ID).Error
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	return r.toModel(ctx, book)
+}
+
+// datestampColumn is the moment of the last change of a book. GORM doesn't touch updated_at on soft deletion,
+// and GREATEST ignores NULL, so the expression covers deletions as well.
+const datestampColumn = "GREATEST(updated_at, deleted_at)"
+
+// Harvest retrieves a page of books, soft-deleted ones included, ordered by datestamps with keyset pagination.
+func (r *Repo) Harvest(ctx context.Context, query models.HarvestQuery) ([]models.Book, error) {
+	db := r.db.WithContext(ctx).Unscoped().Model(&Book{})
+	if query.From != nil {
+		db = db.Where(datestampColumn+" >= ?", *query.From)
+	}
+	if query.Until != nil {
+		db = db.Where(datestampColumn+" < ?", *query.Until)
+	}
+	if query.After != nil {
+		db = db.Where("("+datestampColumn+", id) > (?, ?)", query.After.Value, query.After.ID)
+	}
+
+	var rows []Book
+	err := db.Order(datestampColumn).
+		Order("id").
+		Limit(query.Limit).
+		Find(&rows).Error
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	result, err := r.fromEntitiesToModels(r.db.WithContext(ctx), rows)
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	return result, nil
+}
+
+// Existing returns those of the identities that books outside the trash have.
+func (r *Repo) Existing(ctx context.Context, identities []models.BookIdentity) ([]models.BookIdentity, error) {
+	if len(identities) == 0 {
+		return []models.BookIdentity{}, nil
+	}
+	pairs := make([][]any, len(identities))
+	for i, identity := range identities {
+		pairs[i] = []any{identity.TitleKey, identity.AuthorKey}
+	}
+
+	var rows []models.BookIdentity
+	err := r.db.WithContext(ctx).Model(&Book{}).
+		Distinct("title_key", "author_key").
+		Where("(title_key, author_key) IN ?", pairs).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	return rows, nil
+}
+
+// ExistingISBNs returns those of the ISBNs that books outside the trash have.
+func (r *Repo) ExistingISBNs(ctx context.Context, isbns []string) ([]string, error) {
+	result := []string{}
+	if len(isbns) == 0 {
+		return result, nil
+	}
+	err := r.db.WithContext(ctx).Model(&Book{}).
+		Where("isbn IN ?", isbns).
+		Pluck("isbn", &result).Error
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	return result, nil
+}
+
+// Create inserts a new book with its credits into the database and returns it with the timestamps set on insert.
+func (r *Repo) Create(ctx context.Context, model models.Book) (*models.Book, error) {
+	created, err := r.CreateBatch(ctx, []models.Book{model})
 	if err != nil {
 		return nil, err
 	}
-	model := r.fromEntityToModel(book)
-	return &model, nil
+	return &created[0], nil
 }
 
-// Create inserts a new book into the database.
-func (r *Repo) Create(ctx context.Context, model models.Book) error {
-	book := r.fromModelToEntity(model)
-	return r.db.WithContext(ctx).Create(&book).Error
+// CreateBatch inserts books with multi-row INSERT statements, either all of them or none.
+func (r *Repo) CreateBatch(ctx context.Context, batch []models.Book) ([]models.Book, error) {
+	if len(batch) == 0 {
+		return []models.Book{}, nil
+	}
+	batch = slices.Clone(batch)
+	rows := make([]Book, len(batch))
+	// inside Transaction this becomes a savepoint
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := r.resolveCredits(tx, batch); err != nil {
+			return err
+		}
+		if err := r.checkGenres(tx, batch); err != nil {
+			return err
+		}
+		for i, model := range batch {
+			rows[i] = r.fromModelToEntity(model)
+			rows[i].Version = 1
+		}
+		if err := tx.CreateInBatches(&rows, insertBatchSize).Error; err != nil {
+			return err
+		}
+		if err := r.saveCredits(tx, batch); err != nil {
+			return err
+		}
+		return r.saveClassification(tx, batch)
+	})
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	result := make([]models.Book, len(rows))
+	for i, book := range rows {
+		result[i] = r.fromEntityToModel(book)
+		result[i].Authors = batch[i].Authors
+		result[i].Genres = batch[i].Genres
+		result[i].Tags = batch[i].Tags
+	}
+	return result, nil
 }
 
-// Update modifies an existing book in the database.
+// Update replaces fields of an existing book in the database.
+// If the model has a version, the book is updated only if it still has that version.
 func (r *Repo) Update(ctx context.Context, ID uuid.UUID, model models.Book) error {
-	tx := r.db.WithContext(ctx).Begin()
+	// inside Transaction this becomes a savepoint
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Find existing book, the lock keeps concurrent updates from interleaving with the version check
+		var existing Book
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&existing, "id = ?", ID).Error; err != nil {
+			return err
+		}
+		if model.Version != 0 && model.Version != existing.Version {
+			return r.versionMismatch(ID, model.Version)
+		}
+
+		batch := []models.Book{model}
+		if err := r.resolveCredits(tx, batch); err != nil {
+			return err
+		}
+		if err := r.checkGenres(tx, batch); err != nil {
+			return err
+		}
+		book := r.fromModelToEntity(batch[0])
+		book.CreatedAt = existing.CreatedAt
+		book.WorkID = existing.WorkID
+		book.Version = existing.Version + 1
 
-	// Find existing book
-	var existing Book
-	if err := tx.First(&existing, "id = ?", ID).Error; err != nil {
-		tx.Rollback()
-		return err
+		// Save updated book
+		if err := tx.Save(&book).Error; err != nil {
+			return err
+		}
+		if err := r.saveCredits(tx, batch); err != nil {
+			return err
+		}
+		return r.saveClassification(tx, batch)
+	})
+	return r.translateError(err)
+}
+
+// Delete removes a book from the database by its UUID.
+// If version isn't zero, the book is deleted only if it still has that version.
+func (r *Repo) Delete(ctx context.Context, ID uuid.UUID, version uint64) error {
+	db := r.db.WithContext(ctx).Where("id = ?", ID)
+	if version != 0 {
+		db = db.Where("version = ?", version)
+	}
+	res := db.Delete(&Book{})
+	if res.Error != nil {
+		return r.translateError(res.Error)
+	}
+	if res.RowsAffected > 0 {
+		return nil
 	}
+	if version != 0 {
+		// tell a missing book from a modified one
+		if _, err := r.GetOne(ctx, ID); err == nil {
+			return r.versionMismatch(ID, version)
+		}
+	}
+	return errs.NotFound("book with ID = %s doesn't exist", ID)
+}
+
+// GetTrash retrieves a page of soft-deleted books, most recently deleted first.
+func (r *Repo) GetTrash(ctx context.Context, query models.TrashQuery) ([]models.Book, error) {
+	db := r.db.WithContext(ctx).Unscoped().Model(&Book{}).Where("deleted_at IS NOT NULL")
+	if query.After != nil {
+		db = db.Where("(deleted_at, id) < (?, ?)", query.After.Value, query.After.ID)
+	}
+
+	var rows []Book
+	err := db.Order("deleted_at DESC").
+		Order("id DESC").
+		Limit(query.Limit).
+		Find(&rows).Error
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	result, err := r.fromEntitiesToModels(r.db.WithContext(ctx), rows)
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	return result, nil
+}
 
-	book := r.fromModelToEntity(model)
-	// Fill missing fields
-	r.fillEmptyFields(&existing, &book)
+// Restore clears the deletion mark of a soft-deleted book.
+func (r *Repo) Restore(ctx context.Context, ID uuid.UUID) error {
+	res := r.db.WithContext(ctx).Unscoped().Model(&Book{}).
+		Where("id = ? AND deleted_at IS NOT NULL", ID).
+		Update("deleted_at", nil)
+	if res.Error != nil {
+		return r.translateError(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return errs.NotFound("book with ID = %s is not in the trash", ID)
+	}
+	return nil
+}
 
-	// Save updated book
-	if err := tx.Save(&book).Error; err != nil {
-		tx.Rollback()
-		return err
+// Purge permanently removes a soft-deleted book.
+func (r *Repo) Purge(ctx context.Context, ID uuid.UUID) error {
+	res := r.db.WithContext(ctx).Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", ID).
+		Delete(&Book{})
+	if res.Error != nil {
+		return r.translateError(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return errs.NotFound("book with ID = %s is not in the trash", ID)
 	}
+	return nil
+}
 
-	return tx.Commit().Error
+// PurgeDeletedBefore permanently removes books soft-deleted before the given moment
+// and returns their number.
+func (r *Repo) PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at < ?", before).
+		Delete(&Book{})
+	if res.Error != nil {
+		return 0, r.translateError(res.Error)
+	}
+	return res.RowsAffected, nil
 }
 
-// Delete removes a book from the database by its UUID.
-func (r *Repo) Delete(ctx context.Context, ID uuid.UUID) error {
-	return r.db.WithContext(ctx).Where("id = ?", ID).Delete(&Book{}).Error
+// Transaction runs fn with a repository bound to a database transaction, which is rolled back if fn fails.
+func (r *Repo) Transaction(ctx context.Context, fn func(repo books.Repository) error) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&Repo{db: tx})
+	})
+	return r.translateError(err)
+}
+
+// versionMismatch returns the error of a conditional write to a book that has been modified.
+func (r *Repo) versionMismatch(ID uuid.UUID, version uint64) error {
+	return errs.PreconditionFailed("book with ID = %s has been modified since version %d", ID, version)
+}
+
+// translateError converts GORM errors to domain errors.
+func (r *Repo) translateError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, new(*errs.Error)):
+		return err // already translated inside a transaction
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return errs.NotFound("book doesn't exist")
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return errs.Conflict("book with the same ID or ISBN already exists")
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		return errs.Conflict("credited author or genre of the book has been deleted meanwhile")
+	default:
+		return errs.Internal(err)
+	}
+}
+
+// applyFilter adds WHERE clauses for the filter to the query.
+func (r *Repo) applyFilter(db *gorm.DB, filter models.BookFilter) *gorm.DB {
+	if filter.Author != "" {
+		db = db.Where("LOWER(author) = LOWER(?)", filter.Author)
+	}
+	if filter.AuthorID != uuid.Nil {
+		db = db.Where("id IN (SELECT book_id FROM book_authors WHERE author_id = ?)", filter.AuthorID)
+	}
+	if filter.YearFrom != 0 {
+		db = db.Where("year >= ?", filter.YearFrom)
+	}
+	if filter.YearTo != 0 {
+		db = db.Where("year <= ?", filter.YearTo)
+	}
+	if filter.TitlePrefix != "" {
+		db = db.Where("title ILIKE ?", likeEscaper.Replace(filter.TitlePrefix)+"%")
+	}
+	if filter.GenreID != uuid.Nil {
+		db = db.Where(`id IN (SELECT book_genres.book_id FROM book_genres
+			JOIN genre_closure ON genre_closure.descendant_id = book_genres.genre_id
+			WHERE genre_closure.ancestor_id = ?)`, filter.GenreID)
+	}
+	if filter.Tag != "" {
+		db = db.Where("id IN (SELECT book_id FROM book_tags WHERE tag = ?)", filter.Tag)
+	}
+	if filter.WorkID != uuid.Nil {
+		db = db.Where("work_id = ?", filter.WorkID)
+	}
+	if filter.OnePerWork {
+		// the earliest edition of every work among the books matching the rest of the filter
+		rest := filter
+		rest.OnePerWork = false
+		first := r.applyFilter(r.db.Model(&Book{}), rest).
+			Select("DISTINCT ON (work_id) id").
+			Where("work_id IS NOT NULL").
+			Order("work_id, created_at, id")
+		db = db.Where("(work_id IS NULL OR id IN (?))", first)
+	}
+	return db
+}
+
+// conditionColumns maps fields of conditions to the columns of the books table.
+var conditionColumns = map[models.BookConditionField]string{
+	models.BookConditionTitle:  "title",
+	models.BookConditionAuthor: "author",
+	models.BookConditionYear:   "year",
+}
+
+// booleanOperators maps nodes of conditions to SQL operators.
+var booleanOperators = map[models.BookConditionOp]string{
+	models.BookConditionAnd: "AND",
+	models.BookConditionOr:  "OR",
+	models.BookConditionNot: "AND NOT",
+}
+
+// yearOperators maps relations of years to SQL operators.
+var yearOperators = map[models.BookRelation]string{
+	models.BookRelationEqual:          "=",
+	models.BookRelationNotEqual:       "<>",
+	models.BookRelationLess:           "<",
+	models.BookRelationLessOrEqual:    "<=",
+	models.BookRelationGreater:        ">",
+	models.BookRelationGreaterOrEqual: ">=",
+}
+
+// applyCondition adds a WHERE clause for the condition to the query.
+func (r *Repo) applyCondition(db *gorm.DB, condition *models.BookCondition) (*gorm.DB, error) {
+	if condition == nil {
+		return db, nil
+	}
+	sql, vars, err := r.conditionSQL(*condition)
+	if err != nil {
+		return nil, errs.Internal(err)
+	}
+	return db.Where(sql, vars...), nil
+}
+
+// conditionSQL translates a condition to a parenthesized SQL expression and its parameters.
+func (r *Repo) conditionSQL(condition models.BookCondition) (string, []any, error) {
+	switch condition.Op {
+	case models.BookConditionAnd, models.BookConditionOr, models.BookConditionNot:
+		if condition.Left == nil || condition.Right == nil {
+			return "", nil, fmt.Errorf("condition %q lacks an operand", condition.Op)
+		}
+		left, leftVars, err := r.conditionSQL(*condition.Left)
+		if err != nil {
+			return "", nil, err
+		}
+		right, rightVars, err := r.conditionSQL(*condition.Right)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("(%s %s %s)", left, booleanOperators[condition.Op], right), append(leftVars, rightVars...), nil
+	case models.BookConditionAll:
+		return "TRUE", nil, nil
+	case models.BookConditionMatch:
+	default:
+		return "", nil, fmt.Errorf("unknown condition %q", condition.Op)
+	}
+
+	column, ok := conditionColumns[condition.Field]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown field %q", condition.Field)
+	}
+	if year, ok := condition.Value.(uint16); ok {
+		operator, ok := yearOperators[condition.Relation]
+		if !ok || condition.Field != models.BookConditionYear {
+			return "", nil, fmt.Errorf("unsupported comparison of %s %s %d", condition.Field, condition.Relation, year)
+		}
+		return fmt.Sprintf("(%s %s ?)", column, operator), []any{year}, nil
+	}
+
+	text, ok := condition.Value.(string)
+	if !ok || condition.Field == models.BookConditionYear {
+		return "", nil, fmt.Errorf("unsupported value %v of %s", condition.Value, condition.Field)
+	}
+	switch condition.Relation {
+	case models.BookRelationContains:
+		return fmt.Sprintf("(%s ILIKE ?)", column), []any{"%" + likeEscaper.Replace(text) + "%"}, nil
+	case models.BookRelationEqual:
+		return fmt.Sprintf("(LOWER(%s) = LOWER(?))", column), []any{text}, nil
+	case models.BookRelationNotEqual:
+		return fmt.Sprintf("(LOWER(%s) <> LOWER(?))", column), []any{text}, nil
+	default:
+		return "", nil, fmt.Errorf("unsupported comparison of %s %s %q", condition.Field, condition.Relation, text)
+	}
+}
+
+// likeEscaper escapes LIKE wildcards so that user input is matched literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// toModel converts a single entity to a model along with its credits.
+func (r *Repo) toModel(ctx context.Context, entity Book) (*models.Book, error) {
+	result, err := r.fromEntitiesToModels(r.db.WithContext(ctx), []Book{entity})
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	return &result[0], nil
 }
 
 // fromEntityToModel converts an entity to a model (to the business logic layer from the db layer)
 func (r *Repo) fromEntityToModel(entity Book) models.Book {
-	return models.Book{
-		ID:     entity.ID,
-		Title:  entity.Title,
-		Author: entity.Author,
-		Year:   entity.Year,
+	model := models.Book{
+		ID:        entity.ID,
+		Title:     entity.Title,
+		Author:    entity.Author,
+		Year:      entity.Year,
+		Publisher: entity.Publisher,
+		Language:  entity.Language,
+		Format:    models.BookFormat(entity.Format),
+		WorkID:    entity.WorkID,
+		Version:   entity.Version,
+		CreatedAt: entity.CreatedAt,
+		UpdatedAt: entity.UpdatedAt,
+	}
+	if entity.ISBN != nil {
+		model.ISBN = *entity.ISBN
 	}
+	if entity.DeletedAt.Valid {
+		model.DeletedAt = &entity.DeletedAt.Time
+	}
+	return model
 }
 
 // fromModelToEntity converts a model to an entity (from the business logic layer to the db layer)
 func (r *Repo) fromModelToEntity(model models.Book) Book {
-	return Book{
+	book := Book{
 		Base: Base{
-			ID: model.ID,
+			ID:        model.ID,
+			CreatedAt: model.CreatedAt,
+			UpdatedAt: model.UpdatedAt,
 		},
-		Title:  model.Title,
-		Author: model.Author,
-		Year:   model.Year,
-	}
-}
-
-// fillEmptyFields copies missing fields from the existing book to the updated book.
-func (r *Repo) fillEmptyFields(existing, updated *Book) {
-	if updated.Title == "" {
-		updated.Title = existing.Title
-	}
-	if updated.Author == "" {
-		updated.Author = existing.Author
+		Title:     model.Title,
+		Author:    model.Author,
+		Year:      model.Year,
+		Publisher: model.Publisher,
+		Language:  model.Language,
+		Format:    string(model.Format),
+		WorkID:    model.WorkID,
+		Version:   model.Version,
 	}
-	if updated.Year == 0 {
-		updated.Year = existing.Year
+	if model.ISBN != "" {
+		book.ISBN = &model.ISBN
 	}
+	return book
 }
@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SearchKeysMigration is the version of the migration that added search keys. It fills them in
+// for existing books with lower(), which doesn't transliterate or fold the way textnorm.Key does,
+// so RekeyBooks has to be run after it.
+const SearchKeysMigration = 2
+
+// rekeyBatchSize is the number of books RekeyBooks reads at once.
+const rekeyBatchSize = 500
+
+// keyRow holds the columns RekeyBooks reads.
+type keyRow struct {
+	ID         uuid.UUID
+	Title      string
+	Author     string
+	TitleKey   string
+	AuthorKey  string
+	SearchText string
+}
+
+// RekeyBooks recomputes the search keys of all books, the ones in the trash included, and returns
+// the number of books whose keys have changed. Versions and update times of the books stay the same.
+func RekeyBooks(ctx context.Context, db *gorm.DB) (int64, error) {
+	db = db.WithContext(ctx)
+	var (
+		changed int64
+		after   uuid.UUID
+	)
+	for {
+		var rows []keyRow
+		err := db.Unscoped().Model(&Book{}).
+			Select("id, title, author, title_key, author_key, search_text").
+			Where("id > ?", after).
+			Order("id").
+			Limit(rekeyBatchSize).
+			Scan(&rows).Error
+		if err != nil {
+			return changed, err
+		}
+		for _, row := range rows {
+			titleKey, authorKey, searchText := searchKeys(row.Title, row.Author)
+			if titleKey == row.TitleKey && authorKey == row.AuthorKey && searchText == row.SearchText {
+				continue
+			}
+			err := db.Unscoped().Model(&Book{}).Where("id = ?", row.ID).UpdateColumns(map[string]any{
+				"title_key":   titleKey,
+				"author_key":  authorKey,
+				"search_text": searchText,
+			}).Error
+			if err != nil {
+				return changed, err
+			}
+			changed++
+		}
+		if len(rows) < rekeyBatchSize {
+			return changed, nil
+		}
+		after = rows[len(rows)-1].ID
+	}
+}
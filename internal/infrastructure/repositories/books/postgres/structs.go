@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"github.com/KinitaL/testovoye/pkg/textnorm"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"time"
@@ -8,18 +9,167 @@ import (
 
 type (
 	// Base contains common columns for all tables.
+	// The schema itself is defined by SQL files in the migrations directory.
 	Base struct {
 		ID        uuid.UUID `gorm:"type:uuid;primary_key;"`
 		CreatedAt time.Time
 		UpdatedAt time.Time
-		DeletedAt gorm.DeletedAt `sql:"index"`
+		DeletedAt gorm.DeletedAt
 	}
 
 	// Book contains columns for books table
 	Book struct {
 		Base
-		Title  string `gorm:"not_null"`
-		Author string `gorm:"not_null"`
-		Year   uint16 `gorm:"type:int;not_null"`
+		Title  string
+		Author string
+		Year   uint16
+		// ISBN is NULL if unknown, as the unique index ignores only NULLs.
+		ISBN      *string `gorm:"column:isbn"`
+		Publisher string
+		Language  string
+		Format    string
+		// WorkID is changed only by WorksRepo, see Repo.Update.
+		WorkID *uuid.UUID `gorm:"type:uuid"`
+		// Version is incremented on every update, see Repo.Update.
+		Version uint64
+		// SearchText holds script-independent search keys of the title and the author, see BeforeSave.
+		SearchText string
+		// TitleKey and AuthorKey are search keys of separate fields used for trigram similarity.
+		TitleKey  string
+		AuthorKey string
+		// SearchVector is maintained by PostgreSQL and is used only inside full-text search queries.
+		SearchVector string `gorm:"->:false;<-:false"`
+	}
+
+	// Work contains columns for works table
+	Work struct {
+		ID        uuid.UUID `gorm:"type:uuid;primary_key;"`
+		Title     string
+		Author    string
+		CreatedAt time.Time
+		UpdatedAt time.Time
+	}
+
+	// Genre contains columns for genres table
+	Genre struct {
+		ID        uuid.UUID `gorm:"type:uuid;primary_key;"`
+		Name      string
+		ParentID  *uuid.UUID `gorm:"type:uuid"`
+		CreatedAt time.Time
+		UpdatedAt time.Time
+	}
+
+	// GenreClosure contains columns for genre_closure table: a row for every ancestor of every genre,
+	// the genre itself included with depth 0.
+	GenreClosure struct {
+		AncestorID   uuid.UUID `gorm:"type:uuid;primaryKey"`
+		DescendantID uuid.UUID `gorm:"type:uuid;primaryKey"`
+		Depth        int
+	}
+
+	// BookGenre contains columns for book_genres table, the genres books are classified under.
+	BookGenre struct {
+		BookID  uuid.UUID `gorm:"type:uuid;primaryKey"`
+		GenreID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	}
+
+	// BookTag contains columns for book_tags table.
+	BookTag struct {
+		BookID uuid.UUID `gorm:"type:uuid;primaryKey"`
+		Tag    string    `gorm:"primaryKey"`
+	}
+
+	// genreFacetRow is a genre with the number of matching books in its subtree.
+	genreFacetRow struct {
+		ID       uuid.UUID
+		Name     string
+		ParentID *uuid.UUID
+		Count    int64
+	}
+
+	// tagCountRow is a tag with the number of books.
+	tagCountRow struct {
+		Tag   string
+		Count int64
+	}
+
+	// decadeFacetRow is a decade with the number of matching books.
+	decadeFacetRow struct {
+		Decade uint16
+		Count  int64
+	}
+
+	// Author contains columns for authors table
+	Author struct {
+		ID        uuid.UUID `gorm:"type:uuid;primary_key;"`
+		Name      string
+		CreatedAt time.Time
+		UpdatedAt time.Time
+	}
+
+	// BookAuthor contains columns for book_authors table, the credits of authors for books.
+	BookAuthor struct {
+		BookID   uuid.UUID `gorm:"type:uuid;primaryKey"`
+		Position int       `gorm:"primaryKey"`
+		AuthorID uuid.UUID `gorm:"type:uuid"`
+		Role     string
+	}
+
+	// AuthorVariant contains columns for author_variants table, the variant names of authors.
+	AuthorVariant struct {
+		AuthorID uuid.UUID `gorm:"type:uuid;primaryKey"`
+		Name     string    `gorm:"primaryKey"`
+	}
+
+	// AuthorRedirect contains columns for author_redirects table: ID is the ID of a merged author,
+	// AuthorID is the author it has been merged into.
+	AuthorRedirect struct {
+		ID       uuid.UUID `gorm:"type:uuid;primary_key;"`
+		AuthorID uuid.UUID `gorm:"type:uuid"`
+	}
+
+	// nameMatch is an author whose preferred name or variant is the lower-cased Key.
+	nameMatch struct {
+		Key string
+		ID  uuid.UUID
+	}
+
+	// creditRow is a credit joined with the name of the author.
+	creditRow struct {
+		BookID   uuid.UUID
+		AuthorID uuid.UUID
+		Name     string
+		Role     string
+	}
+
+	// searchRow is a row returned by full-text search.
+	searchRow struct {
+		Book
+		Rank float64
+	}
+
+	// suggestionRow is a row returned by the suggestion query.
+	suggestionRow struct {
+		Text  string
+		Field string
+		Score float64
 	}
 )
+
+// BeforeSave keeps search keys in sync with the title and the author.
+func (b *Book) BeforeSave(_ *gorm.DB) error {
+	b.TitleKey, b.AuthorKey, b.SearchText = searchKeys(b.Title, b.Author)
+	return nil
+}
+
+// searchKeys computes the search keys of a book from its title and author.
+func searchKeys(title, author string) (titleKey, authorKey, searchText string) {
+	titleKey = textnorm.Key(title)
+	authorKey = textnorm.Key(author)
+	return titleKey, authorKey, titleKey + " " + authorKey
+}
+
+// TableName keeps the conventional name of the closure table instead of the plural GORM derives.
+func (GenreClosure) TableName() string {
+	return "genre_closure"
+}
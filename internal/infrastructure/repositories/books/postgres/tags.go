@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/internal/usecases/tags"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// TagsRepo is a GORM-based implementation of the tag repository. Tags live in book_tags,
+// so renaming and deleting them change books.
+type TagsRepo struct {
+	db *gorm.DB
+}
+
+// NewTagsRepo creates and returns a new tag repository instance using GORM and PostgreSQL.
+func NewTagsRepo(db *gorm.DB) tags.Repository {
+	return &TagsRepo{db: db}
+}
+
+// GetAll retrieves the most used tags of books outside the trash.
+func (r *TagsRepo) GetAll(ctx context.Context, query models.TagQuery) ([]models.TagCount, error) {
+	db := r.db.WithContext(ctx).Table("book_tags").
+		Select("book_tags.tag, COUNT(*) AS count").
+		Joins("JOIN books ON books.id = book_tags.book_id AND books.deleted_at IS NULL")
+	if query.Prefix != "" {
+		db = db.Where("book_tags.tag LIKE ?", likeEscaper.Replace(query.Prefix)+"%")
+	}
+
+	var rows []tagCountRow
+	err := db.Group("book_tags.tag").
+		Order(`count DESC, book_tags.tag COLLATE "C"`).
+		Limit(query.Limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	result := make([]models.TagCount, len(rows))
+	for i, row := range rows {
+		result[i] = models.TagCount{Tag: row.Tag, Books: row.Count}
+	}
+	return result, nil
+}
+
+// Rename replaces a tag with another one on every book having it. Books that already have both keep one.
+func (r *TagsRepo) Rename(ctx context.Context, tag string, name string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		IDs, err := r.lockBooks(tx, tag)
+		if err != nil {
+			return err
+		}
+		err = tx.Exec(`INSERT INTO book_tags (book_id, tag)
+			SELECT book_id, ? FROM book_tags WHERE tag = ?
+			ON CONFLICT DO NOTHING`, name, tag).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("tag = ?", tag).Delete(&BookTag{}).Error; err != nil {
+			return err
+		}
+		return r.bumpVersions(tx, IDs)
+	})
+	return r.translateError(err)
+}
+
+// Delete removes a tag from every book having it.
+func (r *TagsRepo) Delete(ctx context.Context, tag string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		IDs, err := r.lockBooks(tx, tag)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("tag = ?", tag).Delete(&BookTag{}).Error; err != nil {
+			return err
+		}
+		return r.bumpVersions(tx, IDs)
+	})
+	return r.translateError(err)
+}
+
+// lockBooks locks the books having a tag, the ones in the trash included, and returns their IDs.
+func (r *TagsRepo) lockBooks(tx *gorm.DB, tag string) ([]uuid.UUID, error) {
+	var IDs []uuid.UUID
+	err := tx.Unscoped().Model(&Book{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN (SELECT book_id FROM book_tags WHERE tag = ?)", tag).
+		Pluck("id", &IDs).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(IDs) == 0 {
+		return nil, errs.NotFound("tag %q doesn't exist", tag)
+	}
+	return IDs, nil
+}
+
+// bumpVersions gives books new versions after their tags have changed.
+func (r *TagsRepo) bumpVersions(tx *gorm.DB, IDs []uuid.UUID) error {
+	return tx.Unscoped().Model(&Book{}).Where("id IN ?", IDs).Updates(map[string]any{
+		"version":    gorm.Expr("version + 1"),
+		"updated_at": time.Now(),
+	}).Error
+}
+
+// translateError converts GORM errors to domain errors.
+func (r *TagsRepo) translateError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, new(*errs.Error)):
+		return err // already translated inside a transaction
+	default:
+		return errs.Internal(err)
+	}
+}
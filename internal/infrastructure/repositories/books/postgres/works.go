@@ -0,0 +1,202 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/internal/usecases/works"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WorksRepo is a GORM-based implementation of the work repository. It lives next to Repo
+// because attaching and detaching editions change books.
+type WorksRepo struct {
+	db *gorm.DB
+}
+
+// NewWorksRepo creates and returns a new work repository instance using GORM and PostgreSQL.
+func NewWorksRepo(db *gorm.DB) works.Repository {
+	return &WorksRepo{db: db}
+}
+
+// GetAll retrieves a page of works ordered by title using keyset pagination.
+func (r *WorksRepo) GetAll(ctx context.Context, query models.WorkQuery) ([]models.Work, error) {
+	db := r.db.WithContext(ctx).Model(&Work{})
+	if query.TitlePrefix != "" {
+		db = db.Where("title ILIKE ?", likeEscaper.Replace(query.TitlePrefix)+"%")
+	}
+	if query.After != nil {
+		db = db.Where("(title, id) > (?, ?)", query.After.Title, query.After.ID)
+	}
+
+	var rows []Work
+	if err := db.Order("title").Order("id").Limit(query.Limit).Find(&rows).Error; err != nil {
+		return nil, r.translateError(err)
+	}
+	result := make([]models.Work, len(rows))
+	for i, row := range rows {
+		result[i] = r.fromEntityToModel(row)
+	}
+	return result, nil
+}
+
+// GetOne retrieves a work by its UUID.
+func (r *WorksRepo) GetOne(ctx context.Context, ID uuid.UUID) (*models.Work, error) {
+	var work Work
+	if err := r.db.WithContext(ctx).First(&work, "id = ?", ID).Error; err != nil {
+		return nil, r.translateError(err)
+	}
+	model := r.fromEntityToModel(work)
+	return &model, nil
+}
+
+// Create inserts a new work into the database and returns it with the timestamps set on insert.
+func (r *WorksRepo) Create(ctx context.Context, model models.Work) (*models.Work, error) {
+	work := r.fromModelToEntity(model)
+	if err := r.db.WithContext(ctx).Create(&work).Error; err != nil {
+		return nil, r.translateError(err)
+	}
+	created := r.fromEntityToModel(work)
+	return &created, nil
+}
+
+// Update replaces the title and the creators of a work.
+func (r *WorksRepo) Update(ctx context.Context, ID uuid.UUID, model models.Work) error {
+	res := r.db.WithContext(ctx).Model(&Work{ID: ID}).Select("title", "author").Updates(r.fromModelToEntity(model))
+	if res.Error != nil {
+		return r.translateError(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return errs.NotFound("work with ID = %s doesn't exist", ID)
+	}
+	return nil
+}
+
+// Delete removes a work that has no editions.
+func (r *WorksRepo) Delete(ctx context.Context, ID uuid.UUID) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var editions int64
+		if err := tx.Unscoped().Model(&Book{}).Where("work_id = ?", ID).Count(&editions).Error; err != nil {
+			return err
+		}
+		if editions > 0 {
+			return r.hasEditions(ID)
+		}
+		res := tx.Where("id = ?", ID).Delete(&Work{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errs.NotFound("work with ID = %s doesn't exist", ID)
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrForeignKeyViolated) {
+		// an edition has been attached after the check
+		return r.hasEditions(ID)
+	}
+	return r.translateError(err)
+}
+
+// Attach makes a book outside the trash an edition of the work; the book gets a new version.
+func (r *WorksRepo) Attach(ctx context.Context, ID uuid.UUID, bookID uuid.UUID) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// the share lock keeps the work from being deleted until the edition is attached
+		var work Work
+		err := tx.Clauses(clause.Locking{Strength: "SHARE"}).First(&work, "id = ?", ID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errs.NotFound("work with ID = %s doesn't exist", ID)
+		}
+		if err != nil {
+			return err
+		}
+		var book Book
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&book, "id = ?", bookID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errs.NotFound("book with ID = %s doesn't exist", bookID)
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case book.WorkID == nil:
+		case *book.WorkID == ID:
+			return nil
+		default:
+			return errs.Conflict("book with ID = %s is an edition of work %s", bookID, *book.WorkID)
+		}
+		return r.setWork(tx, book, &ID)
+	})
+	return r.translateError(err)
+}
+
+// Detach makes an edition of the work a standalone book; the book gets a new version.
+func (r *WorksRepo) Detach(ctx context.Context, ID uuid.UUID, bookID uuid.UUID) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var book Book
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&book, "id = ? AND work_id = ?", bookID, ID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errs.NotFound("book with ID = %s isn't an edition of work %s", bookID, ID)
+		}
+		if err != nil {
+			return err
+		}
+		return r.setWork(tx, book, nil)
+	})
+	return r.translateError(err)
+}
+
+// setWork changes the work of a book and bumps its version.
+func (r *WorksRepo) setWork(tx *gorm.DB, book Book, ID *uuid.UUID) error {
+	return tx.Model(&book).Updates(map[string]any{
+		"work_id": ID,
+		"version": gorm.Expr("version + 1"),
+	}).Error
+}
+
+// hasEditions returns the error of deleting a work that has editions.
+func (r *WorksRepo) hasEditions(ID uuid.UUID) error {
+	return errs.Conflict("work with ID = %s has editions", ID)
+}
+
+// translateError converts GORM errors to domain errors.
+func (r *WorksRepo) translateError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, new(*errs.Error)):
+		return err // already translated inside a transaction
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return errs.NotFound("work doesn't exist")
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return errs.Conflict("work with the same ID already exists")
+	default:
+		return errs.Internal(err)
+	}
+}
+
+// fromEntityToModel converts an entity to a model (to the business logic layer from the db layer)
+func (r *WorksRepo) fromEntityToModel(entity Work) models.Work {
+	return models.Work{
+		ID:        entity.ID,
+		Title:     entity.Title,
+		Author:    entity.Author,
+		CreatedAt: entity.CreatedAt,
+		UpdatedAt: entity.UpdatedAt,
+	}
+}
+
+// fromModelToEntity converts a model to an entity (from the business logic layer to the db layer)
+func (r *WorksRepo) fromModelToEntity(model models.Work) Work {
+	return Work{
+		ID:        model.ID,
+		Title:     model.Title,
+		Author:    model.Author,
+		CreatedAt: model.CreatedAt,
+		UpdatedAt: model.UpdatedAt,
+	}
+}
@@ -0,0 +1,80 @@
+package idempotency
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/internal/usecases/idempotency"
+	"sync"
+	"time"
+)
+
+// InMemoryRepo is a thread-safe in-memory implementation of the idempotency key repository.
+type InMemoryRepo struct {
+	sync.Mutex
+	keys map[string]models.IdempotencyKey // Map to store keys by their value
+}
+
+// NewInMemoryRepo creates and returns a new instance of InMemoryRepo.
+func NewInMemoryRepo() idempotency.Repository {
+	return &InMemoryRepo{
+		Mutex: sync.Mutex{},
+		keys:  make(map[string]models.IdempotencyKey),
+	}
+}
+
+// Reserve stores the key without a response unless an unexpired key with the same value exists.
+// An abandoned key, one without a response created before abandonedBefore, is reserved anew.
+func (r *InMemoryRepo) Reserve(_ context.Context, key models.IdempotencyKey, abandonedBefore time.Time) (*models.IdempotencyKey, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	existing, ok := r.keys[key.Key]
+	abandoned := existing.Response == nil && !existing.CreatedAt.After(abandonedBefore)
+	if ok && existing.ExpiresAt.After(key.CreatedAt) && !abandoned {
+		return &existing, nil
+	}
+	key.Response = nil
+	r.keys[key.Key] = key
+	return nil, nil
+}
+
+// Complete records the response of a reserved key.
+func (r *InMemoryRepo) Complete(_ context.Context, key string, response models.IdempotentResponse) error {
+	r.Lock()
+	defer r.Unlock()
+
+	existing, ok := r.keys[key]
+	if !ok || existing.Response != nil {
+		return errs.NotFound("idempotency key %q isn't reserved", key)
+	}
+	existing.Response = &response
+	r.keys[key] = existing
+	return nil
+}
+
+// Release deletes a reserved key that has no response yet.
+func (r *InMemoryRepo) Release(_ context.Context, key string) error {
+	r.Lock()
+	defer r.Unlock()
+
+	if existing, ok := r.keys[key]; ok && existing.Response == nil {
+		delete(r.keys, key)
+	}
+	return nil
+}
+
+// DeleteExpired deletes keys that expired before the given moment and returns their number.
+func (r *InMemoryRepo) DeleteExpired(_ context.Context, before time.Time) (int64, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	var deleted int64
+	for value, key := range r.keys {
+		if !key.ExpiresAt.After(before) {
+			delete(r.keys, value)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,63 @@
+package idempotency
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestReserve(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	lease, ttl := time.Minute, 24*time.Hour
+
+	// reserve reserves the key for a request with the fingerprint made the given time after the start.
+	reserve := func(repo *InMemoryRepo, after time.Duration, fingerprint string) *models.IdempotencyKey {
+		now := start.Add(after)
+		existing, err := repo.Reserve(ctx, models.IdempotencyKey{
+			Key:         "key",
+			Fingerprint: fingerprint,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		}, now.Add(-lease))
+		assert.NoError(t, err)
+		return existing
+	}
+
+	t.Run("Request in progress", func(t *testing.T) {
+		repo := NewInMemoryRepo().(*InMemoryRepo)
+		assert.Nil(t, reserve(repo, 0, "first"))
+
+		existing := reserve(repo, lease/2, "retry")
+		if assert.NotNil(t, existing) {
+			assert.Equal(t, "first", existing.Fingerprint)
+			assert.Nil(t, existing.Response)
+		}
+	})
+
+	t.Run("Retry after an abandoned reservation", func(t *testing.T) {
+		repo := NewInMemoryRepo().(*InMemoryRepo)
+		assert.Nil(t, reserve(repo, 0, "first"))
+
+		// the first request never completes, so a retry takes the key over once the lease has passed
+		assert.Nil(t, reserve(repo, lease, "retry"))
+		assert.NoError(t, repo.Complete(ctx, "key", models.IdempotentResponse{Status: 201}))
+
+		existing := reserve(repo, 2*lease, "retry")
+		if assert.NotNil(t, existing) {
+			assert.Equal(t, "retry", existing.Fingerprint)
+			assert.Equal(t, &models.IdempotentResponse{Status: 201}, existing.Response)
+		}
+	})
+
+	t.Run("Completed key is kept until it expires", func(t *testing.T) {
+		repo := NewInMemoryRepo().(*InMemoryRepo)
+		assert.Nil(t, reserve(repo, 0, "first"))
+		assert.NoError(t, repo.Complete(ctx, "key", models.IdempotentResponse{Status: 201}))
+
+		assert.NotNil(t, reserve(repo, ttl-time.Second, "retry"))
+		assert.Nil(t, reserve(repo, ttl, "retry"))
+	})
+}
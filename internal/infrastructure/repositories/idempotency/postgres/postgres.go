@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/internal/usecases/idempotency"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// Repo is a GORM-based implementation of the idempotency key repository.
+type Repo struct {
+	db *gorm.DB
+}
+
+// NewPostgresRepo creates and returns a new repository instance using GORM and PostgreSQL.
+func NewPostgresRepo(db *gorm.DB) idempotency.Repository {
+	return &Repo{db: db}
+}
+
+// Reserve stores the key without a response unless an unexpired key with the same value exists.
+// An expired or abandoned key is overwritten in the same statement, so concurrent requests can't both reserve it.
+func (r *Repo) Reserve(ctx context.Context, model models.IdempotencyKey, abandonedBefore time.Time) (*models.IdempotencyKey, error) {
+	key := IdempotencyKey{
+		Key:         model.Key,
+		Fingerprint: model.Fingerprint,
+		CreatedAt:   model.CreatedAt,
+		ExpiresAt:   model.ExpiresAt,
+	}
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"fingerprint", "status", "header", "body", "created_at", "expires_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{
+				SQL: "idempotency_keys.expires_at <= EXCLUDED.created_at OR " +
+					"(idempotency_keys.status IS NULL AND idempotency_keys.created_at <= ?)",
+				Vars: []any{abandonedBefore},
+			},
+		}},
+	}).Create(&key)
+	if result.Error != nil {
+		return nil, r.translateError(result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return nil, nil
+	}
+
+	var existing IdempotencyKey
+	err := r.db.WithContext(ctx).Where("key = ?", model.Key).Take(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// the key has been purged in between, so it is free again
+		return r.Reserve(ctx, model, abandonedBefore)
+	}
+	if err != nil {
+		return nil, r.translateError(err)
+	}
+	return r.fromEntityToModel(existing)
+}
+
+// Complete records the response of a reserved key.
+func (r *Repo) Complete(ctx context.Context, key string, response models.IdempotentResponse) error {
+	header, err := json.Marshal(response.Header)
+	if err != nil {
+		return errs.Internal(err)
+	}
+	result := r.db.WithContext(ctx).Model(&IdempotencyKey{}).
+		Where("key = ? AND status IS NULL", key).
+		Updates(map[string]any{
+			"status": response.Status,
+			"header": string(header),
+			"body":   response.Body,
+		})
+	if result.Error != nil {
+		return r.translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errs.NotFound("idempotency key %q isn't reserved", key)
+	}
+	return nil
+}
+
+// Release deletes a reserved key that has no response yet.
+func (r *Repo) Release(ctx context.Context, key string) error {
+	err := r.db.WithContext(ctx).Where("key = ? AND status IS NULL", key).Delete(&IdempotencyKey{}).Error
+	return r.translateError(err)
+}
+
+// DeleteExpired deletes keys that expired before the given moment and returns their number.
+func (r *Repo) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at <= ?", before).Delete(&IdempotencyKey{})
+	if result.Error != nil {
+		return 0, r.translateError(result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// fromEntityToModel converts an entity to a model (to the business logic layer from the db layer)
+func (r *Repo) fromEntityToModel(key IdempotencyKey) (*models.IdempotencyKey, error) {
+	model := models.IdempotencyKey{
+		Key:         key.Key,
+		Fingerprint: key.Fingerprint,
+		CreatedAt:   key.CreatedAt,
+		ExpiresAt:   key.ExpiresAt,
+	}
+	if key.Status != nil {
+		model.Response = &models.IdempotentResponse{Status: *key.Status, Body: key.Body}
+		if key.Header != nil {
+			if err := json.Unmarshal([]byte(*key.Header), &model.Response.Header); err != nil {
+				return nil, errs.Internal(err)
+			}
+		}
+	}
+	return &model, nil
+}
+
+// translateError converts GORM errors to domain errors.
+func (r *Repo) translateError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return errs.NotFound("idempotency key doesn't exist")
+	default:
+		return errs.Internal(err)
+	}
+}
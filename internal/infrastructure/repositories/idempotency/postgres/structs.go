@@ -0,0 +1,18 @@
+package postgres
+
+import (
+	"time"
+)
+
+// IdempotencyKey contains columns for idempotency_keys table.
+// The schema itself is defined by SQL files in the migrations directory.
+type IdempotencyKey struct {
+	Key         string `gorm:"primaryKey"`
+	Fingerprint string
+	// Status, Header and Body are NULL until the response of the request is recorded.
+	Status    *int
+	Header    *string // JSON object of header values
+	Body      []byte
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+// AuthorRole is the contribution of an author to a book.
+type AuthorRole string
+
+const (
+	AuthorRoleAuthor      AuthorRole = "author"
+	AuthorRoleEditor      AuthorRole = "editor"
+	AuthorRoleTranslator  AuthorRole = "translator"
+	AuthorRoleIllustrator AuthorRole = "illustrator"
+)
+
+// AuthorSeparator joins the names of several authors in Book.Author, the way BibTeX does.
+const AuthorSeparator = " and "
+
+type (
+	// Author is an authority record of a person or an organization credited for books: the preferred name
+	// and the variants, like other spellings and transliterations, that credits are resolved by as well.
+	Author struct {
+		ID        uuid.UUID
+		Name      string
+		Variants  []string `json:",omitempty"`
+		CreatedAt time.Time
+		UpdatedAt time.Time
+	}
+
+	// BookAuthor is a credit of an author for a book. A credit without AuthorID refers to the author
+	// with the Name, who is created if there is no such author yet.
+	BookAuthor struct {
+		AuthorID uuid.UUID
+		Name     string
+		Role     AuthorRole
+	}
+
+	// AuthorListParams is a request for a page of authors as it comes from API clients.
+	AuthorListParams struct {
+		NamePrefix string // Case-insensitive name prefix
+		Limit      int
+		Cursor     string // Opaque cursor returned with the previous page
+	}
+
+	// AuthorQuery is a request for a page of authors in the order of their names.
+	AuthorQuery struct {
+		NamePrefix string
+		Limit      int
+		After      *AuthorCursor // Keyset position to continue from, nil for the first page
+	}
+
+	// AuthorCursor is a keyset position: the name and the ID of the last author on a page.
+	AuthorCursor struct {
+		Name string
+		ID   uuid.UUID
+	}
+
+	// AuthorPage is a page of authors.
+	AuthorPage struct {
+		Items      []Author
+		NextCursor string // Empty if there are no more pages
+	}
+)
+
+// AuthorText returns the names of the credits with the author role joined with AuthorSeparator,
+// which is what Book.Author holds. A book without such credits, like an anthology, is attributed to all credits.
+func AuthorText(credits []BookAuthor) string {
+	var names []string
+	for _, credit := range credits {
+		if credit.Role == AuthorRoleAuthor {
+			names = append(names, credit.Name)
+		}
+	}
+	if len(names) == 0 {
+		for _, credit := range credits {
+			names = append(names, credit.Name)
+		}
+	}
+	return strings.Join(names, AuthorSeparator)
+}
+
+// SplitAuthors returns the credits of the author role for the names in a Book.Author text.
+func SplitAuthors(text string) []BookAuthor {
+	var credits []BookAuthor
+	for _, name := range strings.Split(text, AuthorSeparator) {
+		if name = strings.TrimSpace(name); name != "" {
+			credits = append(credits, BookAuthor{Name: name, Role: AuthorRoleAuthor})
+		}
+	}
+	return credits
+}
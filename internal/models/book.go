@@ -1,11 +1,48 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"github.com/google/uuid"
+	"time"
+)
 
-// Book is a model that is used as a business logic unit
+// BookFormat is the physical or digital form of an edition.
+type BookFormat string
+
+const (
+	BookFormatHardcover BookFormat = "hardcover"
+	BookFormatPaperback BookFormat = "paperback"
+	BookFormatEbook     BookFormat = "ebook"
+	BookFormatAudiobook BookFormat = "audiobook"
+)
+
+// Book is a model that is used as a business logic unit. Every book is an edition,
+// which may belong to a Work along with other editions and translations of it.
 type Book struct {
-	ID     uuid.UUID
-	Title  string
-	Author string
-	Year   uint16
+	ID        uuid.UUID
+	Title     string
+	Author    string       // Names of the credited authors, see AuthorText
+	Authors   []BookAuthor `json:",omitempty"` // Credits in the order they are listed in the book, translators included
+	Year      uint16
+	ISBN      string      `json:",omitempty"` // ISBN-13 without hyphens, empty if unknown
+	Publisher string      `json:",omitempty"`
+	Language  string      `json:",omitempty"` // ISO 639 code in lower case, empty if unknown
+	Format    BookFormat  `json:",omitempty"`
+	WorkID    *uuid.UUID  `json:",omitempty"` // Work the edition belongs to, nil if it hasn't been attached to any
+	Genres    []uuid.UUID `json:",omitempty"` // Genres the book is classified under, see Genre
+	Tags      []string    `json:",omitempty"` // Free-form tags in lower case
+	Version   uint64      // Incremented on every update, used for optimistic concurrency control
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time `json:",omitempty"` // Set only for books in the trash
 }
+
+// Datestamp returns the moment of the last change of a book, its deletion included.
+func (b Book) Datestamp() time.Time {
+	if b.DeletedAt != nil && b.DeletedAt.After(b.UpdatedAt) {
+		return *b.DeletedAt
+	}
+	return b.UpdatedAt
+}
+
+// BookPatch computes the new state of a book from its current state.
+type BookPatch func(book Book) (Book, error)
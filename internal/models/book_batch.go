@@ -0,0 +1,43 @@
+package models
+
+import "github.com/google/uuid"
+
+// BookOperationType is the kind of change a batch operation makes.
+type BookOperationType string
+
+const (
+	BookOperationCreate BookOperationType = "create"
+	BookOperationUpdate BookOperationType = "update"
+	BookOperationDelete BookOperationType = "delete"
+)
+
+type (
+	// BookOperation is a single change of a batch.
+	BookOperation struct {
+		Type    BookOperationType
+		ID      uuid.UUID // Book to update or delete
+		Version uint64    // Version the update or deletion is based on, 0 means any
+		Book    Book      // New fields of a created or updated book
+	}
+
+	// BookOperationResult is the outcome of a single batch operation.
+	BookOperationResult struct {
+		Book *Book // Created or updated book
+		Err  error // Why the operation failed, nil on success
+	}
+)
+
+type (
+	// BookIdentity is the normalized title and author by which imported books are matched against the catalog.
+	BookIdentity struct {
+		TitleKey  string
+		AuthorKey string
+	}
+
+	// BookImportResult is the outcome of importing a single book.
+	BookImportResult struct {
+		Book      *Book // Created book, or the book that would be created in a dry run
+		Duplicate bool  // The book is already in the catalog or earlier in the same import
+		Err       error // Why the book is invalid, nil if it has been checked for duplicates
+	}
+)
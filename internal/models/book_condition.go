@@ -0,0 +1,60 @@
+package models
+
+// BookConditionOp is the kind of a node of a condition.
+type BookConditionOp string
+
+const (
+	BookConditionAnd   BookConditionOp = "and"
+	BookConditionOr    BookConditionOp = "or"
+	BookConditionNot   BookConditionOp = "not" // Left and not Right
+	BookConditionMatch BookConditionOp = "match"
+	BookConditionAll   BookConditionOp = "all" // Matches every book
+)
+
+// BookConditionField is a field of books a condition compares.
+type BookConditionField string
+
+const (
+	BookConditionTitle  BookConditionField = "title"
+	BookConditionAuthor BookConditionField = "author"
+	BookConditionYear   BookConditionField = "year"
+)
+
+// BookRelation is the comparison of a field with a value. Text comparisons are case-insensitive.
+type BookRelation string
+
+const (
+	BookRelationContains       BookRelation = "contains" // Text fields only
+	BookRelationEqual          BookRelation = "eq"
+	BookRelationNotEqual       BookRelation = "ne"
+	BookRelationLess           BookRelation = "lt" // Year only
+	BookRelationLessOrEqual    BookRelation = "le" // Year only
+	BookRelationGreater        BookRelation = "gt" // Year only
+	BookRelationGreaterOrEqual BookRelation = "ge" // Year only
+)
+
+type (
+	// BookCondition is a boolean expression over fields of books, such as the one of a CQL query.
+	// Nodes combine Left and Right, leaves (BookConditionMatch) compare Field with Value.
+	BookCondition struct {
+		Op       BookConditionOp
+		Left     *BookCondition
+		Right    *BookCondition
+		Field    BookConditionField
+		Relation BookRelation
+		Value    any // string for title/author, uint16 for year
+	}
+
+	// BookMatchQuery is a request for a page of books satisfying a condition, in the order of creation.
+	BookMatchQuery struct {
+		Condition *BookCondition // nil matches every book
+		Limit     int            // Zero only counts the books
+		Offset    int
+	}
+
+	// BookMatchResult is a page of books satisfying a condition.
+	BookMatchResult struct {
+		Items []Book
+		Total int64 // Number of all books satisfying the condition
+	}
+)
@@ -0,0 +1,36 @@
+package models
+
+import "github.com/google/uuid"
+
+type (
+	// BookFacets holds numbers of books matching a filter by genre, tag and decade,
+	// which clients build filter sidebars with.
+	BookFacets struct {
+		Genres  []GenreFacet  // Genres with matching books in their subtrees, ordered by name
+		Tags    []TagFacet    // The most used tags, see MaxTagFacets
+		Decades []DecadeFacet // Decades of publication in chronological order
+	}
+
+	// GenreFacet is the number of matching books classified under the genre or its subgenres.
+	GenreFacet struct {
+		ID       uuid.UUID
+		Name     string
+		ParentID *uuid.UUID
+		Count    int64
+	}
+
+	// TagFacet is the number of matching books with the tag.
+	TagFacet struct {
+		Tag   string
+		Count int64
+	}
+
+	// DecadeFacet is the number of matching books published in the decade starting with the year.
+	DecadeFacet struct {
+		Decade uint16
+		Count  int64
+	}
+)
+
+// MaxTagFacets is the number of the most used tags facets are counted for.
+const MaxTagFacets = 20
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+type (
+	// HarvestParams is a request for a page of changed books as it comes from metadata harvesters.
+	HarvestParams struct {
+		From   *time.Time // Inclusive lower bound of datestamps
+		Until  *time.Time // Exclusive upper bound of datestamps
+		Limit  int
+		Cursor string // Opaque cursor returned with the previous page, which also keeps the bounds
+	}
+
+	// HarvestQuery is a request for a page of changed books as it is passed to repositories.
+	// Books are ordered by their datestamps and IDs, deleted ones included.
+	HarvestQuery struct {
+		From  *time.Time
+		Until *time.Time
+		Limit int
+		After *BookCursor // Keyset position to continue from, nil for the first page
+	}
+
+	// HarvestPage is a page of changed books.
+	HarvestPage struct {
+		Items      []Book // Deleted books have DeletedAt set
+		NextCursor string // Empty if there are no more pages
+	}
+)
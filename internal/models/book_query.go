@@ -0,0 +1,85 @@
+package models
+
+import "github.com/google/uuid"
+
+// BookSortField is a field the list of books can be ordered by.
+type BookSortField string
+
+const (
+	BookSortTitle     BookSortField = "title"
+	BookSortAuthor    BookSortField = "author"
+	BookSortYear      BookSortField = "year"
+	BookSortCreatedAt BookSortField = "created_at"
+	BookSortDeletedAt BookSortField = "deleted_at" // Used only for the trash
+)
+
+// Locale selects the collation used to order titles and authors.
+type Locale string
+
+const (
+	LocaleEnglish Locale = "en"
+	LocaleRussian Locale = "ru"
+)
+
+type (
+	// BookFilter narrows down the list of books. Zero values mean "no restriction".
+	BookFilter struct {
+		Author      string    // Exact author match, case-insensitive
+		AuthorID    uuid.UUID // Books crediting the author in any role
+		YearFrom    uint16    // Inclusive lower bound of the publication year
+		YearTo      uint16    // Inclusive upper bound of the publication year
+		TitlePrefix string    // Case-insensitive title prefix
+		WorkID      uuid.UUID // Editions of the work
+		GenreID     uuid.UUID // Books classified under the genre or any of its subgenres
+		Tag         string    // Books with the tag
+		OnePerWork  bool      // Only the first edition of every work among the matching ones stands for the work
+	}
+
+	// BookListParams is a request for a page of books as it comes from API clients.
+	BookListParams struct {
+		BookFilter
+		SortBy     BookSortField
+		Desc       bool
+		Locale     Locale // Collation of text sort fields, English by default
+		Limit      int
+		Cursor     string // Opaque cursor returned with the previous page
+		WithTotal  bool   // Whether to count all books matching the filter
+		WithFacets bool   // Whether to count books matching the filter by genre, tag and decade
+	}
+
+	// BookQuery is a request for a page of books as it is passed to repositories.
+	BookQuery struct {
+		BookFilter
+		SortBy BookSortField
+		Desc   bool
+		Locale Locale
+		Limit  int
+		After  *BookCursor // Keyset position to continue from, nil for the first page
+	}
+
+	// BookCursor is a keyset position: the sort value and the ID of the last book on a page.
+	BookCursor struct {
+		Value any // string for title/author, uint16 for year, time.Time for created_at/deleted_at
+		ID    uuid.UUID
+	}
+
+	// TrashParams is a request for a page of deleted books as it comes from API clients.
+	TrashParams struct {
+		Limit  int
+		Cursor string // Opaque cursor returned with the previous page
+	}
+
+	// TrashQuery is a request for a page of deleted books, most recently deleted first.
+	TrashQuery struct {
+		Limit int
+		After *BookCursor // Keyset position to continue from, nil for the first page
+	}
+
+	// BookPage is a page of books.
+	BookPage struct {
+		Items      []Book
+		NextCursor string      // Empty if there are no more pages
+		Total      *int64      // Set only if it was requested
+		Facets     *BookFacets // Set only if they were requested
+	}
+)
@@ -0,0 +1,52 @@
+package models
+
+type (
+	// BookSearchQuery is a full-text search request over titles and authors.
+	BookSearchQuery struct {
+		Query     string  // Free-form text typed by the user
+		Fuzzy     bool    // Whether to also match books with similarly spelled titles or authors
+		Threshold float64 // Minimal similarity (0-1] of fuzzy matches and suggestions
+		Limit     int
+		Offset    int
+	}
+
+	// BookHighlight holds HTML-escaped fields with matched terms wrapped in HighlightStart and HighlightStop.
+	BookHighlight struct {
+		Title  string
+		Author string
+	}
+
+	// BookSearchHit is a single book found by full-text search.
+	BookSearchHit struct {
+		Book      Book
+		Rank      float64 // Relevance, the higher the better
+		Highlight BookHighlight
+	}
+
+	// BookSuggestion is a known title or author spelled similarly to a search query.
+	BookSuggestion struct {
+		Text  string
+		Field BookSuggestionField
+		Score float64 // Similarity to the query, the higher the better
+	}
+
+	// BookSearchResult is the outcome of full-text search.
+	BookSearchResult struct {
+		Hits        []BookSearchHit
+		Suggestions []BookSuggestion // Filled only when nothing was found
+	}
+)
+
+// BookSuggestionField is the field a suggestion comes from.
+type BookSuggestionField string
+
+const (
+	BookSuggestionTitle  BookSuggestionField = "title"
+	BookSuggestionAuthor BookSuggestionField = "author"
+)
+
+// Markers that surround matched terms in highlights.
+const (
+	HighlightStart = "<mark>"
+	HighlightStop  = "</mark>"
+)
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type (
+	// Genre is a node of the genre taxonomy. Books classified under a genre are found
+	// by the genre and by all of its ancestors.
+	Genre struct {
+		ID        uuid.UUID
+		Name      string
+		ParentID  *uuid.UUID `json:",omitempty"` // nil for top-level genres
+		CreatedAt time.Time
+		UpdatedAt time.Time
+	}
+
+	// GenreNode is a genre along with its subgenres.
+	GenreNode struct {
+		Genre
+		Children []GenreNode `json:",omitempty"`
+	}
+)
+
+// GenreTree builds the taxonomy from genres, keeping the order of the genres among siblings.
+func GenreTree(genres []Genre) []GenreNode {
+	children := make(map[uuid.UUID][]Genre)
+	for _, genre := range genres {
+		var parent uuid.UUID
+		if genre.ParentID != nil {
+			parent = *genre.ParentID
+		}
+		children[parent] = append(children[parent], genre)
+	}
+	var build func(parent uuid.UUID) []GenreNode
+	build = func(parent uuid.UUID) []GenreNode {
+		var nodes []GenreNode
+		for _, genre := range children[parent] {
+			nodes = append(nodes, GenreNode{Genre: genre, Children: build(genre.ID)})
+		}
+		return nodes
+	}
+	return build(uuid.Nil)
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+type (
+	// IdempotencyKey is a client-chosen key that makes retries of a non-idempotent request safe.
+	IdempotencyKey struct {
+		Key         string
+		Fingerprint string              // Hash of the request the key was first used with
+		Response    *IdempotentResponse // Nil while the first request is still being processed
+		CreatedAt   time.Time
+		ExpiresAt   time.Time // After this moment the key can be reused
+	}
+
+	// IdempotentResponse is a recorded response that is replayed for retries of the same request.
+	IdempotentResponse struct {
+		Status int
+		Header map[string][]string
+		Body   []byte
+	}
+)
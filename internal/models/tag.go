@@ -0,0 +1,15 @@
+package models
+
+type (
+	// TagQuery is a request for the most used tags.
+	TagQuery struct {
+		Prefix string // Tag prefix, tags are stored in lower case
+		Limit  int
+	}
+
+	// TagCount is a tag along with the number of books outside the trash tagged with it.
+	TagCount struct {
+		Tag   string
+		Books int64
+	}
+)
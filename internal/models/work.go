@@ -0,0 +1,44 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type (
+	// Work is an abstract creation that books are editions of: its editions and translations
+	// differ in publishers, years, ISBNs, languages, formats and translators.
+	Work struct {
+		ID        uuid.UUID
+		Title     string
+		Author    string `json:",omitempty"` // Names of the creators, free text
+		CreatedAt time.Time
+		UpdatedAt time.Time
+	}
+
+	// WorkListParams is a request for a page of works as it comes from API clients.
+	WorkListParams struct {
+		TitlePrefix string // Case-insensitive title prefix
+		Limit       int
+		Cursor      string // Opaque cursor returned with the previous page
+	}
+
+	// WorkQuery is a request for a page of works ordered by title as it is passed to repositories.
+	WorkQuery struct {
+		TitlePrefix string
+		Limit       int
+		After       *WorkCursor // Keyset position to continue from, nil for the first page
+	}
+
+	// WorkCursor is a keyset position: the title and the ID of the last work on a page.
+	WorkCursor struct {
+		Title string
+		ID    uuid.UUID
+	}
+
+	// WorkPage is a page of works.
+	WorkPage struct {
+		Items      []Work
+		NextCursor string // Empty if there are no more pages
+	}
+)
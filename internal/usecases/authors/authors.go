@@ -0,0 +1,198 @@
+package authors
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/pkg/textnorm"
+	"github.com/google/uuid"
+	"slices"
+	"strings"
+)
+
+//go:generate go install go.uber.org/mock/mockgen@v0.5.0
+//go:generate mockgen -destination usecase_mock.go -package authors . Authors
+
+// Authors interface defines the operations on authors credited for books.
+type (
+	Authors interface {
+		GetAll(ctx context.Context, params models.AuthorListParams) (*models.AuthorPage, error)  // Retrieve a page of authors ordered by name
+		GetOne(ctx context.Context, ID uuid.UUID) (*models.Author, error)                        // Get a single author by ID, following redirects of merged authors
+		Lookup(ctx context.Context, name string) (*models.Author, error)                         // Find the author by the preferred name or a variant
+		Create(ctx context.Context, author models.Author) (*models.Author, error)                // Create a new author
+		Update(ctx context.Context, ID uuid.UUID, author models.Author) (*models.Author, error)  // Rename an author along with the books crediting them
+		Delete(ctx context.Context, ID uuid.UUID) error                                          // Delete an author who isn't credited for any book
+		Merge(ctx context.Context, ID uuid.UUID, duplicates []uuid.UUID) (*models.Author, error) // Merge duplicates into the author
+	}
+
+	// authors struct implements the Authors interface.
+	authors struct {
+		repo Repository // Repository for data operations
+	}
+)
+
+// NewAuthorsUsecase creates and returns a new instance of the author use case.
+func NewAuthorsUsecase(repo Repository) Authors {
+	return &authors{
+		repo: repo,
+	}
+}
+
+const (
+	DefaultPageSize = 20  // Page size used when the client doesn't specify one
+	MaxPageSize     = 100 // Largest page size a client can request
+)
+
+// cursor is the serialized form of a keyset position handed out to clients.
+type cursor struct {
+	Name string    `json:"n"`
+	ID   uuid.UUID `json:"id"`
+}
+
+// GetAll retrieves a page of authors ordered by name, optionally only those whose names start with a prefix.
+func (u *authors) GetAll(ctx context.Context, params models.AuthorListParams) (*models.AuthorPage, error) {
+	query := models.AuthorQuery{NamePrefix: strings.TrimSpace(params.NamePrefix), Limit: params.Limit}
+	if query.Limit <= 0 {
+		query.Limit = DefaultPageSize
+	}
+	if query.Limit > MaxPageSize {
+		query.Limit = MaxPageSize
+	}
+	if params.Cursor != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(params.Cursor)
+		var c cursor
+		if err != nil || json.Unmarshal(raw, &c) != nil {
+			return nil, errs.Validation("invalid cursor")
+		}
+		query.After = &models.AuthorCursor{Name: c.Name, ID: c.ID}
+	}
+
+	// fetch one extra author to find out whether there is a next page
+	limit := query.Limit
+	query.Limit++
+	items, err := u.repo.GetAll(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.AuthorPage{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		last := page.Items[limit-1]
+		raw, err := json.Marshal(cursor{Name: last.Name, ID: last.ID})
+		if err != nil {
+			return nil, errs.Internal(err)
+		}
+		page.NextCursor = base64.RawURLEncoding.EncodeToString(raw)
+	}
+	return page, nil
+}
+
+// GetOne fetches an author by their ID. The ID of a merged author leads to the author they have been
+// merged into, so the result may have another ID.
+func (u *authors) GetOne(ctx context.Context, ID uuid.UUID) (*models.Author, error) {
+	author, err := u.repo.GetOne(ctx, ID)
+	if errs.KindOf(err) != errs.KindNotFound {
+		return author, err
+	}
+	target, redirectErr := u.repo.Redirect(ctx, ID)
+	if redirectErr != nil {
+		if errs.KindOf(redirectErr) == errs.KindNotFound {
+			return nil, err
+		}
+		return nil, redirectErr
+	}
+	return u.repo.GetOne(ctx, target)
+}
+
+// Lookup finds the author whose preferred name or variant is the name, ignoring case.
+func (u *authors) Lookup(ctx context.Context, name string) (*models.Author, error) {
+	name = textnorm.NFC(name)
+	if name == "" {
+		return nil, errs.InvalidFields(errs.FieldError{Field: "name", Message: "is required"})
+	}
+	return u.repo.GetByName(ctx, name)
+}
+
+// Create adds a new author with a unique identifier and returns them as they were stored.
+func (u *authors) Create(ctx context.Context, author models.Author) (*models.Author, error) {
+	author.ID = uuid.New() // Generate a new UUID for the author
+	if err := u.normalize(&author); err != nil {
+		return nil, err
+	}
+	return u.repo.Create(ctx, author)
+}
+
+// Update renames an author and returns the stored author. The books crediting the author
+// get the new name as well, which makes them new versions.
+func (u *authors) Update(ctx context.Context, ID uuid.UUID, author models.Author) (*models.Author, error) {
+	author.ID = ID // Ensure the ID remains unchanged
+	if err := u.normalize(&author); err != nil {
+		return nil, err
+	}
+	if err := u.repo.Update(ctx, ID, author); err != nil {
+		return nil, err
+	}
+	return u.repo.GetOne(ctx, ID)
+}
+
+// Delete removes an author by their ID. Authors credited for books, deleted ones included, can't be removed.
+func (u *authors) Delete(ctx context.Context, ID uuid.UUID) error {
+	return u.repo.Delete(ctx, ID)
+}
+
+// Merge merges the duplicates into the author: books crediting the duplicates credit the author instead,
+// names of the duplicates become variants of the author, and the IDs of the duplicates redirect to the author.
+func (u *authors) Merge(ctx context.Context, ID uuid.UUID, duplicates []uuid.UUID) (*models.Author, error) {
+	if len(duplicates) == 0 {
+		return nil, errs.InvalidFields(errs.FieldError{Field: "duplicates", Message: "is required"})
+	}
+	unique := make([]uuid.UUID, 0, len(duplicates))
+	for i, duplicate := range duplicates {
+		if duplicate == ID {
+			return nil, errs.InvalidFields(errs.FieldError{Field: fmt.Sprintf("duplicates[%d]", i), Message: "must differ from the author"})
+		}
+		if !slices.Contains(unique, duplicate) {
+			unique = append(unique, duplicate)
+		}
+	}
+	if err := u.repo.Merge(ctx, ID, unique); err != nil {
+		return nil, err
+	}
+	return u.repo.GetOne(ctx, ID)
+}
+
+// normalize brings the names to Unicode NFC, the same way as names in credits of books,
+// and drops repeated variants and variants differing from the preferred name only in case.
+// The separator of names in Book.Author can't be a part of a name.
+func (u *authors) normalize(author *models.Author) error {
+	author.Name = textnorm.NFC(author.Name)
+	if author.Name == "" {
+		return errs.InvalidFields(errs.FieldError{Field: "name", Message: "is required"})
+	}
+	if strings.Contains(author.Name, models.AuthorSeparator) {
+		return errs.InvalidFields(errs.FieldError{Field: "name", Message: fmt.Sprintf("must not contain %q", models.AuthorSeparator)})
+	}
+
+	var variants []string
+	for i, variant := range author.Variants {
+		field := fmt.Sprintf("variants[%d]", i)
+		variant = textnorm.NFC(variant)
+		if variant == "" {
+			return errs.InvalidFields(errs.FieldError{Field: field, Message: "is required"})
+		}
+		if strings.Contains(variant, models.AuthorSeparator) {
+			return errs.InvalidFields(errs.FieldError{Field: field, Message: fmt.Sprintf("must not contain %q", models.AuthorSeparator)})
+		}
+		if !strings.EqualFold(variant, author.Name) && !slices.ContainsFunc(variants, func(other string) bool {
+			return strings.EqualFold(other, variant)
+		}) {
+			variants = append(variants, variant)
+		}
+	}
+	author.Variants = variants
+	return nil
+}
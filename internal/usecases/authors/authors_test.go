@@ -0,0 +1,286 @@
+package authors
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+)
+
+func TestGetAll(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewAuthorsUsecase(repo)
+
+	authors := []models.Author{
+		{ID: uuid.New(), Name: "Anna Akhmatova"},
+		{ID: uuid.New(), Name: "Leo Tolstoy"},
+		{ID: uuid.New(), Name: "Neil Gaiman"},
+	}
+	ctx := context.Background()
+
+	t.Run("Pages", func(t *testing.T) {
+		repo.EXPECT().GetAll(ctx, models.AuthorQuery{Limit: 3}).Return(authors, nil)
+		page, err := usecase.GetAll(ctx, models.AuthorListParams{Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, authors[:2], page.Items)
+		assert.NotEmpty(t, page.NextCursor)
+
+		after := &models.AuthorCursor{Name: authors[1].Name, ID: authors[1].ID}
+		repo.EXPECT().GetAll(ctx, models.AuthorQuery{NamePrefix: "ne", Limit: 3, After: after}).Return(authors[2:], nil)
+		page, err = usecase.GetAll(ctx, models.AuthorListParams{NamePrefix: " ne ", Limit: 2, Cursor: page.NextCursor})
+		assert.NoError(t, err)
+		assert.Equal(t, authors[2:], page.Items)
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("Default and maximal page size", func(t *testing.T) {
+		repo.EXPECT().GetAll(ctx, models.AuthorQuery{Limit: DefaultPageSize + 1}).Return(nil, nil)
+		_, err := usecase.GetAll(ctx, models.AuthorListParams{})
+		assert.NoError(t, err)
+
+		repo.EXPECT().GetAll(ctx, models.AuthorQuery{Limit: MaxPageSize + 1}).Return(nil, nil)
+		_, err = usecase.GetAll(ctx, models.AuthorListParams{Limit: 1000})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Invalid cursor", func(t *testing.T) {
+		_, err := usecase.GetAll(ctx, models.AuthorListParams{Cursor: "not a cursor"})
+		assert.ErrorIs(t, err, errs.ErrValidation)
+	})
+}
+
+func TestCreate(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewAuthorsUsecase(repo)
+
+	// test cases
+	cases := []struct {
+		name string
+
+		req      models.Author
+		stored   string
+		variants []string
+		field    string
+	}{
+		{
+			name:   "Create",
+			req:    models.Author{Name: "Leo Tolstoy"},
+			stored: "Leo Tolstoy",
+		},
+		{
+			name:   "Create normalizes the name",
+			req:    models.Author{Name: " Толстой "},
+			stored: "Толстой",
+		},
+		{
+			name:     "Create with variants",
+			req:      models.Author{Name: "Leo Tolstoy", Variants: []string{"Лев Толстой", " Lev Tolstoy", "лев толстой", "LEO TOLSTOY"}},
+			stored:   "Leo Tolstoy",
+			variants: []string{"Лев Толстой", "Lev Tolstoy"},
+		},
+		{
+			name:  "Empty variant",
+			req:   models.Author{Name: "Leo Tolstoy", Variants: []string{"Лев Толстой", " "}},
+			field: "variants[1]",
+		},
+		{
+			name:  "Empty name",
+			req:   models.Author{Name: " "},
+			field: "name",
+		},
+		{
+			name:  "Name with the separator",
+			req:   models.Author{Name: "Terry Pratchett and Neil Gaiman"},
+			field: "name",
+		},
+	}
+
+	// execution
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.Background()
+			if testCase.field == "" {
+				repo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, author models.Author) (*models.Author, error) {
+					assert.NotEqual(t, uuid.Nil, author.ID)
+					assert.Equal(t, testCase.stored, author.Name)
+					assert.Equal(t, testCase.variants, author.Variants)
+					return &author, nil
+				})
+			}
+			author, err := usecase.Create(ctx, testCase.req)
+			if testCase.field != "" {
+				assert.Equal(t, errs.KindValidation, errs.KindOf(err))
+				assert.Equal(t, testCase.field, errs.FieldsOf(err)[0].Field)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.stored, author.Name)
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewAuthorsUsecase(repo)
+
+	ctx := context.Background()
+	ID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		renamed := models.Author{ID: ID, Name: "Lev Tolstoy"}
+		repo.EXPECT().Update(ctx, ID, renamed).Return(nil)
+		repo.EXPECT().GetOne(ctx, ID).Return(&renamed, nil)
+
+		author, err := usecase.Update(ctx, ID, models.Author{Name: " Lev Tolstoy"})
+		assert.NoError(t, err)
+		assert.Equal(t, &renamed, author)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		repo.EXPECT().Update(ctx, ID, gomock.Any()).Return(errs.NotFound("author doesn't exist"))
+
+		_, err := usecase.Update(ctx, ID, models.Author{Name: "Lev Tolstoy"})
+		assert.ErrorIs(t, err, errs.ErrNotFound)
+	})
+
+	t.Run("Invalid name", func(t *testing.T) {
+		repo.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := usecase.Update(ctx, ID, models.Author{})
+		assert.ErrorIs(t, err, errs.ErrValidation)
+	})
+}
+
+func TestDelete(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewAuthorsUsecase(repo)
+
+	ctx := context.Background()
+	ID := uuid.New()
+	repo.EXPECT().Delete(ctx, ID).Return(errs.Conflict("author is credited for books"))
+
+	err := usecase.Delete(ctx, ID)
+	assert.ErrorIs(t, err, errs.ErrConflict)
+}
+
+func TestGetOne(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewAuthorsUsecase(repo)
+
+	ctx := context.Background()
+	ID := uuid.New()
+	target := models.Author{ID: uuid.New(), Name: "Leo Tolstoy"}
+
+	t.Run("Redirect of a merged author", func(t *testing.T) {
+		repo.EXPECT().GetOne(ctx, ID).Return(nil, errs.NotFound("author doesn't exist"))
+		repo.EXPECT().Redirect(ctx, ID).Return(target.ID, nil)
+		repo.EXPECT().GetOne(ctx, target.ID).Return(&target, nil)
+
+		author, err := usecase.GetOne(ctx, ID)
+		assert.NoError(t, err)
+		assert.Equal(t, &target, author)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		repo.EXPECT().GetOne(ctx, ID).Return(nil, errs.NotFound("author doesn't exist"))
+		repo.EXPECT().Redirect(ctx, ID).Return(uuid.Nil, errs.NotFound("author hasn't been merged"))
+
+		_, err := usecase.GetOne(ctx, ID)
+		assert.ErrorIs(t, err, errs.ErrNotFound)
+	})
+}
+
+func TestLookup(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewAuthorsUsecase(repo)
+
+	ctx := context.Background()
+	author := models.Author{ID: uuid.New(), Name: "Leo Tolstoy", Variants: []string{"Лев Толстой"}}
+
+	t.Run("Success", func(t *testing.T) {
+		repo.EXPECT().GetByName(ctx, "Лев Толстой").Return(&author, nil)
+
+		found, err := usecase.Lookup(ctx, " Лев Толстой ")
+		assert.NoError(t, err)
+		assert.Equal(t, &author, found)
+	})
+
+	t.Run("Empty name", func(t *testing.T) {
+		_, err := usecase.Lookup(ctx, " ")
+		assert.ErrorIs(t, err, errs.ErrValidation)
+	})
+}
+
+func TestMerge(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewAuthorsUsecase(repo)
+
+	ctx := context.Background()
+	author := models.Author{ID: uuid.New(), Name: "Leo Tolstoy", Variants: []string{"Лев Толстой"}}
+	duplicate := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		repo.EXPECT().Merge(ctx, author.ID, []uuid.UUID{duplicate}).Return(nil)
+		repo.EXPECT().GetOne(ctx, author.ID).Return(&author, nil)
+
+		merged, err := usecase.Merge(ctx, author.ID, []uuid.UUID{duplicate, duplicate})
+		assert.NoError(t, err)
+		assert.Equal(t, &author, merged)
+	})
+
+	t.Run("Merge into itself", func(t *testing.T) {
+		_, err := usecase.Merge(ctx, author.ID, []uuid.UUID{duplicate, author.ID})
+		assert.Equal(t, errs.KindValidation, errs.KindOf(err))
+		assert.Equal(t, "duplicates[1]", errs.FieldsOf(err)[0].Field)
+	})
+
+	t.Run("No duplicates", func(t *testing.T) {
+		_, err := usecase.Merge(ctx, author.ID, nil)
+		assert.ErrorIs(t, err, errs.ErrValidation)
+	})
+
+	t.Run("Missing duplicate", func(t *testing.T) {
+		repo.EXPECT().Merge(ctx, author.ID, []uuid.UUID{duplicate}).Return(errs.NotFound("author doesn't exist"))
+
+		_, err := usecase.Merge(ctx, author.ID, []uuid.UUID{duplicate})
+		assert.ErrorIs(t, err, errs.ErrNotFound)
+	})
+}
@@ -0,0 +1,28 @@
+package authors
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/google/uuid"
+)
+
+//go:generate mockgen -destination repository_mock.go -package authors . Repository
+
+type Repository interface {
+	// GetAll retrieves a page of authors in the order of their names.
+	GetAll(ctx context.Context, query models.AuthorQuery) ([]models.Author, error)
+	GetOne(ctx context.Context, ID uuid.UUID) (*models.Author, error)
+	Create(ctx context.Context, author models.Author) (*models.Author, error)
+	// Update renames an author, and the books crediting the author are renamed along with it.
+	Update(ctx context.Context, ID uuid.UUID, author models.Author) error
+	// Delete removes an author that isn't credited for any book, the ones in the trash included.
+	Delete(ctx context.Context, ID uuid.UUID) error
+	// GetByName retrieves the author with the preferred name or a variant, ignoring case. Preferred names
+	// are matched first, then the oldest author wins.
+	GetByName(ctx context.Context, name string) (*models.Author, error)
+	// Redirect returns the ID of the author that a merged author has been merged into.
+	Redirect(ctx context.Context, ID uuid.UUID) (uuid.UUID, error)
+	// Merge repoints the credits of the duplicates to the author, adds their names to the variants of the author
+	// and replaces the duplicates with redirects to it, all at once.
+	Merge(ctx context.Context, ID uuid.UUID, duplicates []uuid.UUID) error
+}
@@ -2,8 +2,18 @@ package books
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/KinitaL/testovoye/internal/errs"
 	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/pkg/isbn"
+	"github.com/KinitaL/testovoye/pkg/textnorm"
 	"github.com/google/uuid"
+	"iter"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 )
 
 //go:generate go install go.uber.org/mock/mockgen@v0.5.0
@@ -12,11 +22,25 @@ import (
 // Books interface defines the main operations for managing books.
 type (
 	Books interface {
-		GetAll(ctx context.Context) ([]models.Book, error)                // Retrieve all books
-		GetOne(ctx context.Context, ID uuid.UUID) (*models.Book, error)   // Get a single book by ID
-		Create(ctx context.Context, book models.Book) error               // Create a new book
-		Update(ctx context.Context, ID uuid.UUID, book models.Book) error // Update an existing book
-		Delete(ctx context.Context, ID uuid.UUID) error                   // Delete a book by ID
+		GetAll(ctx context.Context, params models.BookListParams) (*models.BookPage, error)                       // Retrieve a page of books
+		Export(ctx context.Context, filter models.BookFilter) (iter.Seq2[models.Book, error], error)              // Stream all books matching the filter
+		Search(ctx context.Context, query models.BookSearchQuery) (*models.BookSearchResult, error)               // Full-text search over titles and authors
+		Match(ctx context.Context, query models.BookMatchQuery) (*models.BookMatchResult, error)                  // Retrieve a page of books satisfying a condition
+		GetOne(ctx context.Context, ID uuid.UUID) (*models.Book, error)                                           // Get a single book by ID
+		GetAny(ctx context.Context, ID uuid.UUID) (*models.Book, error)                                           // Get a single book by ID, even if it is in the trash
+		GetByISBN(ctx context.Context, number string) (*models.Book, error)                                       // Get a single book by its ISBN-10 or ISBN-13
+		Harvest(ctx context.Context, params models.HarvestParams) (*models.HarvestPage, error)                    // Retrieve a page of changed books, deleted ones included
+		EarliestDatestamp(ctx context.Context) (time.Time, error)                                                 // Get the earliest datestamp of all books, zero if there are none
+		Create(ctx context.Context, book models.Book) (*models.Book, error)                                       // Create a new book
+		Update(ctx context.Context, ID uuid.UUID, book models.Book) (*models.Book, error)                         // Replace an existing book; a non-zero book.Version must match the stored one
+		Patch(ctx context.Context, ID uuid.UUID, version uint64, patch models.BookPatch) (*models.Book, error)    // Change an existing book with a patch; a non-zero version must match the stored one
+		Batch(ctx context.Context, ops []models.BookOperation, atomic bool) ([]models.BookOperationResult, error) // Apply many creations, updates and deletions, all or nothing if atomic
+		Import(ctx context.Context, books []models.Book, dryRun bool) ([]models.BookImportResult, error)          // Create books that aren't in the catalog yet
+		Delete(ctx context.Context, ID uuid.UUID, version uint64) error                                           // Move a book to the trash; a non-zero version must match the stored one
+		GetTrash(ctx context.Context, params models.TrashParams) (*models.BookPage, error)                        // Retrieve a page of deleted books
+		Restore(ctx context.Context, ID uuid.UUID) (*models.Book, error)                                          // Move a book back from the trash
+		Purge(ctx context.Context, ID uuid.UUID) error                                                            // Permanently delete a book from the trash
+		PurgeExpired(ctx context.Context, retention time.Duration) (int64, error)                                 // Permanently delete books kept in the trash longer than retention
 	}
 
 	// books struct implements the Books interface.
@@ -32,9 +56,228 @@ func NewBooksUsecase(repo Repository) Books {
 	}
 }
 
-// GetAll retrieves a list of all books.
-func (u *books) GetAll(ctx context.Context) ([]models.Book, error) {
-	return u.repo.GetAll(ctx)
+const (
+	DefaultPageSize            = 20   // Page size used when the client doesn't specify one
+	MaxPageSize                = 100  // Largest page size a client can request
+	DefaultSimilarityThreshold = 0.3  // Similarity threshold of fuzzy search, the same as in pg_trgm
+	MaxSuggestions             = 5    // Number of "did you mean" suggestions
+	MaxPatchAttempts           = 3    // Number of times an unconditional patch is reapplied after concurrent updates
+	MaxBatchSize               = 1000 // Largest number of operations in a batch
+)
+
+// GetAll retrieves a page of books matching the filter. With OnePerWork, editions of a work are collapsed
+// into the earliest matching one, and the total and the facets count works and standalone books.
+func (u *books) GetAll(ctx context.Context, params models.BookListParams) (*models.BookPage, error) {
+	if params.YearFrom != 0 && params.YearTo != 0 && params.YearFrom > params.YearTo {
+		return nil, errs.Validation("year_from must not be greater than year_to")
+	}
+	params.Tag = strings.ToLower(textnorm.NFC(params.Tag))
+
+	query := models.BookQuery{
+		BookFilter: params.BookFilter,
+		SortBy:     params.SortBy,
+		Desc:       params.Desc,
+		Locale:     params.Locale,
+		Limit:      params.Limit,
+	}
+	switch query.SortBy {
+	case models.BookSortTitle, models.BookSortAuthor, models.BookSortYear, models.BookSortCreatedAt:
+	case "":
+		query.SortBy = models.BookSortCreatedAt
+	default:
+		return nil, errs.Validation("unknown sort field %q", query.SortBy)
+	}
+	switch query.Locale {
+	case models.LocaleEnglish, models.LocaleRussian:
+	case "":
+		query.Locale = models.LocaleEnglish
+	default:
+		return nil, errs.Validation("unsupported locale %q", query.Locale)
+	}
+	if query.Limit <= 0 {
+		query.Limit = DefaultPageSize
+	}
+	if query.Limit > MaxPageSize {
+		query.Limit = MaxPageSize
+	}
+	if params.Cursor != "" {
+		after, err := decodeCursor(params.Cursor, query)
+		if err != nil {
+			return nil, err
+		}
+		query.After = after
+	}
+
+	// fetch one extra book to find out whether there is a next page
+	limit := query.Limit
+	query.Limit++
+	items, err := u.repo.GetAll(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.BookPage{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		next, err := encodeCursor(page.Items[limit-1], query)
+		if err != nil {
+			return nil, errs.Internal(err)
+		}
+		page.NextCursor = next
+	}
+
+	if params.WithTotal {
+		total, err := u.repo.Count(ctx, params.BookFilter)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+	if params.WithFacets {
+		facets, err := u.repo.Facets(ctx, params.BookFilter)
+		if err != nil {
+			return nil, err
+		}
+		page.Facets = facets
+	}
+	return page, nil
+}
+
+// Export streams all books matching the filter in the order of creation.
+// The returned sequence stops after yielding an error.
+func (u *books) Export(ctx context.Context, filter models.BookFilter) (iter.Seq2[models.Book, error], error) {
+	if filter.YearFrom != 0 && filter.YearTo != 0 && filter.YearFrom > filter.YearTo {
+		return nil, errs.Validation("year_from must not be greater than year_to")
+	}
+	return u.repo.Export(ctx, filter), nil
+}
+
+// Search finds books whose title or author match the query, most relevant first.
+func (u *books) Search(ctx context.Context, query models.BookSearchQuery) (*models.BookSearchResult, error) {
+	// the same key is used when books are indexed, so the query matches regardless of the script
+	query.Query = textnorm.Key(query.Query)
+	if query.Query == "" {
+		return nil, errs.Validation("search query must not be empty")
+	}
+	if query.Limit <= 0 {
+		query.Limit = DefaultPageSize
+	}
+	if query.Limit > MaxPageSize {
+		query.Limit = MaxPageSize
+	}
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+	if query.Threshold == 0 {
+		query.Threshold = DefaultSimilarityThreshold
+	}
+	if query.Threshold < 0 || query.Threshold > 1 {
+		return nil, errs.Validation("similarity threshold must be between 0 and 1")
+	}
+
+	hits, err := u.repo.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.BookSearchResult{Hits: hits}
+	if len(hits) == 0 && query.Offset == 0 {
+		suggestions, err := u.repo.Suggest(ctx, models.BookSearchQuery{
+			Query:     query.Query,
+			Threshold: query.Threshold,
+			Limit:     MaxSuggestions,
+		})
+		if err != nil {
+			return nil, err
+		}
+		result.Suggestions = suggestions
+	}
+
+	terms := make(map[string]struct{})
+	for _, term := range textnorm.Words(query.Query) {
+		terms[term] = struct{}{}
+	}
+	for i := range hits {
+		hits[i].Highlight = models.BookHighlight{
+			Title:  highlight(hits[i].Book.Title, terms),
+			Author: highlight(hits[i].Book.Author, terms),
+		}
+	}
+	return result, nil
+}
+
+// Match retrieves a page of books satisfying the condition in the order of creation, along with their number.
+func (u *books) Match(ctx context.Context, query models.BookMatchQuery) (*models.BookMatchResult, error) {
+	if query.Condition != nil {
+		if err := validateCondition(*query.Condition); err != nil {
+			return nil, err
+		}
+	}
+	if query.Limit < 0 {
+		query.Limit = 0
+	}
+	if query.Limit > MaxPageSize {
+		query.Limit = MaxPageSize
+	}
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+
+	total, err := u.repo.CountMatches(ctx, query.Condition)
+	if err != nil {
+		return nil, err
+	}
+	result := &models.BookMatchResult{Items: []models.Book{}, Total: total}
+	if query.Limit == 0 || int64(query.Offset) >= total {
+		return result, nil
+	}
+	if result.Items, err = u.repo.Match(ctx, query); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// validateCondition checks that every comparison of a condition is applicable to its field.
+func validateCondition(condition models.BookCondition) error {
+	switch condition.Op {
+	case models.BookConditionAnd, models.BookConditionOr, models.BookConditionNot:
+		if condition.Left == nil || condition.Right == nil {
+			return errs.Validation("condition %q needs two operands", condition.Op)
+		}
+		if err := validateCondition(*condition.Left); err != nil {
+			return err
+		}
+		return validateCondition(*condition.Right)
+	case models.BookConditionAll:
+		return nil
+	case models.BookConditionMatch:
+	default:
+		return errs.Validation("unknown condition %q", condition.Op)
+	}
+
+	switch condition.Field {
+	case models.BookConditionTitle, models.BookConditionAuthor:
+		text, ok := condition.Value.(string)
+		if !ok || text == "" {
+			return errs.Validation("%s must be compared with a non-empty text", condition.Field)
+		}
+		switch condition.Relation {
+		case models.BookRelationContains, models.BookRelationEqual, models.BookRelationNotEqual:
+			return nil
+		}
+	case models.BookConditionYear:
+		if _, ok := condition.Value.(uint16); !ok {
+			return errs.Validation("year must be compared with a number")
+		}
+		switch condition.Relation {
+		case models.BookRelationEqual, models.BookRelationNotEqual, models.BookRelationLess,
+			models.BookRelationLessOrEqual, models.BookRelationGreater, models.BookRelationGreaterOrEqual:
+			return nil
+		}
+	default:
+		return errs.Validation("unknown field %q", condition.Field)
+	}
+	return errs.Validation("%s can't be compared with %q", condition.Field, condition.Relation)
 }
 
 // GetOne fetches a book by its ID.
@@ -42,19 +285,522 @@ func (u *books) GetOne(ctx context.Context, ID uuid.UUID) (*models.Book, error)
 	return u.repo.GetOne(ctx, ID)
 }
 
-// Create adds a new book with a unique identifier.
-func (u *books) Create(ctx context.Context, book models.Book) error {
+// GetAny retrieves a book by its ID whether or not it is in the trash.
+func (u *books) GetAny(ctx context.Context, ID uuid.UUID) (*models.Book, error) {
+	return u.repo.GetAny(ctx, ID)
+}
+
+// GetByISBN fetches a book by its ISBN. Both ISBN-10 and ISBN-13 are accepted, with or without hyphens.
+func (u *books) GetByISBN(ctx context.Context, number string) (*models.Book, error) {
+	normalized, err := isbn.Normalize(number)
+	if err != nil {
+		return nil, errs.Validation("%q is not a valid ISBN", number)
+	}
+	return u.repo.GetByISBN(ctx, normalized)
+}
+
+// Harvest retrieves a page of books changed within the bounds in the order of their datestamps,
+// deleted ones included, so that harvesters can keep their copies of the catalog in sync.
+func (u *books) Harvest(ctx context.Context, params models.HarvestParams) (*models.HarvestPage, error) {
+	query := models.HarvestQuery{From: params.From, Until: params.Until, Limit: params.Limit}
+	if query.Limit <= 0 {
+		query.Limit = DefaultPageSize
+	}
+	if query.Limit > MaxPageSize {
+		query.Limit = MaxPageSize
+	}
+	if params.Cursor != "" {
+		// the cursor keeps the bounds of the first request
+		c, err := decodeHarvestCursor(params.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query.From, query.Until = c.From, c.Until
+		query.After = &models.BookCursor{Value: c.Value, ID: c.ID}
+	}
+	if query.From != nil && query.Until != nil && query.Until.Before(*query.From) {
+		return nil, errs.Validation("from must not be later than until")
+	}
+
+	// fetch one extra book to find out whether there is a next page
+	limit := query.Limit
+	query.Limit++
+	items, err := u.repo.Harvest(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.HarvestPage{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		next, err := encodeHarvestCursor(page.Items[limit-1], query)
+		if err != nil {
+			return nil, errs.Internal(err)
+		}
+		page.NextCursor = next
+	}
+	return page, nil
+}
+
+// EarliestDatestamp returns the datestamp of the book that has been changed least recently, deleted ones included.
+func (u *books) EarliestDatestamp(ctx context.Context) (time.Time, error) {
+	items, err := u.repo.Harvest(ctx, models.HarvestQuery{Limit: 1})
+	if err != nil || len(items) == 0 {
+		return time.Time{}, err
+	}
+	return items[0].Datestamp(), nil
+}
+
+// Create adds a new book with a unique identifier and returns it as it was stored.
+func (u *books) Create(ctx context.Context, book models.Book) (*models.Book, error) {
 	book.ID = uuid.New() // Generate a new UUID for the book
+	if err := u.normalize(&book); err != nil {
+		return nil, err
+	}
 	return u.repo.Create(ctx, book)
 }
 
-// Update modifies an existing book by its ID.
-func (u *books) Update(ctx context.Context, ID uuid.UUID, book models.Book) error {
+// Update replaces all fields of an existing book and returns the stored book.
+func (u *books) Update(ctx context.Context, ID uuid.UUID, book models.Book) (*models.Book, error) {
 	book.ID = ID // Ensure the ID remains unchanged
-	return u.repo.Update(ctx, ID, book)
+	if err := u.normalize(&book); err != nil {
+		return nil, err
+	}
+	if err := u.repo.Update(ctx, ID, book); err != nil {
+		return nil, err
+	}
+	return u.repo.GetOne(ctx, ID)
+}
+
+// Patch applies a patch to the current state of a book and returns the stored book.
+// The book is written only if nobody has changed it since it was read. When the client
+// hasn't asked for a particular version, the patch is reapplied to the fresh state instead of failing.
+func (u *books) Patch(ctx context.Context, ID uuid.UUID, version uint64, patch models.BookPatch) (*models.Book, error) {
+	for attempt := 1; ; attempt++ {
+		current, err := u.repo.GetOne(ctx, ID)
+		if err != nil {
+			return nil, err
+		}
+		if version != 0 && current.Version != version {
+			return nil, errs.PreconditionFailed("book with ID = %s has been modified since version %d", ID, version)
+		}
+
+		book, err := patch(*current)
+		if err != nil {
+			return nil, err
+		}
+		book.ID = ID
+		book.Version = current.Version
+		if err := u.normalize(&book); err != nil {
+			return nil, err
+		}
+
+		err = u.repo.Update(ctx, ID, book)
+		if errors.Is(err, errs.ErrPreconditionFailed) && version == 0 && attempt < MaxPatchAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return u.repo.GetOne(ctx, ID)
+	}
+}
+
+// Batch applies a list of creations, updates and deletions; an update replaces all fields of a book, as Update does.
+// Creations are applied first with a single multi-row insert, so either all of them succeed or none.
+// In atomic mode the whole batch is applied in a transaction, and the error of the first failed operation is returned.
+// Otherwise, the outcome of each operation is reported in the result with the same index.
+func (u *books) Batch(ctx context.Context, ops []models.BookOperation, atomic bool) ([]models.BookOperationResult, error) {
+	if len(ops) == 0 || len(ops) > MaxBatchSize {
+		return nil, errs.Validation("batch must contain from 1 to %d operations", MaxBatchSize)
+	}
+
+	ops = slices.Clone(ops)
+	results := make([]models.BookOperationResult, len(ops))
+	changed := make(map[uuid.UUID]struct{}, len(ops)) // updated and deleted books
+	for i := range ops {
+		op := &ops[i]
+		switch op.Type {
+		case models.BookOperationCreate:
+			op.Book.ID = uuid.New()
+		case models.BookOperationUpdate, models.BookOperationDelete:
+			if _, ok := changed[op.ID]; ok {
+				return nil, errs.Validation("book with ID = %s is changed by more than one operation", op.ID)
+			}
+			changed[op.ID] = struct{}{}
+			op.Book.ID = op.ID
+			op.Book.Version = op.Version
+		default:
+			return nil, errs.Validation("unknown operation type %q", op.Type)
+		}
+		if err := u.normalize(&op.Book); err != nil {
+			if atomic {
+				return nil, operationError(i, err)
+			}
+			results[i].Err = err // the operation is skipped, the rest are still applied
+		}
+	}
+
+	if !atomic {
+		_ = u.applyBatch(ctx, u.repo, ops, results, false)
+		return results, nil
+	}
+	err := u.repo.Transaction(ctx, func(repo Repository) error {
+		return u.applyBatch(ctx, repo, ops, results, true)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Import creates the books that aren't in the catalog yet with a single multi-row insert, matching them by Identity
+// and by ISBN. Books that repeat existing ones or each other are reported as duplicates, and books that fail
+// normalization are reported with their errors. If the repository rejects the insert, the books are created
+// one by one, so that every rejected book is reported with its own error. In a dry run nothing is written.
+func (u *books) Import(ctx context.Context, batch []models.Book, dryRun bool) ([]models.BookImportResult, error) {
+	if len(batch) > MaxBatchSize {
+		return nil, errs.Validation("import batch must contain at most %d books", MaxBatchSize)
+	}
+
+	batch = slices.Clone(batch)
+	results := make([]models.BookImportResult, len(batch))
+	identities := make([]models.BookIdentity, len(batch))
+	var (
+		valid []models.BookIdentity
+		isbns []string
+	)
+	for i := range batch {
+		if err := u.normalize(&batch[i]); err != nil {
+			results[i].Err = err // the book is skipped, the rest are still imported
+			continue
+		}
+		identities[i] = Identity(batch[i])
+		valid = append(valid, identities[i])
+		if batch[i].ISBN != "" {
+			isbns = append(isbns, batch[i].ISBN)
+		}
+	}
+	if len(valid) == 0 {
+		return results, nil
+	}
+	existing, err := u.repo.Existing(ctx, valid)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[models.BookIdentity]struct{}, len(batch))
+	for _, identity := range existing {
+		known[identity] = struct{}{}
+	}
+	knownISBNs := make(map[string]struct{}, len(isbns))
+	if len(isbns) > 0 {
+		existingISBNs, err := u.repo.ExistingISBNs(ctx, isbns)
+		if err != nil {
+			return nil, err
+		}
+		for _, number := range existingISBNs {
+			knownISBNs[number] = struct{}{}
+		}
+	}
+
+	var (
+		creations []models.Book
+		positions []int
+	)
+	for i, book := range batch {
+		if results[i].Err != nil {
+			continue
+		}
+		_, ok := known[identities[i]]
+		if _, taken := knownISBNs[book.ISBN]; ok || taken {
+			results[i].Duplicate = true
+			continue
+		}
+		known[identities[i]] = struct{}{}
+		if book.ISBN != "" {
+			knownISBNs[book.ISBN] = struct{}{}
+		}
+		book.ID = uuid.New() // Generate a new UUID for the book
+		creations = append(creations, book)
+		positions = append(positions, i)
+	}
+
+	if !dryRun && len(creations) > 0 {
+		created, err := u.repo.CreateBatch(ctx, creations)
+		if err != nil && errs.KindOf(err) == errs.KindInternal {
+			return nil, err
+		}
+		if err != nil {
+			// a book the repository rejects, like one of an unknown genre, fails only itself
+			for k, i := range positions {
+				book, err := u.repo.Create(ctx, creations[k])
+				switch {
+				case err == nil:
+					results[i].Book = book
+				case errs.KindOf(err) == errs.KindInternal:
+					return nil, err
+				case errs.KindOf(err) == errs.KindConflict:
+					results[i].Duplicate = true // inserted concurrently by someone else
+				default:
+					results[i].Err = err
+				}
+			}
+			return results, nil
+		}
+		creations = created
+	}
+	for k, i := range positions {
+		results[i].Book = &creations[k]
+	}
+	return results, nil
+}
+
+// Identity returns the script-independent keys of the title and the author, so that
+// "Война и мир" by "Толстой" and "Voina i mir" by "Tolstoy" are the same book.
+func Identity(book models.Book) models.BookIdentity {
+	return models.BookIdentity{
+		TitleKey:  textnorm.Key(book.Title),
+		AuthorKey: textnorm.Key(book.Author),
+	}
+}
+
+// applyBatch applies prepared operations and records their outcomes in results, skipping the ones that already failed.
+// If stop is set, it returns the error of the first failed operation without applying the rest.
+// Otherwise the creations that fail together in the multi-row insert are retried one by one,
+// so that every operation gets its own outcome.
+func (u *books) applyBatch(ctx context.Context, repo Repository, ops []models.BookOperation, results []models.BookOperationResult, stop bool) error {
+	var (
+		creations []models.Book
+		positions []int
+	)
+	for i, op := range ops {
+		if op.Type == models.BookOperationCreate && results[i].Err == nil {
+			creations = append(creations, op.Book)
+			positions = append(positions, i)
+		}
+	}
+	if len(creations) > 0 {
+		created, err := repo.CreateBatch(ctx, creations)
+		if err != nil && stop {
+			return operationError(positions[0], err)
+		}
+		for k, i := range positions {
+			if err == nil {
+				results[i].Book = &created[k]
+				continue
+			}
+			results[i].Book, results[i].Err = repo.Create(ctx, creations[k])
+		}
+	}
+
+	for i, op := range ops {
+		if results[i].Err != nil {
+			continue
+		}
+		var err error
+		switch op.Type {
+		case models.BookOperationUpdate:
+			if err = repo.Update(ctx, op.ID, op.Book); err == nil {
+				results[i].Book, err = repo.GetOne(ctx, op.ID)
+			}
+		case models.BookOperationDelete:
+			err = repo.Delete(ctx, op.ID, op.Version)
+		default:
+			continue
+		}
+		if err != nil && stop {
+			return operationError(i, err)
+		}
+		results[i].Err = err
+	}
+	return nil
+}
+
+// operationError prefixes the message of a domain error with the position of the failed batch operation.
+func operationError(index int, err error) error {
+	kind := errs.KindOf(err)
+	if kind == errs.KindInternal {
+		return err
+	}
+	return &errs.Error{
+		Kind:    kind,
+		Message: fmt.Sprintf("operation %d: %s", index, errs.MessageOf(err)),
+		Fields:  errs.FieldsOf(err),
+	}
 }
 
 // Delete removes a book by its ID.
-func (u *books) Delete(ctx context.Context, ID uuid.UUID) error {
-	return u.repo.Delete(ctx, ID)
+func (u *books) Delete(ctx context.Context, ID uuid.UUID, version uint64) error {
+	return u.repo.Delete(ctx, ID, version)
+}
+
+// GetTrash retrieves a page of deleted books, most recently deleted first.
+func (u *books) GetTrash(ctx context.Context, params models.TrashParams) (*models.BookPage, error) {
+	query := models.TrashQuery{Limit: params.Limit}
+	if query.Limit <= 0 {
+		query.Limit = DefaultPageSize
+	}
+	if query.Limit > MaxPageSize {
+		query.Limit = MaxPageSize
+	}
+	// the trash shares the cursor format of the listing, ordered by deletion time
+	ordering := models.BookQuery{SortBy: models.BookSortDeletedAt, Desc: true}
+	if params.Cursor != "" {
+		after, err := decodeCursor(params.Cursor, ordering)
+		if err != nil {
+			return nil, err
+		}
+		query.After = after
+	}
+
+	// fetch one extra book to find out whether there is a next page
+	limit := query.Limit
+	query.Limit++
+	items, err := u.repo.GetTrash(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.BookPage{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		next, err := encodeCursor(page.Items[limit-1], ordering)
+		if err != nil {
+			return nil, errs.Internal(err)
+		}
+		page.NextCursor = next
+	}
+	return page, nil
+}
+
+// Restore moves a book back from the trash and returns it.
+func (u *books) Restore(ctx context.Context, ID uuid.UUID) (*models.Book, error) {
+	if err := u.repo.Restore(ctx, ID); err != nil {
+		return nil, err
+	}
+	return u.repo.GetOne(ctx, ID)
+}
+
+// Purge permanently deletes a book that is in the trash.
+func (u *books) Purge(ctx context.Context, ID uuid.UUID) error {
+	return u.repo.Purge(ctx, ID)
+}
+
+// PurgeExpired permanently deletes books that have been in the trash longer than retention.
+func (u *books) PurgeExpired(ctx context.Context, retention time.Duration) (int64, error) {
+	if retention <= 0 {
+		return 0, errs.Validation("retention period must be positive")
+	}
+	return u.repo.PurgeDeletedBefore(ctx, time.Now().Add(-retention))
+}
+
+// roles are the known contributions of authors to books.
+var roles = map[models.AuthorRole]struct{}{
+	models.AuthorRoleAuthor:      {},
+	models.AuthorRoleEditor:      {},
+	models.AuthorRoleTranslator:  {},
+	models.AuthorRoleIllustrator: {},
+}
+
+// formats are the known forms of editions.
+var formats = map[models.BookFormat]struct{}{
+	models.BookFormatHardcover: {},
+	models.BookFormatPaperback: {},
+	models.BookFormatEbook:     {},
+	models.BookFormatAudiobook: {},
+}
+
+// languageCode matches ISO 639-1 and ISO 639-2 codes in lower case.
+var languageCode = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+// normalize brings text fields to Unicode NFC, the language code to lower case and the ISBN to ISBN-13
+// without hyphens, so that equal texts and numbers are stored identically. A book without credits is credited
+// to the authors named in its Author text; otherwise the text is made of the credits.
+func (u *books) normalize(book *models.Book) error {
+	book.Title = textnorm.NFC(book.Title)
+	book.Author = textnorm.NFC(book.Author)
+	book.Publisher = textnorm.NFC(book.Publisher)
+	book.Language = strings.ToLower(strings.TrimSpace(book.Language))
+	if err := u.normalizeCredits(book); err != nil {
+		return err
+	}
+	if err := u.normalizeClassification(book); err != nil {
+		return err
+	}
+	if book.Language != "" && !languageCode.MatchString(book.Language) {
+		return errs.InvalidFields(errs.FieldError{Field: "language", Message: "must be an ISO 639 language code"})
+	}
+	if _, ok := formats[book.Format]; book.Format != "" && !ok {
+		return errs.InvalidFields(errs.FieldError{Field: "format", Message: "must be one of: hardcover paperback ebook audiobook"})
+	}
+	if book.ISBN == "" {
+		return nil
+	}
+	normalized, err := isbn.Normalize(book.ISBN)
+	if err != nil {
+		return errs.InvalidFields(errs.FieldError{Field: "isbn", Message: "must be an ISBN-10 or ISBN-13 with a valid check digit"})
+	}
+	book.ISBN = normalized
+	return nil
+}
+
+// normalizeClassification brings tags to Unicode NFC in lower case and sorts genres and tags
+// dropping duplicates. Genres are checked by repositories.
+func (u *books) normalizeClassification(book *models.Book) error {
+	if len(book.Genres) > 0 {
+		genres := slices.Clone(book.Genres)
+		slices.SortFunc(genres, func(a, b uuid.UUID) int { return strings.Compare(a.String(), b.String()) })
+		book.Genres = slices.Compact(genres)
+	}
+	if len(book.Tags) == 0 {
+		return nil
+	}
+	tags := make([]string, len(book.Tags))
+	for i, tag := range book.Tags {
+		tags[i] = strings.ToLower(textnorm.NFC(tag))
+		if tags[i] == "" {
+			return errs.InvalidFields(errs.FieldError{Field: fmt.Sprintf("tags[%d]", i), Message: "is required"})
+		}
+	}
+	slices.Sort(tags)
+	book.Tags = slices.Compact(tags)
+	return nil
+}
+
+// normalizeCredits fills in the credits of a book and checks them. Names of credits referring
+// to authors by ID are left to repositories, which also build the Author text of such books.
+func (u *books) normalizeCredits(book *models.Book) error {
+	if len(book.Authors) == 0 {
+		book.Authors = models.SplitAuthors(book.Author)
+		if len(book.Authors) > 0 {
+			book.Author = models.AuthorText(book.Authors)
+		}
+		return nil
+	}
+
+	credits := slices.Clone(book.Authors)
+	named := true
+	for i := range credits {
+		credit := &credits[i]
+		credit.Name = textnorm.NFC(credit.Name)
+		if credit.Role == "" {
+			credit.Role = models.AuthorRoleAuthor
+		}
+		field := fmt.Sprintf("authors[%d]", i)
+		if _, ok := roles[credit.Role]; !ok {
+			return errs.InvalidFields(errs.FieldError{Field: field + ".role", Message: "must be one of: author editor translator illustrator"})
+		}
+		if credit.AuthorID == uuid.Nil && credit.Name == "" {
+			return errs.InvalidFields(errs.FieldError{Field: field + ".name", Message: "is required without author_id"})
+		}
+		if strings.Contains(credit.Name, models.AuthorSeparator) {
+			return errs.InvalidFields(errs.FieldError{Field: field + ".name", Message: fmt.Sprintf("must not contain %q", models.AuthorSeparator)})
+		}
+		named = named && credit.Name != ""
+	}
+	book.Authors = credits
+	if named {
+		book.Author = models.AuthorText(credits)
+	}
+	return nil
 }
@@ -2,11 +2,15 @@ package books
 
 import (
 	"context"
+	"errors"
+	"github.com/KinitaL/testovoye/internal/errs"
 	"github.com/KinitaL/testovoye/internal/models"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
+	"iter"
 	"testing"
+	"time"
 )
 
 func TestCreate(t *testing.T) {
@@ -22,8 +26,9 @@ func TestCreate(t *testing.T) {
 	cases := []struct {
 		name string
 
-		req models.Book
-		err error
+		req    models.Book
+		stored models.Book
+		err    error
 	}{
 		{
 			name: "Create",
@@ -32,6 +37,105 @@ func TestCreate(t *testing.T) {
 				Author: "Tester",
 				Year:   2025,
 			},
+			stored: models.Book{
+				Title:   "Test Book",
+				Author:  "Tester",
+				Authors: []models.BookAuthor{{Name: "Tester", Role: models.AuthorRoleAuthor}},
+				Year:    2025,
+			},
+			err: nil,
+		},
+		{
+			name: "Create normalizes text",
+			req: models.Book{
+				Title:  " Jose\u0301 ",
+				Author: "Толсто\u0438\u0306",
+				Year:   2025,
+			},
+			stored: models.Book{
+				Title:   "José",
+				Author:  "Толстой",
+				Authors: []models.BookAuthor{{Name: "Толстой", Role: models.AuthorRoleAuthor}},
+				Year:    2025,
+			},
+			err: nil,
+		},
+		{
+			name: "Create converts ISBN-10 to ISBN-13",
+			req: models.Book{
+				Title:  "War and Peace",
+				Author: "Leo Tolstoy",
+				Year:   1869,
+				ISBN:   "0-14-044913-2",
+			},
+			stored: models.Book{
+				Title:   "War and Peace",
+				Author:  "Leo Tolstoy",
+				Authors: []models.BookAuthor{{Name: "Leo Tolstoy", Role: models.AuthorRoleAuthor}},
+				Year:    1869,
+				ISBN:    "9780140449136",
+			},
+			err: nil,
+		},
+		{
+			name: "Create splits authors",
+			req: models.Book{
+				Title:  "Good Omens",
+				Author: "Terry Pratchett and  Neil Gaiman",
+				Year:   1990,
+			},
+			stored: models.Book{
+				Title:  "Good Omens",
+				Author: "Terry Pratchett and Neil Gaiman",
+				Authors: []models.BookAuthor{
+					{Name: "Terry Pratchett", Role: models.AuthorRoleAuthor},
+					{Name: "Neil Gaiman", Role: models.AuthorRoleAuthor},
+				},
+				Year: 1990,
+			},
+			err: nil,
+		},
+		{
+			name: "Create with credits",
+			req: models.Book{
+				Title:  "War and Peace",
+				Author: "ignored",
+				Authors: []models.BookAuthor{
+					{Name: "Leo Tolstoy"},
+					{Name: "Richard Pevear", Role: models.AuthorRoleTranslator},
+				},
+				Year: 2007,
+			},
+			stored: models.Book{
+				Title:  "War and Peace",
+				Author: "Leo Tolstoy",
+				Authors: []models.BookAuthor{
+					{Name: "Leo Tolstoy", Role: models.AuthorRoleAuthor},
+					{Name: "Richard Pevear", Role: models.AuthorRoleTranslator},
+				},
+				Year: 2007,
+			},
+			err: nil,
+		},
+		{
+			name: "Create an edition",
+			req: models.Book{
+				Title:     "War and Peace",
+				Author:    "Leo Tolstoy",
+				Year:      2007,
+				Publisher: " Vintage ",
+				Language:  "EN",
+				Format:    models.BookFormatPaperback,
+			},
+			stored: models.Book{
+				Title:     "War and Peace",
+				Author:    "Leo Tolstoy",
+				Authors:   []models.BookAuthor{{Name: "Leo Tolstoy", Role: models.AuthorRoleAuthor}},
+				Year:      2007,
+				Publisher: "Vintage",
+				Language:  "en",
+				Format:    models.BookFormatPaperback,
+			},
 			err: nil,
 		},
 	}
@@ -40,12 +144,81 @@ func TestCreate(t *testing.T) {
 	for _, testCase := range cases {
 		t.Run(testCase.name, func(t *testing.T) {
 			ctx := context.Background()
-			repo.EXPECT().Create(ctx, gomock.Any()).Return(testCase.err).AnyTimes()
+			repo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, book models.Book) (*models.Book, error) {
+				assert.Equal(t, testCase.stored.Title, book.Title)
+				assert.Equal(t, testCase.stored.Author, book.Author)
+				assert.Equal(t, testCase.stored.Authors, book.Authors)
+				assert.Equal(t, testCase.stored.ISBN, book.ISBN)
+				assert.Equal(t, testCase.stored.Publisher, book.Publisher)
+				assert.Equal(t, testCase.stored.Language, book.Language)
+				assert.Equal(t, testCase.stored.Format, book.Format)
+				book.CreatedAt = time.Now()
+				book.UpdatedAt = book.CreatedAt
+				book.Version = 1
+				return &book, testCase.err
+			})
 			// execution
-			err := usecase.Create(ctx, testCase.req)
+			book, err := usecase.Create(ctx, testCase.req)
 			assert.Equal(t, testCase.err, err)
+			assert.NotEqual(t, uuid.Nil, book.ID)
+			assert.Equal(t, testCase.stored.Title, book.Title)
+			assert.False(t, book.CreatedAt.IsZero())
+			assert.Equal(t, uint64(1), book.Version)
 		})
 	}
+
+	t.Run("Create with invalid ISBN", func(t *testing.T) {
+		_, err := usecase.Create(context.Background(), models.Book{Title: "Test Book", Author: "Tester", Year: 2025, ISBN: "9780140449137"})
+		assert.Equal(t, errs.KindValidation, errs.KindOf(err))
+		assert.Equal(t, "isbn", errs.FieldsOf(err)[0].Field)
+	})
+
+	t.Run("Create with invalid edition fields", func(t *testing.T) {
+		_, err := usecase.Create(context.Background(), models.Book{Title: "Test Book", Author: "Tester", Year: 2025, Language: "english"})
+		assert.Equal(t, errs.KindValidation, errs.KindOf(err))
+		assert.Equal(t, "language", errs.FieldsOf(err)[0].Field)
+
+		_, err = usecase.Create(context.Background(), models.Book{Title: "Test Book", Author: "Tester", Year: 2025, Format: "scroll"})
+		assert.Equal(t, errs.KindValidation, errs.KindOf(err))
+		assert.Equal(t, "format", errs.FieldsOf(err)[0].Field)
+	})
+
+	t.Run("Create with genres and tags", func(t *testing.T) {
+		first, second := uuid.MustParse("10000000-0000-0000-0000-000000000000"), uuid.MustParse("20000000-0000-0000-0000-000000000000")
+		repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, book models.Book) (*models.Book, error) {
+			assert.Equal(t, []uuid.UUID{first, second}, book.Genres)
+			assert.Equal(t, []string{"classics", "science fiction"}, book.Tags)
+			return &book, nil
+		})
+
+		_, err := usecase.Create(context.Background(), models.Book{
+			Title:  "Dune",
+			Author: "Frank Herbert",
+			Year:   1965,
+			Genres: []uuid.UUID{second, first, second},
+			Tags:   []string{"Science Fiction ", "classics", "CLASSICS"},
+		})
+		assert.NoError(t, err)
+
+		_, err = usecase.Create(context.Background(), models.Book{Title: "Dune", Author: "Frank Herbert", Year: 1965, Tags: []string{"classics", " "}})
+		assert.Equal(t, errs.KindValidation, errs.KindOf(err))
+		assert.Equal(t, "tags[1]", errs.FieldsOf(err)[0].Field)
+	})
+
+	t.Run("Create with invalid credits", func(t *testing.T) {
+		_, err := usecase.Create(context.Background(), models.Book{Title: "Test Book", Year: 2025, Authors: []models.BookAuthor{
+			{Name: "Tester"},
+			{Role: models.AuthorRoleEditor},
+		}})
+		assert.Equal(t, errs.KindValidation, errs.KindOf(err))
+		assert.Equal(t, "authors[1].name", errs.FieldsOf(err)[0].Field)
+
+		_, err = usecase.Create(context.Background(), models.Book{Title: "Test Book", Year: 2025, Authors: []models.BookAuthor{
+			{Name: "Tester", Role: "reader"},
+		}})
+		assert.Equal(t, errs.KindValidation, errs.KindOf(err))
+		assert.Equal(t, "authors[0].role", errs.FieldsOf(err)[0].Field)
+	})
 }
 
 func TestGetAll(t *testing.T) {
@@ -57,25 +230,59 @@ func TestGetAll(t *testing.T) {
 	// init core
 	usecase := NewBooksUsecase(repo)
 
+	books := []models.Book{
+		{ID: uuid.New(), Title: "A", Author: "Test Author", Year: 2023},
+		{ID: uuid.New(), Title: "B", Author: "Test Author", Year: 2024},
+		{ID: uuid.New(), Title: "C", Author: "Test Author", Year: 2025},
+	}
+	total := int64(len(books))
+
 	// test cases
 	cases := []struct {
 		name string
 
-		resp []models.Book
-		err  error
+		req        models.BookListParams
+		repoResp   []models.Book
+		resp       []models.Book
+		total      *int64
+		nextCursor bool
+		err        error
 	}{
 		{
 			name: "GetAll",
 
-			resp: []models.Book{
-				{
-					ID:     uuid.New(),
-					Title:  "Test Book",
-					Author: "Test Author",
-					Year:   2024,
-				},
-			},
-			err: nil,
+			req:      models.BookListParams{},
+			repoResp: books,
+			resp:     books,
+			err:      nil,
+		},
+		{
+			name: "GetAll next page",
+
+			req:        models.BookListParams{SortBy: models.BookSortTitle, Limit: 2, WithTotal: true},
+			repoResp:   books,
+			resp:       books[:2],
+			total:      &total,
+			nextCursor: true,
+			err:        nil,
+		},
+		{
+			name: "GetAll unknown sort field",
+
+			req: models.BookListParams{SortBy: "isbn"},
+			err: errs.ErrValidation,
+		},
+		{
+			name: "GetAll invalid cursor",
+
+			req: models.BookListParams{Cursor: "not a cursor"},
+			err: errs.ErrValidation,
+		},
+		{
+			name: "GetAll invalid year range",
+
+			req: models.BookListParams{BookFilter: models.BookFilter{YearFrom: 2025, YearTo: 2000}},
+			err: errs.ErrValidation,
 		},
 	}
 
@@ -83,11 +290,292 @@ func TestGetAll(t *testing.T) {
 	for _, testCase := range cases {
 		t.Run(testCase.name, func(t *testing.T) {
 			ctx := context.Background()
-			repo.EXPECT().GetAll(ctx).Return(testCase.resp, testCase.err).AnyTimes()
+			repo.EXPECT().GetAll(ctx, gomock.Any()).Return(testCase.repoResp, nil).AnyTimes()
+			repo.EXPECT().Count(ctx, testCase.req.BookFilter).Return(total, nil).AnyTimes()
 			// execution
-			resp, err := usecase.GetAll(ctx)
-			assert.Equal(t, testCase.err, err)
-			assert.Equal(t, testCase.resp, resp)
+			resp, err := usecase.GetAll(ctx, testCase.req)
+			if testCase.err != nil {
+				assert.ErrorIs(t, err, testCase.err)
+				return
+			}
+			assert.Equal(t, nil, err)
+			assert.Equal(t, testCase.resp, resp.Items)
+			assert.Equal(t, testCase.total, resp.Total)
+			assert.Equal(t, testCase.nextCursor, resp.NextCursor != "")
+		})
+	}
+}
+
+func TestGetAllFacets(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewBooksUsecase(repo)
+
+	ctx := context.Background()
+	genreID := uuid.New()
+	books := []models.Book{{ID: uuid.New(), Title: "Dune", Author: "Frank Herbert", Year: 1965, Genres: []uuid.UUID{genreID}, Tags: []string{"classics"}}}
+	facets := &models.BookFacets{
+		Genres:  []models.GenreFacet{{ID: genreID, Name: "Science fiction", Count: 1}},
+		Tags:    []models.TagFacet{{Tag: "classics", Count: 1}},
+		Decades: []models.DecadeFacet{{Decade: 1960, Count: 1}},
+	}
+
+	filter := models.BookFilter{GenreID: genreID, Tag: "classics"}
+	repo.EXPECT().GetAll(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, query models.BookQuery) ([]models.Book, error) {
+		assert.Equal(t, filter, query.BookFilter)
+		return books, nil
+	})
+	repo.EXPECT().Facets(ctx, filter).Return(facets, nil)
+
+	page, err := usecase.GetAll(ctx, models.BookListParams{
+		BookFilter: models.BookFilter{GenreID: genreID, Tag: " Classics"},
+		WithFacets: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, books, page.Items)
+	assert.Equal(t, facets, page.Facets)
+}
+
+func TestGetAllCursor(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewBooksUsecase(repo)
+
+	ctx := context.Background()
+	books := []models.Book{
+		{ID: uuid.New(), Title: "A", Year: 2023},
+		{ID: uuid.New(), Title: "B", Year: 2024},
+	}
+	params := models.BookListParams{SortBy: models.BookSortYear, Desc: true, Limit: 1}
+
+	// first page
+	repo.EXPECT().GetAll(ctx, models.BookQuery{SortBy: models.BookSortYear, Desc: true, Locale: models.LocaleEnglish, Limit: 2}).
+		Return(books, nil)
+	page, err := usecase.GetAll(ctx, params)
+	assert.Equal(t, nil, err)
+	assert.NotEmpty(t, page.NextCursor)
+
+	// second page continues right after the last book of the first one
+	params.Cursor = page.NextCursor
+	repo.EXPECT().GetAll(ctx, models.BookQuery{
+		SortBy: models.BookSortYear,
+		Desc:   true,
+		Locale: models.LocaleEnglish,
+		Limit:  2,
+		After:  &models.BookCursor{Value: uint16(2023), ID: books[0].ID},
+	}).Return(books[1:], nil)
+	page, err = usecase.GetAll(ctx, params)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, books[1:], page.Items)
+	assert.Empty(t, page.NextCursor)
+
+	// a cursor can't be reused with another ordering
+	params.Locale = models.LocaleRussian
+	_, err = usecase.GetAll(ctx, params)
+	assert.ErrorIs(t, err, errs.ErrValidation)
+
+	params.Locale = models.LocaleEnglish
+	params.Desc = false
+	_, err = usecase.GetAll(ctx, params)
+	assert.ErrorIs(t, err, errs.ErrValidation)
+}
+
+func TestSearch(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewBooksUsecase(repo)
+
+	book := models.Book{ID: uuid.New(), Title: "Братья Карамазовы", Author: "Фёдор Достоевский", Year: 1880}
+
+	// test cases
+	cases := []struct {
+		name string
+
+		req       models.BookSearchQuery
+		repoQuery models.BookSearchQuery
+		highlight models.BookHighlight
+		err       error
+	}{
+		{
+			name: "Search",
+
+			req:       models.BookSearchQuery{Query: "  Karamazovy "},
+			repoQuery: models.BookSearchQuery{Query: "karamazovy", Threshold: DefaultSimilarityThreshold, Limit: DefaultPageSize},
+			highlight: models.BookHighlight{Title: "Братья <mark>Карамазовы</mark>", Author: "Фёдор Достоевский"},
+			err:       nil,
+		},
+		{
+			name: "Search transliterated",
+
+			req:       models.BookSearchQuery{Query: "Fyodor DOSTOYEVSKY", Fuzzy: true, Threshold: 0.5, Limit: 5},
+			repoQuery: models.BookSearchQuery{Query: "fedor dostoevsky", Fuzzy: true, Threshold: 0.5, Limit: 5},
+			highlight: models.BookHighlight{Title: "Братья Карамазовы", Author: "<mark>Фёдор</mark> <mark>Достоевский</mark>"},
+			err:       nil,
+		},
+		{
+			name: "Search cyrillic",
+
+			req:       models.BookSearchQuery{Query: "достоевский"},
+			repoQuery: models.BookSearchQuery{Query: "dostoevsky", Threshold: DefaultSimilarityThreshold, Limit: DefaultPageSize},
+			highlight: models.BookHighlight{Title: "Братья Карамазовы", Author: "Фёдор <mark>Достоевский</mark>"},
+			err:       nil,
+		},
+		{
+			name: "Search empty query",
+
+			req: models.BookSearchQuery{Query: " ,. "},
+			err: errs.ErrValidation,
+		},
+		{
+			name: "Search invalid threshold",
+
+			req: models.BookSearchQuery{Query: "war", Threshold: 2},
+			err: errs.ErrValidation,
+		},
+	}
+
+	// execution
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo.EXPECT().Search(ctx, testCase.repoQuery).
+				Return([]models.BookSearchHit{{Book: book, Rank: 0.5}}, nil).AnyTimes()
+			repo.EXPECT().Suggest(ctx, gomock.Any()).Times(0)
+			// execution
+			resp, err := usecase.Search(ctx, testCase.req)
+			if testCase.err != nil {
+				assert.ErrorIs(t, err, testCase.err)
+				return
+			}
+			assert.Equal(t, nil, err)
+			assert.Equal(t, []models.BookSearchHit{{Book: book, Rank: 0.5, Highlight: testCase.highlight}}, resp.Hits)
+		})
+	}
+}
+
+func TestSearchSuggestions(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewBooksUsecase(repo)
+
+	ctx := context.Background()
+	suggestions := []models.BookSuggestion{
+		{Text: "Фёдор Достоевский", Field: models.BookSuggestionAuthor, Score: 0.8},
+	}
+
+	repo.EXPECT().Search(ctx, gomock.Any()).Return([]models.BookSearchHit{}, nil)
+	repo.EXPECT().Suggest(ctx, models.BookSearchQuery{
+		Query:     "dostoevksy",
+		Threshold: DefaultSimilarityThreshold,
+		Limit:     MaxSuggestions,
+	}).Return(suggestions, nil)
+
+	// execution
+	resp, err := usecase.Search(ctx, models.BookSearchQuery{Query: "Dostoevksy"})
+	assert.Equal(t, nil, err)
+	assert.Empty(t, resp.Hits)
+	assert.Equal(t, suggestions, resp.Suggestions)
+}
+
+func TestSearchHighlightEscaping(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewBooksUsecase(repo)
+
+	ctx := context.Background()
+	book := models.Book{ID: uuid.New(), Title: `Tom & Jerry <"Война">`, Author: "<img src=x onerror=alert(1)>", Year: 1940}
+	repo.EXPECT().Search(ctx, gomock.Any()).Return([]models.BookSearchHit{{Book: book, Rank: 0.5}}, nil)
+
+	// execution
+	resp, err := usecase.Search(ctx, models.BookSearchQuery{Query: "voyna img"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []models.BookSearchHit{{Book: book, Rank: 0.5, Highlight: models.BookHighlight{
+		Title:  "Tom &amp; Jerry &lt;&#34;<mark>Война</mark>&#34;&gt;",
+		Author: "&lt;<mark>img</mark> src=x onerror=alert(1)&gt;",
+	}}}, resp.Hits)
+}
+
+func TestExport(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewBooksUsecase(repo)
+
+	books := []models.Book{
+		{ID: uuid.New(), Title: "Book 1", Author: "Author", Year: 2001},
+		{ID: uuid.New(), Title: "Book 2", Author: "Author", Year: 2002},
+	}
+	stream := func(yield func(models.Book, error) bool) {
+		for _, book := range books {
+			if !yield(book, nil) {
+				return
+			}
+		}
+	}
+
+	// test cases
+	cases := []struct {
+		name string
+
+		filter models.BookFilter
+		err    error
+	}{
+		{
+			name: "Export",
+
+			filter: models.BookFilter{Author: "Author", YearFrom: 2000, YearTo: 2010},
+			err:    nil,
+		},
+		{
+			name: "Export inverted year range",
+
+			filter: models.BookFilter{YearFrom: 2010, YearTo: 2000},
+			err:    errs.ErrValidation,
+		},
+	}
+
+	// execution
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo.EXPECT().Export(ctx, testCase.filter).Return(iter.Seq2[models.Book, error](stream)).AnyTimes()
+			// execution
+			seq, err := usecase.Export(ctx, testCase.filter)
+			if testCase.err != nil {
+				assert.ErrorIs(t, err, testCase.err)
+				return
+			}
+			assert.Equal(t, nil, err)
+
+			var exported []models.Book
+			for book, err := range seq {
+				assert.Equal(t, nil, err)
+				exported = append(exported, book)
+			}
+			assert.Equal(t, books, exported)
 		})
 	}
 }
@@ -121,6 +609,13 @@ func TestGetOne(t *testing.T) {
 			},
 			err: nil,
 		},
+		{
+			name: "GetOne not found",
+
+			req:  uuid.New(),
+			resp: nil,
+			err:  errs.NotFound("book doesn't exist"),
+		},
 	}
 
 	// execution
@@ -136,6 +631,46 @@ func TestGetOne(t *testing.T) {
 	}
 }
 
+func TestGetByISBN(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewBooksUsecase(repo)
+
+	book := &models.Book{ID: uuid.New(), Title: "War and Peace", Author: "Leo Tolstoy", Year: 1869, ISBN: "9780140449136"}
+
+	// test cases
+	cases := []struct {
+		name string
+
+		req    string
+		stored string // ISBN looked up in the repository, empty if it isn't called
+		resp   *models.Book
+		err    error
+	}{
+		{name: "ISBN-13", req: "9780140449136", stored: "9780140449136", resp: book},
+		{name: "ISBN-10 with hyphens", req: "0-14-044913-2", stored: "9780140449136", resp: book},
+		{name: "Not found", req: "978-5-17-090335-1", stored: "9785170903351", err: errs.NotFound("book with ISBN 9785170903351 doesn't exist")},
+		{name: "Wrong check digit", req: "0-14-044913-3", err: errs.Validation("%q is not a valid ISBN", "0-14-044913-3")},
+	}
+
+	// execution
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.Background()
+			if testCase.stored != "" {
+				repo.EXPECT().GetByISBN(ctx, testCase.stored).Return(testCase.resp, testCase.err)
+			}
+			resp, err := usecase.GetByISBN(ctx, testCase.req)
+			assert.Equal(t, testCase.err, err)
+			assert.Equal(t, testCase.resp, resp)
+		})
+	}
+}
+
 func TestUpdate(t *testing.T) {
 	// init mocks
 	mockCtrl := gomock.NewController(t)
@@ -149,9 +684,10 @@ func TestUpdate(t *testing.T) {
 	cases := []struct {
 		name string
 
-		req models.Book
-		ID  uuid.UUID
-		err error
+		req    models.Book
+		ID     uuid.UUID
+		stored models.Book
+		err    error
 	}{
 		{
 			name: "Update full",
@@ -162,31 +698,150 @@ func TestUpdate(t *testing.T) {
 				Author: "Tester",
 				Year:   2025,
 			},
+			stored: models.Book{
+				Title:   "Test Update",
+				Author:  "Tester",
+				Authors: []models.BookAuthor{{Name: "Tester", Role: models.AuthorRoleAuthor}},
+				Year:    2025,
+			},
 			err: nil,
 		},
 		{
-			name: "Update one field",
+			name: "Normalized",
 
 			ID: uuid.New(),
 			req: models.Book{
-				Title: "Test Update",
+				Title:   " Test Update ",
+				Author:  "Tester",
+				Year:    2025,
+				Version: 2,
+			},
+			stored: models.Book{
+				Title:   "Test Update",
+				Author:  "Tester",
+				Authors: []models.BookAuthor{{Name: "Tester", Role: models.AuthorRoleAuthor}},
+				Year:    2025,
+				Version: 2,
 			},
 			err: nil,
 		},
+		{
+			name: "Version mismatch",
+
+			ID: uuid.New(),
+			req: models.Book{
+				Title:   "Test Update",
+				Author:  "Tester",
+				Year:    2025,
+				Version: 1,
+			},
+			stored: models.Book{
+				Title:   "Test Update",
+				Author:  "Tester",
+				Authors: []models.BookAuthor{{Name: "Tester", Role: models.AuthorRoleAuthor}},
+				Year:    2025,
+				Version: 1,
+			},
+			err: errs.PreconditionFailed("book has been modified"),
+		},
 	}
 
 	// execution
 	for _, testCase := range cases {
 		t.Run(testCase.name, func(t *testing.T) {
 			ctx := context.Background()
-			repo.EXPECT().Update(ctx, testCase.ID, gomock.Any()).Return(testCase.err).AnyTimes()
+			testCase.stored.ID = testCase.ID
+			repo.EXPECT().Update(ctx, testCase.ID, testCase.stored).Return(testCase.err)
+			if testCase.err == nil {
+				repo.EXPECT().GetOne(ctx, testCase.ID).Return(&testCase.stored, nil)
+			}
 			// execution
-			err := usecase.Update(ctx, testCase.ID, testCase.req)
+			book, err := usecase.Update(ctx, testCase.ID, testCase.req)
 			assert.Equal(t, testCase.err, err)
+			if testCase.err == nil {
+				assert.Equal(t, &testCase.stored, book)
+			}
 		})
 	}
 }
 
+func TestPatch(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewBooksUsecase(repo)
+
+	ctx := context.Background()
+	ID := uuid.New()
+	current := models.Book{
+		ID:      ID,
+		Title:   "Title",
+		Author:  "Author",
+		Authors: []models.BookAuthor{{AuthorID: uuid.New(), Name: "Author", Role: models.AuthorRoleAuthor}},
+		Year:    2000,
+		Version: 4,
+	}
+	retitle := func(book models.Book) (models.Book, error) {
+		book.Title = "New title"
+		return book, nil
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		patched := current
+		patched.Title = "New title"
+		repo.EXPECT().GetOne(ctx, ID).Return(&current, nil)
+		repo.EXPECT().Update(ctx, ID, patched).Return(nil)
+		repo.EXPECT().GetOne(ctx, ID).Return(&patched, nil)
+
+		book, err := usecase.Patch(ctx, ID, 4, retitle)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, &patched, book)
+	})
+
+	t.Run("Version mismatch", func(t *testing.T) {
+		repo.EXPECT().GetOne(ctx, ID).Return(&current, nil)
+		repo.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := usecase.Patch(ctx, ID, 3, retitle)
+		assert.ErrorIs(t, err, errs.ErrPreconditionFailed)
+	})
+
+	t.Run("Invalid patch", func(t *testing.T) {
+		repo.EXPECT().GetOne(ctx, ID).Return(&current, nil)
+		repo.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := usecase.Patch(ctx, ID, 0, func(models.Book) (models.Book, error) {
+			return models.Book{}, errs.Validation("invalid patch")
+		})
+		assert.ErrorIs(t, err, errs.ErrValidation)
+	})
+
+	t.Run("Concurrent update", func(t *testing.T) {
+		// the book changes between reading and writing, the patch is applied again to the new state
+		changed := current
+		changed.Author = "Other author"
+		changed.Authors = []models.BookAuthor{{AuthorID: uuid.New(), Name: "Other author", Role: models.AuthorRoleAuthor}}
+		changed.Version = 5
+		patched := changed
+		patched.Title = "New title"
+
+		gomock.InOrder(
+			repo.EXPECT().GetOne(ctx, ID).Return(&current, nil),
+			repo.EXPECT().Update(ctx, ID, gomock.Any()).Return(errs.PreconditionFailed("book has been modified")),
+			repo.EXPECT().GetOne(ctx, ID).Return(&changed, nil),
+			repo.EXPECT().Update(ctx, ID, patched).Return(nil),
+			repo.EXPECT().GetOne(ctx, ID).Return(&patched, nil),
+		)
+
+		book, err := usecase.Patch(ctx, ID, 0, retitle)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, &patched, book)
+	})
+}
+
 func TestDelete(t *testing.T) {
 	// init mocks
 	mockCtrl := gomock.NewController(t)
@@ -200,8 +855,9 @@ func TestDelete(t *testing.T) {
 	cases := []struct {
 		name string
 
-		req uuid.UUID
-		err error
+		req     uuid.UUID
+		version uint64
+		err     error
 	}{
 		{
 			name: "GetOne",
@@ -209,17 +865,533 @@ func TestDelete(t *testing.T) {
 			req: uuid.New(),
 			err: nil,
 		},
+		{
+			name: "Version mismatch",
+
+			req:     uuid.New(),
+			version: 2,
+			err:     errs.PreconditionFailed("book has been modified"),
+		},
 	}
 
 	// execution
 	for _, testCase := range cases {
 		t.Run(testCase.name, func(t *testing.T) {
 			ctx := context.Background()
-			repo.EXPECT().Delete(ctx, testCase.req).Return(testCase.err).AnyTimes()
+			repo.EXPECT().Delete(ctx, testCase.req, testCase.version).Return(testCase.err).AnyTimes()
 			// execution
-			err := usecase.Delete(ctx, testCase.req)
+			err := usecase.Delete(ctx, testCase.req, testCase.version)
 			assert.Equal(t, testCase.err, err)
 		})
 
 	}
 }
+
+func TestBatch(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewBooksUsecase(repo)
+
+	updatedID, deletedID := uuid.New(), uuid.New()
+	ops := []models.BookOperation{
+		{Type: models.BookOperationDelete, ID: deletedID, Version: 2},
+		{Type: models.BookOperationCreate, Book: models.Book{Title: " Jose\u0301 ", Author: "Tester", Year: 2025}},
+		{Type: models.BookOperationUpdate, ID: updatedID, Book: models.Book{Title: "Updated", Author: "Tester", Year: 2024}},
+	}
+	createBatch := func(_ context.Context, books []models.Book) ([]models.Book, error) {
+		assert.Len(t, books, 1)
+		assert.NotEqual(t, uuid.Nil, books[0].ID)
+		assert.Equal(t, "José", books[0].Title)
+		books[0].Version = 1
+		return books, nil
+	}
+	transaction := func(_ context.Context, fn func(repo Repository) error) error {
+		return fn(repo)
+	}
+
+	t.Run("Best effort", func(t *testing.T) {
+		ctx := context.Background()
+		repo.EXPECT().CreateBatch(ctx, gomock.Any()).DoAndReturn(createBatch)
+		repo.EXPECT().Delete(ctx, deletedID, uint64(2)).Return(errs.PreconditionFailed("book has been modified"))
+		repo.EXPECT().Update(ctx, updatedID, models.Book{
+			ID:      updatedID,
+			Title:   "Updated",
+			Author:  "Tester",
+			Authors: []models.BookAuthor{{Name: "Tester", Role: models.AuthorRoleAuthor}},
+			Year:    2024,
+		}).Return(nil)
+		repo.EXPECT().GetOne(ctx, updatedID).Return(&models.Book{ID: updatedID, Title: "Updated", Version: 3}, nil)
+
+		results, err := usecase.Batch(ctx, ops, false)
+		assert.NoError(t, err)
+		assert.Len(t, results, 3)
+		assert.ErrorIs(t, results[0].Err, errs.ErrPreconditionFailed)
+		assert.Nil(t, results[0].Book)
+		assert.NoError(t, results[1].Err)
+		assert.Equal(t, "José", results[1].Book.Title)
+		assert.NoError(t, results[2].Err)
+		assert.Equal(t, uint64(3), results[2].Book.Version)
+	})
+
+	t.Run("Best effort with a conflicting create", func(t *testing.T) {
+		ctx := context.Background()
+		creations := []models.BookOperation{
+			{Type: models.BookOperationCreate, Book: models.Book{Title: "Valid", Author: "Tester", Year: 2025}},
+			{Type: models.BookOperationCreate, Book: models.Book{Title: "Conflicting", Author: "Tester", Year: 2025, ISBN: "9780306406157"}},
+		}
+		repo.EXPECT().CreateBatch(ctx, gomock.Any()).Return(nil, errs.Conflict("book with this ISBN already exists"))
+		repo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, book models.Book) (*models.Book, error) {
+			if book.ISBN != "" {
+				return nil, errs.Conflict("book with this ISBN already exists")
+			}
+			book.Version = 1
+			return &book, nil
+		}).Times(2)
+
+		results, err := usecase.Batch(ctx, creations, false)
+		assert.NoError(t, err)
+		assert.Len(t, results, 2)
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, "Valid", results[0].Book.Title)
+		assert.ErrorIs(t, results[1].Err, errs.ErrConflict)
+		assert.Nil(t, results[1].Book)
+	})
+
+	t.Run("Best effort with an invalid operation", func(t *testing.T) {
+		ctx := context.Background()
+		repo.EXPECT().CreateBatch(ctx, gomock.Any()).DoAndReturn(createBatch)
+
+		results, err := usecase.Batch(ctx, []models.BookOperation{
+			{Type: models.BookOperationUpdate, ID: updatedID, Book: models.Book{Title: "Updated", Author: "Tester", Year: 2024, Tags: []string{" "}}},
+			ops[1],
+		}, false)
+		assert.NoError(t, err)
+		assert.Len(t, results, 2)
+		assert.ErrorIs(t, results[0].Err, errs.ErrValidation)
+		assert.Nil(t, results[0].Book)
+		assert.NoError(t, results[1].Err)
+		assert.Equal(t, "José", results[1].Book.Title)
+	})
+
+	t.Run("Atomic", func(t *testing.T) {
+		ctx := context.Background()
+		repo.EXPECT().Transaction(ctx, gomock.Any()).DoAndReturn(transaction)
+		repo.EXPECT().CreateBatch(ctx, gomock.Any()).DoAndReturn(createBatch)
+		repo.EXPECT().Delete(ctx, deletedID, uint64(2)).Return(nil)
+		repo.EXPECT().Update(ctx, updatedID, gomock.Any()).Return(nil)
+		repo.EXPECT().GetOne(ctx, updatedID).Return(&models.Book{ID: updatedID, Version: 3}, nil)
+
+		results, err := usecase.Batch(ctx, ops, true)
+		assert.NoError(t, err)
+		assert.Len(t, results, 3)
+		for _, result := range results {
+			assert.NoError(t, result.Err)
+		}
+	})
+
+	t.Run("Atomic failure", func(t *testing.T) {
+		ctx := context.Background()
+		repo.EXPECT().Transaction(ctx, gomock.Any()).DoAndReturn(transaction)
+		repo.EXPECT().CreateBatch(ctx, gomock.Any()).DoAndReturn(createBatch)
+		repo.EXPECT().Delete(ctx, deletedID, uint64(2)).Return(errs.NotFound("book doesn't exist"))
+
+		results, err := usecase.Batch(ctx, ops, true)
+		assert.Nil(t, results)
+		assert.ErrorIs(t, err, errs.ErrNotFound)
+		assert.Equal(t, "operation 0: book doesn't exist", errs.MessageOf(err))
+	})
+
+	t.Run("Same book twice", func(t *testing.T) {
+		_, err := usecase.Batch(context.Background(), []models.BookOperation{
+			{Type: models.BookOperationDelete, ID: deletedID},
+			{Type: models.BookOperationUpdate, ID: deletedID, Book: models.Book{Title: "Title"}},
+		}, false)
+		assert.ErrorIs(t, err, errs.ErrValidation)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		_, err := usecase.Batch(context.Background(), nil, true)
+		assert.ErrorIs(t, err, errs.ErrValidation)
+	})
+}
+
+func TestImport(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewBooksUsecase(repo)
+
+	batch := []models.Book{
+		{Title: "Война и мир", Author: "Толстой", Year: 1869},
+		{Title: "Nos", Author: "Gogol", Year: 1836},
+		{Title: " NOS ", Author: "gogol", Year: 1836},
+	}
+	existing := []models.BookIdentity{{TitleKey: "voyna i mir", AuthorKey: "tolstoy"}}
+
+	t.Run("Import", func(t *testing.T) {
+		ctx := context.Background()
+		repo.EXPECT().Existing(ctx, []models.BookIdentity{
+			{TitleKey: "voyna i mir", AuthorKey: "tolstoy"},
+			{TitleKey: "nos", AuthorKey: "gogol"},
+			{TitleKey: "nos", AuthorKey: "gogol"},
+		}).Return(existing, nil)
+		repo.EXPECT().CreateBatch(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, books []models.Book) ([]models.Book, error) {
+			assert.Len(t, books, 1)
+			assert.Equal(t, "Nos", books[0].Title)
+			books[0].Version = 1
+			return books, nil
+		})
+
+		results, err := usecase.Import(ctx, batch, false)
+		assert.NoError(t, err)
+		assert.Equal(t, []bool{true, false, true}, []bool{results[0].Duplicate, results[1].Duplicate, results[2].Duplicate})
+		assert.Equal(t, uint64(1), results[1].Book.Version)
+	})
+
+	t.Run("Dry run", func(t *testing.T) {
+		ctx := context.Background()
+		repo.EXPECT().Existing(ctx, gomock.Any()).Return(existing, nil)
+
+		results, err := usecase.Import(ctx, batch, true)
+		assert.NoError(t, err)
+		assert.False(t, results[1].Duplicate)
+		assert.NotEqual(t, uuid.Nil, results[1].Book.ID)
+	})
+
+	t.Run("Same ISBN", func(t *testing.T) {
+		ctx := context.Background()
+		batch := []models.Book{
+			{Title: "War and Peace", Author: "Leo Tolstoy", Year: 1869, ISBN: "0-14-044913-2"},
+			{Title: "Anna Karenina", Author: "Leo Tolstoy", Year: 1878, ISBN: "978-0-14-303500-8"},
+			{Title: "Anna Karenina", Author: "Lev Tolstoy", Year: 1877, ISBN: "0143035002"},
+		}
+		repo.EXPECT().Existing(ctx, gomock.Any()).Return(nil, nil)
+		repo.EXPECT().ExistingISBNs(ctx, []string{"9780140449136", "9780143035008", "9780143035008"}).Return([]string{"9780140449136"}, nil)
+
+		results, err := usecase.Import(ctx, batch, true)
+		assert.NoError(t, err)
+		assert.Equal(t, []bool{true, false, true}, []bool{results[0].Duplicate, results[1].Duplicate, results[2].Duplicate})
+		assert.Equal(t, "9780143035008", results[1].Book.ISBN)
+	})
+
+	t.Run("Book rejected by the repository", func(t *testing.T) {
+		ctx := context.Background()
+		batch := []models.Book{
+			{Title: "Nos", Author: "Gogol", Year: 1836, Genres: []uuid.UUID{uuid.New()}},
+			{Title: "Shinel", Author: "Gogol", Year: 1842},
+		}
+		repo.EXPECT().Existing(ctx, gomock.Any()).Return(nil, nil)
+		repo.EXPECT().CreateBatch(ctx, gomock.Any()).Return(nil, errs.Validation("genre doesn't exist"))
+		repo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, book models.Book) (*models.Book, error) {
+			if len(book.Genres) > 0 {
+				return nil, errs.Validation("genre doesn't exist")
+			}
+			book.Version = 1
+			return &book, nil
+		}).Times(2)
+
+		results, err := usecase.Import(ctx, batch, false)
+		assert.NoError(t, err)
+		assert.ErrorIs(t, results[0].Err, errs.ErrValidation)
+		assert.Nil(t, results[0].Book)
+		assert.NoError(t, results[1].Err)
+		assert.Equal(t, uint64(1), results[1].Book.Version)
+	})
+
+	t.Run("Book inserted concurrently", func(t *testing.T) {
+		ctx := context.Background()
+		batch := []models.Book{
+			{Title: "Nos", Author: "Gogol", Year: 1836, ISBN: "0-14-044913-2"},
+			{Title: "Shinel", Author: "Gogol", Year: 1842},
+		}
+		repo.EXPECT().Existing(ctx, gomock.Any()).Return(nil, nil)
+		repo.EXPECT().ExistingISBNs(ctx, gomock.Any()).Return(nil, nil)
+		repo.EXPECT().CreateBatch(ctx, gomock.Any()).Return(nil, errs.Conflict("book with this ISBN already exists"))
+		repo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, book models.Book) (*models.Book, error) {
+			if book.ISBN != "" {
+				return nil, errs.Conflict("book with this ISBN already exists")
+			}
+			book.Version = 1
+			return &book, nil
+		}).Times(2)
+
+		results, err := usecase.Import(ctx, batch, false)
+		assert.NoError(t, err)
+		assert.True(t, results[0].Duplicate)
+		assert.NoError(t, results[0].Err)
+		assert.Nil(t, results[0].Book)
+		assert.False(t, results[1].Duplicate)
+		assert.Equal(t, uint64(1), results[1].Book.Version)
+	})
+
+	t.Run("Internal error of a book created alone", func(t *testing.T) {
+		ctx := context.Background()
+		batch := []models.Book{
+			{Title: "Nos", Author: "Gogol", Year: 1836, Genres: []uuid.UUID{uuid.New()}},
+			{Title: "Shinel", Author: "Gogol", Year: 1842},
+		}
+		repo.EXPECT().Existing(ctx, gomock.Any()).Return(nil, nil)
+		repo.EXPECT().CreateBatch(ctx, gomock.Any()).Return(nil, errs.Validation("genre doesn't exist"))
+		repo.EXPECT().Create(ctx, gomock.Any()).Return(nil, errs.Validation("genre doesn't exist"))
+		repo.EXPECT().Create(ctx, gomock.Any()).Return(nil, errors.New("connection refused"))
+
+		results, err := usecase.Import(ctx, batch, false)
+		assert.Nil(t, results)
+		assert.EqualError(t, err, "connection refused")
+	})
+
+	t.Run("Internal error", func(t *testing.T) {
+		ctx := context.Background()
+		repo.EXPECT().Existing(ctx, gomock.Any()).Return(nil, nil)
+		repo.EXPECT().CreateBatch(ctx, gomock.Any()).Return(nil, errors.New("connection refused"))
+
+		results, err := usecase.Import(ctx, []models.Book{{Title: "Nos", Author: "Gogol", Year: 1836}}, false)
+		assert.Nil(t, results)
+		assert.EqualError(t, err, "connection refused")
+	})
+
+	t.Run("Book that fails normalization", func(t *testing.T) {
+		ctx := context.Background()
+		batch := []models.Book{
+			{Title: "Nos", Author: "Gogol", Year: 1836, Tags: []string{" "}},
+			{Title: "Nos", Author: "Gogol", Year: 1836},
+		}
+		repo.EXPECT().Existing(ctx, []models.BookIdentity{{TitleKey: "nos", AuthorKey: "gogol"}}).Return(nil, nil)
+
+		results, err := usecase.Import(ctx, batch, true)
+		assert.NoError(t, err)
+		assert.ErrorIs(t, results[0].Err, errs.ErrValidation)
+		assert.Nil(t, results[0].Book)
+		assert.NoError(t, results[1].Err)
+		assert.False(t, results[1].Duplicate)
+		assert.Equal(t, "Nos", results[1].Book.Title)
+	})
+}
+
+func TestGetTrash(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewBooksUsecase(repo)
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	earlier := now.Add(-time.Hour)
+	books := []models.Book{
+		{ID: uuid.New(), Title: "A", DeletedAt: &now},
+		{ID: uuid.New(), Title: "B", DeletedAt: &earlier},
+	}
+
+	// first page
+	repo.EXPECT().GetTrash(ctx, models.TrashQuery{Limit: 2}).Return(books, nil)
+	page, err := usecase.GetTrash(ctx, models.TrashParams{Limit: 1})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, books[:1], page.Items)
+	assert.NotEmpty(t, page.NextCursor)
+
+	// second page continues right after the most recently deleted book
+	repo.EXPECT().GetTrash(ctx, models.TrashQuery{
+		Limit: 2,
+		After: &models.BookCursor{Value: now, ID: books[0].ID},
+	}).Return(books[1:], nil)
+	page, err = usecase.GetTrash(ctx, models.TrashParams{Limit: 1, Cursor: page.NextCursor})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, books[1:], page.Items)
+	assert.Empty(t, page.NextCursor)
+
+	// a listing cursor can't be used for the trash
+	listing, err := encodeCursor(books[0], models.BookQuery{SortBy: models.BookSortCreatedAt})
+	assert.Equal(t, nil, err)
+	_, err = usecase.GetTrash(ctx, models.TrashParams{Cursor: listing})
+	assert.ErrorIs(t, err, errs.ErrValidation)
+}
+
+func TestHarvest(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewBooksUsecase(repo)
+
+	ctx := context.Background()
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	updated := from.Add(time.Hour)
+	deleted := from.Add(2 * time.Hour)
+	books := []models.Book{
+		{ID: uuid.New(), Title: "A", UpdatedAt: updated},
+		{ID: uuid.New(), Title: "B", UpdatedAt: updated, DeletedAt: &deleted},
+	}
+
+	// first page
+	repo.EXPECT().Harvest(ctx, models.HarvestQuery{From: &from, Limit: 2}).Return(books, nil)
+	page, err := usecase.Harvest(ctx, models.HarvestParams{From: &from, Limit: 1})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, books[:1], page.Items)
+	assert.NotEmpty(t, page.NextCursor)
+
+	// the cursor keeps the bounds of the first page
+	repo.EXPECT().Harvest(ctx, models.HarvestQuery{
+		From:  &from,
+		Limit: 2,
+		After: &models.BookCursor{Value: updated, ID: books[0].ID},
+	}).Return(books[1:], nil)
+	page, err = usecase.Harvest(ctx, models.HarvestParams{Limit: 1, Cursor: page.NextCursor})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, books[1:], page.Items)
+	assert.Empty(t, page.NextCursor)
+
+	t.Run("Invalid range", func(t *testing.T) {
+		until := from.Add(-time.Second)
+		_, err := usecase.Harvest(ctx, models.HarvestParams{From: &from, Until: &until})
+		assert.ErrorIs(t, err, errs.ErrValidation)
+	})
+
+	t.Run("Invalid cursor", func(t *testing.T) {
+		_, err := usecase.Harvest(ctx, models.HarvestParams{Cursor: "e30"})
+		assert.ErrorIs(t, err, errs.ErrValidation)
+	})
+
+	t.Run("Earliest datestamp", func(t *testing.T) {
+		repo.EXPECT().Harvest(ctx, models.HarvestQuery{Limit: 1}).Return(books[1:], nil)
+		earliest, err := usecase.EarliestDatestamp(ctx)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, deleted, earliest)
+
+		repo.EXPECT().Harvest(ctx, models.HarvestQuery{Limit: 1}).Return([]models.Book{}, nil)
+		earliest, err = usecase.EarliestDatestamp(ctx)
+		assert.Equal(t, nil, err)
+		assert.True(t, earliest.IsZero())
+	})
+}
+
+func TestMatch(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewBooksUsecase(repo)
+
+	ctx := context.Background()
+	condition := &models.BookCondition{
+		Op:    models.BookConditionAnd,
+		Left:  &models.BookCondition{Op: models.BookConditionMatch, Field: models.BookConditionTitle, Relation: models.BookRelationContains, Value: "war"},
+		Right: &models.BookCondition{Op: models.BookConditionMatch, Field: models.BookConditionYear, Relation: models.BookRelationLess, Value: uint16(1900)},
+	}
+	books := []models.Book{{ID: uuid.New(), Title: "War and Peace", Year: 1869}}
+
+	repo.EXPECT().CountMatches(ctx, condition).Return(int64(3), nil)
+	repo.EXPECT().Match(ctx, models.BookMatchQuery{Condition: condition, Limit: MaxPageSize, Offset: 2}).Return(books, nil)
+	result, err := usecase.Match(ctx, models.BookMatchQuery{Condition: condition, Limit: 1000, Offset: 2})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, &models.BookMatchResult{Items: books, Total: 3}, result)
+
+	t.Run("Count only", func(t *testing.T) {
+		repo.EXPECT().CountMatches(ctx, nil).Return(int64(3), nil)
+		result, err := usecase.Match(ctx, models.BookMatchQuery{})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, &models.BookMatchResult{Items: []models.Book{}, Total: 3}, result)
+	})
+
+	t.Run("Beyond the last book", func(t *testing.T) {
+		repo.EXPECT().CountMatches(ctx, condition).Return(int64(3), nil)
+		result, err := usecase.Match(ctx, models.BookMatchQuery{Condition: condition, Limit: 10, Offset: 3})
+		assert.Equal(t, nil, err)
+		assert.Empty(t, result.Items)
+	})
+
+	for _, testCase := range []struct {
+		name      string
+		condition models.BookCondition
+	}{
+		{
+			name:      "Missing operand",
+			condition: models.BookCondition{Op: models.BookConditionOr, Left: condition},
+		},
+		{
+			name:      "Empty text",
+			condition: models.BookCondition{Op: models.BookConditionMatch, Field: models.BookConditionAuthor, Relation: models.BookRelationEqual, Value: ""},
+		},
+		{
+			name:      "Ordered text",
+			condition: models.BookCondition{Op: models.BookConditionMatch, Field: models.BookConditionTitle, Relation: models.BookRelationLess, Value: "war"},
+		},
+		{
+			name:      "Contained year",
+			condition: models.BookCondition{Op: models.BookConditionMatch, Field: models.BookConditionYear, Relation: models.BookRelationContains, Value: uint16(1869)},
+		},
+		{
+			name:      "Textual year",
+			condition: models.BookCondition{Op: models.BookConditionMatch, Field: models.BookConditionYear, Relation: models.BookRelationEqual, Value: "1869"},
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := usecase.Match(ctx, models.BookMatchQuery{Condition: &testCase.condition, Limit: 10})
+			assert.ErrorIs(t, err, errs.ErrValidation)
+		})
+	}
+}
+
+func TestRestore(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewBooksUsecase(repo)
+
+	ctx := context.Background()
+	book := models.Book{ID: uuid.New(), Title: "Title", Author: "Author", Year: 2000}
+
+	repo.EXPECT().Restore(ctx, book.ID).Return(nil)
+	repo.EXPECT().GetOne(ctx, book.ID).Return(&book, nil)
+	restored, err := usecase.Restore(ctx, book.ID)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, &book, restored)
+
+	t.Run("Not in trash", func(t *testing.T) {
+		ID := uuid.New()
+		repo.EXPECT().Restore(ctx, ID).Return(errs.NotFound("book is not in the trash"))
+		restored, err := usecase.Restore(ctx, ID)
+		assert.ErrorIs(t, err, errs.ErrNotFound)
+		assert.Nil(t, restored)
+	})
+}
+
+func TestPurgeExpired(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewBooksUsecase(repo)
+
+	ctx := context.Background()
+	retention := 30 * 24 * time.Hour
+
+	repo.EXPECT().PurgeDeletedBefore(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, before time.Time) (int64, error) {
+		assert.WithinDuration(t, time.Now().Add(-retention), before, time.Minute)
+		return 3, nil
+	})
+	purged, err := usecase.PurgeExpired(ctx, retention)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(3), purged)
+
+	_, err = usecase.PurgeExpired(ctx, 0)
+	assert.ErrorIs(t, err, errs.ErrValidation)
+}
@@ -0,0 +1,128 @@
+package books
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/google/uuid"
+	"time"
+)
+
+// cursor is the serialized form of a keyset position handed out to clients.
+type cursor struct {
+	SortBy models.BookSortField `json:"s"`
+	Desc   bool                 `json:"d,omitempty"`
+	Locale models.Locale        `json:"l,omitempty"`
+	Value  json.RawMessage      `json:"v"`
+	ID     uuid.UUID            `json:"id"`
+}
+
+// encodeCursor builds an opaque cursor pointing right after the given book.
+func encodeCursor(book models.Book, query models.BookQuery) (string, error) {
+	value, err := json.Marshal(sortValue(book, query.SortBy))
+	if err != nil {
+		return "", err
+	}
+	raw, err := json.Marshal(cursor{
+		SortBy: query.SortBy,
+		Desc:   query.Desc,
+		Locale: query.Locale,
+		Value:  value,
+		ID:     book.ID,
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeCursor parses an opaque cursor and checks that it was issued for the same ordering.
+func decodeCursor(s string, query models.BookQuery) (*models.BookCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errs.Validation("invalid cursor")
+	}
+	var c cursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, errs.Validation("invalid cursor")
+	}
+	if c.SortBy != query.SortBy || c.Desc != query.Desc || c.Locale != query.Locale {
+		return nil, errs.Validation("cursor was issued for a different sort order")
+	}
+
+	result := &models.BookCursor{ID: c.ID}
+	switch query.SortBy {
+	case models.BookSortYear:
+		var year uint16
+		err = json.Unmarshal(c.Value, &year)
+		result.Value = year
+	case models.BookSortCreatedAt, models.BookSortDeletedAt:
+		var moment time.Time
+		err = json.Unmarshal(c.Value, &moment)
+		result.Value = moment
+	default:
+		var str string
+		err = json.Unmarshal(c.Value, &str)
+		result.Value = str
+	}
+	if err != nil {
+		return nil, errs.Validation("invalid cursor")
+	}
+	return result, nil
+}
+
+// sortValue returns the value of the field the books are ordered by.
+func sortValue(book models.Book, sortBy models.BookSortField) any {
+	switch sortBy {
+	case models.BookSortTitle:
+		return book.Title
+	case models.BookSortAuthor:
+		return book.Author
+	case models.BookSortYear:
+		return book.Year
+	case models.BookSortDeletedAt:
+		if book.DeletedAt == nil {
+			return time.Time{}
+		}
+		return *book.DeletedAt
+	default:
+		return book.CreatedAt
+	}
+}
+
+// harvestCursor is the serialized form of a position in harvested books. Along with the position
+// it keeps the bounds of datestamps, as harvesters send only the cursor to get the next page.
+type harvestCursor struct {
+	From  *time.Time `json:"f,omitempty"`
+	Until *time.Time `json:"u,omitempty"`
+	Value time.Time  `json:"v"`
+	ID    uuid.UUID  `json:"id"`
+}
+
+// encodeHarvestCursor builds an opaque cursor pointing right after the given book.
+func encodeHarvestCursor(book models.Book, query models.HarvestQuery) (string, error) {
+	raw, err := json.Marshal(harvestCursor{
+		From:  query.From,
+		Until: query.Until,
+		Value: book.Datestamp(),
+		ID:    book.ID,
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// decodeHarvestCursor parses an opaque cursor of harvested books.
+func decodeHarvestCursor(s string) (*harvestCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errs.Validation("invalid cursor")
+	}
+	var c harvestCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == uuid.Nil {
+		return nil, errs.Validation("invalid cursor")
+	}
+	return &c, nil
+}
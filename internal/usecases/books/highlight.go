@@ -0,0 +1,37 @@
+package books
+
+import (
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/pkg/textnorm"
+	"html"
+	"strings"
+	"unicode/utf8"
+)
+
+// highlight wraps words of the text whose search key is among the terms in highlight markers,
+// so "Достоевский" is highlighted for the query "dostoevsky" as well. The text is HTML-escaped,
+// so that only the markers are markup.
+func highlight(text string, terms map[string]struct{}) string {
+	var b strings.Builder
+	for len(text) > 0 {
+		r, size := utf8.DecodeRuneInString(text)
+		if textnorm.IsSeparator(r) {
+			b.WriteString(html.EscapeString(text[:size]))
+			text = text[size:]
+			continue
+		}
+
+		end := strings.IndexFunc(text, textnorm.IsSeparator)
+		if end < 0 {
+			end = len(text)
+		}
+		word := text[:end]
+		if _, ok := terms[textnorm.Key(word)]; ok {
+			b.WriteString(models.HighlightStart + html.EscapeString(word) + models.HighlightStop)
+		} else {
+			b.WriteString(html.EscapeString(word))
+		}
+		text = text[end:]
+	}
+	return b.String()
+}
@@ -4,14 +4,43 @@ import (
 	"context"
 	"github.com/KinitaL/testovoye/internal/models"
 	"github.com/google/uuid"
+	"iter"
+	"time"
 )
 
 //go:generate mockgen -destination repository_mock.go -package books . Repository
 
 type Repository interface {
-	GetAll(ctx context.Context) ([]models.Book, error)
+	GetAll(ctx context.Context, query models.BookQuery) ([]models.Book, error)
+	Count(ctx context.Context, filter models.BookFilter) (int64, error)
+	// Facets counts books matching the filter by genre, with subgenres included, by tag and by decade.
+	Facets(ctx context.Context, filter models.BookFilter) (*models.BookFacets, error)
+	// Export yields books matching the filter in the order of creation without loading all of them at once.
+	Export(ctx context.Context, filter models.BookFilter) iter.Seq2[models.Book, error]
+	Search(ctx context.Context, query models.BookSearchQuery) ([]models.BookSearchHit, error)
+	Suggest(ctx context.Context, query models.BookSearchQuery) ([]models.BookSuggestion, error)
+	// Match retrieves a page of books satisfying the condition in the order of creation.
+	Match(ctx context.Context, query models.BookMatchQuery) ([]models.Book, error)
+	CountMatches(ctx context.Context, condition *models.BookCondition) (int64, error)
 	GetOne(ctx context.Context, ID uuid.UUID) (*models.Book, error)
-	Create(ctx context.Context, book models.Book) error
+	// GetByISBN retrieves a book outside the trash by its ISBN-13.
+	GetByISBN(ctx context.Context, isbn string) (*models.Book, error)
+	// GetAny retrieves a book whether or not it is in the trash.
+	GetAny(ctx context.Context, ID uuid.UUID) (*models.Book, error)
+	// Harvest retrieves a page of books, deleted ones included, in the order of their datestamps.
+	Harvest(ctx context.Context, query models.HarvestQuery) ([]models.Book, error)
+	// Existing returns those of the identities that books in the catalog have.
+	Existing(ctx context.Context, identities []models.BookIdentity) ([]models.BookIdentity, error)
+	// ExistingISBNs returns those of the ISBN-13 that books in the catalog have.
+	ExistingISBNs(ctx context.Context, isbns []string) ([]string, error)
+	Create(ctx context.Context, book models.Book) (*models.Book, error)
+	CreateBatch(ctx context.Context, books []models.Book) ([]models.Book, error)
 	Update(ctx context.Context, ID uuid.UUID, book models.Book) error
-	Delete(ctx context.Context, ID uuid.UUID) error
+	Delete(ctx context.Context, ID uuid.UUID, version uint64) error
+	GetTrash(ctx context.Context, query models.TrashQuery) ([]models.Book, error)
+	Restore(ctx context.Context, ID uuid.UUID) error
+	Purge(ctx context.Context, ID uuid.UUID) error
+	PurgeDeletedBefore(ctx context.Context, before time.Time) (int64, error)
+	// Transaction runs fn with a repository whose changes are discarded if fn returns an error.
+	Transaction(ctx context.Context, fn func(repo Repository) error) error
 }
@@ -0,0 +1,88 @@
+package genres
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/pkg/textnorm"
+	"github.com/google/uuid"
+)
+
+//go:generate go install go.uber.org/mock/mockgen@v0.5.0
+//go:generate mockgen -destination usecase_mock.go -package genres . Genres
+
+// Genres interface defines the operations on the genre taxonomy.
+type (
+	Genres interface {
+		GetAll(ctx context.Context) ([]models.GenreNode, error)                              // Retrieve the whole taxonomy as a tree
+		GetOne(ctx context.Context, ID uuid.UUID) (*models.Genre, error)                     // Get a single genre by ID
+		Create(ctx context.Context, genre models.Genre) (*models.Genre, error)               // Create a new genre
+		Update(ctx context.Context, ID uuid.UUID, genre models.Genre) (*models.Genre, error) // Rename a genre or move it under another parent
+		Delete(ctx context.Context, ID uuid.UUID) error                                      // Delete a genre without subgenres and books
+	}
+
+	// genres struct implements the Genres interface.
+	genres struct {
+		repo Repository // Repository for data operations
+	}
+)
+
+// NewGenresUsecase creates and returns a new instance of the genre use case.
+func NewGenresUsecase(repo Repository) Genres {
+	return &genres{
+		repo: repo,
+	}
+}
+
+// GetAll retrieves the taxonomy: top-level genres with their subgenres, siblings ordered by name.
+func (u *genres) GetAll(ctx context.Context) ([]models.GenreNode, error) {
+	items, err := u.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return models.GenreTree(items), nil
+}
+
+// GetOne fetches a genre by its ID.
+func (u *genres) GetOne(ctx context.Context, ID uuid.UUID) (*models.Genre, error) {
+	return u.repo.GetOne(ctx, ID)
+}
+
+// Create adds a new genre with a unique identifier and returns it as it was stored.
+func (u *genres) Create(ctx context.Context, genre models.Genre) (*models.Genre, error) {
+	genre.ID = uuid.New() // Generate a new UUID for the genre
+	if err := u.normalize(&genre); err != nil {
+		return nil, err
+	}
+	return u.repo.Create(ctx, genre)
+}
+
+// Update renames a genre or moves it under another parent and returns the stored genre.
+// Subgenres move along, and books keep their genres.
+func (u *genres) Update(ctx context.Context, ID uuid.UUID, genre models.Genre) (*models.Genre, error) {
+	genre.ID = ID // Ensure the ID remains unchanged
+	if err := u.normalize(&genre); err != nil {
+		return nil, err
+	}
+	if genre.ParentID != nil && *genre.ParentID == ID {
+		return nil, errs.InvalidFields(errs.FieldError{Field: "parent_id", Message: "must not be the genre or its subgenre"})
+	}
+	if err := u.repo.Update(ctx, ID, genre); err != nil {
+		return nil, err
+	}
+	return u.repo.GetOne(ctx, ID)
+}
+
+// Delete removes a genre by its ID. Genres with subgenres or books, deleted ones included, can't be removed.
+func (u *genres) Delete(ctx context.Context, ID uuid.UUID) error {
+	return u.repo.Delete(ctx, ID)
+}
+
+// normalize brings the name to Unicode NFC, the same way as titles of books.
+func (u *genres) normalize(genre *models.Genre) error {
+	genre.Name = textnorm.NFC(genre.Name)
+	if genre.Name == "" {
+		return errs.InvalidFields(errs.FieldError{Field: "name", Message: "is required"})
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package genres
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+)
+
+func TestGetAll(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewGenresUsecase(repo)
+
+	fiction := models.Genre{ID: uuid.New(), Name: "Fiction"}
+	fantasy := models.Genre{ID: uuid.New(), Name: "Fantasy", ParentID: &fiction.ID}
+	poetry := models.Genre{ID: uuid.New(), Name: "Poetry"}
+	science := models.Genre{ID: uuid.New(), Name: "Science fiction", ParentID: &fiction.ID}
+	ctx := context.Background()
+
+	repo.EXPECT().GetAll(ctx).Return([]models.Genre{fantasy, fiction, poetry, science}, nil)
+
+	tree, err := usecase.GetAll(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.GenreNode{
+		{Genre: fiction, Children: []models.GenreNode{{Genre: fantasy}, {Genre: science}}},
+		{Genre: poetry},
+	}, tree)
+}
+
+func TestCreate(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewGenresUsecase(repo)
+
+	ctx := context.Background()
+	parentID := uuid.New()
+
+	t.Run("Create normalizes the name", func(t *testing.T) {
+		repo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, genre models.Genre) (*models.Genre, error) {
+			assert.NotEqual(t, uuid.Nil, genre.ID)
+			assert.Equal(t, "Фэнтези", genre.Name)
+			assert.Equal(t, &parentID, genre.ParentID)
+			return &genre, nil
+		})
+
+		genre, err := usecase.Create(ctx, models.Genre{Name: " Фэнтези ", ParentID: &parentID})
+		assert.NoError(t, err)
+		assert.Equal(t, "Фэнтези", genre.Name)
+	})
+
+	t.Run("Empty name", func(t *testing.T) {
+		_, err := usecase.Create(ctx, models.Genre{Name: " "})
+		assert.Equal(t, errs.KindValidation, errs.KindOf(err))
+		assert.Equal(t, "name", errs.FieldsOf(err)[0].Field)
+	})
+
+	t.Run("Sibling with the same name", func(t *testing.T) {
+		repo.EXPECT().Create(ctx, gomock.Any()).Return(nil, errs.Conflict("genre with the same name already exists under the parent"))
+
+		_, err := usecase.Create(ctx, models.Genre{Name: "Fantasy", ParentID: &parentID})
+		assert.ErrorIs(t, err, errs.ErrConflict)
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewGenresUsecase(repo)
+
+	ctx := context.Background()
+	ID := uuid.New()
+	parentID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		updated := models.Genre{ID: ID, Name: "Fantasy", ParentID: &parentID}
+		repo.EXPECT().Update(ctx, ID, updated).Return(nil)
+		repo.EXPECT().GetOne(ctx, ID).Return(&updated, nil)
+
+		genre, err := usecase.Update(ctx, ID, models.Genre{Name: "Fantasy ", ParentID: &parentID})
+		assert.NoError(t, err)
+		assert.Equal(t, &updated, genre)
+	})
+
+	t.Run("Genre under itself", func(t *testing.T) {
+		_, err := usecase.Update(ctx, ID, models.Genre{Name: "Fantasy", ParentID: &ID})
+		assert.Equal(t, errs.KindValidation, errs.KindOf(err))
+		assert.Equal(t, "parent_id", errs.FieldsOf(err)[0].Field)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		repo.EXPECT().Update(ctx, ID, gomock.Any()).Return(errs.NotFound("genre doesn't exist"))
+
+		_, err := usecase.Update(ctx, ID, models.Genre{Name: "Fantasy"})
+		assert.ErrorIs(t, err, errs.ErrNotFound)
+	})
+}
+
+func TestDelete(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewGenresUsecase(repo)
+
+	ctx := context.Background()
+	ID := uuid.New()
+	repo.EXPECT().Delete(ctx, ID).Return(errs.Conflict("genre has subgenres"))
+
+	err := usecase.Delete(ctx, ID)
+	assert.ErrorIs(t, err, errs.ErrConflict)
+}
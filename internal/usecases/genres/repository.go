@@ -0,0 +1,22 @@
+package genres
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/google/uuid"
+)
+
+//go:generate mockgen -destination repository_mock.go -package genres . Repository
+
+type Repository interface {
+	// GetAll retrieves all genres in the order of their names.
+	GetAll(ctx context.Context) ([]models.Genre, error)
+	GetOne(ctx context.Context, ID uuid.UUID) (*models.Genre, error)
+	// Create adds a genre under an existing parent. Names are unique among siblings regardless of case.
+	Create(ctx context.Context, genre models.Genre) (*models.Genre, error)
+	// Update renames a genre and moves it along with its subgenres under another parent,
+	// which can't be the genre itself or one of its subgenres.
+	Update(ctx context.Context, ID uuid.UUID, genre models.Genre) error
+	// Delete removes a genre that has neither subgenres nor books, the ones in the trash included.
+	Delete(ctx context.Context, ID uuid.UUID) error
+}
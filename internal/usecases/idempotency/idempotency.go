@@ -0,0 +1,87 @@
+package idempotency
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"time"
+)
+
+//go:generate go install go.uber.org/mock/mockgen@v0.5.0
+//go:generate mockgen -destination usecase_mock.go -package idempotency . Keys
+
+// Keys interface defines the operations that make retries of non-idempotent requests safe.
+type (
+	Keys interface {
+		Begin(ctx context.Context, key, fingerprint string) (*models.IdempotentResponse, error) // Reserve a key for a request, or return the response recorded for it
+		Complete(ctx context.Context, key string, response models.IdempotentResponse) error     // Record the response of the request the key has been reserved for
+		Release(ctx context.Context, key string) error                                          // Forget a reserved key, so that the request can be retried
+		PurgeExpired(ctx context.Context) (int64, error)                                        // Delete keys whose TTL has passed
+	}
+
+	// keys struct implements the Keys interface.
+	keys struct {
+		repo Repository    // Repository for data operations
+		ttl  time.Duration // How long a key is remembered
+	}
+)
+
+// NewKeysUsecase creates and returns a new instance of the idempotency key use case.
+func NewKeysUsecase(repo Repository, ttl time.Duration) Keys {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &keys{
+		repo: repo,
+		ttl:  ttl,
+	}
+}
+
+const (
+	DefaultTTL   = 24 * time.Hour // How long a key is remembered when the TTL isn't configured
+	Lease        = time.Minute    // How long a key without a response blocks retries, e.g. after a crash mid-request
+	MaxKeyLength = 255            // Longest key a client can send
+)
+
+// Begin reserves the key for a request identified by the fingerprint.
+// It returns nil if the request has to be processed, and the recorded response if it has been processed already.
+func (u *keys) Begin(ctx context.Context, key, fingerprint string) (*models.IdempotentResponse, error) {
+	if key == "" || len(key) > MaxKeyLength {
+		return nil, errs.Validation("idempotency key must be from 1 to %d characters long", MaxKeyLength)
+	}
+
+	now := time.Now()
+	existing, err := u.repo.Reserve(ctx, models.IdempotencyKey{
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(u.ttl),
+	}, now.Add(-Lease))
+	switch {
+	case err != nil:
+		return nil, err
+	case existing == nil:
+		return nil, nil
+	case existing.Fingerprint != fingerprint:
+		return nil, errs.Unprocessable("idempotency key %q has already been used for a different request", key)
+	case existing.Response == nil:
+		return nil, errs.Conflict("request with idempotency key %q is still being processed", key)
+	default:
+		return existing.Response, nil
+	}
+}
+
+// Complete records the response, so that retries of the request get it instead of being processed again.
+func (u *keys) Complete(ctx context.Context, key string, response models.IdempotentResponse) error {
+	return u.repo.Complete(ctx, key, response)
+}
+
+// Release forgets a key whose request failed, so that the client can retry it.
+func (u *keys) Release(ctx context.Context, key string) error {
+	return u.repo.Release(ctx, key)
+}
+
+// PurgeExpired deletes keys whose TTL has passed and returns their number.
+func (u *keys) PurgeExpired(ctx context.Context) (int64, error) {
+	return u.repo.DeleteExpired(ctx, time.Now())
+}
@@ -0,0 +1,114 @@
+package idempotency
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBegin(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewKeysUsecase(repo, time.Hour)
+
+	response := &models.IdempotentResponse{Status: 201, Body: []byte(`{}`)}
+
+	// test cases
+	cases := []struct {
+		name string
+
+		key      string
+		existing *models.IdempotencyKey
+		reserve  bool
+
+		response *models.IdempotentResponse
+		err      error
+	}{
+		{
+			name:    "New key",
+			key:     "key",
+			reserve: true,
+		},
+		{
+			name:     "Replay",
+			key:      "key",
+			existing: &models.IdempotencyKey{Key: "key", Fingerprint: "fingerprint", Response: response},
+			reserve:  true,
+			response: response,
+		},
+		{
+			name:     "Different request",
+			key:      "key",
+			existing: &models.IdempotencyKey{Key: "key", Fingerprint: "other", Response: response},
+			reserve:  true,
+			err:      errs.ErrUnprocessable,
+		},
+		{
+			name:     "Request in progress",
+			key:      "key",
+			existing: &models.IdempotencyKey{Key: "key", Fingerprint: "fingerprint"},
+			reserve:  true,
+			err:      errs.ErrConflict,
+		},
+		{
+			name: "Empty key",
+			key:  "",
+			err:  errs.ErrValidation,
+		},
+		{
+			name: "Too long key",
+			key:  strings.Repeat("k", MaxKeyLength+1),
+			err:  errs.ErrValidation,
+		},
+	}
+
+	// execution
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ctx := context.Background()
+			if testCase.reserve {
+				repo.EXPECT().Reserve(ctx, gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, key models.IdempotencyKey, abandonedBefore time.Time) (*models.IdempotencyKey, error) {
+					assert.Equal(t, testCase.key, key.Key)
+					assert.Equal(t, "fingerprint", key.Fingerprint)
+					assert.Equal(t, time.Hour, key.ExpiresAt.Sub(key.CreatedAt))
+					assert.Equal(t, Lease, key.CreatedAt.Sub(abandonedBefore))
+					return testCase.existing, nil
+				})
+			}
+			response, err := usecase.Begin(ctx, testCase.key, "fingerprint")
+			if testCase.err != nil {
+				assert.ErrorIs(t, err, testCase.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.response, response)
+		})
+	}
+}
+
+func TestPurgeExpired(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewKeysUsecase(repo, 0)
+
+	repo.EXPECT().DeleteExpired(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, before time.Time) (int64, error) {
+		assert.WithinDuration(t, time.Now(), before, time.Second)
+		return 2, nil
+	})
+	purged, err := usecase.PurgeExpired(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+}
@@ -0,0 +1,19 @@
+package idempotency
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/models"
+	"time"
+)
+
+//go:generate mockgen -destination repository_mock.go -package idempotency . Repository
+
+type Repository interface {
+	// Reserve stores a key without a response unless an unexpired key with the same value exists.
+	// A key that is still without a response and was created before abandonedBefore is reserved anew.
+	// It returns nil if the key has been reserved, and the existing key otherwise.
+	Reserve(ctx context.Context, key models.IdempotencyKey, abandonedBefore time.Time) (*models.IdempotencyKey, error)
+	Complete(ctx context.Context, key string, response models.IdempotentResponse) error
+	Release(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
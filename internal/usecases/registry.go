@@ -1,22 +1,45 @@
 package usecases
 
-import "github.com/KinitaL/testovoye/internal/usecases/books"
+import (
+	"github.com/KinitaL/testovoye/internal/usecases/authors"
+	"github.com/KinitaL/testovoye/internal/usecases/books"
+	"github.com/KinitaL/testovoye/internal/usecases/genres"
+	"github.com/KinitaL/testovoye/internal/usecases/idempotency"
+	"github.com/KinitaL/testovoye/internal/usecases/tags"
+	"github.com/KinitaL/testovoye/internal/usecases/works"
+	"time"
+)
 
 type (
 	Registry struct {
-		Books books.Books
+		Books       books.Books
+		Authors     authors.Authors
+		Works       works.Works
+		Genres      genres.Genres
+		Tags        tags.Tags
+		Idempotency idempotency.Keys
 	}
 	RepositoriesRegistry struct {
-		Books books.Repository
+		Books       books.Repository
+		Authors     authors.Repository
+		Works       works.Repository
+		Genres      genres.Repository
+		Tags        tags.Repository
+		Idempotency idempotency.Repository
 	}
 )
 
-func NewRegistry(repos *RepositoriesRegistry) *Registry {
+func NewRegistry(repos *RepositoriesRegistry, idempotencyTTL time.Duration) *Registry {
 	return &Registry{
-		Books: books.NewBooksUsecase(repos.Books),
+		Books:       books.NewBooksUsecase(repos.Books),
+		Authors:     authors.NewAuthorsUsecase(repos.Authors),
+		Works:       works.NewWorksUsecase(repos.Works),
+		Genres:      genres.NewGenresUsecase(repos.Genres),
+		Tags:        tags.NewTagsUsecase(repos.Tags),
+		Idempotency: idempotency.NewKeysUsecase(repos.Idempotency, idempotencyTTL),
 	}
 }
 
-func NewRepositoriesRegistry(books books.Repository) *RepositoriesRegistry {
-	return &RepositoriesRegistry{Books: books}
+func NewRepositoriesRegistry(books books.Repository, authors authors.Repository, works works.Repository, genres genres.Repository, tags tags.Repository, idempotency idempotency.Repository) *RepositoriesRegistry {
+	return &RepositoriesRegistry{Books: books, Authors: authors, Works: works, Genres: genres, Tags: tags, Idempotency: idempotency}
 }
@@ -0,0 +1,18 @@
+package tags
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/models"
+)
+
+//go:generate mockgen -destination repository_mock.go -package tags . Repository
+
+type Repository interface {
+	// GetAll retrieves the tags of books outside the trash, the most used first and then in byte order.
+	GetAll(ctx context.Context, query models.TagQuery) ([]models.TagCount, error)
+	// Rename replaces a tag with another one on every book having it, the ones in the trash included;
+	// the books get new versions. Renaming to a tag a book already has merges the two.
+	Rename(ctx context.Context, tag string, name string) error
+	// Delete removes a tag from every book having it; the books get new versions.
+	Delete(ctx context.Context, tag string) error
+}
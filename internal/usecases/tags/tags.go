@@ -0,0 +1,73 @@
+package tags
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/pkg/textnorm"
+	"strings"
+)
+
+//go:generate go install go.uber.org/mock/mockgen@v0.5.0
+//go:generate mockgen -destination usecase_mock.go -package tags . Tags
+
+// Tags interface defines the operations on free-form tags of books.
+type (
+	Tags interface {
+		GetAll(ctx context.Context, query models.TagQuery) ([]models.TagCount, error) // Retrieve the most used tags
+		Rename(ctx context.Context, tag string, name string) error                    // Rename a tag on every book
+		Delete(ctx context.Context, tag string) error                                 // Remove a tag from every book
+	}
+
+	// tags struct implements the Tags interface.
+	tags struct {
+		repo Repository // Repository for data operations
+	}
+)
+
+// NewTagsUsecase creates and returns a new instance of the tag use case.
+func NewTagsUsecase(repo Repository) Tags {
+	return &tags{
+		repo: repo,
+	}
+}
+
+const (
+	DefaultPageSize = 50  // Number of tags returned when the client doesn't specify one
+	MaxPageSize     = 500 // Largest number of tags a client can request
+)
+
+// GetAll retrieves the most used tags with the numbers of books, optionally only those starting with a prefix.
+func (u *tags) GetAll(ctx context.Context, query models.TagQuery) ([]models.TagCount, error) {
+	query.Prefix = Normalize(query.Prefix)
+	if query.Limit <= 0 {
+		query.Limit = DefaultPageSize
+	}
+	if query.Limit > MaxPageSize {
+		query.Limit = MaxPageSize
+	}
+	return u.repo.GetAll(ctx, query)
+}
+
+// Rename replaces a tag with another one on every book having it. Tags are compared after normalization,
+// so renaming a tag to itself changes nothing.
+func (u *tags) Rename(ctx context.Context, tag string, name string) error {
+	tag, name = Normalize(tag), Normalize(name)
+	if name == "" {
+		return errs.InvalidFields(errs.FieldError{Field: "name", Message: "is required"})
+	}
+	if tag == name {
+		return nil
+	}
+	return u.repo.Rename(ctx, tag, name)
+}
+
+// Delete removes a tag from every book having it.
+func (u *tags) Delete(ctx context.Context, tag string) error {
+	return u.repo.Delete(ctx, Normalize(tag))
+}
+
+// Normalize brings a tag to the form it is stored in: Unicode NFC in lower case.
+func Normalize(tag string) string {
+	return strings.ToLower(textnorm.NFC(tag))
+}
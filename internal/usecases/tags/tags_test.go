@@ -0,0 +1,94 @@
+package tags
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+)
+
+func TestGetAll(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewTagsUsecase(repo)
+
+	ctx := context.Background()
+
+	t.Run("Prefix is normalized", func(t *testing.T) {
+		tags := []models.TagCount{{Tag: "classics", Books: 3}}
+		repo.EXPECT().GetAll(ctx, models.TagQuery{Prefix: "class", Limit: 10}).Return(tags, nil)
+
+		result, err := usecase.GetAll(ctx, models.TagQuery{Prefix: " Class", Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, tags, result)
+	})
+
+	t.Run("Default and maximal limit", func(t *testing.T) {
+		repo.EXPECT().GetAll(ctx, models.TagQuery{Limit: DefaultPageSize}).Return(nil, nil)
+		_, err := usecase.GetAll(ctx, models.TagQuery{})
+		assert.NoError(t, err)
+
+		repo.EXPECT().GetAll(ctx, models.TagQuery{Limit: MaxPageSize}).Return(nil, nil)
+		_, err = usecase.GetAll(ctx, models.TagQuery{Limit: 10000})
+		assert.NoError(t, err)
+	})
+}
+
+func TestRename(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewTagsUsecase(repo)
+
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		repo.EXPECT().Rename(ctx, "sci-fi", "science fiction").Return(nil)
+
+		err := usecase.Rename(ctx, "Sci-Fi", " Science Fiction")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Same tag", func(t *testing.T) {
+		err := usecase.Rename(ctx, "classics", "Classics")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Empty name", func(t *testing.T) {
+		err := usecase.Rename(ctx, "classics", " ")
+		assert.Equal(t, errs.KindValidation, errs.KindOf(err))
+		assert.Equal(t, "name", errs.FieldsOf(err)[0].Field)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		repo.EXPECT().Rename(ctx, "classic", "classics").Return(errs.NotFound("tag doesn't exist"))
+
+		err := usecase.Rename(ctx, "classic", "classics")
+		assert.ErrorIs(t, err, errs.ErrNotFound)
+	})
+}
+
+func TestDelete(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewTagsUsecase(repo)
+
+	ctx := context.Background()
+	repo.EXPECT().Delete(ctx, "classics").Return(nil)
+
+	err := usecase.Delete(ctx, "Classics")
+	assert.NoError(t, err)
+}
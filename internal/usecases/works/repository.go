@@ -0,0 +1,24 @@
+package works
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/google/uuid"
+)
+
+//go:generate mockgen -destination repository_mock.go -package works . Repository
+
+type Repository interface {
+	// GetAll retrieves a page of works in the order of their titles.
+	GetAll(ctx context.Context, query models.WorkQuery) ([]models.Work, error)
+	GetOne(ctx context.Context, ID uuid.UUID) (*models.Work, error)
+	Create(ctx context.Context, work models.Work) (*models.Work, error)
+	Update(ctx context.Context, ID uuid.UUID, work models.Work) error
+	// Delete removes a work that has no editions, the ones in the trash included.
+	Delete(ctx context.Context, ID uuid.UUID) error
+	// Attach makes a book outside the trash an edition of the work. A book that is already an edition
+	// of another work has to be detached from it first.
+	Attach(ctx context.Context, ID uuid.UUID, bookID uuid.UUID) error
+	// Detach makes an edition of the work a standalone book.
+	Detach(ctx context.Context, ID uuid.UUID, bookID uuid.UUID) error
+}
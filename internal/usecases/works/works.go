@@ -0,0 +1,143 @@
+package works
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/pkg/textnorm"
+	"github.com/google/uuid"
+	"strings"
+)
+
+//go:generate go install go.uber.org/mock/mockgen@v0.5.0
+//go:generate mockgen -destination usecase_mock.go -package works . Works
+
+// Works interface defines the operations on works and their editions.
+type (
+	Works interface {
+		GetAll(ctx context.Context, params models.WorkListParams) (*models.WorkPage, error) // Retrieve a page of works ordered by title
+		GetOne(ctx context.Context, ID uuid.UUID) (*models.Work, error)                     // Get a single work by ID
+		Create(ctx context.Context, work models.Work) (*models.Work, error)                 // Create a new work
+		Update(ctx context.Context, ID uuid.UUID, work models.Work) (*models.Work, error)   // Replace the title and the creators of a work
+		Delete(ctx context.Context, ID uuid.UUID) error                                     // Delete a work without editions
+		Attach(ctx context.Context, ID uuid.UUID, bookID uuid.UUID) error                   // Make a book an edition of the work
+		Detach(ctx context.Context, ID uuid.UUID, bookID uuid.UUID) error                   // Make an edition of the work a standalone book
+	}
+
+	// works struct implements the Works interface.
+	works struct {
+		repo Repository // Repository for data operations
+	}
+)
+
+// NewWorksUsecase creates and returns a new instance of the work use case.
+func NewWorksUsecase(repo Repository) Works {
+	return &works{
+		repo: repo,
+	}
+}
+
+const (
+	DefaultPageSize = 20  // Page size used when the client doesn't specify one
+	MaxPageSize     = 100 // Largest page size a client can request
+)
+
+// cursor is the serialized form of a keyset position handed out to clients.
+type cursor struct {
+	Title string    `json:"t"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// GetAll retrieves a page of works ordered by title, optionally only those whose titles start with a prefix.
+func (u *works) GetAll(ctx context.Context, params models.WorkListParams) (*models.WorkPage, error) {
+	query := models.WorkQuery{TitlePrefix: strings.TrimSpace(params.TitlePrefix), Limit: params.Limit}
+	if query.Limit <= 0 {
+		query.Limit = DefaultPageSize
+	}
+	if query.Limit > MaxPageSize {
+		query.Limit = MaxPageSize
+	}
+	if params.Cursor != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(params.Cursor)
+		var c cursor
+		if err != nil || json.Unmarshal(raw, &c) != nil {
+			return nil, errs.Validation("invalid cursor")
+		}
+		query.After = &models.WorkCursor{Title: c.Title, ID: c.ID}
+	}
+
+	// fetch one extra work to find out whether there is a next page
+	limit := query.Limit
+	query.Limit++
+	items, err := u.repo.GetAll(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.WorkPage{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		last := page.Items[limit-1]
+		raw, err := json.Marshal(cursor{Title: last.Title, ID: last.ID})
+		if err != nil {
+			return nil, errs.Internal(err)
+		}
+		page.NextCursor = base64.RawURLEncoding.EncodeToString(raw)
+	}
+	return page, nil
+}
+
+// GetOne fetches a work by its ID.
+func (u *works) GetOne(ctx context.Context, ID uuid.UUID) (*models.Work, error) {
+	return u.repo.GetOne(ctx, ID)
+}
+
+// Create adds a new work with a unique identifier and returns it as it was stored.
+func (u *works) Create(ctx context.Context, work models.Work) (*models.Work, error) {
+	work.ID = uuid.New() // Generate a new UUID for the work
+	if err := u.normalize(&work); err != nil {
+		return nil, err
+	}
+	return u.repo.Create(ctx, work)
+}
+
+// Update replaces the title and the creators of a work and returns the stored work.
+// The editions keep their own titles and credits.
+func (u *works) Update(ctx context.Context, ID uuid.UUID, work models.Work) (*models.Work, error) {
+	work.ID = ID // Ensure the ID remains unchanged
+	if err := u.normalize(&work); err != nil {
+		return nil, err
+	}
+	if err := u.repo.Update(ctx, ID, work); err != nil {
+		return nil, err
+	}
+	return u.repo.GetOne(ctx, ID)
+}
+
+// Delete removes a work by its ID. Works with editions, deleted ones included, can't be removed.
+func (u *works) Delete(ctx context.Context, ID uuid.UUID) error {
+	return u.repo.Delete(ctx, ID)
+}
+
+// Attach makes a book an edition of the work. Attaching an edition of the same work again changes nothing,
+// and an edition of another work has to be detached from it first.
+func (u *works) Attach(ctx context.Context, ID uuid.UUID, bookID uuid.UUID) error {
+	return u.repo.Attach(ctx, ID, bookID)
+}
+
+// Detach makes an edition of the work a standalone book.
+func (u *works) Detach(ctx context.Context, ID uuid.UUID, bookID uuid.UUID) error {
+	return u.repo.Detach(ctx, ID, bookID)
+}
+
+// normalize brings the title and the creators to Unicode NFC, the same way as those of books.
+func (u *works) normalize(work *models.Work) error {
+	work.Title = textnorm.NFC(work.Title)
+	work.Author = textnorm.NFC(work.Author)
+	if work.Title == "" {
+		return errs.InvalidFields(errs.FieldError{Field: "title", Message: "is required"})
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package works
+
+import (
+	"context"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+)
+
+func TestGetAll(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewWorksUsecase(repo)
+
+	works := []models.Work{
+		{ID: uuid.New(), Title: "Anna Karenina"},
+		{ID: uuid.New(), Title: "The Brothers Karamazov"},
+		{ID: uuid.New(), Title: "War and Peace"},
+	}
+	ctx := context.Background()
+
+	t.Run("Pages", func(t *testing.T) {
+		repo.EXPECT().GetAll(ctx, models.WorkQuery{Limit: 3}).Return(works, nil)
+		page, err := usecase.GetAll(ctx, models.WorkListParams{Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, works[:2], page.Items)
+		assert.NotEmpty(t, page.NextCursor)
+
+		after := &models.WorkCursor{Title: works[1].Title, ID: works[1].ID}
+		repo.EXPECT().GetAll(ctx, models.WorkQuery{TitlePrefix: "war", Limit: 3, After: after}).Return(works[2:], nil)
+		page, err = usecase.GetAll(ctx, models.WorkListParams{TitlePrefix: " war ", Limit: 2, Cursor: page.NextCursor})
+		assert.NoError(t, err)
+		assert.Equal(t, works[2:], page.Items)
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("Default and maximal page size", func(t *testing.T) {
+		repo.EXPECT().GetAll(ctx, models.WorkQuery{Limit: DefaultPageSize + 1}).Return(nil, nil)
+		_, err := usecase.GetAll(ctx, models.WorkListParams{})
+		assert.NoError(t, err)
+
+		repo.EXPECT().GetAll(ctx, models.WorkQuery{Limit: MaxPageSize + 1}).Return(nil, nil)
+		_, err = usecase.GetAll(ctx, models.WorkListParams{Limit: 1000})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Invalid cursor", func(t *testing.T) {
+		_, err := usecase.GetAll(ctx, models.WorkListParams{Cursor: "not a cursor"})
+		assert.ErrorIs(t, err, errs.ErrValidation)
+	})
+}
+
+func TestCreate(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewWorksUsecase(repo)
+
+	ctx := context.Background()
+
+	t.Run("Create normalizes text", func(t *testing.T) {
+		repo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, work models.Work) (*models.Work, error) {
+			assert.NotEqual(t, uuid.Nil, work.ID)
+			assert.Equal(t, "Война и мир", work.Title)
+			assert.Equal(t, "Лев Толстой", work.Author)
+			return &work, nil
+		})
+
+		work, err := usecase.Create(ctx, models.Work{Title: " Война и мир ", Author: "Лев Толстой "})
+		assert.NoError(t, err)
+		assert.Equal(t, "Война и мир", work.Title)
+	})
+
+	t.Run("Empty title", func(t *testing.T) {
+		_, err := usecase.Create(ctx, models.Work{Title: " ", Author: "Leo Tolstoy"})
+		assert.Equal(t, errs.KindValidation, errs.KindOf(err))
+		assert.Equal(t, "title", errs.FieldsOf(err)[0].Field)
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewWorksUsecase(repo)
+
+	ctx := context.Background()
+	ID := uuid.New()
+
+	t.Run("Success", func(t *testing.T) {
+		updated := models.Work{ID: ID, Title: "War and Peace", Author: "Leo Tolstoy"}
+		repo.EXPECT().Update(ctx, ID, updated).Return(nil)
+		repo.EXPECT().GetOne(ctx, ID).Return(&updated, nil)
+
+		work, err := usecase.Update(ctx, ID, models.Work{Title: "War and Peace ", Author: "Leo Tolstoy"})
+		assert.NoError(t, err)
+		assert.Equal(t, &updated, work)
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		repo.EXPECT().Update(ctx, ID, gomock.Any()).Return(errs.NotFound("work doesn't exist"))
+
+		_, err := usecase.Update(ctx, ID, models.Work{Title: "War and Peace"})
+		assert.ErrorIs(t, err, errs.ErrNotFound)
+	})
+}
+
+func TestDelete(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewWorksUsecase(repo)
+
+	ctx := context.Background()
+	ID := uuid.New()
+	repo.EXPECT().Delete(ctx, ID).Return(errs.Conflict("work has editions"))
+
+	err := usecase.Delete(ctx, ID)
+	assert.ErrorIs(t, err, errs.ErrConflict)
+}
+
+func TestAttach(t *testing.T) {
+	// init mocks
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	repo := NewMockRepository(mockCtrl)
+
+	// init core
+	usecase := NewWorksUsecase(repo)
+
+	ctx := context.Background()
+	ID, bookID := uuid.New(), uuid.New()
+
+	t.Run("Attach", func(t *testing.T) {
+		repo.EXPECT().Attach(ctx, ID, bookID).Return(nil)
+		assert.NoError(t, usecase.Attach(ctx, ID, bookID))
+	})
+
+	t.Run("Edition of another work", func(t *testing.T) {
+		repo.EXPECT().Attach(ctx, ID, bookID).Return(errs.Conflict("book is an edition of another work"))
+		assert.ErrorIs(t, usecase.Attach(ctx, ID, bookID), errs.ErrConflict)
+	})
+
+	t.Run("Detach a book that isn't an edition", func(t *testing.T) {
+		repo.EXPECT().Detach(ctx, ID, bookID).Return(errs.NotFound("book isn't an edition of the work"))
+		assert.ErrorIs(t, usecase.Detach(ctx, ID, bookID), errs.ErrNotFound)
+	})
+}
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS books (
+    id         UUID PRIMARY KEY,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    title      TEXT NOT NULL,
+    author     TEXT NOT NULL,
+    year       INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_books_deleted_at ON books (deleted_at);
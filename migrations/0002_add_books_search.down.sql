@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_books_author_key_trgm;
+DROP INDEX IF EXISTS idx_books_title_key_trgm;
+DROP INDEX IF EXISTS idx_books_search_vector;
+
+ALTER TABLE books
+    DROP COLUMN IF EXISTS search_vector,
+    DROP COLUMN IF EXISTS author_key,
+    DROP COLUMN IF EXISTS title_key,
+    DROP COLUMN IF EXISTS search_text;
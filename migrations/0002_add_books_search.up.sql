@@ -0,0 +1,23 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE books
+    ADD COLUMN IF NOT EXISTS search_text TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS title_key   TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS author_key  TEXT NOT NULL DEFAULT '';
+
+-- Search keys are computed by the application (see textnorm.Key), rows written before
+-- they existed get an approximation that "migrate up" replaces right after this migration
+-- (see RekeyBooks); databases migrated before that need "migrate rekey" once.
+UPDATE books
+SET title_key   = lower(title),
+    author_key  = lower(author),
+    search_text = lower(title) || ' ' || lower(author)
+WHERE search_text = '';
+
+ALTER TABLE books
+    ADD COLUMN IF NOT EXISTS search_vector TSVECTOR
+        GENERATED ALWAYS AS (to_tsvector('simple', search_text)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_books_search_vector ON books USING gin (search_vector);
+CREATE INDEX IF NOT EXISTS idx_books_title_key_trgm ON books USING gin (title_key gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_books_author_key_trgm ON books USING gin (author_key gin_trgm_ops);
@@ -0,0 +1,2 @@
+-- version is incremented on every update and is exposed to clients as the ETag of a book
+ALTER TABLE books ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
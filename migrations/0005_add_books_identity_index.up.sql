@@ -0,0 +1,2 @@
+-- imports look up books by their normalized title and author to skip duplicates
+CREATE INDEX IF NOT EXISTS idx_books_identity ON books (title_key, author_key) WHERE deleted_at IS NULL;
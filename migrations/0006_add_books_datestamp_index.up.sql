@@ -0,0 +1,2 @@
+-- OAI-PMH harvesting pages through all books, deleted ones included, in the order of their last change
+CREATE INDEX IF NOT EXISTS idx_books_datestamp ON books ((GREATEST(updated_at, deleted_at)), id);
@@ -0,0 +1,3 @@
+-- isbn holds ISBN-13 without hyphens; an ISBN identifies a single book, unless the book is in the trash
+ALTER TABLE books ADD COLUMN IF NOT EXISTS isbn TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_books_isbn ON books (isbn) WHERE deleted_at IS NULL;
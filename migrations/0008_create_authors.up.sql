@@ -0,0 +1,37 @@
+-- book_authors holds the ordered credits of books; books.author keeps the names of the credited authors joined with " and "
+CREATE TABLE IF NOT EXISTS authors (
+    id         UUID PRIMARY KEY,
+    name       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_authors_name ON authors (name, id);
+
+CREATE TABLE IF NOT EXISTS book_authors (
+    book_id   UUID NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    position  INTEGER NOT NULL,
+    author_id UUID NOT NULL REFERENCES authors (id),
+    role      TEXT NOT NULL,
+    PRIMARY KEY (book_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS idx_book_authors_author_id ON book_authors (author_id);
+
+-- existing books get an author for every distinct name in their author text
+WITH credits AS (
+    SELECT books.id AS book_id, btrim(name.value) AS name, name.position - 1 AS position
+    FROM books, regexp_split_to_table(books.author, ' and ') WITH ORDINALITY AS name (value, position)
+    WHERE btrim(name.value) <> ''
+),
+new_authors AS (
+    INSERT INTO authors (id, name, created_at, updated_at)
+    SELECT gen_random_uuid(), name, now(), now()
+    FROM (SELECT DISTINCT name FROM credits) AS names
+    RETURNING id, name
+)
+INSERT INTO book_authors (book_id, position, author_id, role)
+SELECT credits.book_id, row_number() OVER (PARTITION BY credits.book_id ORDER BY credits.position) - 1,
+       new_authors.id, 'author'
+FROM credits
+JOIN new_authors ON new_authors.name = credits.name;
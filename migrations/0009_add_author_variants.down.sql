@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS author_redirects;
+DROP INDEX IF EXISTS idx_authors_lower_name;
+DROP TABLE IF EXISTS author_variants;
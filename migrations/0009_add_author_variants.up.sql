@@ -0,0 +1,16 @@
+-- credits are resolved by preferred names and variants case-insensitively; merged authors leave redirects to their authority
+CREATE TABLE IF NOT EXISTS author_variants (
+    author_id UUID NOT NULL REFERENCES authors (id) ON DELETE CASCADE,
+    name      TEXT NOT NULL,
+    PRIMARY KEY (author_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_author_variants_name ON author_variants (LOWER(name));
+CREATE INDEX IF NOT EXISTS idx_authors_lower_name ON authors (LOWER(name));
+
+CREATE TABLE IF NOT EXISTS author_redirects (
+    id        UUID PRIMARY KEY,
+    author_id UUID NOT NULL REFERENCES authors (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_author_redirects_author_id ON author_redirects (author_id);
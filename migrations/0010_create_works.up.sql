@@ -0,0 +1,17 @@
+-- books are editions; works group editions and translations of the same creation
+CREATE TABLE IF NOT EXISTS works (
+    id         UUID PRIMARY KEY,
+    title      TEXT NOT NULL,
+    author     TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_works_title ON works (title, id);
+
+ALTER TABLE books ADD COLUMN IF NOT EXISTS work_id UUID REFERENCES works (id);
+ALTER TABLE books ADD COLUMN IF NOT EXISTS publisher TEXT NOT NULL DEFAULT '';
+ALTER TABLE books ADD COLUMN IF NOT EXISTS language TEXT NOT NULL DEFAULT '';
+ALTER TABLE books ADD COLUMN IF NOT EXISTS format TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_books_work_id ON books (work_id, created_at, id) WHERE work_id IS NOT NULL;
@@ -0,0 +1,36 @@
+-- genre_closure holds a row for every ancestor of every genre, the genre itself included, so that subtrees are found without recursion
+CREATE TABLE IF NOT EXISTS genres (
+    id         UUID PRIMARY KEY,
+    name       TEXT NOT NULL,
+    parent_id  UUID REFERENCES genres (id),
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_genres_parent_name
+    ON genres (COALESCE(parent_id, '00000000-0000-0000-0000-000000000000'), LOWER(name));
+
+CREATE TABLE IF NOT EXISTS genre_closure (
+    ancestor_id   UUID NOT NULL REFERENCES genres (id) ON DELETE CASCADE,
+    descendant_id UUID NOT NULL REFERENCES genres (id) ON DELETE CASCADE,
+    depth         INTEGER NOT NULL,
+    PRIMARY KEY (ancestor_id, descendant_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_genre_closure_descendant_id ON genre_closure (descendant_id);
+
+CREATE TABLE IF NOT EXISTS book_genres (
+    book_id  UUID NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    genre_id UUID NOT NULL REFERENCES genres (id),
+    PRIMARY KEY (book_id, genre_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_book_genres_genre_id ON book_genres (genre_id);
+
+CREATE TABLE IF NOT EXISTS book_tags (
+    book_id UUID NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    tag     TEXT NOT NULL,
+    PRIMARY KEY (book_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_book_tags_tag ON book_tags (tag);
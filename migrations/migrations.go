@@ -0,0 +1,11 @@
+// Package migrations contains versioned SQL migrations of the database schema.
+// Every migration is a pair of "<version>_<name>.up.sql" and "<version>_<name>.down.sql" files
+// that are compiled into the binary and applied by pkg/migrate.
+package migrations
+
+import "embed"
+
+// FS holds migration files.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,517 @@
+package citation
+
+import (
+	"bufio"
+	"errors"
+	"github.com/KinitaL/testovoye/pkg/textnorm"
+	"io"
+	"strings"
+	"unicode"
+)
+
+type (
+	// Entry is a BibTeX entry. Field names are in lower case, values are decoded from LaTeX.
+	Entry struct {
+		Type   string
+		Key    string
+		Fields []Field
+		Line   int // Line where the entry starts in the file it was read from
+	}
+
+	// Field is a field of a BibTeX entry.
+	Field struct {
+		Name  string
+		Value string
+	}
+)
+
+// Field returns the value of the field with the name, or an empty string.
+func (e Entry) Field(name string) string {
+	for _, field := range e.Fields {
+		if field.Name == name {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+// BibTeXWriter writes BibTeX entries.
+type BibTeXWriter struct {
+	w       io.Writer
+	written bool // Whether an entry has been written, so the next one is separated with a blank line
+}
+
+// NewBibTeXWriter returns a writer of BibTeX entries.
+func NewBibTeXWriter(w io.Writer) *BibTeXWriter {
+	return &BibTeXWriter{w: w}
+}
+
+// Write writes an entry with values enclosed in braces.
+func (w *BibTeXWriter) Write(entry Entry) error {
+	var b strings.Builder
+	if w.written {
+		b.WriteString("\n")
+	}
+	w.written = true
+	b.WriteString("@" + entry.Type + "{" + entry.Key)
+	for _, field := range entry.Fields {
+		b.WriteString(",\n  " + field.Name + " = {" + latexEscaper.Replace(field.Value) + "}")
+	}
+	b.WriteString("\n}\n")
+	_, err := io.WriteString(w.w, b.String())
+	return err
+}
+
+// latexEscaper escapes characters that are special in LaTeX. Other characters are written as UTF-8.
+var latexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`&`, `\&`,
+	`%`, `\%`,
+	`$`, `\$`,
+	`#`, `\#`,
+	`_`, `\_`,
+	`~`, `\textasciitilde{}`,
+	`^`, `\textasciicircum{}`,
+)
+
+// BibTeXReader reads entries of a BibTeX file. Text outside of entries is ignored, @string macros are expanded,
+// and @comment and @preamble are skipped.
+type BibTeXReader struct {
+	r      *bufio.Reader
+	line   int
+	macros map[string]string
+}
+
+// NewBibTeXReader returns a reader of a BibTeX file.
+func NewBibTeXReader(r io.Reader) *BibTeXReader {
+	macros := make(map[string]string, 12)
+	for _, month := range []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"} {
+		macros[month] = month
+	}
+	return &BibTeXReader{r: bufio.NewReader(r), line: 1, macros: macros}
+}
+
+// errEOF is returned by the scanner when the file ends in the middle of an entry.
+var errEOF = errors.New("unexpected end of file")
+
+// Read returns the next entry, or io.EOF after the last one. A malformed entry is reported
+// with *SyntaxError, after which the next call goes on from the following entry.
+func (r *BibTeXReader) Read() (Entry, error) {
+	for {
+		if err := r.skipTo('@'); err != nil {
+			return Entry{}, err
+		}
+		line := r.line
+		entry, err := r.entry()
+		var syntaxErr *SyntaxError
+		switch {
+		case errors.Is(err, errSkipped):
+			continue
+		case errors.Is(err, errEOF):
+			return Entry{}, &SyntaxError{Line: line, Msg: err.Error()}
+		case errors.As(err, &syntaxErr):
+			syntaxErr.Line = line
+			return Entry{}, syntaxErr
+		case err != nil:
+			return Entry{}, err
+		}
+		entry.Line = line
+		return entry, nil
+	}
+}
+
+// errSkipped is returned by entry for @comment, @preamble and @string.
+var errSkipped = errors.New("skipped")
+
+// entry parses an entry after the @ sign.
+func (r *BibTeXReader) entry() (Entry, error) {
+	entryType, err := r.identifier()
+	if err != nil {
+		return Entry{}, err
+	}
+	entryType = strings.ToLower(entryType)
+	open, err := r.nextNonSpace()
+	if err != nil {
+		return Entry{}, err
+	}
+	closing := '}'
+	switch open {
+	case '{':
+	case '(':
+		closing = ')'
+	default:
+		return Entry{}, r.syntaxError("expected { after @" + entryType)
+	}
+
+	switch entryType {
+	case "comment":
+		return Entry{}, r.skipBraces(open, closing)
+	case "preamble":
+		if _, err := r.value(); err != nil {
+			return Entry{}, err
+		}
+		return Entry{}, r.expect(closing, errSkipped)
+	case "string":
+		name, err := r.identifier()
+		if err != nil {
+			return Entry{}, err
+		}
+		if err := r.expect('=', nil); err != nil {
+			return Entry{}, err
+		}
+		value, err := r.value()
+		if err != nil {
+			return Entry{}, err
+		}
+		r.macros[strings.ToLower(name)] = value
+		return Entry{}, r.expect(closing, errSkipped)
+	}
+
+	entry := Entry{Type: entryType}
+	key, delimiter, err := r.until(',', closing)
+	if err != nil {
+		return Entry{}, err
+	}
+	entry.Key = strings.TrimSpace(key)
+	for delimiter == ',' {
+		c, err := r.peekNonSpace()
+		if err != nil {
+			return Entry{}, err
+		}
+		if c == closing { // a trailing comma
+			_, _ = r.next()
+			break
+		}
+		name, err := r.identifier()
+		if err != nil {
+			return Entry{}, err
+		}
+		if err := r.expect('=', nil); err != nil {
+			return Entry{}, err
+		}
+		value, err := r.value()
+		if err != nil {
+			return Entry{}, err
+		}
+		entry.Fields = append(entry.Fields, Field{Name: strings.ToLower(name), Value: DecodeLaTeX(value)})
+		if delimiter, err = r.nextNonSpace(); err != nil {
+			return Entry{}, err
+		}
+		if delimiter != ',' && delimiter != closing {
+			return Entry{}, r.syntaxError("expected , or " + string(closing) + " after field " + name)
+		}
+	}
+	return entry, nil
+}
+
+// value parses a field value: quoted or braced strings, numbers and macros concatenated with #.
+// The value is returned as it is written, without the delimiters.
+func (r *BibTeXReader) value() (string, error) {
+	var b strings.Builder
+	for {
+		c, err := r.peekNonSpace()
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case c == '{':
+			_, _ = r.next()
+			part, err := r.balanced('}')
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(part)
+		case c == '"':
+			_, _ = r.next()
+			part, err := r.balanced('"')
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(part)
+		case unicode.IsDigit(c):
+			number, err := r.identifier()
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(number)
+		default:
+			name, err := r.identifier()
+			if err != nil {
+				return "", err
+			}
+			macro, ok := r.macros[strings.ToLower(name)]
+			if !ok {
+				return "", r.syntaxError("undefined macro " + name)
+			}
+			b.WriteString(macro)
+		}
+
+		if c, err = r.peekNonSpace(); err != nil {
+			return "", err
+		}
+		if c != '#' {
+			return b.String(), nil
+		}
+		_, _ = r.next()
+	}
+}
+
+// balanced reads a string up to the closing delimiter that isn't inside braces.
+func (r *BibTeXReader) balanced(closing rune) (string, error) {
+	var b strings.Builder
+	depth := 0
+	for {
+		c, err := r.next()
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case c == closing && depth == 0:
+			return b.String(), nil
+		case c == '\\':
+			// an escaped character never closes the string
+			b.WriteRune(c)
+			if c, err = r.next(); err != nil {
+				return "", err
+			}
+		case c == '{':
+			depth++
+		case c == '}':
+			if depth == 0 {
+				return "", r.syntaxError("unbalanced braces")
+			}
+			depth--
+		}
+		b.WriteRune(c)
+	}
+}
+
+// skipBraces skips the body of an entry up to the matching closing delimiter.
+func (r *BibTeXReader) skipBraces(open, closing rune) error {
+	depth := 0
+	for {
+		c, err := r.next()
+		if err != nil {
+			return err
+		}
+		switch c {
+		case open:
+			depth++
+		case closing:
+			if depth == 0 {
+				return errSkipped
+			}
+			depth--
+		}
+	}
+}
+
+// identifier reads an entry type, a field name, a macro name or a number.
+func (r *BibTeXReader) identifier() (string, error) {
+	if _, err := r.peekNonSpace(); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for {
+		c, err := r.peek()
+		if errors.Is(err, errEOF) && b.Len() > 0 {
+			return b.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if unicode.IsSpace(c) || strings.ContainsRune(`{}(),="#%'~\`, c) {
+			break
+		}
+		_, _ = r.next()
+		b.WriteRune(c)
+	}
+	if b.Len() == 0 {
+		return "", r.syntaxError("expected a name")
+	}
+	return b.String(), nil
+}
+
+// until reads a string up to one of the delimiters and returns the delimiter found.
+func (r *BibTeXReader) until(delimiters ...rune) (string, rune, error) {
+	var b strings.Builder
+	for {
+		c, err := r.next()
+		if err != nil {
+			return "", 0, err
+		}
+		for _, delimiter := range delimiters {
+			if c == delimiter {
+				return b.String(), c, nil
+			}
+		}
+		b.WriteRune(c)
+	}
+}
+
+// expect reads the character, skipping spaces, and returns result if it is found.
+func (r *BibTeXReader) expect(expected rune, result error) error {
+	c, err := r.nextNonSpace()
+	if err != nil {
+		return err
+	}
+	if c != expected {
+		return r.syntaxError("expected " + string(expected))
+	}
+	return result
+}
+
+// skipTo skips characters up to and including the character. It returns io.EOF if there is no such character.
+func (r *BibTeXReader) skipTo(target rune) error {
+	for {
+		c, err := r.next()
+		if errors.Is(err, errEOF) {
+			return io.EOF
+		}
+		if err != nil {
+			return err
+		}
+		if c == target {
+			return nil
+		}
+	}
+}
+
+func (r *BibTeXReader) peekNonSpace() (rune, error) {
+	for {
+		c, err := r.peek()
+		if err != nil || !unicode.IsSpace(c) {
+			return c, err
+		}
+		_, _ = r.next()
+	}
+}
+
+func (r *BibTeXReader) nextNonSpace() (rune, error) {
+	if _, err := r.peekNonSpace(); err != nil {
+		return 0, err
+	}
+	return r.next()
+}
+
+func (r *BibTeXReader) peek() (rune, error) {
+	c, err := r.next()
+	if err != nil {
+		return 0, err
+	}
+	if c == '\n' {
+		r.line--
+	}
+	return c, r.r.UnreadRune()
+}
+
+func (r *BibTeXReader) next() (rune, error) {
+	c, _, err := r.r.ReadRune()
+	if errors.Is(err, io.EOF) {
+		return 0, errEOF
+	}
+	if err != nil {
+		return 0, err
+	}
+	if c == '\n' {
+		r.line++
+	}
+	return c, nil
+}
+
+// syntaxError returns the error of the entry being read; its line is set by Read.
+func (r *BibTeXReader) syntaxError(msg string) error {
+	return &SyntaxError{Msg: msg}
+}
+
+// DecodeLaTeX converts a BibTeX value to plain text: accent commands become accented letters,
+// special characters are unescaped, braces are removed and whitespace is collapsed.
+func DecodeLaTeX(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == '{' || c == '}':
+		case c == '~':
+			b.WriteRune(' ')
+		case c == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			if i+2 < len(runes) && runes[i+2] == '-' {
+				b.WriteRune('—')
+				i += 2
+			} else {
+				b.WriteRune('–')
+				i++
+			}
+		case c == '\\' && i+1 < len(runes):
+			i = decodeCommand(&b, runes, i+1) - 1
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return strings.Join(strings.Fields(textnorm.NFC(b.String())), " ")
+}
+
+// accents maps LaTeX accent commands to combining characters.
+var accents = map[string]rune{
+	"'": '́', "`": '̀', "^": '̂', `"`: '̈', "~": '̃', "=": '̄', ".": '̇',
+	"u": '̆', "v": '̌', "H": '̋', "c": '̧', "k": '̨', "r": '̊', "d": '̣', "b": '̱',
+}
+
+// symbols maps LaTeX commands to the characters they produce.
+var symbols = map[string]string{
+	"ss": "ß", "o": "ø", "O": "Ø", "ae": "æ", "AE": "Æ", "oe": "œ", "OE": "Œ", "aa": "å", "AA": "Å",
+	"l": "ł", "L": "Ł", "i": "ı", "j": "ȷ", "textbackslash": `\`, "textasciitilde": "~", "textasciicircum": "^",
+	"textendash": "–", "textemdash": "—", "dots": "…", "ldots": "…",
+}
+
+// dotted restores the dots of letters that are written dotless under accents, like \'{\i}.
+var dotted = strings.NewReplacer("ı", "i", "ȷ", "j")
+
+// decodeCommand writes the text of the command starting at runes[i], right after the backslash,
+// and returns the position after the command.
+func decodeCommand(b *strings.Builder, runes []rune, i int) int {
+	var name string
+	if unicode.IsLetter(runes[i]) {
+		start := i
+		for i < len(runes) && unicode.IsLetter(runes[i]) {
+			i++
+		}
+		name = string(runes[start:i])
+		// spaces after a command word only end it
+		for i < len(runes) && runes[i] == ' ' {
+			i++
+		}
+	} else {
+		name = string(runes[i])
+		i++
+	}
+
+	if mark, ok := accents[name]; ok {
+		// the accented letter is either in braces or follows the command
+		for i < len(runes) && runes[i] == '{' {
+			i++
+		}
+		if i < len(runes) && runes[i] == '\\' && i+1 < len(runes) {
+			var letter strings.Builder
+			i = decodeCommand(&letter, runes, i+1)
+			b.WriteString(dotted.Replace(letter.String()))
+		} else if i < len(runes) {
+			b.WriteRune(runes[i])
+			i++
+		}
+		b.WriteRune(mark)
+		return i
+	}
+	if symbol, ok := symbols[name]; ok {
+		b.WriteString(symbol)
+		return i
+	}
+	if len([]rune(name)) == 1 && !unicode.IsLetter([]rune(name)[0]) {
+		b.WriteString(name) // an escaped special character, like \& or \%
+	}
+	// other commands, like \emph, are dropped, leaving their arguments
+	return i
+}
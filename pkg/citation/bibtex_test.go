@@ -0,0 +1,91 @@
+package citation
+
+import (
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestBibTeXWriter(t *testing.T) {
+	books := []models.Book{
+		{Title: `50% of $100 & {more}_x #1 ~ ^ \`, Author: "Tolstoy, Leo", Year: 1869, ISBN: "9780140449136"},
+		{Title: "Gödel, Escher, Bach", Author: "Douglas Hofstadter", Year: 1979},
+	}
+
+	var b strings.Builder
+	writer := NewBibTeXWriter(&b)
+	for _, book := range books {
+		assert.NoError(t, writer.Write(BibTeX(book, Key(book))))
+	}
+	assert.Equal(t, `@book{tolstoy186950,
+  author = {Tolstoy, Leo},
+  title = {50\% of \$100 \& \{more\}\_x \#1 \textasciitilde{} \textasciicircum{} \textbackslash{}},
+  year = {1869},
+  isbn = {9780140449136}
+}
+
+@book{hofstadter1979godel,
+  author = {Douglas Hofstadter},
+  title = {Gödel, Escher, Bach},
+  year = {1979}
+}
+`, b.String())
+
+	// the escaped values are read back unchanged
+	reader := NewBibTeXReader(strings.NewReader(b.String()))
+	for _, book := range books {
+		entry, err := reader.Read()
+		assert.NoError(t, err)
+		assert.Equal(t, book, FromBibTeX(entry))
+	}
+	_, err := reader.Read()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestBibTeXReader(t *testing.T) {
+	data := `Some text outside of entries.
+@string{pub = "Penguin"}
+@comment{ignored {nested} }
+@Book{tolstoy1869war,
+  Author = {Tolstoy, Leo},
+  title = "War and {Peace}",
+  year = 1869,
+  publisher = pub # " Classics",
+  note = {G{\"o}del and Jos\'{\i}e -- \emph{x}},
+}
+@book(bad, title = undefined)
+@misc{second, title={Last}}
+@book{unterminated, title = {Never
+`
+	reader := NewBibTeXReader(strings.NewReader(data))
+
+	entry, err := reader.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, Entry{
+		Type: "book",
+		Key:  "tolstoy1869war",
+		Fields: []Field{
+			{Name: "author", Value: "Tolstoy, Leo"},
+			{Name: "title", Value: "War and Peace"},
+			{Name: "year", Value: "1869"},
+			{Name: "publisher", Value: "Penguin Classics"},
+			{Name: "note", Value: "Gödel and Josíe – x"},
+		},
+		Line: 4,
+	}, entry)
+
+	// a malformed entry is reported, and reading goes on from the next one
+	_, err = reader.Read()
+	assert.Equal(t, &SyntaxError{Line: 11, Msg: "undefined macro undefined"}, err)
+
+	entry, err = reader.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, Entry{Type: "misc", Key: "second", Fields: []Field{{Name: "title", Value: "Last"}}, Line: 12}, entry)
+
+	_, err = reader.Read()
+	assert.Equal(t, &SyntaxError{Line: 13, Msg: "unexpected end of file"}, err)
+	_, err = reader.Read()
+	assert.ErrorIs(t, err, io.EOF)
+}
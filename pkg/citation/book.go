@@ -0,0 +1,117 @@
+package citation
+
+import (
+	"github.com/KinitaL/testovoye/internal/models"
+	"strconv"
+)
+
+type (
+	// Item is a CSL-JSON item, the input format of citation processors.
+	Item struct {
+		ID     string     `json:"id"`
+		Type   string     `json:"type"`
+		Title  string     `json:"title,omitempty"`
+		Author []ItemName `json:"author,omitempty"`
+		Issued *ItemDate  `json:"issued,omitempty"`
+		ISBN   string     `json:"ISBN,omitempty"`
+	}
+
+	// ItemName is a name of a CSL-JSON item.
+	ItemName struct {
+		Family string `json:"family,omitempty"`
+		Given  string `json:"given,omitempty"`
+	}
+
+	// ItemDate is a date of a CSL-JSON item, possibly a range of dates.
+	ItemDate struct {
+		DateParts [][]int `json:"date-parts"`
+	}
+)
+
+// BibTeX returns the @book entry of a book with the key.
+func BibTeX(book models.Book, key string) Entry {
+	entry := Entry{
+		Type: "book",
+		Key:  key,
+		Fields: []Field{
+			{Name: "author", Value: book.Author},
+			{Name: "title", Value: book.Title},
+			{Name: "year", Value: strconv.Itoa(int(book.Year))},
+		},
+	}
+	if book.ISBN != "" {
+		entry.Fields = append(entry.Fields, Field{Name: "isbn", Value: book.ISBN})
+	}
+	return entry
+}
+
+// RIS returns the BOOK reference of a book with the key.
+func RIS(book models.Book, key string) Reference {
+	reference := Reference{Tags: []Tag{{Name: RISType, Value: "BOOK"}, {Name: RISID, Value: key}}}
+	// names are written as "Family, Given", as the format recommends
+	for _, name := range Names(book.Author) {
+		value := name.Family
+		if name.Given != "" {
+			value += ", " + name.Given
+		}
+		reference.Tags = append(reference.Tags, Tag{Name: RISAuthor, Value: value})
+	}
+	reference.Tags = append(reference.Tags,
+		Tag{Name: RISTitle, Value: book.Title},
+		Tag{Name: RISYear, Value: strconv.Itoa(int(book.Year))},
+	)
+	if book.ISBN != "" {
+		reference.Tags = append(reference.Tags, Tag{Name: RISSerial, Value: book.ISBN})
+	}
+	return reference
+}
+
+// CSL returns the CSL-JSON item of a book with the key.
+func CSL(book models.Book, key string) Item {
+	item := Item{ID: key, Type: "book", Title: book.Title, ISBN: book.ISBN}
+	for _, name := range Names(book.Author) {
+		item.Author = append(item.Author, ItemName{Family: name.Family, Given: name.Given})
+	}
+	if book.Year != 0 {
+		item.Issued = &ItemDate{DateParts: [][]int{{int(book.Year)}}}
+	}
+	return item
+}
+
+// FromBibTeX extracts a book from a BibTeX entry of any type. The author is taken from the author
+// or editor field, and the year from the year or date field. The subtitle of biblatex is appended to the title.
+// An invalid ISBN is ignored.
+func FromBibTeX(entry Entry) models.Book {
+	book := models.Book{
+		Title:  entry.Field("title"),
+		Author: entry.Field("author"),
+		Year:   parseYear(entry.Field("year"), entry.Field("date")),
+		ISBN:   parseISBN(entry.Field("isbn")),
+	}
+	if book.Author == "" {
+		book.Author = entry.Field("editor")
+	}
+	if subtitle := entry.Field("subtitle"); subtitle != "" {
+		book.Title += ": " + subtitle
+	}
+	return book
+}
+
+// FromRIS extracts a book from a RIS reference of any type. Several authors are joined the way BibTeX does.
+// The ISBN is the first valid one of SN, which holds an ISSN for serials.
+func FromRIS(reference Reference) models.Book {
+	authors := reference.Values(RISAuthor)
+	if len(authors) == 0 {
+		authors = reference.Values(RISAuthorOld)
+	}
+	title := reference.Value(RISTitle)
+	if title == "" {
+		title = reference.Value(RISTitleOld)
+	}
+	return models.Book{
+		Title:  title,
+		Author: joinAuthors(authors),
+		Year:   parseYear(reference.Value(RISYear), reference.Value(RISYearOld), reference.Value(RISDate)),
+		ISBN:   parseISBN(reference.Values(RISSerial)...),
+	}
+}
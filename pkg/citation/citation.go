@@ -0,0 +1,147 @@
+// Package citation renders books as BibTeX, RIS and CSL-JSON references and reads BibTeX and RIS files.
+package citation
+
+import (
+	"fmt"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/pkg/isbn"
+	"github.com/KinitaL/testovoye/pkg/textnorm"
+	"golang.org/x/text/unicode/norm"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Media types of the formats.
+const (
+	MIMEBibTeX  = "application/x-bibtex"
+	MIMERIS     = "application/x-research-info-systems"
+	MIMECSLJSON = "application/vnd.citationstyles.csl+json"
+)
+
+// SyntaxError describes a malformed entry of a BibTeX or RIS file.
+// Reading can go on after it from the next entry.
+type SyntaxError struct {
+	Line int // Line where the entry starts
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// Name is a personal name split into the family and given names.
+type Name struct {
+	Family string
+	Given  string
+}
+
+// authorSeparator separates names of several authors, the same way as in BibTeX.
+const authorSeparator = " and "
+
+// Names splits the author of a book into names. Each name is written either as
+// "Family, Given" or as "Given Family"; a name of a single word is the family name.
+func Names(author string) []Name {
+	var names []Name
+	for _, name := range strings.Split(author, authorSeparator) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if family, given, ok := strings.Cut(name, ","); ok {
+			names = append(names, Name{Family: strings.TrimSpace(family), Given: strings.TrimSpace(given)})
+			continue
+		}
+		words := strings.Fields(name)
+		names = append(names, Name{Family: words[len(words)-1], Given: strings.Join(words[:len(words)-1], " ")})
+	}
+	return names
+}
+
+// joinAuthors joins names of several authors into the author of a book.
+func joinAuthors(names []string) string {
+	return strings.Join(names, authorSeparator)
+}
+
+// stopWords are articles skipped when the first word of a title is taken into a citation key.
+var stopWords = map[string]bool{"a": true, "an": true, "the": true, "der": true, "die": true, "das": true, "le": true, "la": true, "les": true}
+
+// Key returns the citation key of a book: the family name of the first author, the year and the first
+// significant word of the title, like "tolstoy1869war". Cyrillic names are transliterated, so that
+// the key stays the same whichever script the book is written in.
+func Key(book models.Book) string {
+	author := "anon"
+	if names := Names(book.Author); len(names) > 0 {
+		if part := keyPart(names[0].Family); part != "" {
+			author = part
+		}
+	}
+	year := "nd"
+	if book.Year != 0 {
+		year = strconv.Itoa(int(book.Year))
+	}
+	var word string
+	for _, w := range strings.Fields(textnorm.Key(book.Title)) {
+		if w = keyPart(w); w != "" && !stopWords[w] {
+			word = w
+			break
+		}
+	}
+	return author + year + word
+}
+
+// keyPart returns the ASCII letters and digits of the search key of a text, dropping diacritics.
+func keyPart(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(textnorm.Key(s)) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Keys makes citation keys unique within a single file.
+type Keys map[string]int
+
+// Unique returns the key if it hasn't been used yet, or the key with a letter suffix
+// ("tolstoy1869wara", "tolstoy1869warb", ...) otherwise.
+func (k Keys) Unique(key string) string {
+	n := k[key]
+	k[key] = n + 1
+	if n == 0 {
+		return key
+	}
+	suffix := ""
+	for ; n > 0; n = (n - 1) / 26 {
+		suffix = string(rune('a'+(n-1)%26)) + suffix
+	}
+	return key + suffix
+}
+
+// yearPattern finds a year in dates like "1869", "1869-03-01" or "1869///".
+var yearPattern = regexp.MustCompile(`\d{4}`)
+
+// parseYear returns the first year found in the dates.
+func parseYear(dates ...string) uint16 {
+	for _, date := range dates {
+		if match := yearPattern.FindString(date); match != "" {
+			year, _ := strconv.ParseUint(match, 10, 16)
+			return uint16(year)
+		}
+	}
+	return 0
+}
+
+// parseISBN returns the first valid ISBN of the values as ISBN-13. A value may hold several numbers
+// separated by commas, semicolons or spaces, like "0140449132; 9780140449136".
+func parseISBN(values ...string) string {
+	for _, value := range values {
+		for _, number := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' || r == ' ' }) {
+			if normalized, err := isbn.Normalize(number); err == nil {
+				return normalized
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+package citation
+
+import (
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	cases := []struct {
+		name string
+
+		book models.Book
+		key  string
+	}{
+		{
+			name: "Given and family name",
+
+			book: models.Book{Title: "War and Peace", Author: "Leo Tolstoy", Year: 1869},
+			key:  "tolstoy1869war",
+		},
+		{
+			name: "Family name first",
+
+			book: models.Book{Title: "The Idiot", Author: "Dostoevsky, Fyodor and Garnett, Constance", Year: 1869},
+			key:  "dostoevsky1869idiot",
+		},
+		{
+			name: "Cyrillic",
+
+			book: models.Book{Title: "Война и мир", Author: "Лев Толстой", Year: 1869},
+			key:  "tolstoy1869voyna",
+		},
+		{
+			name: "Diacritics and an article",
+
+			book: models.Book{Title: "Les Misérables", Author: "Victor Hugo", Year: 1862},
+			key:  "hugo1862miserables",
+		},
+		{
+			name: "Punctuation",
+
+			book: models.Book{Title: "«1984»: a novel", Author: "Orwell, George", Year: 1949},
+			key:  "orwell19491984",
+		},
+		{
+			name: "No author and no year",
+
+			book: models.Book{Title: "Beowulf"},
+			key:  "anonndbeowulf",
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.key, Key(testCase.book))
+		})
+	}
+}
+
+func TestKeysUnique(t *testing.T) {
+	keys := Keys{}
+	var got []string
+	for range 28 {
+		got = append(got, keys.Unique("tolstoy1869war"))
+	}
+	assert.Equal(t, "tolstoy1869war", got[0])
+	assert.Equal(t, "tolstoy1869wara", got[1])
+	assert.Equal(t, "tolstoy1869warb", got[2])
+	assert.Equal(t, "tolstoy1869warz", got[26])
+	assert.Equal(t, "tolstoy1869waraa", got[27])
+	assert.Equal(t, "anon1869war", keys.Unique("anon1869war"))
+}
+
+func TestNames(t *testing.T) {
+	assert.Equal(t, []Name{
+		{Family: "Tolstoy", Given: "Leo"},
+		{Family: "Maude", Given: "Aylmer"},
+		{Family: "Homer"},
+		{Family: "Saint-Exupéry", Given: "Antoine de"},
+	}, Names(" Leo Tolstoy and Maude, Aylmer and Homer and  and Antoine de Saint-Exupéry "))
+}
@@ -0,0 +1,129 @@
+package citation
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// RIS tags used for books.
+const (
+	RISType      = "TY"
+	RISID        = "ID"
+	RISAuthor    = "AU"
+	RISAuthorOld = "A1" // Primary author in older files
+	RISTitle     = "TI"
+	RISTitleOld  = "T1" // Primary title in older files
+	RISYear      = "PY"
+	RISYearOld   = "Y1" // Primary date in older files
+	RISDate      = "DA"
+	RISSerial    = "SN" // ISBN of books, ISSN of serials
+	RISEnd       = "ER"
+)
+
+type (
+	// Reference is a RIS reference: tags and values in their original order.
+	Reference struct {
+		Tags []Tag
+		Line int // Line where the reference starts in the file it was read from
+	}
+
+	// Tag is a line of a RIS reference.
+	Tag struct {
+		Name  string
+		Value string
+	}
+)
+
+// Value returns the value of the first tag with the name, or an empty string.
+func (r Reference) Value(name string) string {
+	for _, tag := range r.Tags {
+		if tag.Name == name {
+			return tag.Value
+		}
+	}
+	return ""
+}
+
+// Values returns values of all tags with the name.
+func (r Reference) Values(name string) []string {
+	var values []string
+	for _, tag := range r.Tags {
+		if tag.Name == name {
+			values = append(values, tag.Value)
+		}
+	}
+	return values
+}
+
+// RISWriter writes RIS references.
+type RISWriter struct {
+	w io.Writer
+}
+
+// NewRISWriter returns a writer of RIS references.
+func NewRISWriter(w io.Writer) *RISWriter {
+	return &RISWriter{w: w}
+}
+
+// Write writes a reference followed by the ER tag. Lines end with CRLF, as the format requires.
+func (w *RISWriter) Write(reference Reference) error {
+	var b strings.Builder
+	for _, tag := range reference.Tags {
+		// a value can't span lines
+		b.WriteString(tag.Name + "  - " + strings.Join(strings.Fields(tag.Value), " ") + "\r\n")
+	}
+	b.WriteString(RISEnd + "  - \r\n\r\n")
+	_, err := io.WriteString(w.w, b.String())
+	return err
+}
+
+// risLine matches a tagged line of a RIS file. Some programs omit the space after the hyphen of empty tags.
+var risLine = regexp.MustCompile(`^([A-Z][A-Z0-9])  -(?: (.*))?$`)
+
+// RISReader reads references of a RIS file.
+type RISReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewRISReader returns a reader of a RIS file.
+func NewRISReader(r io.Reader) *RISReader {
+	return &RISReader{scanner: bufio.NewScanner(r)}
+}
+
+// Read returns the next reference, or io.EOF after the last one. A reference must start with the TY tag;
+// lines before it are ignored, and untagged lines continue the value of the previous tag.
+// A reference that isn't terminated with ER is reported with *SyntaxError.
+func (r *RISReader) Read() (Reference, error) {
+	var reference *Reference
+	for r.scanner.Scan() {
+		r.line++
+		text := strings.TrimRight(r.scanner.Text(), "\r")
+		if r.line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+		match := risLine.FindStringSubmatch(text)
+		switch {
+		case reference == nil && match != nil && match[1] == RISType:
+			reference = &Reference{Line: r.line, Tags: []Tag{{Name: RISType, Value: strings.TrimSpace(match[2])}}}
+		case reference == nil:
+			continue
+		case match != nil && match[1] == RISEnd:
+			return *reference, nil
+		case match != nil:
+			reference.Tags = append(reference.Tags, Tag{Name: match[1], Value: strings.TrimSpace(match[2])})
+		case strings.TrimSpace(text) != "" && len(reference.Tags) > 0:
+			last := &reference.Tags[len(reference.Tags)-1]
+			last.Value = strings.TrimSpace(last.Value + " " + strings.TrimSpace(text))
+		}
+	}
+	if err := r.scanner.Err(); err != nil {
+		return Reference{}, err
+	}
+	if reference != nil {
+		return Reference{}, &SyntaxError{Line: reference.Line, Msg: "reference isn't terminated with ER"}
+	}
+	return Reference{}, io.EOF
+}
@@ -0,0 +1,58 @@
+package citation
+
+import (
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRISWriter(t *testing.T) {
+	book := models.Book{Title: "War\nand  Peace", Author: "Leo Tolstoy and Aylmer Maude", Year: 1869, ISBN: "9780140449136"}
+
+	var b strings.Builder
+	assert.NoError(t, NewRISWriter(&b).Write(RIS(book, Key(book))))
+	assert.Equal(t, "TY  - BOOK\r\n"+
+		"ID  - tolstoy1869war\r\n"+
+		"AU  - Tolstoy, Leo\r\n"+
+		"AU  - Maude, Aylmer\r\n"+
+		"TI  - War and Peace\r\n"+
+		"PY  - 1869\r\n"+
+		"SN  - 9780140449136\r\n"+
+		"ER  - \r\n\r\n", b.String())
+
+	reference, err := NewRISReader(strings.NewReader(b.String())).Read()
+	assert.NoError(t, err)
+	assert.Equal(t, models.Book{
+		Title:  "War and Peace",
+		Author: "Tolstoy, Leo and Maude, Aylmer",
+		Year:   1869,
+		ISBN:   "9780140449136",
+	}, FromRIS(reference))
+}
+
+func TestRISReader(t *testing.T) {
+	data := "\ufeffExported by a reference manager\n" +
+		"TY  - BOOK\n" +
+		"A1  - Tolstoy, Leo\n" +
+		"T1  - War and\n" +
+		"  Peace\n" +
+		"Y1  - 1869///\n" +
+		"SN  - invalid; 0-14-044913-2\n" +
+		"ER  -\n" +
+		"\n" +
+		"TY  - BOOK\n" +
+		"TI  - Unterminated\n"
+	reader := NewRISReader(strings.NewReader(data))
+
+	reference, err := reader.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, reference.Line)
+	assert.Equal(t, models.Book{Title: "War and Peace", Author: "Tolstoy, Leo", Year: 1869, ISBN: "9780140449136"}, FromRIS(reference))
+
+	_, err = reader.Read()
+	assert.Equal(t, &SyntaxError{Line: 10, Msg: "reference isn't terminated with ER"}, err)
+	_, err = reader.Read()
+	assert.ErrorIs(t, err, io.EOF)
+}
@@ -0,0 +1,383 @@
+// Package cql parses queries of the Contextual Query Language (CQL) 1.2, the query language of SRU.
+package cql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Boolean operators. They have the same precedence and are left-associative, so "a or b and c" is "(a or b) and c".
+const (
+	And  = "and"
+	Or   = "or"
+	Not  = "not" // "a not b" matches what a matches and b doesn't
+	Prox = "prox"
+)
+
+// ServerChoice is the index of search clauses that name none, so the server decides where to search.
+const ServerChoice = "cql.serverchoice"
+
+type (
+	// Query is a parsed query with optional sort keys.
+	Query struct {
+		Root     Node
+		SortKeys []SortKey
+	}
+
+	// Node is a node of the query tree, either a Boolean or a Clause.
+	Node interface {
+		node()
+	}
+
+	// Boolean combines two subqueries.
+	Boolean struct {
+		Operator  string // One of And, Or, Not and Prox, in lower case
+		Modifiers []Modifier
+		Left      Node
+		Right     Node
+	}
+
+	// Clause is a search clause "index relation term". A bare term has the ServerChoice index and the "=" relation.
+	Clause struct {
+		Index    string // In lower case, as indexes are case-insensitive
+		Relation Relation
+		Term     string // Without quotes, but with backslash escapes of masking characters
+	}
+
+	// Relation is a comparison of a clause, either a symbol such as "=" or "<=", or a name such as "any".
+	Relation struct {
+		Comparitor string // In lower case
+		Modifiers  []Modifier
+	}
+
+	// Modifier changes the meaning of a relation, a boolean operator or a sort key, as in "=/stem" or "/sort.descending".
+	Modifier struct {
+		Name       string
+		Comparitor string // Empty if the modifier has no value
+		Value      string
+	}
+
+	// SortKey is an index of the "sortBy" part of a query.
+	SortKey struct {
+		Index     string
+		Modifiers []Modifier
+	}
+
+	// SyntaxError is an error in the text of a query.
+	SyntaxError struct {
+		Offset  int // Byte offset of the token where the error was found
+		Message string
+	}
+
+	// MaskError reports a masking character that Unmask doesn't support.
+	MaskError struct {
+		Character byte
+	}
+)
+
+func (Boolean) node() {}
+func (Clause) node()  {}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at offset %d", e.Message, e.Offset)
+}
+
+func (e *MaskError) Error() string {
+	return fmt.Sprintf("unsupported masking character %q", e.Character)
+}
+
+// Parse parses the text of a query. Prefix assignments such as "> dc = ..." are accepted and ignored.
+func Parse(text string) (*Query, error) {
+	tokens, err := tokenize(text)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.query()
+	if err != nil {
+		return nil, err
+	}
+	query := &Query{Root: root}
+	if p.keyword("sortby") {
+		p.next()
+		if query.SortKeys, err = p.sortKeys(); err != nil {
+			return nil, err
+		}
+	}
+	if token := p.peek(); token.kind != tokenEnd {
+		return nil, p.fail(token, "unexpected %q", token.text)
+	}
+	return query, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenWord
+	tokenString // A quoted string, which is never a keyword
+	tokenSymbol
+	tokenLeft
+	tokenRight
+	tokenSlash
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int
+}
+
+// symbols are the comparitor symbols, longest first.
+var symbols = []string{"==", "<>", "<=", ">=", "=", "<", ">"}
+
+// tokenize splits a query into tokens.
+func tokenize(text string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLeft, text: "(", offset: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRight, text: ")", offset: i})
+			i++
+		case c == '/':
+			tokens = append(tokens, token{kind: tokenSlash, text: "/", offset: i})
+			i++
+		case c == '"':
+			var value strings.Builder
+			j := i + 1
+			for ; j < len(text) && text[j] != '"'; j++ {
+				if text[j] == '\\' && j+1 < len(text) {
+					j++
+					// an escaped quote loses its backslash; other escapes are kept for masking characters
+					if text[j] != '"' {
+						value.WriteByte('\\')
+					}
+				}
+				value.WriteByte(text[j])
+			}
+			if j == len(text) {
+				return nil, &SyntaxError{Offset: i, Message: "unterminated string"}
+			}
+			tokens = append(tokens, token{kind: tokenString, text: value.String(), offset: i})
+			i = j + 1
+		case strings.IndexByte("=<>", c) >= 0:
+			for _, symbol := range symbols {
+				if strings.HasPrefix(text[i:], symbol) {
+					tokens = append(tokens, token{kind: tokenSymbol, text: symbol, offset: i})
+					i += len(symbol)
+					break
+				}
+			}
+		default:
+			j := i
+			for j < len(text) && strings.IndexByte(" \t\n\r()/\"=<>", text[j]) < 0 {
+				if text[j] == '\\' && j+1 < len(text) {
+					j++
+				}
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: text[i:j], offset: i})
+			i = j
+		}
+	}
+	return append(tokens, token{kind: tokenEnd, offset: len(text)}), nil
+}
+
+// parser is a recursive descent parser of the CQL grammar.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	token := p.tokens[p.pos]
+	if token.kind != tokenEnd {
+		p.pos++
+	}
+	return token
+}
+
+// keyword reports whether the next token is the unquoted word, which is case-insensitive.
+func (p *parser) keyword(word string) bool {
+	token := p.peek()
+	return token.kind == tokenWord && strings.EqualFold(token.text, word)
+}
+
+// boolean returns the boolean operator of the next token, or an empty string.
+func (p *parser) boolean() string {
+	for _, operator := range []string{And, Or, Not, Prox} {
+		if p.keyword(operator) {
+			return operator
+		}
+	}
+	return ""
+}
+
+func (p *parser) fail(token token, format string, args ...any) error {
+	if token.kind == tokenEnd {
+		return &SyntaxError{Offset: token.offset, Message: "unexpected end of query"}
+	}
+	return &SyntaxError{Offset: token.offset, Message: fmt.Sprintf(format, args...)}
+}
+
+// query parses "[prefixAssignment] scopedClause".
+func (p *parser) query() (Node, error) {
+	for p.peek().kind == tokenSymbol && p.peek().text == ">" {
+		if err := p.prefixAssignment(); err != nil {
+			return nil, err
+		}
+	}
+
+	left, err := p.searchClause()
+	if err != nil {
+		return nil, err
+	}
+	for operator := p.boolean(); operator != ""; operator = p.boolean() {
+		p.next()
+		modifiers, err := p.modifiers()
+		if err != nil {
+			return nil, err
+		}
+		right, err := p.searchClause()
+		if err != nil {
+			return nil, err
+		}
+		left = Boolean{Operator: operator, Modifiers: modifiers, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// prefixAssignment skips "> prefix = uri" or "> uri".
+func (p *parser) prefixAssignment() error {
+	p.next()
+	if err := p.term(); err != nil {
+		return err
+	}
+	if p.peek().kind == tokenSymbol && p.peek().text == "=" {
+		p.next()
+		return p.term()
+	}
+	return nil
+}
+
+// term checks that the next token is a term and skips it.
+func (p *parser) term() error {
+	token := p.next()
+	if token.kind != tokenWord && token.kind != tokenString {
+		return p.fail(token, "expected a term, found %q", token.text)
+	}
+	return nil
+}
+
+// searchClause parses "( query )" or "[index relation] term".
+func (p *parser) searchClause() (Node, error) {
+	token := p.next()
+	switch token.kind {
+	case tokenLeft:
+		node, err := p.query()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRight {
+			return nil, p.fail(closing, "expected \")\", found %q", closing.text)
+		}
+		return node, nil
+	case tokenWord, tokenString:
+	default:
+		return nil, p.fail(token, "expected a search term, found %q", token.text)
+	}
+
+	// a term is followed by a boolean operator, ")", "sortBy" or the end, so anything else makes it an index
+	following := p.peek()
+	isRelation := following.kind == tokenSymbol ||
+		following.kind == tokenWord && p.boolean() == "" && !p.keyword("sortby")
+	if !isRelation {
+		return Clause{Index: ServerChoice, Relation: Relation{Comparitor: "="}, Term: token.text}, nil
+	}
+	if token.kind != tokenWord {
+		return nil, p.fail(token, "an index must not be quoted")
+	}
+
+	p.next()
+	relation := Relation{Comparitor: strings.ToLower(following.text)}
+	var err error
+	if relation.Modifiers, err = p.modifiers(); err != nil {
+		return nil, err
+	}
+	term := p.next()
+	if term.kind != tokenWord && term.kind != tokenString {
+		return nil, p.fail(term, "expected a search term, found %q", term.text)
+	}
+	return Clause{Index: strings.ToLower(token.text), Relation: relation, Term: term.text}, nil
+}
+
+// modifiers parses a list of "/name [comparitor value]".
+func (p *parser) modifiers() ([]Modifier, error) {
+	var result []Modifier
+	for p.peek().kind == tokenSlash {
+		p.next()
+		name := p.next()
+		if name.kind != tokenWord {
+			return nil, p.fail(name, "expected a modifier name, found %q", name.text)
+		}
+		modifier := Modifier{Name: strings.ToLower(name.text)}
+		if p.peek().kind == tokenSymbol {
+			modifier.Comparitor = p.next().text
+			value := p.next()
+			if value.kind != tokenWord && value.kind != tokenString {
+				return nil, p.fail(value, "expected a modifier value, found %q", value.text)
+			}
+			modifier.Value = value.text
+		}
+		result = append(result, modifier)
+	}
+	return result, nil
+}
+
+// sortKeys parses the indexes after "sortBy".
+func (p *parser) sortKeys() ([]SortKey, error) {
+	var result []SortKey
+	for p.peek().kind == tokenWord {
+		key := SortKey{Index: strings.ToLower(p.next().text)}
+		var err error
+		if key.Modifiers, err = p.modifiers(); err != nil {
+			return nil, err
+		}
+		result = append(result, key)
+	}
+	if len(result) == 0 {
+		return nil, p.fail(p.peek(), "expected a sort key, found %q", p.peek().text)
+	}
+	return result, nil
+}
+
+// Unmask resolves the masking characters of a term. Leading and trailing "*" are stripped and reported as
+// truncation; "*", "?" and "^" anywhere else are reported as unsupported masking. Escaped characters are literal.
+func Unmask(term string) (text string, truncated bool, err error) {
+	var result strings.Builder
+	for i := 0; i < len(term); i++ {
+		c := term[i]
+		switch {
+		case c == '\\' && i+1 < len(term):
+			i++
+			result.WriteByte(term[i])
+		case c == '*' && (i == 0 || i == len(term)-1):
+			truncated = true
+		case c == '*' || c == '?' || c == '^':
+			return "", false, &MaskError{Character: c}
+		default:
+			result.WriteByte(c)
+		}
+	}
+	return result.String(), truncated, nil
+}
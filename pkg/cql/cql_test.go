@@ -0,0 +1,207 @@
+package cql
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// testTerm returns a clause of the server's choice.
+func testTerm(term string) Clause {
+	return Clause{Index: ServerChoice, Relation: Relation{Comparitor: "="}, Term: term}
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name string
+
+		text  string
+		query *Query
+	}{
+		{
+			name: "Bare term",
+
+			text:  "war",
+			query: &Query{Root: testTerm("war")},
+		},
+		{
+			name: "Quoted term with a keyword",
+
+			text:  `dc.Title = "war and peace"`,
+			query: &Query{Root: Clause{Index: "dc.title", Relation: Relation{Comparitor: "="}, Term: "war and peace"}},
+		},
+		{
+			name: "Quoted keyword as a bare term",
+
+			text:  `"sortBy"`,
+			query: &Query{Root: testTerm("sortBy")},
+		},
+		{
+			name: "Escapes in terms",
+
+			text: `"say \"hi\"" and tol\*`,
+			query: &Query{Root: Boolean{
+				Operator: And,
+				Left:     testTerm(`say "hi"`),
+				Right:    testTerm(`tol\*`),
+			}},
+		},
+		{
+			name: "Operators of the same precedence are left-associative",
+
+			text: "a or b AND c",
+			query: &Query{Root: Boolean{
+				Operator: And,
+				Left:     Boolean{Operator: Or, Left: testTerm("a"), Right: testTerm("b")},
+				Right:    testTerm("c"),
+			}},
+		},
+		{
+			name: "Parentheses",
+
+			text: "a or (b and c)",
+			query: &Query{Root: Boolean{
+				Operator: Or,
+				Left:     testTerm("a"),
+				Right:    Boolean{Operator: And, Left: testTerm("b"), Right: testTerm("c")},
+			}},
+		},
+		{
+			name: "Boolean modifiers",
+
+			text: "a not b prox/distance<3/unit=word c",
+			query: &Query{Root: Boolean{
+				Operator: Prox,
+				Modifiers: []Modifier{
+					{Name: "distance", Comparitor: "<", Value: "3"},
+					{Name: "unit", Comparitor: "=", Value: "word"},
+				},
+				Left:  Boolean{Operator: Not, Left: testTerm("a"), Right: testTerm("b")},
+				Right: testTerm("c"),
+			}},
+		},
+		{
+			name: "Named relation with a modifier",
+
+			text: `dc.title ANY/Stem "war peace"`,
+			query: &Query{Root: Clause{
+				Index:    "dc.title",
+				Relation: Relation{Comparitor: "any", Modifiers: []Modifier{{Name: "stem"}}},
+				Term:     "war peace",
+			}},
+		},
+		{
+			name: "Prefix assignment and sort keys",
+
+			text: `> dc = "http://purl.org/dc/elements/1.1/" dc.creator == tolstoy sortBy dc.date/sort.descending dc.title`,
+			query: &Query{
+				Root: Clause{Index: "dc.creator", Relation: Relation{Comparitor: "=="}, Term: "tolstoy"},
+				SortKeys: []SortKey{
+					{Index: "dc.date", Modifiers: []Modifier{{Name: "sort.descending"}}},
+					{Index: "dc.title"},
+				},
+			},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			query, err := Parse(testCase.text)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.query, query)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		name string
+
+		text string
+		err  *SyntaxError
+	}{
+		{
+			name: "Unterminated string",
+
+			text: `dc.title = "war`,
+			err:  &SyntaxError{Offset: 11, Message: "unterminated string"},
+		},
+		{
+			name: "Unclosed parenthesis",
+
+			text: "(war",
+			err:  &SyntaxError{Offset: 4, Message: "unexpected end of query"},
+		},
+		{
+			name: "Missing operand",
+
+			text: "war and",
+			err:  &SyntaxError{Offset: 7, Message: "unexpected end of query"},
+		},
+		{
+			name: "Unbalanced parenthesis",
+
+			text: "war )",
+			err:  &SyntaxError{Offset: 4, Message: `unexpected ")"`},
+		},
+		{
+			name: "Quoted index",
+
+			text: `"dc.title" = war`,
+			err:  &SyntaxError{Offset: 0, Message: "an index must not be quoted"},
+		},
+		{
+			name: "Missing term of a relation",
+
+			text: "dc.title = )",
+			err:  &SyntaxError{Offset: 11, Message: `expected a search term, found ")"`},
+		},
+		{
+			name: "Missing modifier name",
+
+			text: `dc.title =/"stem" war`,
+			err:  &SyntaxError{Offset: 11, Message: `expected a modifier name, found "stem"`},
+		},
+		{
+			name: "Missing sort key",
+
+			text: "war sortBy",
+			err:  &SyntaxError{Offset: 10, Message: "unexpected end of query"},
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			query, err := Parse(testCase.text)
+			assert.Nil(t, query)
+			assert.Equal(t, testCase.err, err)
+		})
+	}
+}
+
+func TestUnmask(t *testing.T) {
+	cases := []struct {
+		name string
+
+		term      string
+		text      string
+		truncated bool
+		err       error
+	}{
+		{name: "Plain", term: "tolstoy", text: "tolstoy"},
+		{name: "Right truncation", term: "tol*", text: "tol", truncated: true},
+		{name: "Left truncation", term: "*stoy", text: "stoy", truncated: true},
+		{name: "Escaped masking characters", term: `tol\*s\?\\toy`, text: `tol*s?\toy`},
+		{name: "Masking inside", term: "tol*toy", err: &MaskError{Character: '*'}},
+		{name: "Single character", term: "tol?toy", err: &MaskError{Character: '?'}},
+		{name: "Anchoring", term: "^tolstoy", err: &MaskError{Character: '^'}},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			text, truncated, err := Unmask(testCase.term)
+			assert.Equal(t, testCase.err, err)
+			assert.Equal(t, testCase.text, text)
+			assert.Equal(t, testCase.truncated, truncated)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+// Package dc describes books with elements of Simple Dublin Core, the common denominator of library metadata.
+package dc
+
+import (
+	"github.com/KinitaL/testovoye/internal/models"
+	"strconv"
+)
+
+// Namespace is the XML namespace of Dublin Core elements.
+const Namespace = "http://purl.org/dc/elements/1.1/"
+
+// TypeText is the DCMI type of books.
+const TypeText = "Text"
+
+// Elements are the Dublin Core elements of a resource; every element may repeat. They are written with
+// the dc prefix, which the enclosing element of the format they are embedded into must declare.
+type Elements struct {
+	Titles      []string `xml:"dc:title"`
+	Creators    []string `xml:"dc:creator"`
+	Dates       []string `xml:"dc:date"`
+	Types       []string `xml:"dc:type"`
+	Identifiers []string `xml:"dc:identifier"`
+}
+
+// FromBook returns the elements of a book. The book is identified by its UUID URN and, if it has one, its ISBN URN.
+func FromBook(book models.Book) Elements {
+	elements := Elements{
+		Titles:      []string{book.Title},
+		Creators:    []string{book.Author},
+		Types:       []string{TypeText},
+		Identifiers: []string{"urn:uuid:" + book.ID.String()},
+	}
+	if book.ISBN != "" {
+		elements.Identifiers = append(elements.Identifiers, "urn:isbn:"+book.ISBN)
+	}
+	if book.Year != 0 {
+		elements.Dates = []string{strconv.Itoa(int(book.Year))}
+	}
+	return elements
+}
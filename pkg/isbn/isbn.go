@@ -0,0 +1,114 @@
+// Package isbn validates International Standard Book Numbers and converts them between ISBN-10 and ISBN-13.
+package isbn
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalid is returned for numbers of a wrong length, with wrong characters or with a wrong check digit.
+var ErrInvalid = errors.New("invalid ISBN")
+
+// prefix is the EAN prefix of ISBN-13 that ISBN-10 are converted with; ISBN-13 with the 979 prefix have no ISBN-10.
+const prefix = "978"
+
+// Normalize returns the ISBN-13 of an ISBN-10 or ISBN-13 without hyphens and spaces, as in "9780140449136".
+// Hyphens, spaces and a leading "ISBN" label are ignored, and the check digit "x" of ISBN-10 may be lower case.
+func Normalize(s string) (string, error) {
+	digits := strip(s)
+	switch {
+	case len(digits) == 10 && checkDigit10(digits[:9]) == digits[9]:
+		return To13(digits)
+	case len(digits) == 13 && (strings.HasPrefix(digits, "978") || strings.HasPrefix(digits, "979")) &&
+		checkDigit13(digits[:12]) == digits[12]:
+		return digits, nil
+	default:
+		return "", ErrInvalid
+	}
+}
+
+// Valid reports whether s is an ISBN-10 or ISBN-13 with a correct check digit.
+func Valid(s string) bool {
+	_, err := Normalize(s)
+	return err == nil
+}
+
+// To13 converts an ISBN-10 to ISBN-13, which has the 978 prefix and a check digit of its own.
+func To13(isbn10 string) (string, error) {
+	digits := strip(isbn10)
+	if len(digits) != 10 || checkDigit10(digits[:9]) != digits[9] {
+		return "", ErrInvalid
+	}
+	body := prefix + digits[:9]
+	return body + string(checkDigit13(body)), nil
+}
+
+// To10 converts an ISBN-13 with the 978 prefix to ISBN-10. ISBN-13 with the 979 prefix have no ISBN-10.
+func To10(isbn13 string) (string, error) {
+	digits, err := Normalize(isbn13)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(digits, prefix) {
+		return "", ErrInvalid
+	}
+	body := digits[3:12]
+	return body + string(checkDigit10(body)), nil
+}
+
+// strip removes the label, hyphens and spaces, and upper-cases the check digit "x". The result is empty
+// if there are other characters.
+func strip(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 4 && strings.EqualFold(s[:4], "ISBN") {
+		s = strings.TrimLeft(s[4:], ": ")
+	}
+	var result strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '-' || c == ' ':
+		case c >= '0' && c <= '9':
+			result.WriteByte(c)
+		case (c == 'X' || c == 'x') && i == len(s)-1:
+			result.WriteByte('X')
+		default:
+			return ""
+		}
+	}
+	return result.String()
+}
+
+// checkDigit10 computes the check digit of the first nine digits of an ISBN-10: the weighted sum
+// with weights from 10 down to 2 plus the check digit must be divisible by 11, where "X" stands for 10.
+func checkDigit10(body string) byte {
+	sum := 0
+	for i := 0; i < 9; i++ {
+		if body[i] < '0' || body[i] > '9' {
+			return 0
+		}
+		sum += int(body[i]-'0') * (10 - i)
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return 'X'
+	}
+	return byte('0' + check)
+}
+
+// checkDigit13 computes the check digit of the first twelve digits of an ISBN-13: the sum with
+// alternating weights 1 and 3 plus the check digit must be divisible by 10.
+func checkDigit13(body string) byte {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		if body[i] < '0' || body[i] > '9' {
+			return 0
+		}
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(body[i]-'0') * weight
+	}
+	return byte('0' + (10-sum%10)%10)
+}
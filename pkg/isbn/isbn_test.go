@@ -0,0 +1,115 @@
+package isbn
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		name string
+
+		isbn   string
+		result string
+		err    error
+	}{
+		{name: "ISBN-10", isbn: "0306406152", result: "9780306406157"},
+		{name: "ISBN-10 with check digit X", isbn: "0-8044-2957-X", result: "9780804429573"},
+		{name: "ISBN-10 with lower case x", isbn: "080442957x", result: "9780804429573"},
+		{name: "ISBN-13", isbn: "978-0-306-40615-7", result: "9780306406157"},
+		{name: "ISBN-13 with the 979 prefix", isbn: "979-10-90636-07-1", result: "9791090636071"},
+		{name: "Spaces", isbn: " 0 14 044913 2 ", result: "9780140449136"},
+		{name: "Label", isbn: "ISBN: 0-14-044913-2", result: "9780140449136"},
+		{name: "Label in lower case without a colon", isbn: "isbn 978-0-14-044913-6", result: "9780140449136"},
+		{name: "Wrong check digit of ISBN-10", isbn: "0-306-40615-3", err: ErrInvalid},
+		{name: "Wrong check digit X", isbn: "0-306-40615-X", err: ErrInvalid},
+		{name: "Wrong check digit of ISBN-13", isbn: "978-0-306-40615-8", err: ErrInvalid},
+		{name: "EAN-13 that isn't an ISBN", isbn: "9771234567898", err: ErrInvalid},
+		{name: "X not at the end", isbn: "08044295X7", err: ErrInvalid},
+		{name: "Other characters", isbn: "0-306-40615-2.", err: ErrInvalid},
+		{name: "Nine digits", isbn: "030640615", err: ErrInvalid},
+		{name: "Eleven digits", isbn: "03064061521", err: ErrInvalid},
+		{name: "Fourteen digits", isbn: "97803064061570", err: ErrInvalid},
+		{name: "Empty", isbn: "", err: ErrInvalid},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			result, err := Normalize(testCase.isbn)
+			assert.Equal(t, testCase.err, err)
+			assert.Equal(t, testCase.result, result)
+			assert.Equal(t, testCase.err == nil, Valid(testCase.isbn))
+		})
+	}
+}
+
+func TestTo13(t *testing.T) {
+	cases := []struct {
+		name string
+
+		isbn10 string
+		isbn13 string
+		err    error
+	}{
+		{name: "Check digit changes", isbn10: "0-306-40615-2", isbn13: "9780306406157"},
+		{name: "Check digit X", isbn10: "0-8044-2957-X", isbn13: "9780804429573"},
+		{name: "Check digit X of the ISBN-10 only", isbn10: "123456789X", isbn13: "9781234567897"},
+		{name: "Wrong check digit", isbn10: "0306406153", err: ErrInvalid},
+		{name: "ISBN-13", isbn10: "9780306406157", err: ErrInvalid},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			isbn13, err := To13(testCase.isbn10)
+			assert.Equal(t, testCase.err, err)
+			assert.Equal(t, testCase.isbn13, isbn13)
+		})
+	}
+}
+
+func TestTo10(t *testing.T) {
+	cases := []struct {
+		name string
+
+		isbn13 string
+		isbn10 string
+		err    error
+	}{
+		{name: "ISBN-13", isbn13: "978-0-306-40615-7", isbn10: "0306406152"},
+		{name: "Check digit X", isbn13: "9780804429573", isbn10: "080442957X"},
+		{name: "ISBN-10 is normalized first", isbn13: "ISBN 0-14-044913-2", isbn10: "0140449132"},
+		{name: "979 prefix has no ISBN-10", isbn13: "979-10-90636-07-1", err: ErrInvalid},
+		{name: "Wrong check digit", isbn13: "9780306406158", err: ErrInvalid},
+		{name: "Wrong length", isbn13: "978030640615", err: ErrInvalid},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			isbn10, err := To10(testCase.isbn13)
+			assert.Equal(t, testCase.err, err)
+			assert.Equal(t, testCase.isbn10, isbn10)
+		})
+	}
+}
+
+func TestStrip(t *testing.T) {
+	cases := []struct {
+		name string
+
+		s      string
+		digits string
+	}{
+		{name: "Hyphens and spaces", s: " 978-0 306-40615-7 ", digits: "9780306406157"},
+		{name: "Label with a colon", s: "ISBN: 0-8044-2957-x", digits: "080442957X"},
+		{name: "Label in mixed case", s: "IsBn 0306406152", digits: "0306406152"},
+		{name: "X not at the end", s: "X306406152", digits: ""},
+		{name: "Other characters", s: "0306406152 (paperback)", digits: ""},
+		{name: "Only a label", s: "ISBN", digits: ""},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.digits, strip(testCase.s))
+		})
+	}
+}
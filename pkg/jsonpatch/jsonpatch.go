@@ -0,0 +1,369 @@
+// Package jsonpatch applies JSON Merge Patch (RFC 7396) and JSON Patch (RFC 6902) documents.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Media types of the patch formats.
+const (
+	MIMEMergePatch = "application/merge-patch+json"
+	MIMEJSONPatch  = "application/json-patch+json"
+)
+
+var (
+	// ErrInvalid means that the patch document is malformed.
+	ErrInvalid = errors.New("invalid patch document")
+	// ErrNotApplicable means that the patch is well-formed, but can't be applied to the document:
+	// a path doesn't exist or a "test" operation failed.
+	ErrNotApplicable = errors.New("patch can't be applied")
+)
+
+// Operation is a single operation of a JSON Patch document.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// MergePatch applies a JSON Merge Patch to the document: members of the patch replace members
+// of the document, null members remove them, and objects are merged recursively.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	p, err := decode(patch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return json.Marshal(mergePatch(target, p))
+}
+
+// Apply applies a JSON Patch to the document. Operations are applied in order,
+// and if any of them fails, no patched document is returned at all.
+func Apply(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	var ops []Operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	for i, op := range ops {
+		target, err = apply(target, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+// mergePatch implements the MergePatch algorithm of RFC 7396, section 2.
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = make(map[string]any)
+	}
+	for name, value := range p {
+		if value == nil {
+			delete(t, name)
+			continue
+		}
+		t[name] = mergePatch(t[name], value)
+	}
+	return t
+}
+
+// apply applies a single JSON Patch operation and returns the new document.
+func apply(doc any, op Operation) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: value is required", ErrInvalid)
+		}
+		value, err := decode(op.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			if doc, err = remove(doc, path); err != nil {
+				return nil, err
+			}
+			return add(doc, path, value)
+		default:
+			current, err := get(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(normalize(current), normalize(value)) {
+				return nil, fmt.Errorf("%w: test failed", ErrNotApplicable)
+			}
+			return doc, nil
+		}
+	case "remove":
+		return remove(doc, path)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if isPrefix(from, path) && len(from) < len(path) {
+				return nil, fmt.Errorf("%w: can't move a value into itself", ErrNotApplicable)
+			}
+			if doc, err = remove(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			value = deepCopy(value)
+		}
+		return add(doc, path, value)
+	default:
+		return nil, fmt.Errorf("%w: unknown operation %q", ErrInvalid, op.Op)
+	}
+}
+
+// add sets the value at the path, inserting into arrays and replacing object members.
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	key := path[len(path)-1]
+
+	switch container := parent.(type) {
+	case map[string]any:
+		container[key] = value
+		return doc, nil
+	case []any:
+		index := len(container)
+		if key != "-" {
+			if index, err = arrayIndex(key, len(container)+1); err != nil {
+				return nil, err
+			}
+		}
+		updated := append(container[:index:index], append([]any{value}, container[index:]...)...)
+		return replaceAt(doc, path[:len(path)-1], updated)
+	default:
+		return nil, fmt.Errorf("%w: %s is not a container", ErrNotApplicable, pointer(path[:len(path)-1]))
+	}
+}
+
+// remove deletes the value at the path, which must exist.
+func remove(doc any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	key := path[len(path)-1]
+
+	switch container := parent.(type) {
+	case map[string]any:
+		if _, ok := container[key]; !ok {
+			return nil, fmt.Errorf("%w: %s doesn't exist", ErrNotApplicable, pointer(path))
+		}
+		delete(container, key)
+		return doc, nil
+	case []any:
+		index, err := arrayIndex(key, len(container))
+		if err != nil {
+			return nil, err
+		}
+		updated := append(container[:index:index], container[index+1:]...)
+		return replaceAt(doc, path[:len(path)-1], updated)
+	default:
+		return nil, fmt.Errorf("%w: %s doesn't exist", ErrNotApplicable, pointer(path))
+	}
+}
+
+// get returns the value at the path.
+func get(doc any, path []string) (any, error) {
+	current := doc
+	for i, key := range path {
+		switch container := current.(type) {
+		case map[string]any:
+			value, ok := container[key]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s doesn't exist", ErrNotApplicable, pointer(path[:i+1]))
+			}
+			current = value
+		case []any:
+			index, err := arrayIndex(key, len(container))
+			if err != nil {
+				return nil, err
+			}
+			current = container[index]
+		default:
+			return nil, fmt.Errorf("%w: %s doesn't exist", ErrNotApplicable, pointer(path[:i+1]))
+		}
+	}
+	return current, nil
+}
+
+// replaceAt replaces the value at the path, which is needed when an array changes its length.
+func replaceAt(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := get(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	key := path[len(path)-1]
+	switch container := parent.(type) {
+	case map[string]any:
+		container[key] = value
+	case []any:
+		index, err := arrayIndex(key, len(container))
+		if err != nil {
+			return nil, err
+		}
+		container[index] = value
+	}
+	return doc, nil
+}
+
+// arrayIndex parses an array index of a JSON Pointer that must be less than size.
+func arrayIndex(key string, size int) (int, error) {
+	if key == "" || (len(key) > 1 && key[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalid, key)
+	}
+	index, err := strconv.Atoi(key)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalid, key)
+	}
+	if index >= size {
+		return 0, fmt.Errorf("%w: array index %d is out of range", ErrNotApplicable, index)
+	}
+	return index, nil
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into unescaped reference tokens.
+func parsePointer(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if s[0] != '/' {
+		return nil, fmt.Errorf("%w: JSON pointer %q must start with /", ErrInvalid, s)
+	}
+	tokens := strings.Split(s[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// pointer builds a JSON Pointer from reference tokens.
+func pointer(tokens []string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+// isPrefix reports whether path starts with prefix.
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// decode parses JSON keeping numbers as json.Number, so that they survive the round trip intact.
+func decode(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+	return value, nil
+}
+
+// normalize converts numbers to float64, so that 1 and 1.0 are equal in "test" operations.
+func normalize(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return v.String()
+		}
+		return f
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[key] = normalize(item)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = normalize(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// deepCopy copies objects and arrays, so that a copied value doesn't share them with the source.
+func deepCopy(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[key] = deepCopy(item)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = deepCopy(item)
+		}
+		return result
+	default:
+		return value
+	}
+}
@@ -0,0 +1,380 @@
+package jsonpatch
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	cases := []struct {
+		name string
+
+		doc    string
+		patch  string
+		result string
+		err    error
+	}{
+		// RFC 6902, Appendix A
+		{
+			name: "Adding an object member",
+
+			doc:    `{"foo": "bar"}`,
+			patch:  `[{"op": "add", "path": "/baz", "value": "qux"}]`,
+			result: `{"baz": "qux", "foo": "bar"}`,
+		},
+		{
+			name: "Adding an array element",
+
+			doc:    `{"foo": ["bar", "baz"]}`,
+			patch:  `[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+			result: `{"foo": ["bar", "qux", "baz"]}`,
+		},
+		{
+			name: "Removing an object member",
+
+			doc:    `{"baz": "qux", "foo": "bar"}`,
+			patch:  `[{"op": "remove", "path": "/baz"}]`,
+			result: `{"foo": "bar"}`,
+		},
+		{
+			name: "Removing an array element",
+
+			doc:    `{"foo": ["bar", "qux", "baz"]}`,
+			patch:  `[{"op": "remove", "path": "/foo/1"}]`,
+			result: `{"foo": ["bar", "baz"]}`,
+		},
+		{
+			name: "Replacing a value",
+
+			doc:    `{"baz": "qux", "foo": "bar"}`,
+			patch:  `[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+			result: `{"baz": "boo", "foo": "bar"}`,
+		},
+		{
+			name: "Moving a value",
+
+			doc:    `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			patch:  `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			result: `{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`,
+		},
+		{
+			name: "Moving an array element",
+
+			doc:    `{"foo": ["all", "grass", "cows", "eat"]}`,
+			patch:  `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			result: `{"foo": ["all", "cows", "eat", "grass"]}`,
+		},
+		{
+			name: "Testing a value: success",
+
+			doc:    `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			patch:  `[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2}]`,
+			result: `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+		},
+		{
+			name: "Testing a value: error",
+
+			doc:   `{"baz": "qux"}`,
+			patch: `[{"op": "test", "path": "/baz", "value": "bar"}]`,
+			err:   ErrNotApplicable,
+		},
+		{
+			name: "Adding a nested member object",
+
+			doc:    `{"foo": "bar"}`,
+			patch:  `[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`,
+			result: `{"foo": "bar", "child": {"grandchild": {}}}`,
+		},
+		{
+			name: "Ignoring unrecognized elements",
+
+			doc:    `{"foo": "bar"}`,
+			patch:  `[{"op": "add", "path": "/baz", "value": "qux", "xyz": 123}]`,
+			result: `{"foo": "bar", "baz": "qux"}`,
+		},
+		{
+			name: "Adding to a nonexistent target",
+
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`,
+			err:   ErrNotApplicable,
+		},
+		{
+			name: "Invalid JSON Patch document",
+
+			// the last "op" member wins, and there is nothing to remove
+			doc:   `{"foo": "bar"}`,
+			patch: `[{"op": "add", "path": "/baz", "value": "qux", "op": "remove"}]`,
+			err:   ErrNotApplicable,
+		},
+		{
+			name: "~ escape ordering",
+
+			doc:    `{"/": 9, "~1": 10}`,
+			patch:  `[{"op": "test", "path": "/~01", "value": 10}]`,
+			result: `{"/": 9, "~1": 10}`,
+		},
+		{
+			name: "Comparing strings and numbers",
+
+			doc:   `{"/": 9, "~1": 10}`,
+			patch: `[{"op": "test", "path": "/~01", "value": "10"}]`,
+			err:   ErrNotApplicable,
+		},
+		{
+			name: "Adding an array value",
+
+			doc:    `{"foo": ["bar"]}`,
+			patch:  `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`,
+			result: `{"foo": ["bar", ["abc", "def"]]}`,
+		},
+		// Edge cases
+		{
+			name: "Escaped slash and tilde",
+
+			doc:    `{}`,
+			patch:  `[{"op": "add", "path": "/a~1b", "value": 1}, {"op": "add", "path": "/m~0n", "value": 2}]`,
+			result: `{"a/b": 1, "m~n": 2}`,
+		},
+		{
+			name: "Moving a value into its own child",
+
+			doc:   `{"a": {"b": 1}}`,
+			patch: `[{"op": "move", "from": "/a", "path": "/a/c"}]`,
+			err:   ErrNotApplicable,
+		},
+		{
+			name: "Moving a value onto itself",
+
+			doc:    `{"a": {"b": 1}}`,
+			patch:  `[{"op": "move", "from": "/a", "path": "/a"}]`,
+			result: `{"a": {"b": 1}}`,
+		},
+		{
+			name: "Moving a value to a sibling with a common prefix",
+
+			doc:    `{"a": 1}`,
+			patch:  `[{"op": "move", "from": "/a", "path": "/ab"}]`,
+			result: `{"ab": 1}`,
+		},
+		{
+			name: "Copy doesn't share the value with the source",
+
+			doc:    `{"a": {"b": [1]}}`,
+			patch:  `[{"op": "copy", "from": "/a", "path": "/c"}, {"op": "add", "path": "/c/b/-", "value": 2}]`,
+			result: `{"a": {"b": [1]}, "c": {"b": [1, 2]}}`,
+		},
+		{
+			name: "Copy from a nonexistent value",
+
+			doc:   `{"a": 1}`,
+			patch: `[{"op": "copy", "from": "/b", "path": "/c"}]`,
+			err:   ErrNotApplicable,
+		},
+		{
+			name: "Testing a nonexistent value",
+
+			doc:   `{"a": 1}`,
+			patch: `[{"op": "test", "path": "/b", "value": 1}]`,
+			err:   ErrNotApplicable,
+		},
+		{
+			name: "Testing numbers of different notation",
+
+			doc:    `{"a": [1, {"b": 100}]}`,
+			patch:  `[{"op": "test", "path": "/a", "value": [1.0, {"b": 1e2}]}]`,
+			result: `{"a": [1, {"b": 100}]}`,
+		},
+		{
+			name: "Testing an object with a different member",
+
+			doc:   `{"a": {"b": 1, "c": 2}}`,
+			patch: `[{"op": "test", "path": "/a", "value": {"b": 1}}]`,
+			err:   ErrNotApplicable,
+		},
+		{
+			name: "Adding at the end of an array by index",
+
+			doc:    `[1, 2]`,
+			patch:  `[{"op": "add", "path": "/2", "value": 3}]`,
+			result: `[1, 2, 3]`,
+		},
+		{
+			name: "Adding past the end of an array",
+
+			doc:   `[1, 2]`,
+			patch: `[{"op": "add", "path": "/3", "value": 3}]`,
+			err:   ErrNotApplicable,
+		},
+		{
+			name: "Removing the end of an array",
+
+			doc:   `[1, 2]`,
+			patch: `[{"op": "remove", "path": "/-"}]`,
+			err:   ErrInvalid,
+		},
+		{
+			name: "Array index with a leading zero",
+
+			doc:   `[1, 2]`,
+			patch: `[{"op": "replace", "path": "/01", "value": 3}]`,
+			err:   ErrInvalid,
+		},
+		{
+			name: "Negative array index",
+
+			doc:   `[1, 2]`,
+			patch: `[{"op": "remove", "path": "/-1"}]`,
+			err:   ErrInvalid,
+		},
+		{
+			name: "Replacing the root",
+
+			doc:    `{"a": 1}`,
+			patch:  `[{"op": "replace", "path": "", "value": [1]}]`,
+			result: `[1]`,
+		},
+		{
+			name: "Removing the root",
+
+			doc:    `{"a": 1}`,
+			patch:  `[{"op": "remove", "path": ""}]`,
+			result: `null`,
+		},
+		{
+			name: "Failed operation discards the earlier ones",
+
+			doc:   `{"a": 1}`,
+			patch: `[{"op": "add", "path": "/b", "value": 2}, {"op": "remove", "path": "/c"}]`,
+			err:   ErrNotApplicable,
+		},
+		{
+			name: "Pointer without a leading slash",
+
+			doc:   `{"a": 1}`,
+			patch: `[{"op": "remove", "path": "a"}]`,
+			err:   ErrInvalid,
+		},
+		{
+			name: "Missing value",
+
+			doc:   `{"a": 1}`,
+			patch: `[{"op": "add", "path": "/b"}]`,
+			err:   ErrInvalid,
+		},
+		{
+			name: "Unknown operation",
+
+			doc:   `{"a": 1}`,
+			patch: `[{"op": "increment", "path": "/a", "value": 1}]`,
+			err:   ErrInvalid,
+		},
+		{
+			name: "Patch that isn't an array",
+
+			doc:   `{"a": 1}`,
+			patch: `{"op": "remove", "path": "/a"}`,
+			err:   ErrInvalid,
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			result, err := Apply([]byte(testCase.doc), []byte(testCase.patch))
+			if testCase.err != nil {
+				assert.ErrorIs(t, err, testCase.err)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.JSONEq(t, testCase.result, string(result))
+		})
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	cases := []struct {
+		name string
+
+		doc    string
+		patch  string
+		result string
+		err    error
+	}{
+		// RFC 7396, Appendix A
+		{name: "Replacing a member", doc: `{"a": "b"}`, patch: `{"a": "c"}`, result: `{"a": "c"}`},
+		{name: "Adding a member", doc: `{"a": "b"}`, patch: `{"b": "c"}`, result: `{"a": "b", "b": "c"}`},
+		{name: "Removing the only member", doc: `{"a": "b"}`, patch: `{"a": null}`, result: `{}`},
+		{name: "Removing a member", doc: `{"a": "b", "b": "c"}`, patch: `{"a": null}`, result: `{"b": "c"}`},
+		{name: "Array replaced by a string", doc: `{"a": ["b"]}`, patch: `{"a": "c"}`, result: `{"a": "c"}`},
+		{name: "String replaced by an array", doc: `{"a": "c"}`, patch: `{"a": ["b"]}`, result: `{"a": ["b"]}`},
+		{name: "Nested objects are merged", doc: `{"a": {"b": "c"}}`, patch: `{"a": {"b": "d", "c": null}}`, result: `{"a": {"b": "d"}}`},
+		{name: "Arrays are replaced", doc: `{"a": [{"b": "c"}]}`, patch: `{"a": [1]}`, result: `{"a": [1]}`},
+		{name: "Array document", doc: `["a", "b"]`, patch: `["c", "d"]`, result: `["c", "d"]`},
+		{name: "Array patch", doc: `{"a": "b"}`, patch: `["c"]`, result: `["c"]`},
+		{name: "Null patch", doc: `{"a": "foo"}`, patch: `null`, result: `null`},
+		{name: "String patch", doc: `{"a": "foo"}`, patch: `"bar"`, result: `"bar"`},
+		{name: "Null in the document is kept", doc: `{"e": null}`, patch: `{"a": 1}`, result: `{"e": null, "a": 1}`},
+		{name: "Object patch of an array", doc: `[1, 2]`, patch: `{"a": "b", "c": null}`, result: `{"a": "b"}`},
+		{name: "Nulls in new members are removed", doc: `{}`, patch: `{"a": {"bb": {"ccc": null}}}`, result: `{"a": {"bb": {}}}`},
+		// Edge cases
+		{name: "Malformed patch", doc: `{"a": "b"}`, patch: `{"a": `, err: ErrInvalid},
+		{name: "Trailing data after the patch", doc: `{"a": "b"}`, patch: `{"a": "c"} {}`, err: ErrInvalid},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			result, err := MergePatch([]byte(testCase.doc), []byte(testCase.patch))
+			if testCase.err != nil {
+				assert.ErrorIs(t, err, testCase.err)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.JSONEq(t, testCase.result, string(result))
+		})
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	// numbers don't pass through float64, which would round the first one and change the notation of the others
+	doc := []byte(`{"n": 12345678901234567890, "f": 1.50, "e": 1e2}`)
+
+	result, err := Apply(doc, []byte(`[{"op": "add", "path": "/m", "value": 0.1}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"e":1e2,"f":1.50,"m":0.1,"n":12345678901234567890}`, string(result))
+
+	result, err = MergePatch(doc, []byte(`{"m": 0.1}`))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"e":1e2,"f":1.50,"m":0.1,"n":12345678901234567890}`, string(result))
+}
+
+func TestParsePointer(t *testing.T) {
+	cases := []struct {
+		name string
+
+		pointer string
+		tokens  []string
+		err     error
+	}{
+		{name: "Root", pointer: "", tokens: nil},
+		{name: "Empty member name", pointer: "/", tokens: []string{""}},
+		{name: "Nested", pointer: "/a/0/b", tokens: []string{"a", "0", "b"}},
+		{name: "Escapes", pointer: "/a~1b/m~0n", tokens: []string{"a/b", "m~n"}},
+		{name: "Escapes are unescaped once", pointer: "/~01/~10", tokens: []string{"~1", "/0"}},
+		{name: "No leading slash", pointer: "a/b", err: ErrInvalid},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tokens, err := parsePointer(testCase.pointer)
+			if testCase.err != nil {
+				assert.ErrorIs(t, err, testCase.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.tokens, tokens)
+			assert.Equal(t, testCase.pointer, pointer(tokens))
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package marc
+
+import (
+	"fmt"
+	"github.com/KinitaL/testovoye/internal/models"
+	"github.com/KinitaL/testovoye/pkg/isbn"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Tags of the fields books are mapped to.
+const (
+	TagControlNumber    = "001"
+	TagLatestChange     = "005"
+	TagFixedLength      = "008"
+	TagISBN             = "020"
+	TagPersonalName     = "100"
+	TagCorporateName    = "110"
+	TagMeetingName      = "111"
+	TagTitle            = "245"
+	TagPublication      = "260" // Used before RDA, replaced with TagProductionNotice
+	TagProductionNotice = "264"
+)
+
+// yearPattern finds a year in free-form dates like "c1869.", "[1869?]" or "©2001".
+var yearPattern = regexp.MustCompile(`\d{4}`)
+
+// initialPattern matches a name ending with an initial, like "Tolkien, J. R. R.".
+var initialPattern = regexp.MustCompile(`(^|[\s.])\pL\.$`)
+
+// FromBook builds a record of a book: the ID goes to 001, the ISBN to 020, the author to 100,
+// the title to 245 and the year of publication to 264 and 008.
+func FromBook(book models.Book) Record {
+	record := NewRecord()
+	record.AddControlField(TagControlNumber, book.ID.String())
+	record.AddControlField(TagLatestChange, book.UpdatedAt.UTC().Format("20060102150405.0"))
+	record.AddControlField(TagFixedLength, fixedLengthData(book))
+	if book.ISBN != "" {
+		record.AddDataField(TagISBN, ' ', ' ', Subfield{Code: 'a', Value: book.ISBN})
+	}
+
+	// names written as "Surname, Forename" are inverted, others are in direct order
+	nameType := byte('0')
+	if strings.Contains(book.Author, ",") {
+		nameType = '1'
+	}
+	record.AddDataField(TagPersonalName, nameType, ' ', Subfield{Code: 'a', Value: book.Author})
+	record.AddDataField(TagTitle, '1', '0', Subfield{Code: 'a', Value: book.Title})
+	record.AddDataField(TagProductionNotice, ' ', '1', Subfield{Code: 'c', Value: strconv.Itoa(int(book.Year))})
+	return record
+}
+
+// fixedLengthData returns 008 of a book published in a single known year, with unknown place and language.
+func fixedLengthData(book models.Book) string {
+	date1 := fmt.Sprintf("%04d", book.Year)
+	if len(date1) != 4 {
+		date1 = "uuuu" // unknown digits
+	}
+	return book.CreatedAt.UTC().Format("060102") + // Date entered on file
+		"s" + // Single known date
+		date1 + // Date 1
+		"    " + // Date 2
+		"xx " + // Place of publication
+		strings.Repeat(" ", 17) + // Material specific elements
+		"und" + // Language
+		" " + // Modified record
+		"d" // Cataloging source: other
+}
+
+// ToBook extracts a book from a record. The author is taken from 100, 110 or 111, the title from 245,
+// the year from 264, 260 or 008, and the ISBN from the first 020 with a valid one. Data that isn't found
+// is left empty, so the book fails validation; a missing ISBN is fine.
+func ToBook(record Record) models.Book {
+	var book models.Book
+	for _, tag := range []string{TagPersonalName, TagCorporateName, TagMeetingName} {
+		if fields := record.DataFields(tag); len(fields) > 0 {
+			book.Author = trimPunctuation(fields[0].Subfield('a'))
+			break
+		}
+	}
+	if fields := record.DataFields(TagTitle); len(fields) > 0 {
+		title := trimPunctuation(fields[0].Subfield('a'))
+		if subtitle := trimPunctuation(fields[0].Subfield('b')); subtitle != "" {
+			title += " : " + subtitle
+		}
+		book.Title = title
+	}
+	book.Year = publicationYear(record)
+	book.ISBN = recordISBN(record)
+	return book
+}
+
+// recordISBN returns the first valid ISBN of 020 as ISBN-13. Catalogers often qualify it, as in "0140449132 (pbk.)".
+func recordISBN(record Record) string {
+	for _, field := range record.DataFields(TagISBN) {
+		number, _, _ := strings.Cut(strings.TrimSpace(field.Subfield('a')), " ")
+		if normalized, err := isbn.Normalize(number); err == nil {
+			return normalized
+		}
+	}
+	return ""
+}
+
+// publicationYear returns the first year found in the publication statement or in the fixed-length data.
+func publicationYear(record Record) uint16 {
+	var dates []string
+	for _, field := range record.DataFields(TagProductionNotice) {
+		if field.Indicators[1] == '1' {
+			dates = append(dates, field.Subfield('c'))
+		}
+	}
+	for _, field := range record.DataFields(TagPublication) {
+		dates = append(dates, field.Subfield('c'))
+	}
+	if data := record.ControlField(TagFixedLength); len(data) >= 11 {
+		dates = append(dates, data[7:11])
+	}
+
+	for _, date := range dates {
+		if match := yearPattern.FindString(date); match != "" {
+			year, _ := strconv.ParseUint(match, 10, 16)
+			return uint16(year)
+		}
+	}
+	return 0
+}
+
+// trimPunctuation removes the ISBD punctuation that catalogers put at the end of subfields,
+// like the slash before the statement of responsibility. Periods of ellipses and initials are kept.
+func trimPunctuation(value string) string {
+	value = strings.TrimSpace(value)
+	if value == "" || !strings.ContainsAny(value[len(value)-1:], "/:;=,.") {
+		return value
+	}
+	if strings.HasSuffix(value, ".") && (strings.HasSuffix(value, "...") || initialPattern.MatchString(value)) {
+		return value
+	}
+	return strings.TrimSpace(value[:len(value)-1])
+}
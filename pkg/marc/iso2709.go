@@ -0,0 +1,209 @@
+package marc
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Delimiters of ISO 2709.
+const (
+	subfieldDelimiter = 0x1F
+	fieldTerminator   = 0x1E
+	recordTerminator  = 0x1D
+)
+
+const (
+	directoryEntryLength = 12    // Tag, field length and starting position
+	maxRecordLength      = 99999 // Largest number that fits into 5 digits of the leader
+	maxFieldLength       = 9999  // Largest number that fits into 4 digits of a directory entry
+)
+
+// isDelimiter reports whether the byte separates subfields, fields or records.
+func isDelimiter(b byte) bool {
+	return b == subfieldDelimiter || b == fieldTerminator || b == recordTerminator
+}
+
+// Reader reads records from a stream of ISO 2709 records.
+type Reader struct {
+	r *bufio.Reader
+}
+
+// NewReader returns a reader of ISO 2709 records.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read returns the next record, or io.EOF after the last one.
+func (r *Reader) Read() (Record, error) {
+	// records exported by some systems are separated by line breaks
+	for {
+		b, err := r.r.ReadByte()
+		if err != nil {
+			return Record{}, err
+		}
+		if b != '\n' && b != '\r' {
+			_ = r.r.UnreadByte()
+			break
+		}
+	}
+
+	data := make([]byte, 5, leaderLength)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return Record{}, fmt.Errorf("%w: truncated leader", ErrMalformed)
+	}
+	length, err := strconv.Atoi(string(data))
+	if err != nil || length <= leaderLength {
+		return Record{}, fmt.Errorf("%w: invalid record length %q", ErrMalformed, data)
+	}
+	data = append(data, make([]byte, length-len(data))...)
+	if _, err := io.ReadFull(r.r, data[5:]); err != nil {
+		return Record{}, fmt.Errorf("%w: record is shorter than its length", ErrMalformed)
+	}
+	return Unmarshal(data)
+}
+
+// Unmarshal parses a single ISO 2709 record.
+func Unmarshal(data []byte) (Record, error) {
+	if len(data) <= leaderLength || data[len(data)-1] != recordTerminator {
+		return Record{}, fmt.Errorf("%w: no record terminator", ErrMalformed)
+	}
+	leader := data[:leaderLength]
+	base, err := strconv.Atoi(string(leader[12:17]))
+	if err != nil || base <= leaderLength || base > len(data) || data[base-1] != fieldTerminator {
+		return Record{}, fmt.Errorf("%w: invalid base address of data", ErrMalformed)
+	}
+	if err := checkEncoding(leader[9], data); err != nil {
+		return Record{}, err
+	}
+
+	directory := data[leaderLength : base-1]
+	if len(directory)%directoryEntryLength != 0 {
+		return Record{}, fmt.Errorf("%w: invalid directory length", ErrMalformed)
+	}
+	record := Record{Leader: string(leader), Fields: make([]Field, 0, len(directory)/directoryEntryLength)}
+	for entry := range slices.Chunk(directory, directoryEntryLength) {
+		tag := string(entry[:3])
+		length, lengthErr := strconv.Atoi(string(entry[3:7]))
+		start, startErr := strconv.Atoi(string(entry[7:12]))
+		end := base + start + length
+		if lengthErr != nil || startErr != nil || length <= 0 || start < 0 || end > len(data)-1 || data[end-1] != fieldTerminator {
+			return Record{}, fmt.Errorf("%w: invalid directory entry of field %s", ErrMalformed, tag)
+		}
+		field, err := parseField(tag, data[base+start:end-1])
+		if err != nil {
+			return Record{}, err
+		}
+		record.Fields = append(record.Fields, field)
+	}
+	return record, nil
+}
+
+// parseField parses data of a field without the terminator.
+func parseField(tag string, data []byte) (Field, error) {
+	if IsControlTag(tag) {
+		return Field{Tag: tag, Value: string(data)}, nil
+	}
+	if len(data) < 2 {
+		return Field{}, fmt.Errorf("%w: field %s has no indicators", ErrMalformed, tag)
+	}
+	field := Field{Tag: tag, Indicators: [2]byte{data[0], data[1]}}
+	// anything before the first delimiter isn't a subfield, so it is skipped
+	for i, chunk := range bytes.Split(data[2:], []byte{subfieldDelimiter}) {
+		if i == 0 || len(chunk) == 0 {
+			continue
+		}
+		field.Subfields = append(field.Subfields, Subfield{Code: chunk[0], Value: string(chunk[1:])})
+	}
+	return field, nil
+}
+
+// checkEncoding checks that the record is in UTF-8, or in MARC-8 that has only ASCII characters.
+func checkEncoding(coding byte, data []byte) error {
+	switch coding {
+	case 'a':
+		if !utf8.Valid(data) {
+			return fmt.Errorf("%w: invalid UTF-8", ErrMalformed)
+		}
+	case ' ':
+		if bytes.ContainsFunc(data, func(r rune) bool { return r >= utf8.RuneSelf }) {
+			return fmt.Errorf("%w: MARC-8 characters outside of ASCII aren't supported, only UTF-8", ErrMalformed)
+		}
+	default:
+		return fmt.Errorf("%w: unknown character coding %q", ErrMalformed, coding)
+	}
+	return nil
+}
+
+// Writer writes records as a stream of ISO 2709 records.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter returns a writer of ISO 2709 records.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write writes a record.
+func (w *Writer) Write(record Record) error {
+	data, err := Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(data)
+	return err
+}
+
+// Marshal encodes a record in ISO 2709 with UTF-8 character coding.
+// The record length and the base address of data in the leader are computed.
+func Marshal(record Record) ([]byte, error) {
+	if err := record.validate(); err != nil {
+		return nil, err
+	}
+
+	var directory, fields bytes.Buffer
+	for _, field := range record.Fields {
+		start := fields.Len()
+		if field.IsControl() {
+			fields.WriteString(field.Value)
+		} else {
+			fields.Write(field.Indicators[:])
+			for _, subfield := range field.Subfields {
+				fields.WriteByte(subfieldDelimiter)
+				fields.WriteByte(subfield.Code)
+				fields.WriteString(subfield.Value)
+			}
+		}
+		fields.WriteByte(fieldTerminator)
+		if length := fields.Len() - start; length > maxFieldLength {
+			return nil, fmt.Errorf("marc: field %s is longer than %d bytes", field.Tag, maxFieldLength)
+		}
+		fmt.Fprintf(&directory, "%s%04d%05d", field.Tag, fields.Len()-start, start)
+	}
+	directory.WriteByte(fieldTerminator)
+
+	base := leaderLength + directory.Len()
+	length := base + fields.Len() + 1
+	if length > maxRecordLength {
+		return nil, errors.New("marc: record is longer than 99999 bytes")
+	}
+
+	leader := []byte(record.Leader)
+	copy(leader[0:5], fmt.Sprintf("%05d", length))
+	leader[9] = 'a'
+	copy(leader[10:12], "22")
+	copy(leader[12:17], fmt.Sprintf("%05d", base))
+	copy(leader[20:24], "4500")
+
+	data := make([]byte, 0, length)
+	data = append(data, leader...)
+	data = append(data, directory.Bytes()...)
+	data = append(data, fields.Bytes()...)
+	return append(data, recordTerminator), nil
+}
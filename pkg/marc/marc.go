@@ -0,0 +1,138 @@
+// Package marc reads and writes MARC 21 bibliographic records in ISO 2709, the binary exchange format,
+// and in MARCXML. Records are expected to be encoded in UTF-8; MARC-8 is accepted only for ASCII data.
+package marc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Media types of the formats.
+const (
+	MIMEMARC    = "application/marc"
+	MIMEMARCXML = "application/marcxml+xml"
+)
+
+// DefaultLeader is the leader of a new record of a printed monograph.
+// Lengths and the base address are filled in when the record is written.
+const DefaultLeader = "00000nam a2200000 c 4500"
+
+// leaderLength is the length of a leader in both formats.
+const leaderLength = 24
+
+// ErrMalformed means that the data can't be read as a MARC record.
+var ErrMalformed = errors.New("malformed MARC record")
+
+type (
+	// Record is a bibliographic record: a leader and variable fields in their original order.
+	Record struct {
+		Leader string
+		Fields []Field
+	}
+
+	// Field is a variable field. Control fields (001-009) have a value, data fields have indicators and subfields.
+	Field struct {
+		Tag        string
+		Value      string
+		Indicators [2]byte
+		Subfields  []Subfield
+	}
+
+	// Subfield is an element of a data field identified by a code.
+	Subfield struct {
+		Code  byte
+		Value string
+	}
+)
+
+// NewRecord returns an empty record with DefaultLeader.
+func NewRecord() Record {
+	return Record{Leader: DefaultLeader}
+}
+
+// AddControlField appends a control field with the value.
+func (r *Record) AddControlField(tag, value string) {
+	r.Fields = append(r.Fields, Field{Tag: tag, Value: value})
+}
+
+// AddDataField appends a data field with the indicators and subfields.
+func (r *Record) AddDataField(tag string, ind1, ind2 byte, subfields ...Subfield) {
+	r.Fields = append(r.Fields, Field{Tag: tag, Indicators: [2]byte{ind1, ind2}, Subfields: subfields})
+}
+
+// ControlField returns the value of the first control field with the tag, or an empty string.
+func (r Record) ControlField(tag string) string {
+	for _, field := range r.Fields {
+		if field.Tag == tag {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+// DataFields returns the data fields with the tag.
+func (r Record) DataFields(tag string) []Field {
+	var fields []Field
+	for _, field := range r.Fields {
+		if field.Tag == tag {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// IsControl reports whether the field is a control field.
+func (f Field) IsControl() bool {
+	return IsControlTag(f.Tag)
+}
+
+// Subfield returns the value of the first subfield with the code, or an empty string.
+func (f Field) Subfield(code byte) string {
+	for _, subfield := range f.Subfields {
+		if subfield.Code == code {
+			return subfield.Value
+		}
+	}
+	return ""
+}
+
+// IsControlTag reports whether fields with the tag are control fields, which have no indicators and subfields.
+func IsControlTag(tag string) bool {
+	return strings.HasPrefix(tag, "00")
+}
+
+// validate checks that the record can be written in both formats.
+func (r Record) validate() error {
+	if len(r.Leader) != leaderLength {
+		return fmt.Errorf("marc: leader must be %d characters long", leaderLength)
+	}
+	for _, field := range r.Fields {
+		if len(field.Tag) != 3 {
+			return fmt.Errorf("marc: tag %q must be 3 characters long", field.Tag)
+		}
+		if field.IsControl() {
+			if err := validateData(field.Tag, field.Value); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, subfield := range field.Subfields {
+			if isDelimiter(subfield.Code) {
+				return fmt.Errorf("marc: field %s has an invalid subfield code", field.Tag)
+			}
+			if err := validateData(field.Tag, subfield.Value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateData checks that data of a field has no delimiters of ISO 2709.
+func validateData(tag, data string) error {
+	if strings.ContainsFunc(data, func(r rune) bool { return r < 0x80 && isDelimiter(byte(r)) }) {
+		return fmt.Errorf("marc: field %s contains a delimiter", tag)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package marc
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+// testRecord returns a record with a control field and a data field with Cyrillic, so that lengths are in bytes.
+func testRecord() Record {
+	record := NewRecord()
+	record.AddControlField("001", "id")
+	record.AddDataField("245", '1', '0', Subfield{Code: 'a', Value: "Война и мир"}, Subfield{Code: 'c', Value: "Толстой"})
+	return record
+}
+
+func TestMarshal(t *testing.T) {
+	data, err := Marshal(testRecord())
+	assert.NoError(t, err)
+
+	// 001: "id" and the terminator; 245: indicators, two subfields of 2+20 and 2+14 bytes, and the terminator
+	expected := "00094nam a2200049 c 4500" +
+		"001000300000" + "245004100003" + "\x1e" +
+		"id\x1e" +
+		"10\x1faВойна и мир\x1fcТолстой\x1e" +
+		"\x1d"
+	assert.Equal(t, expected, string(data))
+	assert.Len(t, data, 94)
+
+	record, err := Unmarshal(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "00094nam a2200049 c 4500", record.Leader)
+	assert.Equal(t, testRecord().Fields, record.Fields)
+}
+
+func TestMarshalErrors(t *testing.T) {
+	cases := []struct {
+		name string
+
+		record Record
+		err    string
+	}{
+		{
+			name: "Short leader",
+
+			record: Record{Leader: "00000nam"},
+			err:    "marc: leader must be 24 characters long",
+		},
+		{
+			name: "Short tag",
+
+			record: Record{Leader: DefaultLeader, Fields: []Field{{Tag: "24", Subfields: []Subfield{{Code: 'a', Value: "Title"}}}}},
+			err:    `marc: tag "24" must be 3 characters long`,
+		},
+		{
+			name: "Delimiter in a control field",
+
+			record: Record{Leader: DefaultLeader, Fields: []Field{{Tag: "001", Value: "a\x1eb"}}},
+			err:    "marc: field 001 contains a delimiter",
+		},
+		{
+			name: "Delimiter as a subfield code",
+
+			record: Record{Leader: DefaultLeader, Fields: []Field{{Tag: "245", Subfields: []Subfield{{Code: 0x1F, Value: "Title"}}}}},
+			err:    "marc: field 245 has an invalid subfield code",
+		},
+		{
+			name: "Field longer than a directory entry allows",
+
+			record: Record{Leader: DefaultLeader, Fields: []Field{{Tag: "520", Subfields: []Subfield{{Code: 'a', Value: strings.Repeat("x", 9996)}}}}},
+			err:    "marc: field 520 is longer than 9999 bytes",
+		},
+		{
+			name: "Record longer than the leader allows",
+
+			record: func() Record {
+				record := NewRecord()
+				for range 12 {
+					record.AddDataField("520", ' ', ' ', Subfield{Code: 'a', Value: strings.Repeat("x", 9000)})
+				}
+				return record
+			}(),
+			err: "marc: record is longer than 99999 bytes",
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := Marshal(testCase.record)
+			assert.EqualError(t, err, testCase.err)
+		})
+	}
+
+	t.Run("Longest field", func(t *testing.T) {
+		// indicators, a subfield of 2+9994 bytes and the terminator
+		record := Record{Leader: DefaultLeader, Fields: []Field{{Tag: "520", Subfields: []Subfield{{Code: 'a', Value: strings.Repeat("x", 9994)}}}}}
+		data, err := Marshal(record)
+		assert.NoError(t, err)
+		assert.Equal(t, "520999900000", string(data[24:36]))
+	})
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	valid, err := Marshal(testRecord())
+	assert.NoError(t, err)
+	replace := func(offset int, value string) []byte {
+		data := bytes.Clone(valid)
+		copy(data[offset:], value)
+		return data
+	}
+
+	cases := []struct {
+		name string
+
+		data []byte
+		err  string
+	}{
+		{
+			name: "No record terminator",
+
+			data: valid[:len(valid)-1],
+			err:  "malformed MARC record: no record terminator",
+		},
+		{
+			name: "Base address out of the record",
+
+			data: replace(12, "99999"),
+			err:  "malformed MARC record: invalid base address of data",
+		},
+		{
+			name: "Base address inside the directory",
+
+			data: replace(12, "00040"),
+			err:  "malformed MARC record: invalid base address of data",
+		},
+		{
+			name: "Field length out of the record",
+
+			data: replace(36+3, "0099"),
+			err:  "malformed MARC record: invalid directory entry of field 245",
+		},
+		{
+			name: "MARC-8 with non-ASCII characters",
+
+			data: replace(9, " "),
+			err:  "malformed MARC record: MARC-8 characters outside of ASCII aren't supported, only UTF-8",
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := Unmarshal(testCase.data)
+			assert.ErrorIs(t, err, ErrMalformed)
+			assert.EqualError(t, err, testCase.err)
+		})
+	}
+}
+
+func TestReader(t *testing.T) {
+	data, err := Marshal(testRecord())
+	assert.NoError(t, err)
+
+	// records separated by line breaks, then a truncated one
+	reader := NewReader(bytes.NewReader(bytes.Join([][]byte{data, data, data[:50]}, []byte("\r\n"))))
+	for range 2 {
+		record, err := reader.Read()
+		assert.NoError(t, err)
+		assert.Equal(t, testRecord().Fields, record.Fields)
+	}
+	_, err = reader.Read()
+	assert.ErrorIs(t, err, ErrMalformed)
+}
+
+func TestMarshalXML(t *testing.T) {
+	record := NewRecord()
+	record.AddControlField("001", "a&b")
+	record.AddDataField("245", '1', ' ', Subfield{Code: 'a', Value: `Tom & Jerry <"1">`})
+
+	data, err := MarshalXML(record)
+	assert.NoError(t, err)
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<record xmlns="http://www.loc.gov/MARC21/slim">
+  <leader>00000nam a2200000 c 4500</leader>
+  <controlfield tag="001">a&amp;b</controlfield>
+  <datafield tag="245" ind1="1" ind2=" ">
+    <subfield code="a">Tom &amp; Jerry &lt;&#34;1&#34;&gt;</subfield>
+  </datafield>
+</record>`
+	assert.Equal(t, expected, string(data))
+
+	read, err := NewXMLReader(bytes.NewReader(data)).Read()
+	assert.NoError(t, err)
+	assert.Equal(t, record, read)
+}
+
+func TestXMLWriter(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewXMLWriter(&buf)
+	assert.NoError(t, writer.Write(testRecord()))
+	assert.NoError(t, writer.Write(testRecord()))
+	assert.Error(t, writer.Write(Record{Leader: "short"}))
+	assert.NoError(t, writer.Close())
+
+	reader := NewXMLReader(&buf)
+	for range 2 {
+		record, err := reader.Read()
+		assert.NoError(t, err)
+		assert.Equal(t, testRecord(), record)
+	}
+	_, err := reader.Read()
+	assert.ErrorIs(t, err, io.EOF)
+}
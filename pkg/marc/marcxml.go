@@ -0,0 +1,184 @@
+package marc
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Namespace is the XML namespace of MARCXML.
+const Namespace = "http://www.loc.gov/MARC21/slim"
+
+type (
+	// xmlRecord is the MARCXML representation of a record. Control fields precede data fields, as the schema requires.
+	xmlRecord struct {
+		XMLName       xml.Name          `xml:"record"`
+		Xmlns         string            `xml:"xmlns,attr,omitempty"`
+		Leader        string            `xml:"leader"`
+		ControlFields []xmlControlField `xml:"controlfield"`
+		DataFields    []xmlDataField    `xml:"datafield"`
+	}
+
+	xmlControlField struct {
+		Tag   string `xml:"tag,attr"`
+		Value string `xml:",chardata"`
+	}
+
+	xmlDataField struct {
+		Tag       string        `xml:"tag,attr"`
+		Ind1      string        `xml:"ind1,attr"`
+		Ind2      string        `xml:"ind2,attr"`
+		Subfields []xmlSubfield `xml:"subfield"`
+	}
+
+	xmlSubfield struct {
+		Code  string `xml:"code,attr"`
+		Value string `xml:",chardata"`
+	}
+)
+
+// toXML converts a record to its MARCXML representation.
+func toXML(record Record) xmlRecord {
+	result := xmlRecord{Leader: record.Leader}
+	for _, field := range record.Fields {
+		if field.IsControl() {
+			result.ControlFields = append(result.ControlFields, xmlControlField{Tag: field.Tag, Value: field.Value})
+			continue
+		}
+		data := xmlDataField{Tag: field.Tag, Ind1: string(field.Indicators[0]), Ind2: string(field.Indicators[1])}
+		for _, subfield := range field.Subfields {
+			data.Subfields = append(data.Subfields, xmlSubfield{Code: string(subfield.Code), Value: subfield.Value})
+		}
+		result.DataFields = append(result.DataFields, data)
+	}
+	return result
+}
+
+// fromXML converts a MARCXML record to a record.
+func fromXML(record xmlRecord) (Record, error) {
+	result := Record{Leader: record.Leader}
+	for _, field := range record.ControlFields {
+		result.Fields = append(result.Fields, Field{Tag: field.Tag, Value: field.Value})
+	}
+	for _, field := range record.DataFields {
+		data := Field{Tag: field.Tag, Indicators: [2]byte{indicator(field.Ind1), indicator(field.Ind2)}}
+		for _, subfield := range field.Subfields {
+			if len(subfield.Code) != 1 {
+				return Record{}, fmt.Errorf("%w: field %s has an invalid subfield code %q", ErrMalformed, field.Tag, subfield.Code)
+			}
+			data.Subfields = append(data.Subfields, Subfield{Code: subfield.Code[0], Value: subfield.Value})
+		}
+		result.Fields = append(result.Fields, data)
+	}
+	return result, nil
+}
+
+// indicator returns the indicator of a MARCXML attribute, which may be empty for a blank one.
+func indicator(value string) byte {
+	if len(value) != 1 {
+		return ' '
+	}
+	return value[0]
+}
+
+// MarshalXML encodes a single record as a MARCXML document.
+func MarshalXML(record Record) ([]byte, error) {
+	if err := record.validate(); err != nil {
+		return nil, err
+	}
+	doc := toXML(record)
+	doc.Xmlns = Namespace
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// MarshalXML implements xml.Marshaler, so that a record embedded into another XML document is written
+// as a MARCXML record element that declares its namespace. The name of the start element is ignored.
+func (r Record) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	if err := r.validate(); err != nil {
+		return err
+	}
+	doc := toXML(r)
+	doc.Xmlns = Namespace
+	return e.Encode(doc)
+}
+
+// XMLReader reads records from a MARCXML document, either a collection or a single record.
+type XMLReader struct {
+	decoder *xml.Decoder
+}
+
+// NewXMLReader returns a reader of MARCXML records.
+func NewXMLReader(r io.Reader) *XMLReader {
+	return &XMLReader{decoder: xml.NewDecoder(r)}
+}
+
+// Read returns the next record, or io.EOF after the last one.
+func (r *XMLReader) Read() (Record, error) {
+	for {
+		token, err := r.decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return Record{}, io.EOF
+		}
+		if err != nil {
+			return Record{}, fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+		var record xmlRecord
+		if err := r.decoder.DecodeElement(&record, &start); err != nil {
+			return Record{}, fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+		return fromXML(record)
+	}
+}
+
+// XMLWriter writes records as a MARCXML collection.
+type XMLWriter struct {
+	w       io.Writer
+	encoder *xml.Encoder
+	started bool // Whether the start of the collection has been written
+}
+
+// NewXMLWriter returns a writer of a MARCXML collection. Close must be called to complete the document.
+func NewXMLWriter(w io.Writer) *XMLWriter {
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("  ", "  ")
+	return &XMLWriter{w: w, encoder: encoder}
+}
+
+// Write writes a record of the collection.
+func (w *XMLWriter) Write(record Record) error {
+	if err := record.validate(); err != nil {
+		return err
+	}
+	if err := w.start(); err != nil {
+		return err
+	}
+	return w.encoder.Encode(toXML(record))
+}
+
+// Close writes the end of the collection.
+func (w *XMLWriter) Close() error {
+	if err := w.start(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.w, "\n</collection>\n")
+	return err
+}
+
+// start writes the XML declaration and the start of the collection once.
+func (w *XMLWriter) start() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	_, err := io.WriteString(w.w, xml.Header+`<collection xmlns="`+Namespace+`">`+"\n")
+	return err
+}
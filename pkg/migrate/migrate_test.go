@@ -0,0 +1,80 @@
+package migrate
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	cases := []struct {
+		name string
+
+		files      fstest.MapFS
+		migrations []Migration
+		err        string
+	}{
+		{
+			name: "Up and down pairs in version order",
+
+			files: fstest.MapFS{
+				"0010_add_works.up.sql":      {Data: []byte("CREATE TABLE works ();")},
+				"0002_add_search.up.sql":     {Data: []byte("ALTER TABLE books ADD search_text TEXT;")},
+				"0002_add_search.down.sql":   {Data: []byte("ALTER TABLE books DROP search_text;")},
+				"0001_create_books.up.sql":   {Data: []byte("CREATE TABLE books ();")},
+				"0001_create_books.down.sql": {Data: []byte("DROP TABLE books;")},
+				"README.md":                  {Data: []byte("not a migration")},
+				"0003_notes.txt":             {Data: []byte("not a migration either")},
+			},
+			migrations: []Migration{
+				{Version: 1, Name: "create_books", Up: "CREATE TABLE books ();", Down: "DROP TABLE books;"},
+				{Version: 2, Name: "add_search", Up: "ALTER TABLE books ADD search_text TEXT;", Down: "ALTER TABLE books DROP search_text;"},
+				{Version: 10, Name: "add_works", Up: "CREATE TABLE works ();"},
+			},
+		},
+		{
+			name: "Empty directory",
+
+			files:      fstest.MapFS{},
+			migrations: []Migration{},
+		},
+		{
+			name: "Different names of a version",
+
+			files: fstest.MapFS{
+				"0001_create_books.up.sql":     {Data: []byte("CREATE TABLE books ();")},
+				"0001_create_authors.down.sql": {Data: []byte("DROP TABLE authors;")},
+			},
+			err: "migration 1 has different names: create_authors and create_books",
+		},
+		{
+			name: "Missing up file",
+
+			files: fstest.MapFS{
+				"0001_create_books.up.sql": {Data: []byte("CREATE TABLE books ();")},
+				"0002_add_search.down.sql": {Data: []byte("ALTER TABLE books DROP search_text;")},
+			},
+			err: "migration 2_add_search has no up file",
+		},
+		{
+			name: "Version out of range",
+
+			files: fstest.MapFS{
+				"99999999999999999999_huge.up.sql": {Data: []byte("SELECT 1;")},
+			},
+			err: "invalid migration version in 99999999999999999999_huge.up.sql",
+		},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			migrations, err := Load(testCase.files)
+			if testCase.err != "" {
+				assert.ErrorContains(t, err, testCase.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.migrations, migrations)
+		})
+	}
+}
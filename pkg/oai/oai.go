@@ -0,0 +1,310 @@
+// Package oai writes responses of the Open Archives Initiative Protocol for Metadata Harvesting (OAI-PMH) 2.0.
+package oai
+
+import (
+	"encoding/xml"
+	"github.com/KinitaL/testovoye/pkg/dc"
+	"time"
+)
+
+// XML namespaces and schemas of responses.
+const (
+	Namespace      = "http://www.openarchives.org/OAI/2.0/"
+	Schema         = "http://www.openarchives.org/OAI/2.0/OAI-PMH.xsd"
+	NamespaceOAIDC = "http://www.openarchives.org/OAI/2.0/oai_dc/"
+	SchemaOAIDC    = "http://www.openarchives.org/OAI/2.0/oai_dc.xsd"
+	namespaceXSI   = "http://www.w3.org/2001/XMLSchema-instance"
+)
+
+// Verbs are the requests of the protocol.
+const (
+	VerbIdentify            = "Identify"
+	VerbListMetadataFormats = "ListMetadataFormats"
+	VerbListSets            = "ListSets"
+	VerbListIdentifiers     = "ListIdentifiers"
+	VerbListRecords         = "ListRecords"
+	VerbGetRecord           = "GetRecord"
+)
+
+// Codes of errors, which are reported in a response instead of its verb element.
+const (
+	CodeBadArgument             = "badArgument"
+	CodeBadResumptionToken      = "badResumptionToken"
+	CodeBadVerb                 = "badVerb"
+	CodeCannotDisseminateFormat = "cannotDisseminateFormat"
+	CodeIDDoesNotExist          = "idDoesNotExist"
+	CodeNoRecordsMatch          = "noRecordsMatch"
+	CodeNoMetadataFormats       = "noMetadataFormats"
+	CodeNoSetHierarchy          = "noSetHierarchy"
+)
+
+// Values of deletedRecord of Identify: how long the repository reports deleted records.
+const (
+	DeletedRecordNo         = "no"
+	DeletedRecordTransient  = "transient"
+	DeletedRecordPersistent = "persistent"
+)
+
+// Datestamps have the finest granularity of the protocol, seconds; dates are the coarsest one.
+const (
+	Granularity = "YYYY-MM-DDThh:mm:ssZ"
+	TimeFormat  = "2006-01-02T15:04:05Z"
+	DateFormat  = "2006-01-02"
+)
+
+type (
+	// Response is a response to a request of any verb. Only the part of the verb is written, or errors if there are any.
+	Response struct {
+		Date            time.Time
+		Request         Request
+		Errors          []Error
+		Identify        *Identify
+		MetadataFormats []MetadataFormat // ListMetadataFormats
+		Headers         []Header         // ListIdentifiers
+		Records         []Record         // ListRecords and GetRecord
+		ResumptionToken *ResumptionToken // Set for incomplete lists and for the last part of a resumed one
+	}
+
+	// Request is the request a response is for. Arguments are written only if they are valid.
+	Request struct {
+		BaseURL         string
+		Verb            string
+		Identifier      string
+		MetadataPrefix  string
+		From            string
+		Until           string
+		Set             string
+		ResumptionToken string
+	}
+
+	// Error is an error of a request. A response may carry several errors.
+	Error struct {
+		Code    string
+		Message string
+	}
+
+	// Identify describes the repository.
+	Identify struct {
+		RepositoryName    string
+		BaseURL           string
+		EarliestDatestamp time.Time
+		DeletedRecord     string
+		AdminEmails       []string
+	}
+
+	// MetadataFormat is a format records are disseminated in.
+	MetadataFormat struct {
+		Prefix    string
+		Schema    string
+		Namespace string
+	}
+
+	// Header identifies a record.
+	Header struct {
+		Identifier string
+		Datestamp  time.Time
+		Deleted    bool
+	}
+
+	// Record is a header with metadata, an XML-marshalable value. Deleted records have no metadata.
+	Record struct {
+		Header   Header
+		Metadata any
+	}
+
+	// ResumptionToken continues an incomplete list. The last part of a resumed list carries an empty token.
+	ResumptionToken struct {
+		Token string
+	}
+)
+
+func (e Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+type (
+	xmlResponse struct {
+		XMLName             xml.Name            `xml:"OAI-PMH"`
+		Xmlns               string              `xml:"xmlns,attr"`
+		XmlnsXSI            string              `xml:"xmlns:xsi,attr"`
+		SchemaLocation      string              `xml:"xsi:schemaLocation,attr"`
+		ResponseDate        string              `xml:"responseDate"`
+		Request             xmlRequest          `xml:"request"`
+		Errors              []xmlError          `xml:"error"`
+		Identify            *xmlIdentify        `xml:"Identify"`
+		ListMetadataFormats *xmlMetadataFormats `xml:"ListMetadataFormats"`
+		ListIdentifiers     *xmlList            `xml:"ListIdentifiers"`
+		ListRecords         *xmlList            `xml:"ListRecords"`
+		GetRecord           *xmlList            `xml:"GetRecord"`
+	}
+
+	xmlRequest struct {
+		Verb            string `xml:"verb,attr,omitempty"`
+		Identifier      string `xml:"identifier,attr,omitempty"`
+		MetadataPrefix  string `xml:"metadataPrefix,attr,omitempty"`
+		From            string `xml:"from,attr,omitempty"`
+		Until           string `xml:"until,attr,omitempty"`
+		Set             string `xml:"set,attr,omitempty"`
+		ResumptionToken string `xml:"resumptionToken,attr,omitempty"`
+		BaseURL         string `xml:",chardata"`
+	}
+
+	xmlError struct {
+		Code    string `xml:"code,attr"`
+		Message string `xml:",chardata"`
+	}
+
+	xmlIdentify struct {
+		RepositoryName    string   `xml:"repositoryName"`
+		BaseURL           string   `xml:"baseURL"`
+		ProtocolVersion   string   `xml:"protocolVersion"`
+		AdminEmails       []string `xml:"adminEmail"`
+		EarliestDatestamp string   `xml:"earliestDatestamp"`
+		DeletedRecord     string   `xml:"deletedRecord"`
+		Granularity       string   `xml:"granularity"`
+	}
+
+	xmlMetadataFormats struct {
+		Formats []xmlMetadataFormat `xml:"metadataFormat"`
+	}
+
+	xmlMetadataFormat struct {
+		Prefix    string `xml:"metadataPrefix"`
+		Schema    string `xml:"schema"`
+		Namespace string `xml:"metadataNamespace"`
+	}
+
+	// xmlList holds headers of ListIdentifiers or records of ListRecords and GetRecord.
+	xmlList struct {
+		Headers         []xmlHeader         `xml:"header"`
+		Records         []xmlRecord         `xml:"record"`
+		ResumptionToken *xmlResumptionToken `xml:"resumptionToken"`
+	}
+
+	xmlHeader struct {
+		Status     string `xml:"status,attr,omitempty"`
+		Identifier string `xml:"identifier"`
+		Datestamp  string `xml:"datestamp"`
+	}
+
+	xmlRecord struct {
+		Header   xmlHeader    `xml:"header"`
+		Metadata *xmlMetadata `xml:"metadata"`
+	}
+
+	// xmlMetadata wraps metadata, whose own element name would otherwise replace "metadata".
+	xmlMetadata struct {
+		Value any
+	}
+
+	xmlResumptionToken struct {
+		Token string `xml:",chardata"`
+	}
+)
+
+// Marshal returns the XML document of a response.
+func Marshal(response Response) ([]byte, error) {
+	result := xmlResponse{
+		Xmlns:          Namespace,
+		XmlnsXSI:       namespaceXSI,
+		SchemaLocation: Namespace + " " + Schema,
+		ResponseDate:   response.Date.UTC().Format(TimeFormat),
+		Request: xmlRequest{
+			Verb:            response.Request.Verb,
+			Identifier:      response.Request.Identifier,
+			MetadataPrefix:  response.Request.MetadataPrefix,
+			From:            response.Request.From,
+			Until:           response.Request.Until,
+			Set:             response.Request.Set,
+			ResumptionToken: response.Request.ResumptionToken,
+			BaseURL:         response.Request.BaseURL,
+		},
+	}
+	if len(response.Errors) > 0 {
+		for _, err := range response.Errors {
+			result.Errors = append(result.Errors, xmlError{Code: err.Code, Message: err.Message})
+		}
+		return marshal(result)
+	}
+
+	list := &xmlList{}
+	for _, header := range response.Headers {
+		list.Headers = append(list.Headers, toXMLHeader(header))
+	}
+	for _, record := range response.Records {
+		item := xmlRecord{Header: toXMLHeader(record.Header)}
+		if !record.Header.Deleted {
+			item.Metadata = &xmlMetadata{Value: record.Metadata}
+		}
+		list.Records = append(list.Records, item)
+	}
+	if response.ResumptionToken != nil {
+		list.ResumptionToken = &xmlResumptionToken{Token: response.ResumptionToken.Token}
+	}
+
+	switch response.Request.Verb {
+	case VerbIdentify:
+		identify := response.Identify
+		result.Identify = &xmlIdentify{
+			RepositoryName:    identify.RepositoryName,
+			BaseURL:           identify.BaseURL,
+			ProtocolVersion:   "2.0",
+			AdminEmails:       identify.AdminEmails,
+			EarliestDatestamp: identify.EarliestDatestamp.UTC().Format(TimeFormat),
+			DeletedRecord:     identify.DeletedRecord,
+			Granularity:       Granularity,
+		}
+	case VerbListMetadataFormats:
+		result.ListMetadataFormats = &xmlMetadataFormats{}
+		for _, format := range response.MetadataFormats {
+			result.ListMetadataFormats.Formats = append(result.ListMetadataFormats.Formats,
+				xmlMetadataFormat{Prefix: format.Prefix, Schema: format.Schema, Namespace: format.Namespace})
+		}
+	case VerbListIdentifiers:
+		result.ListIdentifiers = list
+	case VerbListRecords:
+		result.ListRecords = list
+	case VerbGetRecord:
+		result.GetRecord = list
+	}
+	return marshal(result)
+}
+
+// marshal encodes a response with the XML declaration.
+func marshal(response xmlResponse) ([]byte, error) {
+	body, err := xml.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// toXMLHeader converts a header to XML.
+func toXMLHeader(header Header) xmlHeader {
+	result := xmlHeader{Identifier: header.Identifier, Datestamp: header.Datestamp.UTC().Format(TimeFormat)}
+	if header.Deleted {
+		result.Status = "deleted"
+	}
+	return result
+}
+
+// DC is the metadata of a record in the oai_dc format, which every repository must support.
+type DC struct {
+	XMLName        xml.Name `xml:"oai_dc:dc"`
+	XmlnsOAIDC     string   `xml:"xmlns:oai_dc,attr"`
+	XmlnsDC        string   `xml:"xmlns:dc,attr"`
+	XmlnsXSI       string   `xml:"xmlns:xsi,attr"`
+	SchemaLocation string   `xml:"xsi:schemaLocation,attr"`
+	dc.Elements
+}
+
+// NewDC returns the oai_dc metadata of the Dublin Core elements.
+func NewDC(elements dc.Elements) DC {
+	return DC{
+		XmlnsOAIDC:     NamespaceOAIDC,
+		XmlnsDC:        dc.Namespace,
+		XmlnsXSI:       namespaceXSI,
+		SchemaLocation: NamespaceOAIDC + " " + SchemaOAIDC,
+		Elements:       elements,
+	}
+}
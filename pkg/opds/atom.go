@@ -0,0 +1,125 @@
+package opds
+
+import (
+	"encoding/xml"
+	"strconv"
+	"time"
+)
+
+// XML namespaces of OPDS 1.2 feeds.
+const (
+	NamespaceAtom       = "http://www.w3.org/2005/Atom"
+	NamespaceDC         = "http://purl.org/dc/terms/"
+	NamespaceOPDS       = "http://opds-spec.org/2010/catalog"
+	NamespaceOpenSearch = "http://a9.com/-/spec/opensearch/1.1/"
+)
+
+type (
+	// atomFeed is the Atom representation of a feed. Prefixed names are written as is,
+	// as encoding/xml would otherwise declare a namespace on every element.
+	atomFeed struct {
+		XMLName      xml.Name    `xml:"feed"`
+		Xmlns        string      `xml:"xmlns,attr"`
+		XmlnsDC      string      `xml:"xmlns:dc,attr"`
+		XmlnsOPDS    string      `xml:"xmlns:opds,attr"`
+		XmlnsSearch  string      `xml:"xmlns:opensearch,attr"`
+		ID           string      `xml:"id"`
+		Title        string      `xml:"title"`
+		Updated      string      `xml:"updated"`
+		Links        []atomLink  `xml:"link"`
+		TotalResults *int64      `xml:"opensearch:totalResults,omitempty"`
+		ItemsPerPage int         `xml:"opensearch:itemsPerPage,omitempty"`
+		Entries      []atomEntry `xml:"entry"`
+	}
+
+	atomLink struct {
+		Rel   string `xml:"rel,attr,omitempty"`
+		Href  string `xml:"href,attr"`
+		Type  string `xml:"type,attr,omitempty"`
+		Title string `xml:"title,attr,omitempty"`
+	}
+
+	atomEntry struct {
+		ID      string       `xml:"id"`
+		Title   string       `xml:"title"`
+		Updated string       `xml:"updated"`
+		Authors []atomAuthor `xml:"author"`
+		Issued  string       `xml:"dc:issued,omitempty"`
+		Content *atomContent `xml:"content"`
+		Links   []atomLink   `xml:"link"`
+	}
+
+	atomAuthor struct {
+		Name string `xml:"name"`
+	}
+
+	atomContent struct {
+		Type  string `xml:"type,attr"`
+		Value string `xml:",chardata"`
+	}
+)
+
+// MarshalAtom returns the OPDS 1.2 Atom document of a feed.
+func MarshalAtom(feed Feed) ([]byte, error) {
+	result := atomFeed{
+		Xmlns:        NamespaceAtom,
+		XmlnsDC:      NamespaceDC,
+		XmlnsOPDS:    NamespaceOPDS,
+		XmlnsSearch:  NamespaceOpenSearch,
+		ID:           feed.ID,
+		Title:        feed.Title,
+		Updated:      atomTime(feed.Updated),
+		Links:        atomLinks(feed.Links),
+		TotalResults: feed.Total,
+		ItemsPerPage: feed.PerPage,
+	}
+	for _, navigation := range feed.Navigation {
+		entry := atomEntry{
+			ID:      navigation.ID,
+			Title:   navigation.Title,
+			Updated: result.Updated,
+			Links:   atomLinks([]Link{navigation.Link}),
+		}
+		if navigation.Summary != "" {
+			entry.Content = &atomContent{Type: "text", Value: navigation.Summary}
+		}
+		result.Entries = append(result.Entries, entry)
+	}
+	for _, publication := range feed.Publications {
+		entry := atomEntry{
+			ID:      publication.ID,
+			Title:   publication.Title,
+			Updated: atomTime(publication.Updated),
+			Links:   atomLinks(publication.Links),
+		}
+		for _, author := range publication.Authors {
+			entry.Authors = append(entry.Authors, atomAuthor{Name: author})
+		}
+		if publication.Year != 0 {
+			entry.Issued = strconv.Itoa(int(publication.Year))
+		}
+		result.Entries = append(result.Entries, entry)
+	}
+
+	body, err := xml.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// atomLinks converts links to Atom. URI templates are left out, as Atom has no place for them.
+func atomLinks(links []Link) []atomLink {
+	var result []atomLink
+	for _, link := range links {
+		if !link.Templated {
+			result = append(result, atomLink{Rel: link.Rel, Href: link.Href, Type: link.Type, Title: link.Title})
+		}
+	}
+	return result
+}
+
+// atomTime formats a time the way Atom requires (RFC 3339).
+func atomTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
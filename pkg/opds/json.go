@@ -0,0 +1,107 @@
+package opds
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// schemaBook is the type of publications that are books.
+const schemaBook = "http://schema.org/Book"
+
+type (
+	// jsonFeed is the OPDS 2.0 representation of a feed.
+	jsonFeed struct {
+		Metadata     jsonFeedMetadata  `json:"metadata"`
+		Links        []jsonLink        `json:"links"`
+		Navigation   []jsonLink        `json:"navigation,omitempty"`
+		Publications []jsonPublication `json:"publications,omitempty"`
+	}
+
+	jsonFeedMetadata struct {
+		Title         string `json:"title"`
+		Modified      string `json:"modified,omitempty"`
+		NumberOfItems *int64 `json:"numberOfItems,omitempty"`
+		ItemsPerPage  int    `json:"itemsPerPage,omitempty"`
+	}
+
+	jsonLink struct {
+		Rel       string `json:"rel,omitempty"`
+		Href      string `json:"href"`
+		Type      string `json:"type,omitempty"`
+		Title     string `json:"title,omitempty"`
+		Templated bool   `json:"templated,omitempty"`
+	}
+
+	jsonPublication struct {
+		Metadata jsonPublicationMetadata `json:"metadata"`
+		Links    []jsonLink              `json:"links"`
+	}
+
+	jsonPublicationMetadata struct {
+		Type       string            `json:"@type"`
+		Identifier string            `json:"identifier"`
+		Title      string            `json:"title"`
+		Author     []jsonContributor `json:"author,omitempty"`
+		Published  string            `json:"published,omitempty"`
+		Modified   string            `json:"modified,omitempty"`
+	}
+
+	jsonContributor struct {
+		Name string `json:"name"`
+	}
+)
+
+// MarshalJSON returns the OPDS 2.0 document of a feed. A navigation feed lists its entries as navigation links.
+func MarshalJSON(feed Feed) ([]byte, error) {
+	result := jsonFeed{
+		Metadata: jsonFeedMetadata{
+			Title:         feed.Title,
+			Modified:      jsonTime(feed.Updated),
+			NumberOfItems: feed.Total,
+			ItemsPerPage:  feed.PerPage,
+		},
+		Links: jsonLinks(feed.Links),
+	}
+	for _, navigation := range feed.Navigation {
+		link := navigation.Link
+		link.Title = navigation.Title
+		result.Navigation = append(result.Navigation, jsonLinks([]Link{link})...)
+	}
+	for _, publication := range feed.Publications {
+		item := jsonPublication{
+			Metadata: jsonPublicationMetadata{
+				Type:       schemaBook,
+				Identifier: publication.ID,
+				Title:      publication.Title,
+				Modified:   jsonTime(publication.Updated),
+			},
+			Links: jsonLinks(publication.Links),
+		}
+		for _, author := range publication.Authors {
+			item.Metadata.Author = append(item.Metadata.Author, jsonContributor{Name: author})
+		}
+		if publication.Year != 0 {
+			item.Metadata.Published = strconv.Itoa(int(publication.Year))
+		}
+		result.Publications = append(result.Publications, item)
+	}
+	return json.Marshal(result)
+}
+
+// jsonLinks converts links to OPDS 2.0.
+func jsonLinks(links []Link) []jsonLink {
+	result := make([]jsonLink, len(links))
+	for i, link := range links {
+		result[i] = jsonLink{Rel: link.Rel, Href: link.Href, Type: link.Type, Title: link.Title, Templated: link.Templated}
+	}
+	return result
+}
+
+// jsonTime formats a time as RFC 3339, or returns an empty string for the zero time.
+func jsonTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
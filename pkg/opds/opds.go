@@ -0,0 +1,69 @@
+// Package opds renders catalog feeds of the Open Publication Distribution System for e-reader applications:
+// OPDS 1.2 as Atom XML and OPDS 2.0 as JSON, and OpenSearch descriptions of their search.
+package opds
+
+import "time"
+
+// Media types of feeds and links.
+const (
+	MIMEAtomNavigation   = "application/atom+xml;profile=opds-catalog;kind=navigation"
+	MIMEAtomAcquisition  = "application/atom+xml;profile=opds-catalog;kind=acquisition"
+	MIMEOPDS2            = "application/opds+json"
+	MIMEOPDS2Publication = "application/opds-publication+json"
+	MIMEOpenSearch       = "application/opensearchdescription+xml"
+)
+
+// Relations of links.
+const (
+	RelSelf       = "self"
+	RelStart      = "start" // Root of the catalog
+	RelUp         = "up"
+	RelFirst      = "first"
+	RelNext       = "next"
+	RelSearch     = "search"
+	RelAlternate  = "alternate"
+	RelSubsection = "subsection"
+	RelSortNew    = "http://opds-spec.org/sort/new"
+	RelOpenAccess = "http://opds-spec.org/acquisition/open-access"
+)
+
+type (
+	// Feed is a catalog feed. A navigation feed lists other feeds, an acquisition feed lists publications.
+	Feed struct {
+		ID           string // URI that identifies the feed
+		Title        string
+		Updated      time.Time
+		Links        []Link
+		Navigation   []Navigation
+		Publications []Publication
+		Total        *int64 // Number of publications on all pages, if known
+		PerPage      int    // Size of a page of an acquisition feed
+	}
+
+	// Link is a link of a feed or a publication.
+	Link struct {
+		Rel       string
+		Href      string
+		Type      string
+		Title     string
+		Templated bool // Whether Href is a URI template (RFC 6570), used only by OPDS 2.0
+	}
+
+	// Navigation is an entry of a navigation feed that leads to another feed.
+	Navigation struct {
+		ID      string // URI that identifies the entry
+		Title   string
+		Summary string
+		Link    Link
+	}
+
+	// Publication is an entry of an acquisition feed.
+	Publication struct {
+		ID      string // URI that identifies the publication, like "urn:uuid:..."
+		Title   string
+		Authors []string
+		Year    uint16 // Year of publication, 0 if unknown
+		Updated time.Time
+		Links   []Link
+	}
+)
@@ -0,0 +1,53 @@
+package opds
+
+import "encoding/xml"
+
+type (
+	// OpenSearch is an OpenSearch 1.1 description that tells clients how to search the catalog.
+	OpenSearch struct {
+		ShortName   string // Name of at most 16 characters
+		Description string
+		URLs        []OpenSearchURL
+	}
+
+	// OpenSearchURL is a template of search requests returning results of the type.
+	// The template holds {searchTerms} in place of the query.
+	OpenSearchURL struct {
+		Type     string
+		Template string
+	}
+
+	xmlOpenSearch struct {
+		XMLName        xml.Name           `xml:"OpenSearchDescription"`
+		Xmlns          string             `xml:"xmlns,attr"`
+		ShortName      string             `xml:"ShortName"`
+		Description    string             `xml:"Description"`
+		InputEncoding  string             `xml:"InputEncoding"`
+		OutputEncoding string             `xml:"OutputEncoding"`
+		URLs           []xmlOpenSearchURL `xml:"Url"`
+	}
+
+	xmlOpenSearchURL struct {
+		Type     string `xml:"type,attr"`
+		Template string `xml:"template,attr"`
+	}
+)
+
+// MarshalOpenSearch returns the XML document of an OpenSearch description.
+func MarshalOpenSearch(description OpenSearch) ([]byte, error) {
+	result := xmlOpenSearch{
+		Xmlns:          NamespaceOpenSearch,
+		ShortName:      description.ShortName,
+		Description:    description.Description,
+		InputEncoding:  "UTF-8",
+		OutputEncoding: "UTF-8",
+	}
+	for _, url := range description.URLs {
+		result.URLs = append(result.URLs, xmlOpenSearchURL{Type: url.Type, Template: url.Template})
+	}
+	body, err := xml.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
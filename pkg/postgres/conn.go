@@ -3,7 +3,6 @@ package postgres
 import (
 	"fmt"
 	"github.com/KinitaL/testovoye/config"
-	repo "github.com/KinitaL/testovoye/internal/infrastructure/repositories/books/postgres"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -19,16 +18,12 @@ func NewPostgresDB(cfg config.DB) (*gorm.DB, error) {
 
 	// Connect to the database
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info), // Enable query logging
+		Logger:         logger.Default.LogMode(logger.Info), // Enable query logging
+		TranslateError: true,                                // Map driver errors to gorm.ErrDuplicatedKey etc.
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	// Run auto-migration
-	if err := db.AutoMigrate(&repo.Book{}); err != nil {
-		return nil, fmt.Errorf("failed to migrate: %w", err)
-	}
-
 	return db, nil
 }
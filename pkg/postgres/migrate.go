@@ -0,0 +1,17 @@
+package postgres
+
+import (
+	"fmt"
+	"github.com/KinitaL/testovoye/migrations"
+	"github.com/KinitaL/testovoye/pkg/migrate"
+	"gorm.io/gorm"
+)
+
+// NewMigrator returns a migrator of the application schema that uses the GORM connection pool.
+func NewMigrator(db *gorm.DB) (*migrate.Migrator, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database handle: %w", err)
+	}
+	return migrate.New(sqlDB, migrations.FS)
+}
@@ -0,0 +1,336 @@
+// Package sru writes responses of Search/Retrieve via URL (SRU) 2.0.
+package sru
+
+import (
+	"encoding/xml"
+	"github.com/KinitaL/testovoye/pkg/dc"
+	"strconv"
+)
+
+// MIME is the media type of SRU responses.
+const MIME = "application/sru+xml"
+
+// Version is the supported version of the protocol.
+const Version = "2.0"
+
+// XML namespaces of responses.
+const (
+	Namespace           = "http://docs.oasis-open.org/ns/search-ws/sruResponse"
+	NamespaceDiagnostic = "http://docs.oasis-open.org/ns/search-ws/diagnostic"
+	NamespaceExplain    = "http://explain.z3950.org/dtd/2.0/"
+	NamespaceDC         = "info:srw/schema/1/dc-schema"
+)
+
+// Identifiers of record schemas and context sets.
+const (
+	SchemaDC       = "info:srw/schema/1/dc-v1.1"
+	SchemaMARCXML  = "info:srw/schema/1/marcxml-v1.1"
+	ContextSetDC   = "info:srw/cql-context-set/1/dc-v1.1"
+	ContextSetCQL  = "info:srw/cql-context-set/1/cql-v1.2"
+	diagnosticBase = "info:srw/diagnostic/1/"
+)
+
+// Values of recordXMLEscaping: whether records are embedded as XML or as escaped text.
+const (
+	EscapingXML    = "xml"
+	EscapingString = "string"
+)
+
+// Codes of diagnostics, the errors of the protocol.
+const (
+	DiagnosticGeneral                     = 1
+	DiagnosticUnsupportedOperation        = 4
+	DiagnosticUnsupportedVersion          = 5
+	DiagnosticUnsupportedParameterValue   = 6
+	DiagnosticMandatoryParameter          = 7
+	DiagnosticUnsupportedParameter        = 8
+	DiagnosticQuerySyntax                 = 10
+	DiagnosticUnsupportedIndex            = 16
+	DiagnosticUnsupportedRelation         = 19
+	DiagnosticUnsupportedRelationModifier = 20
+	DiagnosticEmptyTerm                   = 27
+	DiagnosticMaskingUnsupported          = 28
+	DiagnosticAnchoringUnsupported        = 31
+	DiagnosticInvalidTerm                 = 36
+	DiagnosticUnsupportedBoolean          = 37
+	DiagnosticUnsupportedBooleanModifier  = 46
+	DiagnosticFirstRecordOutOfRange       = 61
+	DiagnosticUnknownSchema               = 66
+	DiagnosticUnsupportedEscaping         = 71
+	DiagnosticSortUnsupported             = 80
+)
+
+type (
+	// SearchRetrieveResponse is the result of a query.
+	SearchRetrieveResponse struct {
+		NumberOfRecords    int64
+		Records            []Record
+		NextRecordPosition int // Zero if there are no more records
+		Diagnostics        []Diagnostic
+	}
+
+	// Record is a record of a result in a schema. Data is an XML-marshalable value.
+	Record struct {
+		Schema   string
+		Escaping string // EscapingXML if empty
+		Data     any
+		Position int // 1-based position of the record in the result
+	}
+
+	// Diagnostic is an error of a request. A fatal one replaces the records of a response.
+	Diagnostic struct {
+		Code    int
+		Details string // The part of the request that caused the error, such as an index or a parameter
+		Message string
+	}
+
+	// Explain describes the server and the queries it supports.
+	Explain struct {
+		Host           string
+		Port           int
+		Database       string
+		Title          string
+		Indexes        []Index
+		Schemas        []Schema
+		DefaultRecords int // Number of records returned if maximumRecords is absent
+		MaximumRecords int
+	}
+
+	// Index is a searchable index of a context set.
+	Index struct {
+		Title string
+		Set   string // Short name of the context set, such as "dc"
+		Name  string
+	}
+
+	// Schema is a record schema.
+	Schema struct {
+		Identifier string
+		Name       string
+		Title      string
+	}
+)
+
+func (d Diagnostic) Error() string {
+	return d.URI() + ": " + d.Message
+}
+
+// URI returns the identifier of the diagnostic.
+func (d Diagnostic) URI() string {
+	return diagnosticBase + strconv.Itoa(d.Code)
+}
+
+type (
+	xmlSearchRetrieveResponse struct {
+		XMLName            xml.Name        `xml:"searchRetrieveResponse"`
+		Xmlns              string          `xml:"xmlns,attr"`
+		Version            string          `xml:"version"`
+		NumberOfRecords    int64           `xml:"numberOfRecords"`
+		Records            *xmlRecords     `xml:"records"`
+		NextRecordPosition int             `xml:"nextRecordPosition,omitempty"`
+		Diagnostics        *xmlDiagnostics `xml:"diagnostics"`
+	}
+
+	xmlExplainResponse struct {
+		XMLName     xml.Name        `xml:"explainResponse"`
+		Xmlns       string          `xml:"xmlns,attr"`
+		Version     string          `xml:"version"`
+		Record      xmlRecord       `xml:"record"`
+		Diagnostics *xmlDiagnostics `xml:"diagnostics"`
+	}
+
+	xmlRecords struct {
+		Records []xmlRecord `xml:"record"`
+	}
+
+	xmlRecord struct {
+		Schema   string        `xml:"recordSchema"`
+		Escaping string        `xml:"recordXMLEscaping"`
+		Data     xmlRecordData `xml:"recordData"`
+		Position int           `xml:"recordPosition,omitempty"`
+	}
+
+	// xmlRecordData holds either an embedded record or its escaped text.
+	xmlRecordData struct {
+		Value any    `xml:",omitempty"`
+		Text  string `xml:",chardata"`
+	}
+
+	xmlDiagnostics struct {
+		Diagnostics []xmlDiagnostic `xml:"diagnostic"`
+	}
+
+	xmlDiagnostic struct {
+		Xmlns   string `xml:"xmlns,attr"`
+		URI     string `xml:"uri"`
+		Details string `xml:"details,omitempty"`
+		Message string `xml:"message,omitempty"`
+	}
+
+	xmlExplain struct {
+		XMLName      xml.Name         `xml:"explain"`
+		Xmlns        string           `xml:"xmlns,attr"`
+		ServerInfo   xmlServerInfo    `xml:"serverInfo"`
+		DatabaseInfo xmlDatabaseInfo  `xml:"databaseInfo"`
+		Sets         []xmlSet         `xml:"indexInfo>set"`
+		Indexes      []xmlIndex       `xml:"indexInfo>index"`
+		Schemas      []xmlSchema      `xml:"schemaInfo>schema"`
+		Defaults     []xmlConfigValue `xml:"configInfo>default"`
+		Settings     []xmlConfigValue `xml:"configInfo>setting"`
+	}
+
+	xmlServerInfo struct {
+		Protocol string `xml:"protocol,attr"`
+		Version  string `xml:"version,attr"`
+		Host     string `xml:"host"`
+		Port     int    `xml:"port"`
+		Database string `xml:"database"`
+	}
+
+	xmlDatabaseInfo struct {
+		Title string `xml:"title"`
+	}
+
+	xmlSet struct {
+		Name       string `xml:"name,attr"`
+		Identifier string `xml:"identifier,attr"`
+	}
+
+	xmlIndex struct {
+		Title string       `xml:"title"`
+		Name  xmlIndexName `xml:"map>name"`
+	}
+
+	xmlIndexName struct {
+		Set  string `xml:"set,attr"`
+		Name string `xml:",chardata"`
+	}
+
+	xmlSchema struct {
+		Identifier string `xml:"identifier,attr"`
+		Name       string `xml:"name,attr"`
+		Title      string `xml:"title"`
+	}
+
+	xmlConfigValue struct {
+		Type  string `xml:"type,attr"`
+		Value int    `xml:",chardata"`
+	}
+)
+
+// MarshalSearchRetrieve returns the XML document of a response to a query.
+func MarshalSearchRetrieve(response SearchRetrieveResponse) ([]byte, error) {
+	result := xmlSearchRetrieveResponse{
+		Xmlns:              Namespace,
+		Version:            Version,
+		NumberOfRecords:    response.NumberOfRecords,
+		NextRecordPosition: response.NextRecordPosition,
+		Diagnostics:        toXMLDiagnostics(response.Diagnostics),
+	}
+	if len(response.Records) > 0 {
+		result.Records = &xmlRecords{}
+		for _, record := range response.Records {
+			item, err := toXMLRecord(record)
+			if err != nil {
+				return nil, err
+			}
+			result.Records.Records = append(result.Records.Records, item)
+		}
+	}
+	return marshal(result)
+}
+
+// MarshalExplain returns the XML document of a response to an explain request.
+func MarshalExplain(explain Explain, diagnostics []Diagnostic) ([]byte, error) {
+	data := xmlExplain{
+		Xmlns: NamespaceExplain,
+		ServerInfo: xmlServerInfo{
+			Protocol: "SRU",
+			Version:  Version,
+			Host:     explain.Host,
+			Port:     explain.Port,
+			Database: explain.Database,
+		},
+		DatabaseInfo: xmlDatabaseInfo{Title: explain.Title},
+		Sets: []xmlSet{
+			{Name: "cql", Identifier: ContextSetCQL},
+			{Name: "dc", Identifier: ContextSetDC},
+		},
+		Defaults: []xmlConfigValue{{Type: "numberOfRecords", Value: explain.DefaultRecords}},
+		Settings: []xmlConfigValue{{Type: "maximumRecords", Value: explain.MaximumRecords}},
+	}
+	for _, index := range explain.Indexes {
+		data.Indexes = append(data.Indexes, xmlIndex{Title: index.Title, Name: xmlIndexName{Set: index.Set, Name: index.Name}})
+	}
+	for _, schema := range explain.Schemas {
+		data.Schemas = append(data.Schemas, xmlSchema{Identifier: schema.Identifier, Name: schema.Name, Title: schema.Title})
+	}
+
+	record, err := toXMLRecord(Record{Schema: NamespaceExplain, Data: data})
+	if err != nil {
+		return nil, err
+	}
+	return marshal(xmlExplainResponse{
+		Xmlns:       Namespace,
+		Version:     Version,
+		Record:      record,
+		Diagnostics: toXMLDiagnostics(diagnostics),
+	})
+}
+
+// marshal encodes a response with the XML declaration.
+func marshal(response any) ([]byte, error) {
+	body, err := xml.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// toXMLRecord converts a record to XML, escaping its data if requested.
+func toXMLRecord(record Record) (xmlRecord, error) {
+	result := xmlRecord{Schema: record.Schema, Escaping: record.Escaping, Position: record.Position}
+	if result.Escaping == "" {
+		result.Escaping = EscapingXML
+	}
+	if result.Escaping == EscapingXML {
+		result.Data.Value = record.Data
+		return result, nil
+	}
+	data, err := xml.Marshal(record.Data)
+	if err != nil {
+		return result, err
+	}
+	result.Data.Text = string(data)
+	return result, nil
+}
+
+// toXMLDiagnostics converts diagnostics to XML, or returns nil if there are none.
+func toXMLDiagnostics(diagnostics []Diagnostic) *xmlDiagnostics {
+	if len(diagnostics) == 0 {
+		return nil
+	}
+	result := &xmlDiagnostics{}
+	for _, diagnostic := range diagnostics {
+		result.Diagnostics = append(result.Diagnostics, xmlDiagnostic{
+			Xmlns:   NamespaceDiagnostic,
+			URI:     diagnostic.URI(),
+			Details: diagnostic.Details,
+			Message: diagnostic.Message,
+		})
+	}
+	return result
+}
+
+// DC is a record in the Dublin Core schema of SRU.
+type DC struct {
+	XMLName    xml.Name `xml:"srw_dc:dc"`
+	XmlnsSRWDC string   `xml:"xmlns:srw_dc,attr"`
+	XmlnsDC    string   `xml:"xmlns:dc,attr"`
+	dc.Elements
+}
+
+// NewDC returns the record of the Dublin Core elements.
+func NewDC(elements dc.Elements) DC {
+	return DC{XmlnsSRWDC: NamespaceDC, XmlnsDC: dc.Namespace, Elements: elements}
+}
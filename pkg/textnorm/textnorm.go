@@ -0,0 +1,92 @@
+package textnorm
+
+import (
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+	"strings"
+	"unicode"
+)
+
+// cyrillic maps lowercase Cyrillic letters to their Latin transliteration (close to BGN/PCGN).
+var cyrillic = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g",
+}
+
+// spelling collapses Latin spelling variants that different romanization systems produce,
+// so that "Dostoevsky", "Dostoyevskiy" and "Достоевский" end up the same.
+var spelling = strings.NewReplacer(
+	"yo", "e",
+	"ye", "e",
+	"kh", "h",
+	"x", "ks",
+)
+
+// endings unifies word endings of Russian adjectives and surnames ("-ий", "-ый", "-ой").
+var endings = []struct{ from, to string }{
+	{"iy", "y"},
+	{"ii", "y"},
+	{"yi", "y"},
+	{"ij", "y"},
+	{"oi", "oy"},
+	{"oj", "oy"},
+}
+
+// NFC returns the string in Unicode Normalization Form C without surrounding spaces.
+func NFC(s string) string {
+	return strings.TrimSpace(norm.NFC.String(s))
+}
+
+// Fold returns the NFC form of the string with case differences removed.
+func Fold(s string) string {
+	return cases.Fold().String(norm.NFC.String(s))
+}
+
+// Transliterate replaces Cyrillic letters of a folded string with Latin ones.
+func Transliterate(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if latin, ok := cyrillic[r]; ok {
+			b.WriteString(latin)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Key returns the script-independent search key of a text: it is folded, transliterated
+// to Latin and spelling variants are collapsed. Two texts that a reader considers the same
+// name written in Cyrillic and in Latin usually share the key.
+func Key(s string) string {
+	words := strings.FieldsFunc(Transliterate(Fold(s)), IsSeparator)
+	for i, word := range words {
+		words[i] = keyOfWord(word)
+	}
+	return strings.Join(words, " ")
+}
+
+// Words splits a text into words.
+func Words(s string) []string {
+	return strings.FieldsFunc(s, IsSeparator)
+}
+
+// IsSeparator reports whether the rune delimits words.
+func IsSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// keyOfWord collapses spelling variants of a single transliterated word.
+func keyOfWord(word string) string {
+	word = spelling.Replace(word)
+	for _, e := range endings {
+		if strings.HasSuffix(word, e.from) && len(word) > len(e.from)+1 {
+			return strings.TrimSuffix(word, e.from) + e.to
+		}
+	}
+	return word
+}
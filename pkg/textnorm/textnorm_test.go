@@ -0,0 +1,98 @@
+package textnorm
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	cases := []struct {
+		name string
+
+		texts []string // Spellings that share the key
+		key   string
+	}{
+		{name: "Cyrillic and romanizations", texts: []string{"Достоевский", "Dostoevsky", "Dostoyevskiy", "Dostoevskii", "DOSTOEVSKY"}, key: "dostoevsky"},
+		{name: "ё and yo", texts: []string{"Фёдор", "Fyodor", "Fedor"}, key: "fedor"},
+		{name: "ё at the end of a word", texts: []string{"Семён", "Semyon", "Semen"}, key: "semen"},
+		{name: "Ending -ой", texts: []string{"Толстой", "Tolstoy", "Tolstoi", "Tolstoj"}, key: "tolstoy"},
+		{name: "Ending -ий", texts: []string{"Горький", "Gorky", "Gorkiy", "Gorkii"}, key: "gorky"},
+		{name: "Ending -ий after a vowel", texts: []string{"Юрий", "Yuriy", "Yury"}, key: "yury"},
+		{name: "й before о", texts: []string{"Йошкар-Ола", "Yoshkar-Ola", "Eshkar Ola"}, key: "eshkar ola"},
+		{name: "х and kh", texts: []string{"Чехов", "Chekhov", "Chehov"}, key: "chehov"},
+		{name: "x and ks", texts: []string{"Максим", "Maxim", "Maksim"}, key: "maksim"},
+		{name: "Soft sign", texts: []string{"Гоголь", "Gogol"}, key: "gogol"},
+		{name: "Hard sign", texts: []string{"Объём", "Obyom", "Obem"}, key: "obem"},
+		{name: "Separators", texts: []string{"  Лев  Толстой, ", "Lev-Tolstoy", "lev_tolstoy", "«Лев» Толстой!"}, key: "lev tolstoy"},
+		{name: "Digits", texts: []string{"1984", " 1984. "}, key: "1984"},
+		{name: "Decomposed letters", texts: []string{"F\u00e9dor", "Fe\u0301dor"}, key: "f\u00e9dor"},
+		{name: "Short word keeps its ending", texts: []string{"Iy", "ИЙ"}, key: "iy"},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			for _, text := range testCase.texts {
+				assert.Equal(t, testCase.key, Key(text), text)
+			}
+		})
+	}
+}
+
+func TestKeyDiffers(t *testing.T) {
+	cases := []struct {
+		name string
+
+		a, b string
+	}{
+		{name: "Different authors", a: "Достоевский", b: "Tolstoy"},
+		{name: "Different endings", a: "Толстой", b: "Толстая"},
+		{name: "Different first names", a: "Фёдор", b: "Фаддей"},
+		{name: "Word order", a: "Лев Толстой", b: "Толстой Лев"},
+		{name: "Extra word", a: "Война и мир", b: "Война"},
+		{name: "Joined words", a: "Lev Tolstoy", b: "Levtolstoy"},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.NotEqual(t, Key(testCase.a), Key(testCase.b))
+		})
+	}
+}
+
+func TestNormalization(t *testing.T) {
+	assert.Equal(t, "F\u00e9dor", NFC(" Fe\u0301dor\n"))
+	assert.Equal(t, "strasse σασ f\u00e9dor", Fold("Straße ΣΑΣ Fe\u0301dor"))
+	assert.Equal(t, "obem shchuka ganok yizhak", Transliterate("объём щука ґанок їжак"))
+	assert.Equal(t, "War and Peace", Transliterate("War and Peace"))
+}
+
+func TestWords(t *testing.T) {
+	cases := []struct {
+		name string
+
+		text  string
+		words []string
+	}{
+		{name: "Spaces", text: "  War  and\tPeace\n", words: []string{"War", "and", "Peace"}},
+		{name: "Punctuation", text: "War and Peace, vol. 1 — «Том»", words: []string{"War", "and", "Peace", "vol", "1", "Том"}},
+		{name: "Hyphen and apostrophe", text: "Jean-Paul O'Brien", words: []string{"Jean", "Paul", "O", "Brien"}},
+		{name: "Markup characters", text: `<a href="x">&amp;`, words: []string{"a", "href", "x", "amp"}},
+		{name: "Only separators", text: " ,.- ", words: []string{}},
+	}
+
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.words, Words(testCase.text))
+		})
+	}
+}
+
+func TestIsSeparator(t *testing.T) {
+	// highlighting splits a text into words and separators with the same rules as Key
+	for _, r := range "aZяЁ7ß" {
+		assert.False(t, IsSeparator(r), string(r))
+	}
+	for _, r := range " \t\n-_,.'\"«»—<>&/" {
+		assert.True(t, IsSeparator(r), string(r))
+	}
+}
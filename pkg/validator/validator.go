@@ -1,9 +1,13 @@
 package validator
 
 import (
+	"errors"
+	"fmt"
+	"github.com/KinitaL/testovoye/internal/errs"
+	"github.com/KinitaL/testovoye/pkg/isbn"
 	"github.com/go-playground/validator"
-	"github.com/labstack/echo/v4"
-	"net/http"
+	"reflect"
+	"strings"
 )
 
 type CustomValidator struct {
@@ -11,12 +15,61 @@ type CustomValidator struct {
 }
 
 func New() *CustomValidator {
-	return &CustomValidator{validator: validator.New()}
+	v := validator.New()
+	// report fields by their JSON or query names, so clients can match errors to the request
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "query"} {
+			name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+			if name != "" && name != "-" {
+				return name
+			}
+		}
+		return field.Name
+	})
+	// replaces the built-in rule, which accepts neither hyphens nor both kinds of ISBN at once
+	_ = v.RegisterValidation("isbn", func(fl validator.FieldLevel) bool {
+		return isbn.Valid(fl.Field().String())
+	})
+	return &CustomValidator{validator: v}
 }
 
 func (cv *CustomValidator) Validate(i interface{}) error {
-	if err := cv.validator.Struct(i); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	err := cv.validator.Struct(i)
+	if err == nil {
+		return nil
+	}
+
+	var vErrs validator.ValidationErrors
+	if !errors.As(err, &vErrs) {
+		return errs.Validation("request is invalid")
+	}
+
+	fields := make([]errs.FieldError, 0, len(vErrs))
+	for _, fe := range vErrs {
+		fields = append(fields, errs.FieldError{
+			Field:   fe.Field(),
+			Message: message(fe),
+		})
+	}
+	return errs.InvalidFields(fields...)
+}
+
+// message returns a human-readable explanation of a failed validation rule.
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "required_without":
+		return "is required"
+	case "min", "gte":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max", "lte":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", fe.Param())
+	case "uuid":
+		return "must be a UUID"
+	case "isbn":
+		return "must be an ISBN-10 or ISBN-13 with a valid check digit"
+	default:
+		return fmt.Sprintf("failed on the '%s' rule", fe.Tag())
 	}
-	return nil
 }